@@ -2,7 +2,6 @@ package core
 
 import (
 	"bytes"
-	"errors"
 	"strconv"
 	"strings"
 )
@@ -67,10 +66,6 @@ func NewCoreLexer(lexerName string, buffer string) *CoreLexer {
 	coreLexer := &CoreLexer{}
 
 	coreLexer.StringTokenizer.super(buffer)
-
-	coreLexer.globalSymbolTable = make(map[int]string)
-	coreLexer.lexerTables = make(map[string]LexerMap)
-	coreLexer.currentLexer = make(LexerMap)
 	coreLexer.currentLexerName = lexerName
 
 	return coreLexer
@@ -79,9 +74,11 @@ func NewCoreLexer(lexerName string, buffer string) *CoreLexer {
 func (coreLexer *CoreLexer) Super(lexerName, buffer string) {
 	coreLexer.StringTokenizer.super(buffer)
 
-	coreLexer.globalSymbolTable = make(map[int]string)
-	coreLexer.lexerTables = make(map[string]LexerMap)
-	coreLexer.currentLexer = make(LexerMap)
+	// The tables are created when a lexer is added: most lexers use the
+	// shared ones.
+	coreLexer.globalSymbolTable = nil
+	coreLexer.lexerTables = nil
+	coreLexer.currentLexer = nil
 	coreLexer.currentLexerName = lexerName
 }
 
@@ -95,6 +92,9 @@ func (coreLexer *CoreLexer) GetLexerName() string {
 
 func (coreLexer *CoreLexer) AddKeyword(name string, value int) {
 	coreLexer.currentLexer[name] = value
+	if coreLexer.globalSymbolTable == nil {
+		coreLexer.globalSymbolTable = make(map[int]string)
+	}
 	if _, ok := coreLexer.globalSymbolTable[value]; !ok {
 		coreLexer.globalSymbolTable[value] = name
 	}
@@ -102,7 +102,16 @@ func (coreLexer *CoreLexer) AddKeyword(name string, value int) {
 
 func (coreLexer *CoreLexer) LookupToken(value int) string {
 	if value > CORELEXER_START {
-		return coreLexer.globalSymbolTable[value]
+		if name, ok := coreLexer.globalSymbolTable[value]; ok {
+			return name
+		}
+		// The keyword of a shared lexer.
+		for name, tokenType := range coreLexer.currentLexer {
+			if tokenType == value {
+				return name
+			}
+		}
+		return ""
 	} else {
 		return strconv.Itoa(value)
 	}
@@ -110,6 +119,9 @@ func (coreLexer *CoreLexer) LookupToken(value int) string {
 
 func (coreLexer *CoreLexer) AddLexer(lexerName string) LexerMap {
 	var ok bool
+	if coreLexer.lexerTables == nil {
+		coreLexer.lexerTables = make(map[string]LexerMap)
+	}
 	coreLexer.currentLexer, ok = coreLexer.lexerTables[lexerName]
 	if !ok {
 		coreLexer.currentLexer = make(LexerMap)
@@ -118,6 +130,14 @@ func (coreLexer *CoreLexer) AddLexer(lexerName string) LexerMap {
 	return coreLexer.currentLexer
 }
 
+/** Select a keyword table shared between lexers, e.g. built once by the
+ * first lexer that used it. The table must not be modified.
+ */
+func (coreLexer *CoreLexer) UseLexer(lexerName string, lexer LexerMap) {
+	coreLexer.currentLexer = lexer
+	coreLexer.currentLexerName = lexerName
+}
+
 func (coreLexer *CoreLexer) SelectLexer(lexerName string) {
 	coreLexer.currentLexer = coreLexer.lexerTables[lexerName]
 	coreLexer.currentLexerName = lexerName
@@ -206,7 +226,7 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 		if tok == CORELEXER_ID {
 			// Generic ID sought.
 			if !coreLexer.StartsId() {
-				return nil, errors.New("ParseException: ID expected")
+				return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: ID expected", coreLexer.ptr)
 			}
 			id := coreLexer.GetNextId()
 			coreLexer.currentMatch = &Token{}
@@ -216,7 +236,7 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 			nexttok := coreLexer.GetNextId()
 			cur, ok := coreLexer.currentLexer[strings.ToUpper(nexttok)]
 			if !ok || cur != tok {
-				return nil, errors.New("ParseException: Unexpected Token")
+				return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Unexpected Token", coreLexer.ptr)
 			}
 			coreLexer.currentMatch = &Token{}
 			coreLexer.currentMatch.tokenValue = nexttok
@@ -226,11 +246,11 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 		// Character classes.
 		next, err := coreLexer.LookAheadK(0)
 		if err != nil {
-			return nil, errors.New("ParseException: Expecting DIGIT")
+			return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Expecting DIGIT", coreLexer.ptr)
 		}
 		if tok == CORELEXER_DIGIT {
 			if !coreLexer.IsDigit(next) {
-				return nil, errors.New("ParseException: Expecting DIGIT")
+				return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Expecting DIGIT", coreLexer.ptr)
 			}
 			coreLexer.currentMatch = &Token{}
 			coreLexer.currentMatch.tokenValue = string(next)
@@ -238,7 +258,7 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 			coreLexer.ConsumeK(1)
 		} else if tok == CORELEXER_ALPHA {
 			if !coreLexer.IsAlpha(next) {
-				return nil, errors.New("ParseException: Expecting ALPHA")
+				return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Expecting ALPHA", coreLexer.ptr)
 			}
 			coreLexer.currentMatch = &Token{}
 			coreLexer.currentMatch.tokenValue = string(next)
@@ -250,7 +270,7 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 		ch := byte(tok)
 		next, err := coreLexer.LookAheadK(0)
 		if err != nil {
-			return nil, errors.New("ParseException: Expecting DIGIT")
+			return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Expecting DIGIT", coreLexer.ptr)
 		}
 		if next == ch {
 			coreLexer.currentMatch = &Token{}
@@ -258,7 +278,7 @@ func (coreLexer *CoreLexer) Match(tok int) (t *Token, ParseException error) {
 			coreLexer.currentMatch.tokenType = tok
 			coreLexer.ConsumeK(1)
 		} else {
-			return nil, errors.New("ParseException: Expecting")
+			return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Expecting", coreLexer.ptr)
 		}
 	}
 	return coreLexer.currentMatch, nil
@@ -292,7 +312,7 @@ func (coreLexer *CoreLexer) StartsId() bool {
 }
 
 func (coreLexer *CoreLexer) Ttoken() string {
-	var nextId bytes.Buffer
+	start := coreLexer.ptr
 
 	for coreLexer.HasMoreChars() {
 		nextChar, err := coreLexer.LookAheadK(0)
@@ -313,16 +333,15 @@ func (coreLexer *CoreLexer) Ttoken() string {
 			nextChar == '\'' ||
 			nextChar == '~' {
 			coreLexer.ConsumeK(1)
-			nextId.WriteByte(nextChar)
 		} else {
 			break
 		}
 	}
-	return nextId.String()
+	return coreLexer.buffer[start:coreLexer.ptr]
 }
 
 func (coreLexer *CoreLexer) TtokenAllowSpace() string {
-	var nextId bytes.Buffer
+	start := coreLexer.ptr
 
 	for coreLexer.HasMoreChars() {
 		nextChar, err := coreLexer.LookAheadK(0)
@@ -345,13 +364,12 @@ func (coreLexer *CoreLexer) TtokenAllowSpace() string {
 			nextChar == ' ' ||
 			nextChar == '\t' {
 
-			nextId.WriteByte(nextChar)
 			coreLexer.ConsumeK(1)
 		} else {
 			break
 		}
 	}
-	return nextId.String()
+	return coreLexer.buffer[start:coreLexer.ptr]
 }
 
 // Assume the cursor is at a quote.
@@ -487,7 +505,7 @@ func (coreLexer *CoreLexer) Number() (n int, ParseException error) {
 		return -1, err
 	}
 	if !coreLexer.IsDigit(next) {
-		return -1, errors.New("ParseException: unexpected token \"" + string(next) + "\"")
+		return -1, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: unexpected token \""+string(next)+"\"", coreLexer.ptr)
 	}
 
 	retval.WriteByte(next)
@@ -503,7 +521,7 @@ func (coreLexer *CoreLexer) Number() (n int, ParseException error) {
 	}
 
 	if n, err = strconv.Atoi(retval.String()); err != nil {
-		return -1, err
+		return -1, AsParseError(err, ParseErrorKind_BAD_SYNTAX, coreLexer.ptr)
 	} else {
 		return n, nil
 	}
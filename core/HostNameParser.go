@@ -2,7 +2,7 @@ package core
 
 import (
 	"bytes"
-	"errors"
+	"net"
 )
 
 /** SIPParser for host names.
@@ -82,13 +82,18 @@ func (hostNameParser *HostNameParser) Ipv6Reference() (s string, ParseException
 		} else if la == ']' {
 			hostNameParser.lexer.ConsumeK(1)
 			retval.WriteByte(la)
+			// The reference must hold a well formed IPv6 address (RFC 5118
+			// section 4.11).
+			if address := retval.String(); len(address) < 2 || address[0] != '[' || net.ParseIP(address[1:len(address)-1]) == nil {
+				return address, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Illegal IPv6 reference "+address, hostNameParser.lexer.GetPtr())
+			}
 			return retval.String(), nil
 		} else {
 			break
 		}
 	}
 
-	return retval.String(), errors.New("ParseException: Illegal Host name")
+	return retval.String(), NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Illegal Host name", hostNameParser.lexer.GetPtr())
 }
 
 func (hostNameParser *HostNameParser) GetHost() (h *Host, err error) {
@@ -132,7 +137,7 @@ func (hostNameParser *HostNameParser) GetHost() (h *Host, err error) {
 	hostname := hname.String()
 
 	if hostname == "" {
-		return nil, errors.New("ParseException: Illegal Host name")
+		return nil, NewParseError(ParseErrorKind_BAD_SYNTAX, "ParseException: Illegal Host name", hostNameParser.lexer.GetPtr())
 	} else {
 		return NewHost(hostname), nil
 	}
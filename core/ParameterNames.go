@@ -76,6 +76,26 @@ const SIPHeaderNames_SUBSCRIPTION_STATE = "Subscription-State"   //48
 const SIPHeaderNames_EVENT = "Event"                             //44
 const SIPHeaderNames_ALLOW_EVENTS = "Allow-Events"               //45
 const SIPHeaderNames_REFER_TO = "Refer-To"                       //46
+const SIPHeaderNames_PATH = "Path"                               //47
+const SIPHeaderNames_SERVICE_ROUTE = "Service-Route"             //48
+
+const SIPHeaderNames_P_ASSERTED_IDENTITY = "P-Asserted-Identity"   //49
+const SIPHeaderNames_P_PREFERRED_IDENTITY = "P-Preferred-Identity" //50
+const SIPHeaderNames_PRIVACY = "Privacy"                           //51
+const SIPHeaderNames_REPLACES = "Replaces"                         //52
+const SIPHeaderNames_JOIN = "Join"                                 //53
+const SIPHeaderNames_REFERRED_BY = "Referred-By"                   //54
+const SIPHeaderNames_HISTORY_INFO = "History-Info"                 //55
+const SIPHeaderNames_DIVERSION = "Diversion"                       //56
+const SIPHeaderNames_IDENTITY = "Identity"                         //57
+const SIPHeaderNames_SECURITY_CLIENT = "Security-Client"           //58
+const SIPHeaderNames_SECURITY_SERVER = "Security-Server"           //59
+const SIPHeaderNames_SECURITY_VERIFY = "Security-Verify"           //60
+const SIPHeaderNames_ACCEPT_CONTACT = "Accept-Contact"             //61
+const SIPHeaderNames_REJECT_CONTACT = "Reject-Contact"             //62
+const SIPHeaderNames_REQUEST_DISPOSITION = "Request-Disposition"   //63
+const SIPHeaderNames_TARGET_DIALOG = "Target-Dialog"               //64
+
 const SIPHeaderNames_K = "K"
 const SIPHeaderNames_C = "C"
 const SIPHeaderNames_E = "E"
@@ -87,6 +107,13 @@ const SIPHeaderNames_S = "S"
 const SIPHeaderNames_T = "T"
 const SIPHeaderNames_V = "V"
 const SIPHeaderNames_R = "R"
+const SIPHeaderNames_B = "B"
+const SIPHeaderNames_Y = "Y"
+const SIPHeaderNames_A = "A"
+const SIPHeaderNames_J = "J"
+const SIPHeaderNames_D = "D"
+const SIPHeaderNames_O = "O"
+const SIPHeaderNames_U = "U"
 
 const SIPMethodNames_INVITE = "INVITE"
 const SIPMethodNames_ACK = "ACK"
@@ -106,6 +133,7 @@ const SIPTransportNames_PHONE = "phone"
 const SIPTransportNames_MADDR = "maddr"
 const SIPTransportNames_TTL = "ttl"
 const SIPTransportNames_LR = "lr"
+const SIPTransportNames_GR = "gr"
 const SIPTransportNames_SIP = "sip"
 const SIPTransportNames_SIPS = "sips"
 const SIPTransportNames_TEL = "tel"
@@ -0,0 +1,154 @@
+package core
+
+import "errors"
+
+/** The kinds of parse errors.
+ */
+type ParseErrorKind int
+
+const (
+	// The text does not match the grammar.
+	ParseErrorKind_BAD_SYNTAX ParseErrorKind = iota
+	// The method of the CSeq is not that of the request line.
+	ParseErrorKind_METHOD_MISMATCH
+	// The Content-Length is larger than the body of the message.
+	ParseErrorKind_CONTENT_LENGTH_OVERFLOW
+	// A mandatory header is missing.
+	ParseErrorKind_MISSING_HEADER
+	// The message is larger than the maximum message size.
+	ParseErrorKind_MESSAGE_TOO_LARGE
+	// The body is larger than the maximum body size.
+	ParseErrorKind_BODY_TOO_LARGE
+	// The header of the message exceeds a limit of the parser, e.g. the
+	// number of headers.
+	ParseErrorKind_LIMIT_EXCEEDED
+)
+
+func (kind ParseErrorKind) String() string {
+	switch kind {
+	case ParseErrorKind_BAD_SYNTAX:
+		return "bad syntax"
+	case ParseErrorKind_METHOD_MISMATCH:
+		return "method mismatch"
+	case ParseErrorKind_CONTENT_LENGTH_OVERFLOW:
+		return "content-length overflow"
+	case ParseErrorKind_MISSING_HEADER:
+		return "missing header"
+	case ParseErrorKind_MESSAGE_TOO_LARGE:
+		return "message too large"
+	case ParseErrorKind_BODY_TOO_LARGE:
+		return "body too large"
+	case ParseErrorKind_LIMIT_EXCEEDED:
+		return "limit exceeded"
+	}
+	return "unknown"
+}
+
+/**
+ * A ParseError is the error of the lexers and the parsers. The header
+ * parsers give the offset of the error in the header they parse; the
+ * message parser adds the name of the header and the line of the error,
+ * and gives the offset of the error in the message. Use errors.As to get
+ * it from an error:
+ *
+ *	var parseError *core.ParseError
+ *	if errors.As(err, &parseError) && parseError.GetKind() == core.ParseErrorKind_METHOD_MISMATCH {
+ *		...
+ *	}
+ */
+type ParseError struct {
+	kind ParseErrorKind
+
+	message string
+
+	headerName string
+
+	line int
+
+	column int
+
+	offset int
+
+	err error
+}
+
+/** Constructor.
+ * @param kind the kind of the error.
+ * @param message the text of the error.
+ * @param offset the offset of the error in the parsed text, or -1.
+ */
+func NewParseError(kind ParseErrorKind, message string, offset int) *ParseError {
+	this := &ParseError{}
+	this.kind = kind
+	this.message = message
+	this.offset = offset
+	return this
+}
+
+/** Get the ParseError of an error, or make a ParseError of the given kind
+ * that wraps it, with the same text, e.g. for a conversion error.
+ */
+func AsParseError(err error, kind ParseErrorKind, offset int) *ParseError {
+	var parseError *ParseError
+	if errors.As(err, &parseError) {
+		return parseError
+	}
+	parseError = NewParseError(kind, err.Error(), offset)
+	parseError.err = err
+	return parseError
+}
+
+func (this *ParseError) Error() string {
+	return this.message
+}
+
+/** Get the wrapped error, if any.
+ */
+func (this *ParseError) Unwrap() error {
+	return this.err
+}
+
+func (this *ParseError) GetKind() ParseErrorKind {
+	return this.kind
+}
+
+/** Get the name of the header of the error, or "" for the start line or
+ * the message as a whole.
+ */
+func (this *ParseError) GetHeaderName() string {
+	return this.headerName
+}
+
+/** Get the line of the error in the message, from 1, or 0 if unknown.
+ */
+func (this *ParseError) GetLine() int {
+	return this.line
+}
+
+/** Get the column of the error in its line, from 1, or 0 if unknown.
+ */
+func (this *ParseError) GetColumn() int {
+	return this.column
+}
+
+/** Get the byte offset of the error in the parsed text, or -1 if unknown.
+ */
+func (this *ParseError) GetOffset() int {
+	return this.offset
+}
+
+/** Return a copy of the error located in a message: the errors of the
+ * lexers may be shared, so they are not modified.
+ * @param headerName the name of the header of the error.
+ * @param line the line of the error in the message, or 0.
+ * @param column the column of the error in the line, or 0.
+ * @param offset the offset of the error in the message, or -1.
+ */
+func (this *ParseError) At(headerName string, line, column, offset int) *ParseError {
+	located := *this
+	located.headerName = headerName
+	located.line = line
+	located.column = column
+	located.offset = offset
+	return &located
+}
@@ -2,10 +2,15 @@ package core
 
 import (
 	"bytes"
-	"errors"
 	"strings"
 )
 
+// The errors at the end of the buffer, which the parsers run into when
+// they look ahead. They are shared, so their offset is unknown.
+var (
+	errLookAheadEndOfBuffer   = NewParseError(ParseErrorKind_BAD_SYNTAX, "StringTokenizer::LookAheadK: End of buffer", -1)
+	errGetNextCharEndOfBuffer = NewParseError(ParseErrorKind_BAD_SYNTAX, "StringTokenizer::GetNextChar: End of buffer", -1)
+)
 
 // StringTokenizer Base string token splitter.
 type StringTokenizer struct {
@@ -28,22 +33,16 @@ func (stringtokenizer *StringTokenizer) super(buffer string) {
 	stringtokenizer.ptr = 0
 }
 
-// NextToken for string
+// NextToken for string: the next line, with its '\n'. The token is a
+// substring of the buffer, so it is not copied.
 func (stringtokenizer *StringTokenizer) NextToken() string {
-	var retval bytes.Buffer
-
-	for stringtokenizer.ptr < len(stringtokenizer.buffer) {
-		if stringtokenizer.buffer[stringtokenizer.ptr] == '\n' {
-			retval.WriteByte(stringtokenizer.buffer[stringtokenizer.ptr])
-			stringtokenizer.ptr++
-			break
-		} else {
-			retval.WriteByte(stringtokenizer.buffer[stringtokenizer.ptr])
-			stringtokenizer.ptr++
-		}
+	start := stringtokenizer.ptr
+	if end := strings.IndexByte(stringtokenizer.buffer[start:], '\n'); end >= 0 {
+		stringtokenizer.ptr += end + 1
+	} else {
+		stringtokenizer.ptr = len(stringtokenizer.buffer)
 	}
-
-	return retval.String()
+	return stringtokenizer.buffer[start:stringtokenizer.ptr]
 }
 
 func (stringtokenizer *StringTokenizer) HasMoreChars() bool {
@@ -70,16 +69,14 @@ func (stringtokenizer *StringTokenizer) IsDigit(ch byte) bool {
 }
 
 func (stringtokenizer *StringTokenizer) GetLine() string {
-	var retval bytes.Buffer
+	start := stringtokenizer.ptr
 	for stringtokenizer.ptr < len(stringtokenizer.buffer) && stringtokenizer.buffer[stringtokenizer.ptr] != '\n' {
-		retval.WriteByte(stringtokenizer.buffer[stringtokenizer.ptr])
 		stringtokenizer.ptr++
 	}
 	if stringtokenizer.ptr < len(stringtokenizer.buffer) && stringtokenizer.buffer[stringtokenizer.ptr] == '\n' {
-		retval.WriteString("\n")
 		stringtokenizer.ptr++
 	}
-	return retval.String()
+	return stringtokenizer.buffer[start:stringtokenizer.ptr]
 }
 
 func (stringtokenizer *StringTokenizer) PeekLine() string {
@@ -97,12 +94,12 @@ func (stringtokenizer *StringTokenizer) LookAheadK(k int) (byte, error) {
 	if stringtokenizer.ptr+k < len(stringtokenizer.buffer) {
 		return stringtokenizer.buffer[stringtokenizer.ptr+k], nil
 	}
-	return 0, errors.New("StringTokenizer::LookAheadK: End of buffer")
+	return 0, errLookAheadEndOfBuffer
 }
 
 func (stringtokenizer *StringTokenizer) GetNextChar() (byte, error) {
 	if stringtokenizer.ptr >= len(stringtokenizer.buffer) {
-		return 0, errors.New("StringTokenizer::GetNextChar: End of buffer")
+		return 0, errGetNextCharEndOfBuffer
 	}
 	ch := stringtokenizer.buffer[stringtokenizer.ptr]
 	stringtokenizer.ptr++
@@ -59,10 +59,9 @@ type RequestEvent struct { //extends EventObject {
  * this Request was sent
  * @param request - the Request message received by the SipProvider
  */
-/*funct NewRequestEvent(ServerTransaction serverTransaction, Request request) *RequestEvent {
-     m_transaction = serverTransaction;
-     m_request = request;
-}*/
+func NewRequestEvent(serverTransaction ServerTransaction, request message.Request) *RequestEvent {
+	return &RequestEvent{m_request: request, m_transaction: serverTransaction}
+}
 
 /**
  * Gets the server transaction associated with this RequestEvent
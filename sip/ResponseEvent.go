@@ -60,11 +60,9 @@ type ResponseEvent struct { //extends EventObject {
  * this Response was sent
  * @param response - the Response message received by the SipProvider
  */
-/*public ResponseEvent(Object source, ClientTransaction clientTransaction, Response response) {
-    super(source);
-    m_response = response;
-    m_transaction = clientTransaction;
-}*/
+func NewResponseEvent(clientTransaction ClientTransaction, response message.Response) *ResponseEvent {
+	return &ResponseEvent{m_response: response, m_transaction: clientTransaction}
+}
 
 /**
  * Gets the client transaction associated with this ResponseEvent
@@ -0,0 +1,70 @@
+package address
+
+/**
+ * This interface provides the factory methods of AddressFactory for the
+ * URIs of this implementation: a SipURI and a TelURL are created as the
+ * SipURIImpl and the TelURLImpl of the parsed messages, which do not
+ * implement the SipURI and TelURL interfaces.
+ */
+type AddressImplFactory interface {
+
+	/**
+	 * Creates a URI based on given URI string. The URI string is parsed in
+	 * order to create the new URI instance: a SipURIImpl, a TelURLImpl or
+	 * a generic URI depending on its scheme.
+	 *
+	 * @param uri - the new string value of the URI.
+	 * @throws ParseException if the URI string is malformed.
+	 */
+	CreateURI(uriStr string) (uri URI, ParseException error)
+
+	/**
+	 * Creates a SipURIImpl based on the given user and host components. The
+	 * user component may be "". The characters of the user that are not
+	 * legal in the user part of a URI are escaped.
+	 *
+	 * @param user - the new string value of the user, this value may be "".
+	 * @param host - the new string value of the host.
+	 * @throws ParseException if the URI string is malformed.
+	 */
+	CreateSipURI(user, host string) (sipuri *SipURIImpl, ParseException error)
+
+	/**
+	 * Creates a TelURLImpl based on given URI string. The scheme should not
+	 * be included in the phoneNumber string argument.
+	 *
+	 * @param uri - the new string value of the phoneNumber.
+	 * @throws ParseException if the URI string is malformed.
+	 */
+	CreateTelURL(phoneNumber string) (telurl *TelURLImpl, ParseException error)
+
+	/**
+	 * Creates an Address with the new address string value. The address
+	 * string is parsed in order to create the new Address instance. Create
+	 * with a String value of "*" creates a wildcard address.
+	 *
+	 * @param address - the new string value of the address.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the address value.
+	 */
+	CreateAddressFromString(addrStr string) (addr Address, ParseException error)
+
+	/**
+	 * Creates an Address with the new URI attribute value.
+	 *
+	 * @param uri - the URI value of the address.
+	 */
+	CreateAddressFromURI(uri URI) (addr Address)
+
+	/**
+	 * Creates an Address with the new display name and URI attribute
+	 * values.
+	 *
+	 * @param displayName - the new string value of the display name of the
+	 * address. A "" value does not set the display name.
+	 * @param uri - the new URI value of the address.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the displayName value.
+	 */
+	CreateAddressFromURIWithDisplayName(displayName string, uri URI) (addr Address, ParseException error)
+}
@@ -823,6 +823,33 @@ func (this *SipURIImpl) SetLrParam() {
 	this.uriParms.AddNameValue(nv)
 }
 
+/** Returns true if this SipURI is a GRUU, i.e. carries the <code>gr</code>
+ * parameter (RFC 5627). A public GRUU has the instance id as the value of
+ * the parameter, a temporary GRUU has a <code>gr</code> flag.
+ */
+func (this *SipURIImpl) IsGruu() bool {
+	return this.uriParms.HasNameValue(core.SIPTransportNames_GR)
+}
+
+/** Returns the value of the <code>gr</code> parameter, or an empty string
+ * if it is not Set or is a flag.
+ */
+func (this *SipURIImpl) GetGrParam() string {
+	return this.GetParameter(core.SIPTransportNames_GR)
+}
+
+/** Sets the <code>gr</code> parameter of this SipURI. An empty value Sets
+ * the flag form used by temporary GRUUs.
+ */
+func (this *SipURIImpl) SetGrParam(value string) {
+	this.uriParms.Delete(core.SIPTransportNames_GR)
+	if value == "" {
+		this.uriParms.AddNameValue(core.NewNameValue(core.SIPTransportNames_GR, nil))
+	} else {
+		this.uriParms.AddNameValue(core.NewNameValue(core.SIPTransportNames_GR, value))
+	}
+}
+
 /** Sets the value of the <code>maddr</code> parameter of this SipURI. The
  * maddr parameter indicates the server address to be contacted for this
  * user, overriding any address derived from the host field. This is
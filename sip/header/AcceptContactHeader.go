@@ -0,0 +1,25 @@
+package header
+
+/**
+ * The Accept-Contact header (RFC 3841 section 9.2) describes the UAs a
+ * request should reach, as a predicate over their feature sets. With
+ * require, a UA that does not match is not tried; with explicit, only the
+ * UAs that list all the features of the predicate score. A request may
+ * carry several Accept-Contact headers.
+ * <p>
+ * For Example:<br>
+ * <code>Accept-Contact: *;audio;video;require</code>
+ *
+ * @see FeatureSet
+ */
+type AcceptContactHeader interface {
+	ParametersHeader
+	Header
+
+	GetFeatureSet() FeatureSet
+	SetFeature(tag string, values ...string) (ParseException error)
+	IsRequire() bool
+	SetRequire(require bool)
+	IsExplicit() bool
+	SetExplicit(explicit bool)
+}
@@ -0,0 +1,77 @@
+package header
+
+import (
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Accept-Contact SIPHeader Object (RFC 3841).
+ */
+type AcceptContact struct {
+	Parameters
+}
+
+/** Default constructor
+ */
+func NewAcceptContact() *AcceptContact {
+	this := &AcceptContact{}
+	this.Parameters.super(core.SIPHeaderNames_ACCEPT_CONTACT)
+	return this
+}
+
+func (this *AcceptContact) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the header content into a String.
+ * @return String
+ */
+func (this *AcceptContact) EncodeBody() string {
+	return core.SIPSeparatorNames_STAR + encodeFeatureParameters(this.parameters)
+}
+
+/** Get the feature set of the predicate.
+ */
+func (this *AcceptContact) GetFeatureSet() FeatureSet {
+	return NewFeatureSet(this.parameters)
+}
+
+/** Set the values of a feature tag of the predicate.
+ */
+func (this *AcceptContact) SetFeature(tag string, values ...string) (ParseException error) {
+	return setFeatureParameter(&this.Parameters, tag, values)
+}
+
+/** Return true if a UA that does not match the predicate must not be
+ * tried.
+ */
+func (this *AcceptContact) IsRequire() bool {
+	return this.HasParameter(ParameterNames_REQUIRE)
+}
+
+/** Set the require flag.
+ */
+func (this *AcceptContact) SetRequire(require bool) {
+	this.setFlag(ParameterNames_REQUIRE, require)
+}
+
+/** Return true if only the UAs that list all the features of the
+ * predicate match it.
+ */
+func (this *AcceptContact) IsExplicit() bool {
+	return this.HasParameter(ParameterNames_EXPLICIT)
+}
+
+/** Set the explicit flag.
+ */
+func (this *AcceptContact) SetExplicit(explicit bool) {
+	this.setFlag(ParameterNames_EXPLICIT, explicit)
+}
+
+func (this *AcceptContact) setFlag(name string, flag bool) {
+	this.RemoveParameter(name)
+	if flag {
+		this.SetParameterFromNameValue(core.NewNameValue(name, nil))
+	}
+}
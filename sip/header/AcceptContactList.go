@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Accept-Contact Headers.
+ */
+type AcceptContactList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewAcceptContactList() *AcceptContactList {
+	this := &AcceptContactList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_ACCEPT_CONTACT)
+	return this
+}
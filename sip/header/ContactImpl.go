@@ -6,6 +6,7 @@ import (
 	"github.com/use-go/gosips/core"
 	"github.com/use-go/gosips/sip/address"
 	"strconv"
+	"strings"
 )
 
 /**
@@ -129,10 +130,11 @@ func (this *Contact) SetContactList(cl *ContactList) {
  * @param w boolean to set
  */
 func (this *Contact) SetWildCardFlag(w bool) {
-	this.wildCardFlag = true
 	addr := address.NewAddressImpl()
 	addr.SetWildCardFlag()
+	// SetAddress clears the flag.
 	this.SetAddress(addr)
+	this.wildCardFlag = true
 }
 
 /**
@@ -197,3 +199,81 @@ func (this *Contact) SetQValue(q float32) (InvalidArgumentException error) {
 	this.SetParameter(ParameterNames_Q, strconv.FormatFloat(float64(q), 'f', -1, 32))
 	return nil
 }
+
+/** Get the reg-id parameter (RFC 5626), or -1 if the parameter is absent.
+ */
+func (this *Contact) GetRegId() int {
+	if !this.HasParameter(ParameterNames_REG_ID) {
+		return -1
+	}
+	regId, err := strconv.Atoi(this.GetParameter(ParameterNames_REG_ID))
+	if err != nil {
+		return -1
+	}
+	return regId
+}
+
+/** Set the reg-id parameter (RFC 5626).
+ * @param regId a positive flow identifier.
+ */
+func (this *Contact) SetRegId(regId int) (InvalidArgumentException error) {
+	if regId <= 0 {
+		return errors.New("InvalidArgumentException: reg-id must be positive")
+	}
+	this.SetParameter(ParameterNames_REG_ID, strconv.Itoa(regId))
+	return nil
+}
+
+/** Get the +sip.instance parameter without its quotes, or an empty
+ * string if the parameter is absent.
+ */
+func (this *Contact) GetSipInstance() string {
+	return strings.Trim(this.GetParameter(ParameterNames_SIP_INSTANCE), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the +sip.instance parameter, e.g. "<urn:uuid:...>".
+ */
+func (this *Contact) SetSipInstance(instance string) {
+	this.SetQuotedParameter(ParameterNames_SIP_INSTANCE,
+		strings.Trim(instance, core.SIPSeparatorNames_DOUBLE_QUOTE))
+}
+
+/** Get the pub-gruu parameter (RFC 5627) without its quotes, or an empty
+ * string if the parameter is absent.
+ */
+func (this *Contact) GetPubGruu() string {
+	return strings.Trim(this.GetParameter(ParameterNames_PUB_GRUU), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the pub-gruu parameter (RFC 5627).
+ */
+func (this *Contact) SetPubGruu(gruu string) {
+	this.SetQuotedParameter(ParameterNames_PUB_GRUU, gruu)
+}
+
+/** Get the temp-gruu parameter (RFC 5627) without its quotes, or an empty
+ * string if the parameter is absent.
+ */
+func (this *Contact) GetTempGruu() string {
+	return strings.Trim(this.GetParameter(ParameterNames_TEMP_GRUU), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the temp-gruu parameter (RFC 5627).
+ */
+func (this *Contact) SetTempGruu(gruu string) {
+	this.SetQuotedParameter(ParameterNames_TEMP_GRUU, gruu)
+}
+
+/** Get the feature set of the Contact: the capabilities of its UA
+ * (RFC 3840).
+ */
+func (this *Contact) GetFeatureSet() FeatureSet {
+	return NewFeatureSet(this.GetParameters())
+}
+
+/** Set the values of a feature tag, e.g. "sip.audio" with TRUE or
+ * "sip.methods" with INVITE and BYE (RFC 3840 section 9).
+ */
+func (this *Contact) SetFeature(tag string, values ...string) (ParseException error) {
+	return setFeatureParameter(&this.Parameters, tag, values)
+}
@@ -0,0 +1,29 @@
+package header
+
+/**
+ * The Diversion header field (RFC 5806) is the legacy way of recording
+ * call forwarding: each time a request is diverted the diverting party is
+ * added on top of the list, with the reason for the diversion and the
+ * number of diversions so far. History-Info (RFC 7044) supersedes it but
+ * many voicemail and PSTN gateways still rely on it.
+ * <p>
+ * For Example:<br>
+ * <code>Diversion: &lt;sip:bob@example.com&gt;;reason=user-busy;counter=1;privacy=off</code>
+ *
+ * @see HistoryInfoHeader
+ */
+type DiversionHeader interface {
+	AddressHeader
+	ParametersHeader
+
+	GetReason() string
+	SetReason(reason string) (ParseException error)
+	GetCounter() int
+	SetCounter(counter int) (InvalidArgumentException error)
+	GetLimit() int
+	SetLimit(limit int) (InvalidArgumentException error)
+	GetPrivacy() string
+	SetPrivacy(privacy string) (ParseException error)
+	GetScreen() string
+	SetScreen(screen string) (ParseException error)
+}
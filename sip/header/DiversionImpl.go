@@ -0,0 +1,157 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/** The reasons for a diversion (RFC 5806 section 4).
+ */
+const (
+	Diversion_UNKNOWN        = "unknown"
+	Diversion_USER_BUSY      = "user-busy"
+	Diversion_NO_ANSWER      = "no-answer"
+	Diversion_UNAVAILABLE    = "unavailable"
+	Diversion_UNCONDITIONAL  = "unconditional"
+	Diversion_TIME_OF_DAY    = "time-of-day"
+	Diversion_DO_NOT_DISTURB = "do-not-disturb"
+	Diversion_DEFLECTION     = "deflection"
+	Diversion_FOLLOW_ME      = "follow-me"
+	Diversion_OUT_OF_SERVICE = "out-of-service"
+	Diversion_AWAY           = "away"
+)
+
+/**
+* Diversion SIPHeader Object (RFC 5806).
+ */
+type Diversion struct {
+	AddressParameters
+}
+
+/** Default constructor
+ */
+func NewDiversion() *Diversion {
+	this := &Diversion{}
+	this.AddressParameters.super(core.SIPHeaderNames_DIVERSION)
+	return this
+}
+
+/** Default constructor given an address.
+ *
+ *@param address -- address of this header.
+ *
+ */
+func NewDiversionFromAddress(addr address.Address) *Diversion {
+	this := &Diversion{}
+	this.AddressParameters.super(core.SIPHeaderNames_DIVERSION)
+	this.addr = addr
+	return this
+}
+
+func (this *Diversion) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode into canonical form. The address is always a name-addr.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *Diversion) EncodeBody() string {
+	var encoding bytes.Buffer
+	addr, _ := this.addr.(*address.AddressImpl)
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_LESS_THAN)
+	}
+	encoding.WriteString(this.addr.String())
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_GREATER_THAN)
+	}
+
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the reason for the diversion, e.g. "user-busy".
+ */
+func (this *Diversion) GetReason() string {
+	return this.GetParameter(ParameterNames_REASON)
+}
+
+/** Set the reason for the diversion.
+ */
+func (this *Diversion) SetReason(reason string) (ParseException error) {
+	if reason == "" {
+		return errors.New("ParseException: Diversion, SetReason(), the reason is empty")
+	}
+	return this.SetParameter(ParameterNames_REASON, reason)
+}
+
+/** Get the number of diversions of the request so far, 0 if absent.
+ */
+func (this *Diversion) GetCounter() int {
+	counter, _ := strconv.Atoi(this.GetParameter(ParameterNames_COUNTER))
+	return counter
+}
+
+/** Set the number of diversions of the request so far.
+ */
+func (this *Diversion) SetCounter(counter int) (InvalidArgumentException error) {
+	if counter < 1 {
+		return errors.New("InvalidArgumentException: Diversion, SetCounter(), the counter must be positive")
+	}
+	return this.SetParameter(ParameterNames_COUNTER, strconv.Itoa(counter))
+}
+
+/** Get the maximum number of diversions allowed, 0 if absent.
+ */
+func (this *Diversion) GetLimit() int {
+	limit, _ := strconv.Atoi(this.GetParameter(ParameterNames_LIMIT))
+	return limit
+}
+
+/** Set the maximum number of diversions allowed.
+ */
+func (this *Diversion) SetLimit(limit int) (InvalidArgumentException error) {
+	if limit < 1 {
+		return errors.New("InvalidArgumentException: Diversion, SetLimit(), the limit must be positive")
+	}
+	return this.SetParameter(ParameterNames_LIMIT, strconv.Itoa(limit))
+}
+
+/** Get the privacy of the diverting party: "full", "name", "uri" or
+ * "off".
+ */
+func (this *Diversion) GetPrivacy() string {
+	return this.GetParameter(ParameterNames_PRIVACY)
+}
+
+/** Set the privacy of the diverting party.
+ */
+func (this *Diversion) SetPrivacy(privacy string) (ParseException error) {
+	if privacy == "" {
+		return errors.New("ParseException: Diversion, SetPrivacy(), the privacy is empty")
+	}
+	return this.SetParameter(ParameterNames_PRIVACY, privacy)
+}
+
+/** Get whether the diverting party was screened: "yes" or "no".
+ */
+func (this *Diversion) GetScreen() string {
+	return this.GetParameter(ParameterNames_SCREEN)
+}
+
+/** Set whether the diverting party was screened.
+ */
+func (this *Diversion) SetScreen(screen string) (ParseException error) {
+	if screen == "" {
+		return errors.New("ParseException: Diversion, SetScreen(), the screen is empty")
+	}
+	return this.SetParameter(ParameterNames_SCREEN, screen)
+}
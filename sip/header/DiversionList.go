@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Diversion Headers.
+ */
+type DiversionList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewDiversionList() *DiversionList {
+	this := &DiversionList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_DIVERSION)
+	return this
+}
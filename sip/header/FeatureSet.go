@@ -0,0 +1,249 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+)
+
+/** The base feature tags of RFC 3840 section 10, and the prefix they take
+ * in a feature set.
+ */
+const (
+	FeatureTag_SIP_PREFIX  = "sip."
+	FeatureTag_AUDIO       = "audio"
+	FeatureTag_AUTOMATA    = "automata"
+	FeatureTag_CLASS       = "class"
+	FeatureTag_DUPLEX      = "duplex"
+	FeatureTag_DATA        = "data"
+	FeatureTag_CONTROL     = "control"
+	FeatureTag_MOBILITY    = "mobility"
+	FeatureTag_DESCRIPTION = "description"
+	FeatureTag_EVENTS      = "events"
+	FeatureTag_PRIORITY    = "priority"
+	FeatureTag_METHODS     = "methods"
+	FeatureTag_SCHEMES     = "schemes"
+	FeatureTag_APPLICATION = "application"
+	FeatureTag_VIDEO       = "video"
+	FeatureTag_LANGUAGE    = "language"
+	FeatureTag_TYPE        = "type"
+	FeatureTag_ISFOCUS     = "isfocus"
+	FeatureTag_ACTOR       = "actor"
+	FeatureTag_TEXT        = "text"
+	FeatureTag_EXTENSIONS  = "extensions"
+)
+
+/** The boolean values of a feature tag; a flag parameter is TRUE.
+ */
+const (
+	FeatureValue_TRUE  = "TRUE"
+	FeatureValue_FALSE = "FALSE"
+)
+
+var featureTag_BASE = map[string]bool{
+	FeatureTag_AUDIO: true, FeatureTag_AUTOMATA: true, FeatureTag_CLASS: true,
+	FeatureTag_DUPLEX: true, FeatureTag_DATA: true, FeatureTag_CONTROL: true,
+	FeatureTag_MOBILITY: true, FeatureTag_DESCRIPTION: true, FeatureTag_EVENTS: true,
+	FeatureTag_PRIORITY: true, FeatureTag_METHODS: true, FeatureTag_SCHEMES: true,
+	FeatureTag_APPLICATION: true, FeatureTag_VIDEO: true, FeatureTag_LANGUAGE: true,
+	FeatureTag_TYPE: true, FeatureTag_ISFOCUS: true, FeatureTag_ACTOR: true,
+	FeatureTag_TEXT: true, FeatureTag_EXTENSIONS: true,
+}
+
+/** Get the feature tag a header parameter carries, or "" if it is not a
+ * feature parameter (RFC 3840 section 9). A base tag is written without
+ * its "sip." prefix and any other tag with a leading '+': "audio" and
+ * "+sip.audio" are the tag sip.audio, "+sip.instance" is sip.instance and
+ * "+org.example.foo" is org.example.foo.
+ */
+func GetFeatureTag(parameterName string) string {
+	name := strings.ToLower(parameterName)
+	if strings.HasPrefix(name, "+") {
+		return name[1:]
+	}
+	if featureTag_BASE[name] {
+		return FeatureTag_SIP_PREFIX + name
+	}
+	return ""
+}
+
+/**
+ * A FeatureSet holds the feature parameters of a Contact, an
+ * Accept-Contact or a Reject-Contact header (RFC 3840 section 9): each
+ * feature tag maps to the values listed for it. A value is one of
+ * <ul>
+ * <li>a boolean, TRUE or FALSE;
+ * <li>a token, e.g. INVITE, compared without regard to case;
+ * <li>a string in angle brackets, e.g. &lt;urn:uuid:...&gt;, compared
+ * exactly;
+ * <li>a number or a numeric range: #4 or #=4, #>=2, #<=5 or #1:5;
+ * </ul>
+ * and any of them may be negated with a leading '!'. In a Contact the
+ * values are those the UA supports; in a predicate they are alternatives.
+ */
+type FeatureSet map[string][]string
+
+/** Create the feature set of a list of header parameters. */
+func NewFeatureSet(parameters *core.NameValueList) FeatureSet {
+	featureSet := make(FeatureSet)
+	if parameters == nil {
+		return featureSet
+	}
+	for e := parameters.Front(); e != nil; e = e.Next() {
+		nv := e.Value.(*core.NameValue)
+		tag := GetFeatureTag(nv.GetName())
+		if tag == "" {
+			continue
+		}
+		value, _ := nv.GetValue().(string)
+		value = strings.Trim(value, core.SIPSeparatorNames_DOUBLE_QUOTE)
+		if value == "" {
+			featureSet[tag] = append(featureSet[tag], FeatureValue_TRUE)
+			continue
+		}
+		if strings.HasPrefix(value, core.SIPSeparatorNames_LESS_THAN) {
+			featureSet[tag] = append(featureSet[tag], value)
+			continue
+		}
+		for _, item := range strings.Split(value, core.SIPSeparatorNames_COMMA) {
+			if item = strings.TrimSpace(item); item != "" {
+				featureSet[tag] = append(featureSet[tag], item)
+			}
+		}
+	}
+	return featureSet
+}
+
+/** Match the feature set of a Contact against a predicate (RFC 3841
+ * section 7.2.4). The contact matches unless, for a tag of the predicate
+ * the contact lists, none of its values satisfies the predicate; a tag the
+ * contact does not list matches implicitly. The number of tags of the
+ * predicate the contact lists is returned as well: when it is the number
+ * of tags of the predicate the match is explicit.
+ */
+func (this FeatureSet) Matches(predicate FeatureSet) (matches bool, explicit int) {
+	for tag, values := range predicate {
+		supported, ok := this[tag]
+		if !ok {
+			continue
+		}
+		explicit++
+		if !matchesFeature(values, supported) {
+			return false, explicit
+		}
+	}
+	return true, explicit
+}
+
+/** A predicate feature is satisfied when one of its values matches one of
+ * the values of the contact.
+ */
+func matchesFeature(values, supported []string) bool {
+	for _, value := range values {
+		for _, s := range supported {
+			if matchesFeatureValue(value, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesFeatureValue(value, supported string) bool {
+	if strings.HasPrefix(value, "!") {
+		return !matchesFeatureValue(value[1:], supported)
+	}
+	if strings.HasPrefix(supported, "!") {
+		return !matchesFeatureValue(value, supported[1:])
+	}
+	if strings.HasPrefix(value, "#") || strings.HasPrefix(supported, "#") {
+		lo, hi, ok := parseFeatureRange(value)
+		supportedLo, supportedHi, supportedOk := parseFeatureRange(supported)
+		return ok && supportedOk && lo <= supportedHi && supportedLo <= hi
+	}
+	if strings.HasPrefix(value, core.SIPSeparatorNames_LESS_THAN) {
+		return value == supported
+	}
+	return strings.EqualFold(value, supported)
+}
+
+/** Parse a numeric feature value into the range of numbers it covers.
+ */
+func parseFeatureRange(value string) (lo, hi float64, ok bool) {
+	if !strings.HasPrefix(value, "#") {
+		return 0, 0, false
+	}
+	value = strings.TrimPrefix(value[1:], "=")
+	var err error
+	switch {
+	case strings.HasPrefix(value, ">="):
+		lo, err = strconv.ParseFloat(value[2:], 64)
+		hi = math.Inf(1)
+	case strings.HasPrefix(value, "<="):
+		hi, err = strconv.ParseFloat(value[2:], 64)
+		lo = math.Inf(-1)
+	case strings.Contains(value, ":"):
+		bounds := strings.SplitN(value, ":", 2)
+		if lo, err = strconv.ParseFloat(bounds[0], 64); err == nil {
+			hi, err = strconv.ParseFloat(bounds[1], 64)
+		}
+	default:
+		lo, err = strconv.ParseFloat(value, 64)
+		hi = lo
+	}
+	return lo, hi, err == nil
+}
+
+/** Get the name of the header parameter carrying a feature tag: the base
+ * tags lose their "sip." prefix and the other tags get a leading '+'.
+ */
+func GetFeatureParameterName(tag string) string {
+	tag = strings.ToLower(tag)
+	if strings.HasPrefix(tag, FeatureTag_SIP_PREFIX) && featureTag_BASE[tag[len(FeatureTag_SIP_PREFIX):]] {
+		return tag[len(FeatureTag_SIP_PREFIX):]
+	}
+	return "+" + tag
+}
+
+/** Set the values of a feature tag in header parameters. A feature with
+ * the single value TRUE is a flag parameter.
+ */
+func setFeatureParameter(parameters *Parameters, tag string, values []string) (ParseException error) {
+	if len(values) == 0 {
+		return errors.New("ParseException: the feature " + tag + " has no value")
+	}
+	name := GetFeatureParameterName(tag)
+	parameters.RemoveParameter(name)
+	if len(values) == 1 && values[0] == FeatureValue_TRUE {
+		parameters.SetParameterFromNameValue(core.NewNameValue(name, nil))
+		return nil
+	}
+	for _, value := range values {
+		if value == "" || strings.Contains(value, core.SIPSeparatorNames_DOUBLE_QUOTE) {
+			return errors.New("ParseException: bad value of the feature " + tag)
+		}
+	}
+	parameters.SetQuotedParameter(name, strings.Join(values, core.SIPSeparatorNames_COMMA))
+	return nil
+}
+
+/** Encode the parameters of a header carrying a feature predicate. A
+ * parameter without a value is a flag and is encoded without '='.
+ */
+func encodeFeatureParameters(parameters *core.NameValueList) string {
+	var encoding bytes.Buffer
+	for e := parameters.Front(); e != nil; e = e.Next() {
+		nv := e.Value.(*core.NameValue)
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		if value, _ := nv.GetValue().(string); value == "" && !nv.IsValueQuoted() {
+			encoding.WriteString(nv.GetName())
+		} else {
+			encoding.WriteString(nv.String())
+		}
+	}
+	return encoding.String()
+}
@@ -0,0 +1,195 @@
+package header
+
+import (
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+ * This interface provides factory methods that allow an application to create
+ * Header objects from a particular implementation of this specification. The
+ * arguments of the headers are parsed, so that a header that is created is a
+ * header that the parser accepts in a message. A header with several values,
+ * e.g. a Via header, is created one value at a time.
+ */
+type HeaderFactory interface {
+
+	/**
+	 * Creates a new Header based on the newly supplied name and value
+	 * values. The header is parsed by the parser registered for its name, so
+	 * that it has the type of the headers of that name in a parsed message,
+	 * e.g. a ViaList for "Via"; a header that has no registered parser is an
+	 * Extension header.
+	 *
+	 * @param name - the new string name of the Header value.
+	 * @param value - the new string value of the Header.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the name or value parameters.
+	 */
+	CreateHeader(name, value string) (h Header, ParseException error)
+
+	/**
+	 * Creates a new CallIdHeader based on the newly supplied callId value.
+	 *
+	 * @param callId - the new string value of the call-id.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the callId value.
+	 */
+	CreateCallIdHeader(callId string) (callIdHeader CallIdHeader, ParseException error)
+
+	/**
+	 * Creates a new CSeqHeader based on the newly supplied sequence number and
+	 * method values.
+	 *
+	 * @param sequenceNumber - the new integer value of the sequence number.
+	 * @param method - the new string value of the method.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the method value or the sequence number is
+	 * not between 0 and 2**31 - 1.
+	 */
+	CreateCSeqHeader(sequenceNumber int, method string) (cSeqHeader CSeqHeader, ParseException error)
+
+	/**
+	 * Creates a new FromHeader based on the newly supplied address and
+	 * tag values.
+	 *
+	 * @param addr - the new Address object of the address.
+	 * @param tag - the new string value of the tag, or "" for no tag.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the tag value.
+	 */
+	CreateFromHeader(addr address.Address, tag string) (fromHeader FromHeader, ParseException error)
+
+	/**
+	 * Creates a new ToHeader based on the newly supplied address and
+	 * tag values.
+	 *
+	 * @param addr - the new Address object of the address.
+	 * @param tag - the new string value of the tag, or "" for no tag.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the tag value.
+	 */
+	CreateToHeader(addr address.Address, tag string) (toHeader ToHeader, ParseException error)
+
+	/**
+	 * Creates a new ViaHeader based on the newly supplied host, port, transport
+	 * and branch values.
+	 *
+	 * @param host - the new string value of the host.
+	 * @param port - the new integer value of the port, or 0 for no port.
+	 * @param transport - the new string value of the transport.
+	 * @param branch - the new string value of the branch, or "" for no branch.
+	 * @return the Via of the header, as in the parsed messages.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the host, transport or branch value.
+	 */
+	CreateViaHeader(host string, port int, transport, branch string) (via *Via, ParseException error)
+
+	/**
+	 * Creates a new MaxForwardsHeader based on the newly supplied maxForwards
+	 * value.
+	 *
+	 * @param maxForwards - the new integer value of the maxForwards, between
+	 * 0 and 255.
+	 * @throws ParseException if the maxForwards is out of range.
+	 */
+	CreateMaxForwardsHeader(maxForwards int) (maxForwardsHeader MaxForwardsHeader, ParseException error)
+
+	/**
+	 * Creates a new ContactHeader based on the newly supplied address value.
+	 *
+	 * @param addr - the new Address value of the address.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the address value.
+	 */
+	CreateContactHeader(addr address.Address) (contactHeader ContactHeader, ParseException error)
+
+	/**
+	 * Creates a new wildcard ContactHeader. This is used in Register requests
+	 * to indicate to the server that it should remove all locations
+	 * at which the user is currently available.
+	 */
+	CreateWildCardContactHeader() ContactHeader
+
+	/**
+	 * Creates a new ContentTypeHeader based on the newly supplied contentType and
+	 * contentSubType values.
+	 *
+	 * @param contentType - the new string content type value.
+	 * @param contentSubType - the new string content sub-type value.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the content type or content subtype value.
+	 */
+	CreateContentTypeHeader(contentType, contentSubType string) (contentTypeHeader ContentTypeHeader, ParseException error)
+
+	/**
+	 * Creates a new ContentLengthHeader based on the newly supplied contentLength value.
+	 *
+	 * @param contentLength - the new integer value of the contentLength.
+	 * @throws ParseException if the contentLength is negative.
+	 */
+	CreateContentLengthHeader(contentLength int) (contentLengthHeader ContentLengthHeader, ParseException error)
+
+	/**
+	 * Creates a new ExpiresHeader based on the newly supplied expires value.
+	 *
+	 * @param expires - the new integer value of the expires, in seconds.
+	 * @throws ParseException if the expires is negative.
+	 */
+	CreateExpiresHeader(expires int) (expiresHeader ExpiresHeader, ParseException error)
+
+	/**
+	 * Creates a new RouteHeader based on the newly supplied address value.
+	 *
+	 * @param addr - the new Address object of the address.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the address value.
+	 */
+	CreateRouteHeader(addr address.Address) (routeHeader RouteHeader, ParseException error)
+
+	/**
+	 * Creates a new RecordRouteHeader based on the newly supplied address value.
+	 *
+	 * @param addr - the new Address object of the address.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the address value.
+	 */
+	CreateRecordRouteHeader(addr address.Address) (recordRouteHeader RecordRouteHeader, ParseException error)
+
+	/**
+	 * Creates a new EventHeader based on the newly supplied eventType value.
+	 *
+	 * @param eventType - the new string value of the eventType.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the eventType value.
+	 */
+	CreateEventHeader(eventType string) (eventHeader EventHeader, ParseException error)
+
+	/**
+	 * Creates a new AllowHeader based on the newly supplied method value.
+	 *
+	 * @param method - the new string value of the method.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the method value.
+	 */
+	CreateAllowHeader(method string) (allowHeader AllowHeader, ParseException error)
+
+	/**
+	 * Creates a new SupportedHeader based on the newly supplied optionTag
+	 * value.
+	 *
+	 * @param optionTag - the new string value of the optionTag.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the optionTag value.
+	 */
+	CreateSupportedHeader(optionTag string) (supportedHeader SupportedHeader, ParseException error)
+
+	/**
+	 * Creates a new RequireHeader based on the newly supplied optionTag
+	 * value.
+	 *
+	 * @param optionTag - the new string value of the optionTag.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the optionTag value.
+	 */
+	CreateRequireHeader(optionTag string) (requireHeader RequireHeader, ParseException error)
+}
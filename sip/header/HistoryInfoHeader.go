@@ -0,0 +1,39 @@
+package header
+
+/**
+ * The History-Info header field (RFC 7044) records the targets a request
+ * was sent to as it was retargeted by proxies and B2BUAs. Each entry is a
+ * hi-entry: the Request-URI the request was sent to, with an index giving
+ * its position in the tree of retargetings, and one of the rc, mp or np
+ * parameters telling how the target was reached from its parent entry:
+ * <ul>
+ * <li>rc: the Request-URI was changed to a contact bound to the
+ * address-of-record of the parent, e.g. by a registrar lookup.
+ * <li>mp: the target user was changed, e.g. by call forwarding.
+ * <li>np: the Request-URI was not changed.
+ * </ul>
+ * The Reason header embedded in the URI of an entry gives the response
+ * that caused the request to be retargeted away from it.
+ * <p>
+ * For Example:<br>
+ * <code>History-Info: &lt;sip:bob@example.com?Reason=SIP%3Bcause%3D302&gt;;index=1,
+ * &lt;sip:carol@example.com&gt;;index=1.1;mp=1</code>
+ *
+ * @see DiversionHeader
+ */
+type HistoryInfoHeader interface {
+	AddressHeader
+	ParametersHeader
+
+	GetIndex() string
+	SetIndex(index string) (ParseException error)
+	GetRc() string
+	SetRc(parent string) (ParseException error)
+	GetMp() string
+	SetMp(parent string) (ParseException error)
+	GetNp() string
+	SetNp(parent string) (ParseException error)
+	GetReason() string
+	SetReason(cause int, text string)
+	GetCause() int
+}
@@ -0,0 +1,228 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/** The protocol of the Reason header embedded in a hi-entry.
+ */
+const HistoryInfo_REASON_PROTOCOL = "SIP"
+
+/**
+* History-Info SIPHeader Object (RFC 7044).
+ */
+type HistoryInfo struct {
+	AddressParameters
+}
+
+/** Default constructor
+ */
+func NewHistoryInfo() *HistoryInfo {
+	this := &HistoryInfo{}
+	this.AddressParameters.super(core.SIPHeaderNames_HISTORY_INFO)
+	return this
+}
+
+/** Default constructor given an address.
+ *
+ *@param address -- address of this header.
+ *
+ */
+func NewHistoryInfoFromAddress(addr address.Address) *HistoryInfo {
+	this := &HistoryInfo{}
+	this.AddressParameters.super(core.SIPHeaderNames_HISTORY_INFO)
+	this.addr = addr
+	return this
+}
+
+func (this *HistoryInfo) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode into canonical form. The address is always a name-addr.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *HistoryInfo) EncodeBody() string {
+	var encoding bytes.Buffer
+	addr, _ := this.addr.(*address.AddressImpl)
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_LESS_THAN)
+	}
+	encoding.WriteString(this.addr.String())
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_GREATER_THAN)
+	}
+
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the index of the entry, e.g. "1.1.2".
+ */
+func (this *HistoryInfo) GetIndex() string {
+	return this.GetParameter(ParameterNames_INDEX)
+}
+
+/** Set the index of the entry: dot separated numbers, e.g. "1.1.2".
+ */
+func (this *HistoryInfo) SetIndex(index string) (ParseException error) {
+	if !IsHistoryInfoIndex(index) {
+		return errors.New("ParseException: HistoryInfo, SetIndex(), bad index " + index)
+	}
+	return this.SetParameter(ParameterNames_INDEX, index)
+}
+
+/** Get the index of the parent of an entry reached by a change of the
+ * Request-URI to a registered contact, or an empty string.
+ */
+func (this *HistoryInfo) GetRc() string {
+	return this.GetParameter(ParameterNames_RC)
+}
+
+/** Mark the entry as reached from its parent by a change of the
+ * Request-URI to a registered contact.
+ */
+func (this *HistoryInfo) SetRc(parent string) (ParseException error) {
+	return this.setTargetedBy(ParameterNames_RC, parent)
+}
+
+/** Get the index of the parent of an entry reached by a change of the
+ * target user, or an empty string.
+ */
+func (this *HistoryInfo) GetMp() string {
+	return this.GetParameter(ParameterNames_MP)
+}
+
+/** Mark the entry as reached from its parent by a change of the target
+ * user.
+ */
+func (this *HistoryInfo) SetMp(parent string) (ParseException error) {
+	return this.setTargetedBy(ParameterNames_MP, parent)
+}
+
+/** Get the index of the parent of an entry reached without a change of
+ * the Request-URI, or an empty string.
+ */
+func (this *HistoryInfo) GetNp() string {
+	return this.GetParameter(ParameterNames_NP)
+}
+
+/** Mark the entry as reached from its parent without a change of the
+ * Request-URI.
+ */
+func (this *HistoryInfo) SetNp(parent string) (ParseException error) {
+	return this.setTargetedBy(ParameterNames_NP, parent)
+}
+
+/** Get the Reason header embedded in the URI of the entry, unescaped, e.g.
+ * "SIP;cause=302", or an empty string.
+ */
+func (this *HistoryInfo) GetReason() string {
+	uri, ok := this.getSipURI()
+	if !ok {
+		return ""
+	}
+	reason := uri.GetHeader(core.SIPHeaderNames_REASON)
+	if unescaped, err := url.QueryUnescape(reason); err == nil {
+		return unescaped
+	}
+	return reason
+}
+
+/** Embed in the URI of the entry the Reason for retargeting the request
+ * away from it: the response code and its reason phrase, if any.
+ */
+func (this *HistoryInfo) SetReason(cause int, text string) {
+	uri, ok := this.getSipURI()
+	if !ok {
+		return
+	}
+	reason := HistoryInfo_REASON_PROTOCOL + core.SIPSeparatorNames_SEMICOLON +
+		ParameterNames_CAUSE + core.SIPSeparatorNames_EQUALS + strconv.Itoa(cause)
+	if text != "" {
+		reason += core.SIPSeparatorNames_SEMICOLON + ParameterNames_TEXT + core.SIPSeparatorNames_EQUALS +
+			core.SIPSeparatorNames_DOUBLE_QUOTE + text + core.SIPSeparatorNames_DOUBLE_QUOTE
+	}
+	uri.SetHeader(core.SIPHeaderNames_REASON, escapeHeaderValue(reason))
+}
+
+/** Get the cause of the embedded Reason, or 0.
+ */
+func (this *HistoryInfo) GetCause() int {
+	for _, param := range strings.Split(this.GetReason(), core.SIPSeparatorNames_SEMICOLON) {
+		if nv := strings.SplitN(strings.TrimSpace(param), core.SIPSeparatorNames_EQUALS, 2); len(nv) == 2 &&
+			strings.EqualFold(nv[0], ParameterNames_CAUSE) {
+			cause, _ := strconv.Atoi(nv[1])
+			return cause
+		}
+	}
+	return 0
+}
+
+/** Set one of rc, mp or np, removing the others.
+ */
+func (this *HistoryInfo) setTargetedBy(name, parent string) (ParseException error) {
+	if !IsHistoryInfoIndex(parent) {
+		return errors.New("ParseException: HistoryInfo, bad parent index " + parent)
+	}
+	this.RemoveParameter(ParameterNames_RC)
+	this.RemoveParameter(ParameterNames_MP)
+	this.RemoveParameter(ParameterNames_NP)
+	return this.SetParameter(name, parent)
+}
+
+func (this *HistoryInfo) getSipURI() (*address.SipURIImpl, bool) {
+	if this.addr == nil {
+		return nil, false
+	}
+	uri, ok := this.addr.GetURI().(*address.SipURIImpl)
+	return uri, ok
+}
+
+/** Return true if a string is a hi-index: dot separated numbers.
+ */
+func IsHistoryInfoIndex(index string) bool {
+	if index == "" {
+		return false
+	}
+	for _, n := range strings.Split(index, core.SIPSeparatorNames_DOT) {
+		if n == "" {
+			return false
+		}
+		for i := 0; i < len(n); i++ {
+			if n[i] < '0' || n[i] > '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+/** Escape the value of a header embedded in a URI (RFC 3261 section
+ * 25.1): everything but the unreserved characters and hnv-unreserved.
+ */
+func escapeHeaderValue(value string) string {
+	var encoding bytes.Buffer
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			strings.IndexByte("-_.!~*'()[]/?:+$", c) >= 0 {
+			encoding.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoding, "%%%02X", c)
+		}
+	}
+	return encoding.String()
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of HistoryInfo Headers.
+ */
+type HistoryInfoList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewHistoryInfoList() *HistoryInfoList {
+	this := &HistoryInfoList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_HISTORY_INFO)
+	return this
+}
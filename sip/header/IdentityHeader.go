@@ -0,0 +1,27 @@
+package header
+
+/**
+ * The Identity header field (RFC 8224) carries a PASSporT (RFC 8225): a
+ * JSON Web Token signed by the authentication service of the originating
+ * network, asserting the identity of the caller. The info parameter is the
+ * URI of the certificate that verifies the signature, alg its algorithm
+ * and ppt the PASSporT extension, e.g. "shaken" (RFC 8588).
+ * <p>
+ * For Example:<br>
+ * <code>Identity: eyJhbGciOiJFUzI1NiIs...;info=&lt;https://cert.example.org/passport.cer&gt;;alg=ES256;ppt=shaken</code>
+ *
+ * @see Parameters
+ */
+type IdentityHeader interface {
+	ParametersHeader
+	Header
+
+	GetSignedIdentityDigest() string
+	SetSignedIdentityDigest(digest string) (ParseException error)
+	GetInfo() string
+	SetInfo(info string) (ParseException error)
+	GetAlg() string
+	SetAlg(alg string) (ParseException error)
+	GetPpt() string
+	SetPpt(ppt string) (ParseException error)
+}
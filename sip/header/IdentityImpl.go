@@ -0,0 +1,106 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Identity SIPHeader Object (RFC 8224).
+ */
+type Identity struct {
+	Parameters
+
+	signedIdentityDigest string
+}
+
+/** Default constructor
+ */
+func NewIdentity() *Identity {
+	this := &Identity{}
+	this.Parameters.super(core.SIPHeaderNames_IDENTITY)
+	return this
+}
+
+func (this *Identity) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the header content into a String.
+ * @return String
+ */
+func (this *Identity) EncodeBody() string {
+	var encoding bytes.Buffer
+	encoding.WriteString(this.signedIdentityDigest)
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the signed identity digest: the PASSporT in compact form.
+ */
+func (this *Identity) GetSignedIdentityDigest() string {
+	return this.signedIdentityDigest
+}
+
+/** Set the signed identity digest.
+ */
+func (this *Identity) SetSignedIdentityDigest(digest string) (ParseException error) {
+	if digest == "" || strings.ContainsAny(digest, "; \t\r\n") {
+		return errors.New("ParseException: Identity, SetSignedIdentityDigest(), bad digest")
+	}
+	this.signedIdentityDigest = digest
+	return nil
+}
+
+/** Get the URI of the certificate, without its angle brackets.
+ */
+func (this *Identity) GetInfo() string {
+	info := this.GetParameter(ParameterNames_INFO)
+	return strings.TrimSuffix(strings.TrimPrefix(info, core.SIPSeparatorNames_LESS_THAN), core.SIPSeparatorNames_GREATER_THAN)
+}
+
+/** Set the URI of the certificate.
+ */
+func (this *Identity) SetInfo(info string) (ParseException error) {
+	if info == "" || strings.ContainsAny(info, "<> \t\r\n") {
+		return errors.New("ParseException: Identity, SetInfo(), bad info " + info)
+	}
+	return this.SetParameter(ParameterNames_INFO, core.SIPSeparatorNames_LESS_THAN+info+core.SIPSeparatorNames_GREATER_THAN)
+}
+
+/** Get the signature algorithm, e.g. "ES256".
+ */
+func (this *Identity) GetAlg() string {
+	return strings.Trim(this.GetParameter(ParameterNames_ALG), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the signature algorithm.
+ */
+func (this *Identity) SetAlg(alg string) (ParseException error) {
+	if alg == "" {
+		return errors.New("ParseException: Identity, SetAlg(), the alg is empty")
+	}
+	return this.SetParameter(ParameterNames_ALG, alg)
+}
+
+/** Get the PASSporT extension, e.g. "shaken".
+ */
+func (this *Identity) GetPpt() string {
+	return strings.Trim(this.GetParameter(ParameterNames_PPT), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the PASSporT extension.
+ */
+func (this *Identity) SetPpt(ppt string) (ParseException error) {
+	if ppt == "" {
+		return errors.New("ParseException: Identity, SetPpt(), the ppt is empty")
+	}
+	return this.SetParameter(ParameterNames_PPT, ppt)
+}
@@ -0,0 +1,25 @@
+package header
+
+/**
+ * The Join header field (RFC 3911) is carried by an INVITE that asks the
+ * recipient to join a new dialog to an existing one, e.g. to barge into a
+ * call or to add a party to a conference. The dialog is identified like
+ * in the Replaces header field.
+ * <p>
+ * For Example:<br>
+ * <code>Join: 12345600@atlanta.example.com;from-tag=1234567;to-tag=23431</code>
+ *
+ * @see ReplacesHeader
+ * @see Parameters
+ */
+type JoinHeader interface {
+	ParametersHeader
+	Header
+
+	GetCallId() string
+	SetCallId(callId string) (ParseException error)
+	GetToTag() string
+	SetToTag(toTag string) (ParseException error)
+	GetFromTag() string
+	SetFromTag(fromTag string) (ParseException error)
+}
@@ -0,0 +1,89 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Join SIPHeader Object (RFC 3911).
+ */
+type Join struct {
+	Parameters
+
+	callId string
+}
+
+/** Default constructor
+ */
+func NewJoin() *Join {
+	this := &Join{}
+	this.Parameters.super(core.SIPHeaderNames_JOIN)
+	return this
+}
+
+func (this *Join) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the body of the header: the Call-ID and the parameters.
+ * @return String
+ */
+func (this *Join) EncodeBody() string {
+	var encoding bytes.Buffer
+	encoding.WriteString(this.callId)
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the Call-ID of the dialog to join.
+ */
+func (this *Join) GetCallId() string {
+	return this.callId
+}
+
+/** Set the Call-ID of the dialog to join.
+ */
+func (this *Join) SetCallId(callId string) (ParseException error) {
+	if callId == "" {
+		return errors.New("NullPointerException: the callId parameter is null")
+	}
+	this.callId = callId
+	return nil
+}
+
+/** Get the to-tag parameter: the tag of the recipient in the dialog.
+ */
+func (this *Join) GetToTag() string {
+	return this.GetParameter(ParameterNames_TO_TAG)
+}
+
+/** Set the to-tag parameter.
+ */
+func (this *Join) SetToTag(toTag string) (ParseException error) {
+	if toTag == "" {
+		return errors.New("NullPointerException: the toTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_TO_TAG, toTag)
+}
+
+/** Get the from-tag parameter: the tag of the other party in the dialog.
+ */
+func (this *Join) GetFromTag() string {
+	return this.GetParameter(ParameterNames_FROM_TAG)
+}
+
+/** Set the from-tag parameter.
+ */
+func (this *Join) SetFromTag(fromTag string) (ParseException error) {
+	if fromTag == "" {
+		return errors.New("NullPointerException: the fromTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_FROM_TAG, fromTag)
+}
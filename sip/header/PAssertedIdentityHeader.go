@@ -0,0 +1,19 @@
+package header
+
+/**
+ * The P-Asserted-Identity header field (RFC 3325) carries the identity of
+ * the user sending a request, as asserted by a proxy of a Trust Domain
+ * that authenticated the user. It is passed only to the elements trusted
+ * to honor it, and may hold a SIP URI and a tel URI of the same user.
+ * <p>
+ * For Example:<br>
+ * <code>P-Asserted-Identity: "Cullen Jennings" &lt;sip:fluffy@cisco.com&gt;</code>
+ *
+ * @see PPreferredIdentityHeader
+ * @see PrivacyHeader
+ * @see AddressHeader
+ */
+type PAssertedIdentityHeader interface {
+	AddressHeader
+	ParametersHeader
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of P-Asserted-Identity Headers.
+ */
+type PAssertedIdentityList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewPAssertedIdentityList() *PAssertedIdentityList {
+	this := &PAssertedIdentityList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_P_ASSERTED_IDENTITY)
+	return this
+}
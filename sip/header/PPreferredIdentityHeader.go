@@ -0,0 +1,18 @@
+package header
+
+/**
+ * The P-Preferred-Identity header field (RFC 3325) is sent by a user agent
+ * to the first proxy of a Trust Domain to tell which of its identities it
+ * wants asserted. The proxy replaces it with a P-Asserted-Identity once it
+ * has authenticated the user.
+ * <p>
+ * For Example:<br>
+ * <code>P-Preferred-Identity: &lt;sip:alice@atlanta.com&gt;</code>
+ *
+ * @see PAssertedIdentityHeader
+ * @see AddressHeader
+ */
+type PPreferredIdentityHeader interface {
+	AddressHeader
+	ParametersHeader
+}
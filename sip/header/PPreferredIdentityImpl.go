@@ -0,0 +1,60 @@
+package header
+
+import (
+	"bytes"
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+* P-Preferred-Identity SIPHeader Object (RFC 3325).
+ */
+type PPreferredIdentity struct {
+	AddressParameters
+}
+
+/** Default constructor
+ */
+func NewPPreferredIdentity() *PPreferredIdentity {
+	this := &PPreferredIdentity{}
+	this.AddressParameters.super(core.SIPHeaderNames_P_PREFERRED_IDENTITY)
+	return this
+}
+
+/** Default constructor given an address.
+ *
+ *@param address -- address of this header.
+ *
+ */
+func NewPPreferredIdentityFromAddress(addr address.Address) *PPreferredIdentity {
+	this := &PPreferredIdentity{}
+	this.AddressParameters.super(core.SIPHeaderNames_P_PREFERRED_IDENTITY)
+	this.addr = addr
+	return this
+}
+
+func (this *PPreferredIdentity) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode into canonical form. The address is always a name-addr.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *PPreferredIdentity) EncodeBody() string {
+	var encoding bytes.Buffer
+	addr, _ := this.addr.(*address.AddressImpl)
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_LESS_THAN)
+	}
+	encoding.WriteString(this.addr.String())
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_GREATER_THAN)
+	}
+
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of P-Preferred-Identity Headers.
+ */
+type PPreferredIdentityList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewPPreferredIdentityList() *PPreferredIdentityList {
+	this := &PPreferredIdentityList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_P_PREFERRED_IDENTITY)
+	return this
+}
@@ -0,0 +1,20 @@
+package header
+
+/**
+ * The Path header field (RFC 3327) is added by the proxies on the path of a
+ * REGISTER. The registrar stores it with the binding and uses it as the
+ * preloaded route set of the requests sent to the registered contact, so
+ * that they traverse the same proxies, e.g. the edge proxy that holds the
+ * flow to the user agent.
+ * <p>
+ * For Example:<br>
+ * <code>Path: &lt;sip:P1.EXAMPLEVISITED.COM;lr&gt;</code>
+ *
+ * @see RouteHeader
+ * @see AddressHeader
+ * @see Parameters
+ */
+type PathHeader interface {
+	AddressHeader
+	ParametersHeader
+}
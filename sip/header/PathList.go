@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Path Headers.
+ */
+type PathList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewPathList() *PathList {
+	this := &PathList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_PATH)
+	return this
+}
@@ -0,0 +1,34 @@
+package header
+
+/**
+ * The Privacy header field (RFC 3323) tells the privacy services on the
+ * path of a request which privacy the user asks for. Its priv-values are
+ * separated by semicolons:
+ * <ul>
+ * <li>header: hide the headers that reveal the user, e.g. Via and Contact.
+ * <li>session: hide the media addresses.
+ * <li>user: hide the user-level information, e.g. the From display name.
+ * <li>id: do not pass the asserted identity outside the Trust Domain
+ * (RFC 3325).
+ * <li>none: no privacy.
+ * <li>critical: reject the request if the privacy cannot be provided.
+ * </ul>
+ * <p>
+ * For Example:<br>
+ * <code>Privacy: id;header</code>
+ */
+type PrivacyHeader interface {
+	Header
+
+	/** Get the priv-values of the header.
+	 */
+	GetPrivValues() []string
+
+	/** Set the priv-values of the header.
+	 */
+	SetPrivValues(privValues []string) (ParseException error)
+
+	/** Return true if the header contains the given priv-value.
+	 */
+	HasPrivValue(privValue string) bool
+}
@@ -0,0 +1,97 @@
+package header
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+)
+
+/** The priv-values of RFC 3323 and RFC 3325.
+ */
+const (
+	Privacy_HEADER   = "header"
+	Privacy_SESSION  = "session"
+	Privacy_USER     = "user"
+	Privacy_ID       = "id"
+	Privacy_NONE     = "none"
+	Privacy_CRITICAL = "critical"
+)
+
+/**
+* Privacy SIPHeader Object (RFC 3323).
+ */
+type Privacy struct {
+	SIPHeader
+
+	/** the priv-values, in the order they appear.
+	 */
+	privValues []string
+}
+
+/** Default constructor
+ */
+func NewPrivacy() *Privacy {
+	this := &Privacy{}
+	this.SIPHeader.super(core.SIPHeaderNames_PRIVACY)
+	return this
+}
+
+/** Constructor given the priv-values.
+ */
+func NewPrivacyFromValues(privValues ...string) *Privacy {
+	this := &Privacy{}
+	this.SIPHeader.super(core.SIPHeaderNames_PRIVACY)
+	this.privValues = privValues
+	return this
+}
+
+func (this *Privacy) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the priv-values separated by semicolons.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *Privacy) EncodeBody() string {
+	return strings.Join(this.privValues, core.SIPSeparatorNames_SEMICOLON)
+}
+
+/** Get the priv-values of the header.
+ */
+func (this *Privacy) GetPrivValues() []string {
+	return this.privValues
+}
+
+/** Set the priv-values of the header.
+ */
+func (this *Privacy) SetPrivValues(privValues []string) (ParseException error) {
+	if len(privValues) == 0 {
+		return errors.New("NullPointerException: the privValues parameter is empty")
+	}
+	this.privValues = privValues
+	return nil
+}
+
+/** Add a priv-value to the header.
+ */
+func (this *Privacy) AddPrivValue(privValue string) (ParseException error) {
+	if strings.TrimSpace(privValue) == "" {
+		return errors.New("ParseException: bad priv-value")
+	}
+	this.privValues = append(this.privValues, privValue)
+	return nil
+}
+
+/** Return true if the header contains the given priv-value. The values
+ * are compared case-insensitively.
+ */
+func (this *Privacy) HasPrivValue(privValue string) bool {
+	for _, value := range this.privValues {
+		if strings.EqualFold(value, privValue) {
+			return true
+		}
+	}
+	return false
+}
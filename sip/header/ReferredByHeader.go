@@ -0,0 +1,23 @@
+package header
+
+/**
+ * This interface represents the Referred-By SIP header, as defined by
+ * <a href = "http://www.ietf.org/rfc/rfc3892.txt">RFC3892</a>.
+ * <p>
+ * A Referred-By header identifies the referrer of a REFER request and is
+ * copied by the referee into the request the REFER triggers, so that the
+ * refer target learns who asked for it. The cid parameter names the body
+ * part that carries a Referred-By token signed by the referrer.
+ * <p>
+ * For Example:<br>
+ * <code>Referred-By: &lt;sip:referrer@referrer.example&gt;;cid="20398823.2UWQFN309shb3@referrer.example"</code>
+ *
+ * @see ReferToHeader
+ */
+type ReferredByHeader interface {
+	AddressHeader
+	ParametersHeader
+
+	GetCid() string
+	SetCid(cid string) (ParseException error)
+}
@@ -0,0 +1,78 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+*ReferredBy SIP Header.
+ */
+type ReferredBy struct {
+	AddressParameters
+}
+
+/** default Constructor.
+ */
+func NewReferredBy() *ReferredBy {
+	this := &ReferredBy{}
+	this.AddressParameters.super(core.SIPHeaderNames_REFERRED_BY)
+	return this
+}
+
+/** Create a Referred-By for an address.
+ */
+func NewReferredByFromAddress(addr address.Address) *ReferredBy {
+	this := NewReferredBy()
+	this.SetAddress(addr)
+	return this
+}
+
+func (this *ReferredBy) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/**
+ * Encode the header content into a String.
+ * @return String
+ */
+func (this *ReferredBy) EncodeBody() string {
+	var encoding bytes.Buffer
+	addr, _ := this.addr.(*address.AddressImpl)
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_LESS_THAN)
+	}
+	encoding.WriteString(this.addr.String())
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_GREATER_THAN)
+	}
+
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the Content-ID of the body part that carries the Referred-By
+ * token, without its quotes.
+ */
+func (this *ReferredBy) GetCid() string {
+	return strings.Trim(this.GetParameter(ParameterNames_CID), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the Content-ID of the body part that carries the Referred-By
+ * token. It is quoted, as a Content-ID contains '@'.
+ */
+func (this *ReferredBy) SetCid(cid string) (ParseException error) {
+	if cid == "" {
+		return errors.New("ParseException: ReferredBy, SetCid(), the cid parameter is empty")
+	}
+	this.SetQuotedParameter(ParameterNames_CID, strings.Trim(cid, core.SIPSeparatorNames_DOUBLE_QUOTE))
+	return nil
+}
@@ -0,0 +1,19 @@
+package header
+
+/**
+ * The Reject-Contact header (RFC 3841 section 9.3) describes the UAs a
+ * request must not reach: a UA whose feature set explicitly matches the
+ * predicate is not tried.
+ * <p>
+ * For Example:<br>
+ * <code>Reject-Contact: *;actor="msg-taker"</code>
+ *
+ * @see FeatureSet
+ */
+type RejectContactHeader interface {
+	ParametersHeader
+	Header
+
+	GetFeatureSet() FeatureSet
+	SetFeature(tag string, values ...string) (ParseException error)
+}
@@ -0,0 +1,44 @@
+package header
+
+import (
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Reject-Contact SIPHeader Object (RFC 3841).
+ */
+type RejectContact struct {
+	Parameters
+}
+
+/** Default constructor
+ */
+func NewRejectContact() *RejectContact {
+	this := &RejectContact{}
+	this.Parameters.super(core.SIPHeaderNames_REJECT_CONTACT)
+	return this
+}
+
+func (this *RejectContact) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the header content into a String.
+ * @return String
+ */
+func (this *RejectContact) EncodeBody() string {
+	return core.SIPSeparatorNames_STAR + encodeFeatureParameters(this.parameters)
+}
+
+/** Get the feature set of the predicate.
+ */
+func (this *RejectContact) GetFeatureSet() FeatureSet {
+	return NewFeatureSet(this.parameters)
+}
+
+/** Set the values of a feature tag of the predicate.
+ */
+func (this *RejectContact) SetFeature(tag string, values ...string) (ParseException error) {
+	return setFeatureParameter(&this.Parameters, tag, values)
+}
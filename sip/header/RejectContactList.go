@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Reject-Contact Headers.
+ */
+type RejectContactList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewRejectContactList() *RejectContactList {
+	this := &RejectContactList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_REJECT_CONTACT)
+	return this
+}
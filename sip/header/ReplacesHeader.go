@@ -0,0 +1,28 @@
+package header
+
+/**
+ * The Replaces header field (RFC 3891) is carried by an INVITE that
+ * replaces an existing dialog of the recipient, e.g. for an attended
+ * transfer or a call pickup. The dialog is identified by its Call-ID and
+ * by the tags of the recipient (to-tag) and of the other party
+ * (from-tag). With early-only, only an early dialog may be replaced.
+ * <p>
+ * For Example:<br>
+ * <code>Replaces: 425928@bobster.example.org;to-tag=7743;from-tag=6472</code>
+ *
+ * @see JoinHeader
+ * @see Parameters
+ */
+type ReplacesHeader interface {
+	ParametersHeader
+	Header
+
+	GetCallId() string
+	SetCallId(callId string) (ParseException error)
+	GetToTag() string
+	SetToTag(toTag string) (ParseException error)
+	GetFromTag() string
+	SetFromTag(fromTag string) (ParseException error)
+	IsEarlyOnly() bool
+	SetEarlyOnly(earlyOnly bool)
+}
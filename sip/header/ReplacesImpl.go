@@ -0,0 +1,105 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Replaces SIPHeader Object (RFC 3891).
+ */
+type Replaces struct {
+	Parameters
+
+	callId string
+}
+
+/** Default constructor
+ */
+func NewReplaces() *Replaces {
+	this := &Replaces{}
+	this.Parameters.super(core.SIPHeaderNames_REPLACES)
+	return this
+}
+
+func (this *Replaces) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the body of the header: the Call-ID and the parameters.
+ * @return String
+ */
+func (this *Replaces) EncodeBody() string {
+	var encoding bytes.Buffer
+	encoding.WriteString(this.callId)
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the Call-ID of the dialog to replace.
+ */
+func (this *Replaces) GetCallId() string {
+	return this.callId
+}
+
+/** Set the Call-ID of the dialog to replace.
+ */
+func (this *Replaces) SetCallId(callId string) (ParseException error) {
+	if callId == "" {
+		return errors.New("NullPointerException: the callId parameter is null")
+	}
+	this.callId = callId
+	return nil
+}
+
+/** Get the to-tag parameter: the tag of the recipient in the dialog.
+ */
+func (this *Replaces) GetToTag() string {
+	return this.GetParameter(ParameterNames_TO_TAG)
+}
+
+/** Set the to-tag parameter.
+ */
+func (this *Replaces) SetToTag(toTag string) (ParseException error) {
+	if toTag == "" {
+		return errors.New("NullPointerException: the toTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_TO_TAG, toTag)
+}
+
+/** Get the from-tag parameter: the tag of the other party in the dialog.
+ */
+func (this *Replaces) GetFromTag() string {
+	return this.GetParameter(ParameterNames_FROM_TAG)
+}
+
+/** Set the from-tag parameter.
+ */
+func (this *Replaces) SetFromTag(fromTag string) (ParseException error) {
+	if fromTag == "" {
+		return errors.New("NullPointerException: the fromTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_FROM_TAG, fromTag)
+}
+
+/** Return true if only an early dialog may be replaced.
+ */
+func (this *Replaces) IsEarlyOnly() bool {
+	return this.HasParameter(ParameterNames_EARLY_ONLY)
+}
+
+/** Set or remove the early-only flag.
+ */
+func (this *Replaces) SetEarlyOnly(earlyOnly bool) {
+	if !earlyOnly {
+		this.RemoveParameter(ParameterNames_EARLY_ONLY)
+	} else if !this.IsEarlyOnly() {
+		this.SetParameterFromNameValue(core.NewNameValue(ParameterNames_EARLY_ONLY, nil))
+	}
+}
@@ -0,0 +1,35 @@
+package header
+
+/**
+ * The Request-Disposition header field (RFC 3841 section 9.1) tells the
+ * proxies how the caller wants its request handled. Its directives are
+ * separated by commas, at most one of each pair:
+ * <ul>
+ * <li>proxy or redirect: forward the request, or redirect it to the
+ * targets;
+ * <li>cancel or no-cancel: cancel the other branches on a 2xx, or leave it
+ * to the caller;
+ * <li>fork or no-fork: try all the targets, or only the best one;
+ * <li>recurse or no-recurse: follow the 3xx responses, or return them;
+ * <li>parallel or sequential: try the targets at once, or one by one;
+ * <li>queue or no-queue: wait if the callee is busy, or fail.
+ * </ul>
+ * <p>
+ * For Example:<br>
+ * <code>Request-Disposition: proxy, recurse, parallel</code>
+ */
+type RequestDispositionHeader interface {
+	Header
+
+	/** Get the directives of the header.
+	 */
+	GetDirectives() []string
+
+	/** Set the directives of the header.
+	 */
+	SetDirectives(directives []string) (ParseException error)
+
+	/** Return true if the header contains the given directive.
+	 */
+	HasDirective(directive string) bool
+}
@@ -0,0 +1,103 @@
+package header
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+)
+
+/** The directives of RFC 3841 section 9.1.
+ */
+const (
+	RequestDisposition_PROXY      = "proxy"
+	RequestDisposition_REDIRECT   = "redirect"
+	RequestDisposition_CANCEL     = "cancel"
+	RequestDisposition_NO_CANCEL  = "no-cancel"
+	RequestDisposition_FORK       = "fork"
+	RequestDisposition_NO_FORK    = "no-fork"
+	RequestDisposition_RECURSE    = "recurse"
+	RequestDisposition_NO_RECURSE = "no-recurse"
+	RequestDisposition_PARALLEL   = "parallel"
+	RequestDisposition_SEQUENTIAL = "sequential"
+	RequestDisposition_QUEUE      = "queue"
+	RequestDisposition_NO_QUEUE   = "no-queue"
+)
+
+/**
+* Request-Disposition SIPHeader Object (RFC 3841).
+ */
+type RequestDisposition struct {
+	SIPHeader
+
+	/** the directives, in the order they appear.
+	 */
+	directives []string
+}
+
+/** Default constructor
+ */
+func NewRequestDisposition() *RequestDisposition {
+	this := &RequestDisposition{}
+	this.SIPHeader.super(core.SIPHeaderNames_REQUEST_DISPOSITION)
+	return this
+}
+
+/** Constructor given the directives.
+ */
+func NewRequestDispositionFromDirectives(directives ...string) *RequestDisposition {
+	this := &RequestDisposition{}
+	this.SIPHeader.super(core.SIPHeaderNames_REQUEST_DISPOSITION)
+	this.directives = directives
+	return this
+}
+
+func (this *RequestDisposition) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the directives separated by commas.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *RequestDisposition) EncodeBody() string {
+	return strings.Join(this.directives, core.SIPSeparatorNames_COMMA+core.SIPSeparatorNames_SP)
+}
+
+/** Get the directives of the header.
+ */
+func (this *RequestDisposition) GetDirectives() []string {
+	return this.directives
+}
+
+/** Set the directives of the header.
+ */
+func (this *RequestDisposition) SetDirectives(directives []string) (ParseException error) {
+	if len(directives) == 0 {
+		return errors.New("NullPointerException: the directives parameter is empty")
+	}
+	this.directives = directives
+	return nil
+}
+
+/** Add a directive to the header.
+ */
+func (this *RequestDisposition) AddDirective(directive string) (ParseException error) {
+	if strings.TrimSpace(directive) == "" {
+		return errors.New("ParseException: bad directive")
+	}
+	this.directives = append(this.directives, directive)
+	return nil
+}
+
+/** Return true if the header contains the given directive. The
+ * directives are compared case-insensitively.
+ */
+func (this *RequestDisposition) HasDirective(directive string) bool {
+	for _, value := range this.directives {
+		if strings.EqualFold(value, directive) {
+			return true
+		}
+	}
+	return false
+}
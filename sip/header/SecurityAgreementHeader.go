@@ -0,0 +1,36 @@
+package header
+
+/**
+ * The security agreement headers of RFC 3329 negotiate the security
+ * mechanism between a UA and its first-hop server:
+ * <ul>
+ * <li>Security-Client lists the mechanisms the client supports;
+ * <li>Security-Server lists the mechanisms the server supports, in a 494
+ * (Security Agreement Required) or a 421 response;
+ * <li>Security-Verify echoes the Security-Server list in the requests
+ * protected by the chosen mechanism, so that the server can detect a
+ * downgrade attack.
+ * </ul>
+ * Each header names a mechanism, with a preference q and, for the digest
+ * mechanism, the d-alg, d-qop and d-ver parameters.
+ * <p>
+ * For Example:<br>
+ * <code>Security-Server: ipsec-ike;q=0.1, tls;q=0.2</code>
+ *
+ * @see Parameters
+ */
+type SecurityAgreementHeader interface {
+	ParametersHeader
+	Header
+
+	GetMechanismName() string
+	SetMechanismName(mechanismName string) (ParseException error)
+	GetQValue() float32
+	SetQValue(q float32) (InvalidArgumentException error)
+	GetDigestAlgorithm() string
+	SetDigestAlgorithm(algorithm string) (ParseException error)
+	GetDigestQop() string
+	SetDigestQop(qop string) (ParseException error)
+	GetDigestVerify() string
+	SetDigestVerify(verify string) (ParseException error)
+}
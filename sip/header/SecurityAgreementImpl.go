@@ -0,0 +1,166 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+)
+
+/** The security mechanisms of RFC 3329 section 2.2 and of 3GPP TS 33.203.
+ */
+const (
+	SecurityAgreement_DIGEST     = "digest"
+	SecurityAgreement_TLS        = "tls"
+	SecurityAgreement_IPSEC_IKE  = "ipsec-ike"
+	SecurityAgreement_IPSEC_MAN  = "ipsec-man"
+	SecurityAgreement_IPSEC_3GPP = "ipsec-3gpp"
+)
+
+/**
+* The body of a Security-Client, Security-Server or Security-Verify header.
+ */
+type SecurityAgreement struct {
+	Parameters
+
+	mechanismName string
+}
+
+func (this *SecurityAgreement) super(hdrName string) {
+	this.Parameters.super(hdrName)
+}
+
+func (this *SecurityAgreement) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the header content into a String.
+ * @return String
+ */
+func (this *SecurityAgreement) EncodeBody() string {
+	var encoding bytes.Buffer
+	encoding.WriteString(this.mechanismName)
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the name of the security mechanism.
+ */
+func (this *SecurityAgreement) GetMechanismName() string {
+	return this.mechanismName
+}
+
+/** Set the name of the security mechanism.
+ */
+func (this *SecurityAgreement) SetMechanismName(mechanismName string) (ParseException error) {
+	if mechanismName == "" {
+		return errors.New("ParseException: SecurityAgreement, SetMechanismName(), the mechanism name is empty")
+	}
+	this.mechanismName = mechanismName
+	return nil
+}
+
+/** Get the preference of the mechanism. Return -1 if the parameter has not
+ * been set.
+ */
+func (this *SecurityAgreement) GetQValue() float32 {
+	if !this.HasParameter(ParameterNames_Q) {
+		return -1
+	}
+	q, _ := strconv.ParseFloat(this.GetParameter(ParameterNames_Q), 32)
+	return float32(q)
+}
+
+/** Set the preference of the mechanism, between 0 and 1.
+ */
+func (this *SecurityAgreement) SetQValue(q float32) (InvalidArgumentException error) {
+	if q < 0.0 || q > 1.0 {
+		return errors.New("InvalidArgumentException: qvalue out of range!")
+	}
+	return this.SetParameter(ParameterNames_Q, strconv.FormatFloat(float64(q), 'f', -1, 32))
+}
+
+/** Get the digest algorithm of the digest mechanism.
+ */
+func (this *SecurityAgreement) GetDigestAlgorithm() string {
+	return this.GetParameter(ParameterNames_D_ALG)
+}
+
+/** Set the digest algorithm, e.g. "MD5".
+ */
+func (this *SecurityAgreement) SetDigestAlgorithm(algorithm string) (ParseException error) {
+	if algorithm == "" {
+		return errors.New("ParseException: SecurityAgreement, SetDigestAlgorithm(), the algorithm is empty")
+	}
+	return this.SetParameter(ParameterNames_D_ALG, algorithm)
+}
+
+/** Get the quality of protection of the digest mechanism.
+ */
+func (this *SecurityAgreement) GetDigestQop() string {
+	return this.GetParameter(ParameterNames_D_QOP)
+}
+
+/** Set the quality of protection, e.g. "auth-int".
+ */
+func (this *SecurityAgreement) SetDigestQop(qop string) (ParseException error) {
+	if qop == "" {
+		return errors.New("ParseException: SecurityAgreement, SetDigestQop(), the qop is empty")
+	}
+	return this.SetParameter(ParameterNames_D_QOP, qop)
+}
+
+/** Get the d-ver parameter: the hash of the Security-Server list, without
+ * its quotes.
+ */
+func (this *SecurityAgreement) GetDigestVerify() string {
+	return strings.Trim(this.GetParameter(ParameterNames_D_VER), core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
+
+/** Set the d-ver parameter.
+ */
+func (this *SecurityAgreement) SetDigestVerify(verify string) (ParseException error) {
+	if verify == "" || strings.Contains(verify, core.SIPSeparatorNames_DOUBLE_QUOTE) {
+		return errors.New("ParseException: SecurityAgreement, SetDigestVerify(), bad d-ver " + verify)
+	}
+	this.SetQuotedParameter(ParameterNames_D_VER, verify)
+	return nil
+}
+
+/** Return true if two headers name the same mechanism with the same
+ * parameters, in any order (RFC 3329 section 2.3.1). Quoted values are
+ * compared without their quotes.
+ */
+func (this *SecurityAgreement) Matches(other SecurityAgreementHeader) bool {
+	if other == nil || !strings.EqualFold(this.mechanismName, other.GetMechanismName()) {
+		return false
+	}
+	parameters, otherParameters := this.GetParameters(), other.GetParameters()
+	if parameters.Len() != otherParameters.Len() {
+		return false
+	}
+	for e := parameters.Front(); e != nil; e = e.Next() {
+		nv := e.Value.(*core.NameValue)
+		matched := false
+		for o := otherParameters.Front(); o != nil && !matched; o = o.Next() {
+			otherNv := o.Value.(*core.NameValue)
+			matched = strings.EqualFold(nv.GetName(), otherNv.GetName()) &&
+				parameterString(nv) == parameterString(otherNv)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func parameterString(nv *core.NameValue) string {
+	value, _ := nv.GetValue().(string)
+	return strings.Trim(value, core.SIPSeparatorNames_DOUBLE_QUOTE)
+}
@@ -0,0 +1,11 @@
+package header
+
+/**
+ * The Security-Client header lists the security mechanisms a client supports
+ * (RFC 3329 section 2.3.1).
+ *
+ * @see SecurityAgreementHeader
+ */
+type SecurityClientHeader interface {
+	SecurityAgreementHeader
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* Security-Client SIPHeader Object (RFC 3329).
+ */
+type SecurityClient struct {
+	SecurityAgreement
+}
+
+/** Default constructor
+ */
+func NewSecurityClient() *SecurityClient {
+	this := &SecurityClient{}
+	this.SecurityAgreement.super(core.SIPHeaderNames_SECURITY_CLIENT)
+	return this
+}
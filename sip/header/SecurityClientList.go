@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Security-Client Headers.
+ */
+type SecurityClientList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewSecurityClientList() *SecurityClientList {
+	this := &SecurityClientList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_SECURITY_CLIENT)
+	return this
+}
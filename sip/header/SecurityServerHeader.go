@@ -0,0 +1,11 @@
+package header
+
+/**
+ * The Security-Server header lists the security mechanisms a server supports
+ * (RFC 3329 section 2.3.1).
+ *
+ * @see SecurityAgreementHeader
+ */
+type SecurityServerHeader interface {
+	SecurityAgreementHeader
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* Security-Server SIPHeader Object (RFC 3329).
+ */
+type SecurityServer struct {
+	SecurityAgreement
+}
+
+/** Default constructor
+ */
+func NewSecurityServer() *SecurityServer {
+	this := &SecurityServer{}
+	this.SecurityAgreement.super(core.SIPHeaderNames_SECURITY_SERVER)
+	return this
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Security-Server Headers.
+ */
+type SecurityServerList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewSecurityServerList() *SecurityServerList {
+	this := &SecurityServerList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_SECURITY_SERVER)
+	return this
+}
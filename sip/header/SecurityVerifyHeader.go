@@ -0,0 +1,11 @@
+package header
+
+/**
+ * The Security-Verify header echoes the Security-Server list of the server
+ * in the requests protected by the agreed mechanism (RFC 3329 section 2.3.1).
+ *
+ * @see SecurityAgreementHeader
+ */
+type SecurityVerifyHeader interface {
+	SecurityAgreementHeader
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* Security-Verify SIPHeader Object (RFC 3329).
+ */
+type SecurityVerify struct {
+	SecurityAgreement
+}
+
+/** Default constructor
+ */
+func NewSecurityVerify() *SecurityVerify {
+	this := &SecurityVerify{}
+	this.SecurityAgreement.super(core.SIPHeaderNames_SECURITY_VERIFY)
+	return this
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Security-Verify Headers.
+ */
+type SecurityVerifyList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewSecurityVerifyList() *SecurityVerifyList {
+	this := &SecurityVerifyList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_SECURITY_VERIFY)
+	return this
+}
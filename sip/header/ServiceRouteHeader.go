@@ -0,0 +1,19 @@
+package header
+
+/**
+ * The Service-Route header field (RFC 3608) is returned by the registrar in
+ * the 2xx response to a REGISTER. The user agent keeps it and uses it as the
+ * preloaded route set of the requests it sends outside of a dialog, so that
+ * they reach the home proxy of the registration.
+ * <p>
+ * For Example:<br>
+ * <code>Service-Route: &lt;sip:orig@scscf.home.example.com;lr&gt;</code>
+ *
+ * @see RouteHeader
+ * @see AddressHeader
+ * @see Parameters
+ */
+type ServiceRouteHeader interface {
+	AddressHeader
+	ParametersHeader
+}
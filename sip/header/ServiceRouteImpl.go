@@ -0,0 +1,60 @@
+package header
+
+import (
+	"bytes"
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+* Service-Route SIPHeader Object (RFC 3608).
+ */
+type ServiceRoute struct {
+	AddressParameters
+}
+
+/** Default constructor
+ */
+func NewServiceRoute() *ServiceRoute {
+	this := &ServiceRoute{}
+	this.AddressParameters.super(core.SIPHeaderNames_SERVICE_ROUTE)
+	return this
+}
+
+/** Default constructor given an address.
+ *
+ *@param address -- address of this header.
+ *
+ */
+func NewServiceRouteFromAddress(addr address.Address) *ServiceRoute {
+	this := &ServiceRoute{}
+	this.AddressParameters.super(core.SIPHeaderNames_SERVICE_ROUTE)
+	this.addr = addr
+	return this
+}
+
+func (this *ServiceRoute) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode into canonical form. The address is always a name-addr.
+ *@return String containing the canonicaly encoded header.
+ */
+func (this *ServiceRoute) EncodeBody() string {
+	var encoding bytes.Buffer
+	addr, _ := this.addr.(*address.AddressImpl)
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_LESS_THAN)
+	}
+	encoding.WriteString(this.addr.String())
+	if addr.GetAddressType() == address.ADDRESS_SPEC {
+		encoding.WriteString(core.SIPSeparatorNames_GREATER_THAN)
+	}
+
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
@@ -0,0 +1,18 @@
+package header
+
+import "github.com/use-go/gosips/core"
+
+/**
+* A list of Service-Route Headers.
+ */
+type ServiceRouteList struct {
+	SIPHeaderList
+}
+
+/** Default constructor
+ */
+func NewServiceRouteList() *ServiceRouteList {
+	this := &ServiceRouteList{}
+	this.SIPHeaderList.super(core.SIPHeaderNames_SERVICE_ROUTE)
+	return this
+}
@@ -101,3 +101,16 @@ type SubscriptionStateHeader interface {
 	 */
 	SetState(state string) (ParseException error)
 }
+
+/** The states of a subscription and the reason codes of its termination
+ * (RFC 3265 section 3.2.4).
+ */
+const SubscriptionState_ACTIVE = "active"
+const SubscriptionState_PENDING = "pending"
+const SubscriptionState_TERMINATED = "terminated"
+const SubscriptionState_DEACTIVATED = "deactivated"
+const SubscriptionState_PROBATION = "probation"
+const SubscriptionState_REJECTED = "rejected"
+const SubscriptionState_TIMEOUT = "timeout"
+const SubscriptionState_GIVE_UP = "giveup"
+const SubscriptionState_NORESOURCE = "noresource"
@@ -0,0 +1,26 @@
+package header
+
+/**
+ * The Target-Dialog header field (RFC 4538) is carried by a request sent
+ * outside of a dialog, e.g. a REFER or an INFO, to prove that its sender
+ * is a participant of an existing dialog of the recipient. The dialog is
+ * identified by its Call-ID and by the tags of the sender (local-tag) and
+ * of the recipient (remote-tag), both seen from the sender's side.
+ * <p>
+ * For Example:<br>
+ * <code>Target-Dialog: fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544</code>
+ *
+ * @see ReplacesHeader
+ * @see Parameters
+ */
+type TargetDialogHeader interface {
+	ParametersHeader
+	Header
+
+	GetCallId() string
+	SetCallId(callId string) (ParseException error)
+	GetLocalTag() string
+	SetLocalTag(localTag string) (ParseException error)
+	GetRemoteTag() string
+	SetRemoteTag(remoteTag string) (ParseException error)
+}
@@ -0,0 +1,89 @@
+package header
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/use-go/gosips/core"
+)
+
+/**
+* Target-Dialog SIPHeader Object (RFC 4538).
+ */
+type TargetDialog struct {
+	Parameters
+
+	callId string
+}
+
+/** Default constructor
+ */
+func NewTargetDialog() *TargetDialog {
+	this := &TargetDialog{}
+	this.Parameters.super(core.SIPHeaderNames_TARGET_DIALOG)
+	return this
+}
+
+func (this *TargetDialog) String() string {
+	return this.headerName + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+/** Encode the body of the header: the Call-ID and the parameters.
+ * @return String
+ */
+func (this *TargetDialog) EncodeBody() string {
+	var encoding bytes.Buffer
+	encoding.WriteString(this.callId)
+	if this.parameters != nil && this.parameters.Len() > 0 {
+		encoding.WriteString(core.SIPSeparatorNames_SEMICOLON)
+		encoding.WriteString(this.parameters.String())
+	}
+	return encoding.String()
+}
+
+/** Get the Call-ID of the target dialog.
+ */
+func (this *TargetDialog) GetCallId() string {
+	return this.callId
+}
+
+/** Set the Call-ID of the target dialog.
+ */
+func (this *TargetDialog) SetCallId(callId string) (ParseException error) {
+	if callId == "" {
+		return errors.New("NullPointerException: the callId parameter is null")
+	}
+	this.callId = callId
+	return nil
+}
+
+/** Get the local-tag parameter: the tag of the sender in the dialog.
+ */
+func (this *TargetDialog) GetLocalTag() string {
+	return this.GetParameter(ParameterNames_LOCAL_TAG)
+}
+
+/** Set the local-tag parameter.
+ */
+func (this *TargetDialog) SetLocalTag(localTag string) (ParseException error) {
+	if localTag == "" {
+		return errors.New("NullPointerException: the localTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_LOCAL_TAG, localTag)
+}
+
+/** Get the remote-tag parameter: the tag of the recipient in the dialog.
+ */
+func (this *TargetDialog) GetRemoteTag() string {
+	return this.GetParameter(ParameterNames_REMOTE_TAG)
+}
+
+/** Set the remote-tag parameter.
+ */
+func (this *TargetDialog) SetRemoteTag(remoteTag string) (ParseException error) {
+	if remoteTag == "" {
+		return errors.New("NullPointerException: the remoteTag parameter is null")
+	}
+	return this.SetParameter(ParameterNames_REMOTE_TAG, remoteTag)
+}
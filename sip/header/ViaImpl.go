@@ -429,6 +429,55 @@ func (this *Via) SetReceived(received string) (ParseException error) {
 	return nil
 }
 
+/**
+ * Returns true if the ViaHeader carries the rport parameter, with or
+ * without a value (RFC 3581).
+ */
+func (this *Via) HasRPort() bool {
+	return this.HasParameter(ParameterNames_RPORT)
+}
+
+/**
+ * Gets the rport paramater of the ViaHeader. Returns -1 if rport does not
+ * exist or has no value yet.
+ *
+ * @return the integer rport value of ViaHeader
+ */
+func (this *Via) GetRPort() int {
+	if !this.HasRPort() {
+		return -1
+	}
+	rport, err := strconv.Atoi(this.GetParameter(ParameterNames_RPORT))
+	if err != nil {
+		return -1
+	}
+	return rport
+}
+
+/**
+ * Adds the rport parameter without a value, asking the server to send
+ * the response back to the source port of the request (RFC 3581).
+ */
+func (this *Via) SetRPort() {
+	if !this.HasRPort() {
+		this.SetParameterFromNameValue(core.NewNameValue(ParameterNames_RPORT, nil))
+	}
+}
+
+/**
+ * Sets the value of the rport parameter of ViaHeader.
+ *
+ * @param rport - the source port of the request.
+ * @throws InvalidArgumentException if the port is out of range.
+ */
+func (this *Via) SetRPortValue(rport int) (InvalidArgumentException error) {
+	if rport <= 0 || rport > 65535 {
+		return errors.New("InvalidArgumentException: GoSIP Exception, Via, setRPort(), the rport parameter is out of range.")
+	}
+	this.SetParameter(ParameterNames_RPORT, strconv.Itoa(rport))
+	return nil
+}
+
 /**
  * Gets the branch paramater of the ViaHeader. Returns null if branch
  * does not exist.
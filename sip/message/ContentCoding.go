@@ -0,0 +1,191 @@
+package message
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** The content codings of a message body (RFC 3261 section 20.12). The
+ * deflate coding is the zlib format (RFC 1950); a raw deflate body is
+ * accepted on receive.
+ */
+const (
+	ContentCoding_GZIP     = "gzip"
+	ContentCoding_DEFLATE  = "deflate"
+	ContentCoding_IDENTITY = "identity"
+)
+
+/** The limits of DecodeContent: the number of codings of a body, and the
+ * size of the decoded body when the caller gives none.
+ */
+const (
+	ContentCoding_MAX_CODINGS = 2
+	ContentCoding_MAX_SIZE    = 65535
+)
+
+/** Get the content codings of the message, in the order they were
+ * applied, lowercase.
+ */
+func (this *SIPMessage) getContentCodings() []string {
+	var codings []string
+	headers := this.GetHeaders(core.SIPHeaderNames_CONTENT_ENCODING)
+	for e := headers.Front(); e != nil; e = e.Next() {
+		if contentEncoding, ok := e.Value.(*header.ContentEncoding); ok {
+			codings = append(codings, strings.ToLower(strings.TrimSpace(contentEncoding.GetEncoding())))
+		}
+	}
+	return codings
+}
+
+/** Decode the body of a received message with a gzip or deflate
+ * Content-Encoding: the content of the message is then the decoded body,
+ * and GetRawContent returns the body as it was received. A body with
+ * another coding is left as it is. The Content-Length stays that of the
+ * received body, so the message is forwarded as it was received.
+ * A body that decodes to more than maxSize bytes, or
+ * ContentCoding_MAX_SIZE if maxSize is 0, or that has more than
+ * ContentCoding_MAX_CODINGS codings is rejected, so that a small
+ * compressed body cannot expand without bound.
+ */
+func (this *SIPMessage) DecodeContent(maxSize int) (ParseException error) {
+	codings := this.getContentCodings()
+	if len(codings) == 0 {
+		return nil
+	}
+	if len(codings) > ContentCoding_MAX_CODINGS {
+		parseError := core.NewParseError(core.ParseErrorKind_LIMIT_EXCEEDED, "ParseException: too many content codings", -1)
+		return parseError.At(core.SIPHeaderNames_CONTENT_ENCODING, 0, 0, -1)
+	}
+	if maxSize <= 0 {
+		maxSize = ContentCoding_MAX_SIZE
+	}
+	raw := this.getContentBytes()
+	content := raw
+	for i := len(codings) - 1; i >= 0; i-- {
+		var reader io.Reader
+		var err error
+		switch codings[i] {
+		case ContentCoding_GZIP, "x-gzip":
+			reader, err = gzip.NewReader(bytes.NewReader(content))
+		case ContentCoding_DEFLATE:
+			if reader, err = zlib.NewReader(bytes.NewReader(content)); err != nil {
+				reader, err = flate.NewReader(bytes.NewReader(content)), nil
+			}
+		case ContentCoding_IDENTITY:
+			continue
+		default:
+			return nil
+		}
+		if err == nil {
+			// Read one byte more than the limit to tell a body of the
+			// limit from a larger one.
+			content, err = ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+		}
+		if err == nil && len(content) > maxSize {
+			parseError := core.NewParseError(core.ParseErrorKind_BODY_TOO_LARGE, "ParseException: decoded body too large", maxSize)
+			return parseError.At(core.SIPHeaderNames_CONTENT_ENCODING, 0, 0, -1)
+		}
+		if err != nil {
+			parseError := core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+			return parseError.At(core.SIPHeaderNames_CONTENT_ENCODING, 0, 0, -1)
+		}
+	}
+	this.messageContentBytes = content
+	this.messageContent = ""
+	this.messageContentObject = nil
+	this.encodedContent = raw
+	return nil
+}
+
+/** Encode the body of the message with a content coding, gzip or
+ * deflate, and set its Content-Encoding and Content-Length. The content
+ * of the message stays the decoded body, and GetRawContent returns the
+ * body as it is sent.
+ */
+func (this *SIPMessage) EncodeContent(coding string) (IllegalArgumentException error) {
+	if this.encodedContent != nil {
+		return errors.New("IllegalArgumentException: the body is already encoded")
+	}
+	var encoded bytes.Buffer
+	var writer io.WriteCloser
+	switch strings.ToLower(coding) {
+	case ContentCoding_GZIP:
+		writer = gzip.NewWriter(&encoded)
+	case ContentCoding_DEFLATE:
+		writer = zlib.NewWriter(&encoded)
+	default:
+		return errors.New("IllegalArgumentException: unsupported content coding " + coding)
+	}
+	writer.Write(this.getContentBytes())
+	writer.Close()
+
+	contentEncoding := header.NewContentEncoding()
+	contentEncoding.SetEncoding(strings.ToLower(coding))
+	this.SetHeader(contentEncoding)
+	this.encodedContent = encoded.Bytes()
+	this.contentLengthHeader.SetContentLength(len(this.encodedContent))
+	return nil
+}
+
+/** Compress the body of the message if it is larger than a threshold and
+ * the peer accepts gzip or deflate in its Accept-Encoding, e.g. the
+ * SUBSCRIBE of a NOTIFY with a large presence document. gzip is
+ * preferred, unless the peer gives deflate a higher q value.
+ *@return the content coding of the body, or "" if it is not compressed.
+ */
+func (this *SIPMessage) CompressContentFor(peer Message, threshold int) (coding string, IllegalArgumentException error) {
+	if this.encodedContent != nil || len(this.getContentBytes()) <= threshold || len(this.getContentCodings()) > 0 {
+		return "", nil
+	}
+	var q float32
+	headers := peer.GetHeaders(core.SIPHeaderNames_ACCEPT_ENCODING)
+	for e := headers.Front(); e != nil; e = e.Next() {
+		acceptEncoding, ok := e.Value.(*header.AcceptEncoding)
+		if !ok {
+			continue
+		}
+		encodingQ := acceptEncoding.GetQValue()
+		if !acceptEncoding.HasQValue() {
+			encodingQ = 1
+		}
+		switch encoding := strings.ToLower(acceptEncoding.GetEncoding()); encoding {
+		case ContentCoding_GZIP, ContentCoding_DEFLATE:
+			if encodingQ > 0 && (encodingQ > q || encodingQ == q && encoding == ContentCoding_GZIP) {
+				coding, q = encoding, encodingQ
+			}
+		}
+	}
+	if coding == "" {
+		return "", nil
+	}
+	return coding, this.EncodeContent(coding)
+}
+
+/** Get the body of the message as it is received or sent: encoded if the
+ * message has a Content-Encoding, the content of the message otherwise.
+ */
+func (this *SIPMessage) GetRawContent() []byte {
+	if this.encodedContent != nil {
+		return this.encodedContent
+	}
+	return this.getContentBytes()
+}
+
+/** Drop the encoded body when the content of the message is set: the new
+ * content is not encoded, so the Content-Encoding is removed.
+ */
+func (this *SIPMessage) clearEncodedContent() {
+	if this.encodedContent != nil {
+		this.encodedContent = nil
+		this.RemoveHeader(core.SIPHeaderNames_CONTENT_ENCODING)
+	}
+}
@@ -0,0 +1,155 @@
+package message
+
+import (
+	"bytes"
+	"container/list"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** A HeaderParserFunc parses a header line, without its line terminator,
+ * e.g. "Route: <sip:p1.example.com;lr>".
+ */
+type HeaderParserFunc func(line string) (header.Header, error)
+
+/**
+ * A lazyHeader holds the unparsed lines of a header of a received
+ * message. The header is parsed the first time it is accessed through the
+ * message, and it is encoded as it was received until then.
+ */
+type lazyHeader struct {
+	*header.SIPHeader
+
+	lines []string
+
+	parser HeaderParserFunc
+}
+
+func newLazyHeader(headerName, line string, parser HeaderParserFunc) *lazyHeader {
+	this := &lazyHeader{}
+	this.SIPHeader = header.NewSIPHeader(headerName)
+	this.lines = []string{line}
+	this.parser = parser
+	return this
+}
+
+/** Encode the lines of the header as they were received.
+ */
+func (this *lazyHeader) String() string {
+	var encoding bytes.Buffer
+	for _, line := range this.lines {
+		encoding.WriteString(line)
+		encoding.WriteString(core.SIPSeparatorNames_NEWLINE)
+	}
+	return encoding.String()
+}
+
+/** Encode the values of the header, separated by commas.
+ */
+func (this *lazyHeader) EncodeBody() string {
+	values := make([]string, len(this.lines))
+	for i, line := range this.lines {
+		values[i] = strings.TrimSpace(line[strings.IndexByte(line, ':')+1:])
+	}
+	return strings.Join(values, core.SIPSeparatorNames_COMMA)
+}
+
+func (this *lazyHeader) GetHeaderValue() string {
+	return this.EncodeBody()
+}
+
+func (this *lazyHeader) GetValue() string {
+	return this.EncodeBody()
+}
+
+/** Parse the lines of the header, as AttachHeader would have attached
+ * them: the lines of a header list are concatenated and the repeated
+ * lines of another header are dropped. A line that cannot be parsed is
+ * returned as an Extension header, to be kept out of the name table, and
+ * added to the unrecognized headers. The header is nil if no line parses.
+ */
+func (this *lazyHeader) parse(unrecognizedHeaders *list.List) (parsed header.Header, unparsed []header.Header) {
+	for _, line := range this.lines {
+		sipHeader, err := this.parser(line)
+		if err != nil || sipHeader == nil {
+			if extension := newUnparsedHeader(line); extension != nil {
+				unparsed = append(unparsed, extension)
+			}
+			unrecognizedHeaders.PushBack(line)
+			continue
+		}
+		if parsed == nil {
+			parsed = sipHeader
+		} else if hdrlist, ok := parsed.(header.SIPHeaderLister); ok {
+			if hs, ok := sipHeader.(header.SIPHeaderLister); ok {
+				hdrlist.Concatenate(hs, false)
+			}
+		}
+	}
+	return parsed, unparsed
+}
+
+/** Attach a header of a received message without parsing it. The header
+ * is parsed by the given parser when it is first accessed, e.g. with
+ * GetHeader; until then it is encoded as it was received. The header name
+ * is the full name of the header.
+ */
+func (this *SIPMessage) AttachLazyHeader(headerName, line string, parser HeaderParserFunc) {
+	key := strings.ToLower(headerName)
+	if sipHeader, present := this.nameTable[key]; present {
+		if lh, ok := sipHeader.(*lazyHeader); ok {
+			lh.lines = append(lh.lines, line)
+		} else if sipHeader, err := parser(line); err == nil {
+			this.AttachHeader2(sipHeader, false)
+		} else {
+			this.AttachUnparsedHeader(line)
+		}
+		return
+	}
+	lh := newLazyHeader(headerName, line, parser)
+	this.nameTable[key] = lh
+	this.headers.PushBack(lh)
+}
+
+/** Parse the header of the given lower case name if it is still lazy.
+ * The lines that cannot be parsed stay in the headers of the message, so
+ * that they are forwarded, but not under the name of the header: the
+ * typed accessors, e.g. GetRouteHeaders, only find a header of its type.
+ */
+func (this *SIPMessage) parseLazyHeader(key string) {
+	lh, ok := this.nameTable[key].(*lazyHeader)
+	if !ok {
+		return
+	}
+	parsed, unparsed := lh.parse(this.unrecognizedHeaders)
+	if parsed != nil {
+		this.nameTable[key] = parsed
+	} else {
+		delete(this.nameTable, key)
+	}
+	for li := this.headers.Front(); li != nil; li = li.Next() {
+		if li.Value == lh {
+			for _, extension := range unparsed {
+				this.headers.InsertBefore(extension, li)
+			}
+			if parsed != nil {
+				li.Value = parsed
+			} else {
+				this.headers.Remove(li)
+			}
+			break
+		}
+	}
+}
+
+/** Parse all the headers that are still lazy.
+ */
+func (this *SIPMessage) parseLazyHeaders() {
+	for key, sipHeader := range this.nameTable {
+		if _, ok := sipHeader.(*lazyHeader); ok {
+			this.parseLazyHeader(key)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package message
+
+import (
+	"container/list"
+
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/**
+ * This interface provides factory methods that allow an application to create
+ * Request and Response messages from a particular implementation of this
+ * specification. The headers of the messages are created with the
+ * header.HeaderFactory, and their addresses and URIs with the
+ * address.AddressImplFactory.
+ */
+type MessageFactory interface {
+
+	/**
+	 * Creates a new Request message of type specified by the method paramater,
+	 * containing the URI of the Request, the mandatory headers of the message.
+	 * This new Request does not contain a body. The CSeq method must be that
+	 * of the request.
+	 *
+	 * @param requestURI - the new URI object of the requestURI value of this Message.
+	 * @param method - the new string of the method value of this Message.
+	 * @param callId - the new CallIdHeader object of the callId value of this Message.
+	 * @param cSeq - the new CSeqHeader object of the cSeq value of this Message.
+	 * @param from - the new FromHeader object of the from value of this Message.
+	 * @param to - the new ToHeader object of the to value of this Message.
+	 * @param via - the new List object of the ViaHeaders of this Message.
+	 * @param maxForwards - the new MaxForwardsHeader object of the
+	 * max forwards value of this Message.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the method, or a mandatory header is missing.
+	 */
+	CreateRequest(requestURI address.URI, method string, callId header.CallIdHeader, cSeq header.CSeqHeader,
+		from header.FromHeader, to header.ToHeader, via *list.List, maxForwards header.MaxForwardsHeader) (request Request, ParseException error)
+
+	/**
+	 * Creates a new Request message of type specified by the method paramater,
+	 * containing the URI of the Request, the mandatory headers of the message
+	 * with a body in the form of a string or a byte array and body content
+	 * type.
+	 *
+	 * @param contentType - the new ContentTypeHeader object of the content type
+	 * value of this Message.
+	 * @param content - the new string or byte array of the body content value
+	 * of this Message.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the method or the body, or a mandatory header
+	 * is missing.
+	 */
+	CreateRequestWithContent(requestURI address.URI, method string, callId header.CallIdHeader, cSeq header.CSeqHeader,
+		from header.FromHeader, to header.ToHeader, via *list.List, maxForwards header.MaxForwardsHeader,
+		contentType header.ContentTypeHeader, content interface{}) (request Request, ParseException error)
+
+	/**
+	 * Create a new SIP Request object based on a specific string value. This
+	 * method parses the supplied string into a SIP Request. The request string
+	 * "" creates an empty request.
+	 *
+	 * @param requestParam - the new string value of the Request.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the requestParam value.
+	 */
+	CreateRequestFromString(requestParam string) (request Request, ParseException error)
+
+	/**
+	 * Creates a new Response message of type specified by the statusCode
+	 * paramater, based on a specific Request message: it has copies of the
+	 * From, To, Call-ID, CSeq, Via, Timestamp and Record-Route headers of the
+	 * request, so that the headers of the response can be modified without
+	 * modifying the request. This new Response does not contain a body.
+	 *
+	 * @param statusCode - the new integer of the statusCode value of this Message.
+	 * @param request - the received Reqest object upon which to base the Response.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the statusCode value.
+	 */
+	CreateResponse(statusCode int, request Request) (response Response, ParseException error)
+
+	/**
+	 * Creates a new Response message of type specified by the statusCode
+	 * paramater, based on a specific Request with a new body in the form of a
+	 * string or a byte array and body content type.
+	 *
+	 * @param statusCode - the new integer of the statusCode value of this Message.
+	 * @param request - the received Reqest object upon which to base the Response.
+	 * @param contentType - the new ContentTypeHeader object of the content type
+	 * value of this Message.
+	 * @param content - the new string or byte array of the body content value
+	 * of this Message.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the statusCode value or the body.
+	 */
+	CreateResponseWithContent(statusCode int, request Request, contentType header.ContentTypeHeader, content interface{}) (response Response, ParseException error)
+
+	/**
+	 * Creates a new Response message of type specified by the statusCode
+	 * paramater, containing the mandatory headers of the message. This new
+	 * Response does not contain a body.
+	 *
+	 * @param statusCode - the new integer of the statusCode value of this Message.
+	 * @param callId - the new CallIdHeader object of the callId value of this Message.
+	 * @param cSeq - the new CSeqHeader object of the cSeq value of this Message.
+	 * @param from - the new FromHeader object of the from value of this Message.
+	 * @param to - the new ToHeader object of the to value of this Message.
+	 * @param via - the new List object of the ViaHeaders of this Message.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the statusCode, or a mandatory header is
+	 * missing.
+	 */
+	CreateResponseFromHeaders(statusCode int, callId header.CallIdHeader, cSeq header.CSeqHeader,
+		from header.FromHeader, to header.ToHeader, via *list.List) (response Response, ParseException error)
+
+	/**
+	 * Create a new SIP Response object based on a specific string value. This
+	 * method parses the supplied string into a SIP Response. The response
+	 * string "" creates an empty response.
+	 *
+	 * @param responseParam - the new string value of the Response.
+	 * @throws ParseException which signals that an error has been reached
+	 * unexpectedly while parsing the responseParam value.
+	 */
+	CreateResponseFromString(responseParam string) (response Response, ParseException error)
+}
@@ -0,0 +1,261 @@
+package message
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** The encodings of the body of a message in JSON.
+ */
+const (
+	SIPMessageJSON_UTF8   = "utf-8"
+	SIPMessageJSON_BASE64 = "base64"
+)
+
+/**
+ * The JSON form of a SIP request or response, for logging and APIs. The
+ * headers are in the order of the message, one entry per header value;
+ * the value of each header is its encoded value, and the common headers
+ * have typed fields too. The body is the body as it is sent, e.g. still
+ * compressed; it is in UTF-8 if it is valid UTF-8, in base64 otherwise.
+ * The Content-Length is that of the body. The parser decodes it back to
+ * an equivalent message.
+ */
+type SIPMessageJSON struct {
+	Type         string           `json:"type"`
+	Method       string           `json:"method,omitempty"`
+	RequestURI   string           `json:"requestURI,omitempty"`
+	StatusCode   int              `json:"statusCode,omitempty"`
+	ReasonPhrase string           `json:"reasonPhrase,omitempty"`
+	SIPVersion   string           `json:"sipVersion"`
+	Headers      []*SIPHeaderJSON `json:"headers"`
+	Body         string           `json:"body,omitempty"`
+	BodyEncoding string           `json:"bodyEncoding,omitempty"`
+}
+
+/** A header value, with the typed fields of From, To, Contact, Via and
+ * CSeq.
+ */
+type SIPHeaderJSON struct {
+	Name    string          `json:"name"`
+	Value   string          `json:"value"`
+	Address *SIPAddressJSON `json:"address,omitempty"`
+	Via     *SIPViaJSON     `json:"via,omitempty"`
+	CSeq    *SIPCSeqJSON    `json:"cseq,omitempty"`
+}
+
+/** The address of a From, To or Contact header.
+ */
+type SIPAddressJSON struct {
+	DisplayName string            `json:"displayName,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Tag         string            `json:"tag,omitempty"`
+	Wildcard    bool              `json:"wildcard,omitempty"`
+	Parameters  map[string]string `json:"parameters,omitempty"`
+}
+
+type SIPViaJSON struct {
+	Protocol   string            `json:"protocol"`
+	Transport  string            `json:"transport"`
+	Host       string            `json:"host"`
+	Port       int               `json:"port,omitempty"`
+	Branch     string            `json:"branch,omitempty"`
+	Received   string            `json:"received,omitempty"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+type SIPCSeqJSON struct {
+	SequenceNumber int    `json:"seq"`
+	Method         string `json:"method"`
+}
+
+/** Get the JSON form of the request.
+ */
+func (this *SIPRequest) ToJSON() *SIPMessageJSON {
+	messageJSON := this.SIPMessage.toJSON()
+	messageJSON.Type = "request"
+	if this.requestLine != nil {
+		messageJSON.Method = this.requestLine.GetMethod()
+		if this.requestLine.GetUri() != nil {
+			messageJSON.RequestURI = this.requestLine.GetUri().String()
+		}
+		messageJSON.SIPVersion = this.requestLine.GetSipVersion()
+	}
+	return messageJSON
+}
+
+func (this *SIPRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.ToJSON())
+}
+
+/** Get the JSON form of the response.
+ */
+func (this *SIPResponse) ToJSON() *SIPMessageJSON {
+	messageJSON := this.SIPMessage.toJSON()
+	messageJSON.Type = "response"
+	if this.statusLine != nil {
+		messageJSON.StatusCode = this.statusLine.GetStatusCode()
+		messageJSON.ReasonPhrase = this.statusLine.GetReasonPhrase()
+		messageJSON.SIPVersion = this.statusLine.GetSipVersion()
+	}
+	return messageJSON
+}
+
+func (this *SIPResponse) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.ToJSON())
+}
+
+/** Get the JSON form of the headers and the body of the message.
+ */
+func (this *SIPMessage) toJSON() *SIPMessageJSON {
+	messageJSON := &SIPMessageJSON{}
+	messageJSON.Headers = make([]*SIPHeaderJSON, 0, this.headers.Len())
+	for it := this.headers.Front(); it != nil; it = it.Next() {
+		switch siphdr := it.Value.(type) {
+		case *header.ContentLength:
+		case *lazyHeader:
+			for _, line := range siphdr.lines {
+				colon := strings.IndexByte(line, ':')
+				messageJSON.Headers = append(messageJSON.Headers, &SIPHeaderJSON{
+					Name:  strings.TrimSpace(line[:colon]),
+					Value: strings.TrimSpace(line[colon+1:]),
+				})
+			}
+		case header.SIPHeaderLister:
+			for e := siphdr.Front(); e != nil; e = e.Next() {
+				messageJSON.Headers = append(messageJSON.Headers, newSIPHeaderJSON(e.Value.(header.Header)))
+			}
+		case header.Header:
+			messageJSON.Headers = append(messageJSON.Headers, newSIPHeaderJSON(siphdr))
+		}
+	}
+
+	if body := this.GetRawContent(); len(body) > 0 {
+		if utf8.Valid(body) {
+			messageJSON.Body = string(body)
+			messageJSON.BodyEncoding = SIPMessageJSON_UTF8
+		} else {
+			messageJSON.Body = base64.StdEncoding.EncodeToString(body)
+			messageJSON.BodyEncoding = SIPMessageJSON_BASE64
+		}
+	}
+	return messageJSON
+}
+
+func newSIPHeaderJSON(siphdr header.Header) *SIPHeaderJSON {
+	headerJSON := &SIPHeaderJSON{}
+	headerJSON.Name = siphdr.GetName()
+	headerJSON.Value = strings.TrimSpace(siphdr.EncodeBody())
+	switch h := siphdr.(type) {
+	case *header.From:
+		headerJSON.Address = newSIPAddressJSON(h.GetAddress(), h.GetParameters())
+		headerJSON.Address.Tag = h.GetTag()
+	case *header.To:
+		headerJSON.Address = newSIPAddressJSON(h.GetAddress(), h.GetParameters())
+		headerJSON.Address.Tag = h.GetTag()
+	case *header.Contact:
+		headerJSON.Address = newSIPAddressJSON(h.GetAddress(), h.GetContactParms())
+		headerJSON.Address.Wildcard = h.GetWildCardFlag()
+	case *header.Via:
+		headerJSON.Via = &SIPViaJSON{}
+		headerJSON.Via.Protocol = h.GetProtocol()
+		headerJSON.Via.Transport = h.GetTransport()
+		headerJSON.Via.Host = h.GetHost()
+		if port := h.GetPort(); port > 0 {
+			headerJSON.Via.Port = port
+		}
+		headerJSON.Via.Branch = h.GetBranch()
+		headerJSON.Via.Received = h.GetReceived()
+		headerJSON.Via.Parameters = newParametersJSON(h.GetViaParms())
+	case *header.CSeq:
+		headerJSON.CSeq = &SIPCSeqJSON{}
+		headerJSON.CSeq.SequenceNumber = h.GetSequenceNumber()
+		headerJSON.CSeq.Method = h.GetMethod()
+	}
+	return headerJSON
+}
+
+func newSIPAddressJSON(addr address.Address, parameters *core.NameValueList) *SIPAddressJSON {
+	addressJSON := &SIPAddressJSON{}
+	if addr != nil {
+		addressJSON.DisplayName = addr.GetDisplayName()
+		if addr.GetURI() != nil {
+			addressJSON.URI = addr.GetURI().String()
+		}
+	}
+	addressJSON.Parameters = newParametersJSON(parameters)
+	return addressJSON
+}
+
+func newParametersJSON(parameters *core.NameValueList) map[string]string {
+	if parameters == nil || parameters.Len() == 0 {
+		return nil
+	}
+	parametersJSON := make(map[string]string)
+	for e := parameters.GetNames().Front(); e != nil; e = e.Next() {
+		name := e.Value.(string)
+		parametersJSON[name] = parameters.GetParameter(name)
+	}
+	return parametersJSON
+}
+
+/** Encode the message of the JSON form as it is sent, for the parser:
+ * the start line, a line for each header value, the Content-Length and
+ * the body.
+ */
+func (this *SIPMessageJSON) EncodeAsBytes() (encoded []byte, ParseException error) {
+	var body []byte
+	switch this.BodyEncoding {
+	case "", SIPMessageJSON_UTF8:
+		body = []byte(this.Body)
+	case SIPMessageJSON_BASE64:
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(this.Body); err != nil {
+			return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+		}
+	default:
+		return nil, errors.New("ParseException: unknown body encoding " + this.BodyEncoding)
+	}
+
+	sipVersion := this.SIPVersion
+	if sipVersion == "" {
+		sipVersion = header.SIPConstants_SIP_VERSION_STRING
+	}
+	var startLine string
+	switch this.Type {
+	case "request":
+		startLine = this.Method + core.SIPSeparatorNames_SP + this.RequestURI + core.SIPSeparatorNames_SP + sipVersion
+	case "response":
+		startLine = sipVersion + core.SIPSeparatorNames_SP + strconv.Itoa(this.StatusCode) + core.SIPSeparatorNames_SP + this.ReasonPhrase
+	default:
+		return nil, errors.New("ParseException: unknown message type " + this.Type)
+	}
+	if strings.ContainsAny(startLine, "\r\n") {
+		return nil, errors.New("ParseException: bad start line " + strconv.Quote(startLine))
+	}
+	var encoding bytes.Buffer
+	encoding.WriteString(startLine + core.SIPSeparatorNames_NEWLINE)
+
+	for _, headerJSON := range this.Headers {
+		if strings.EqualFold(headerJSON.Name, core.SIPHeaderNames_CONTENT_LENGTH) || strings.EqualFold(headerJSON.Name, core.SIPHeaderNames_L) {
+			continue
+		}
+		if headerJSON.Name == "" || strings.ContainsAny(headerJSON.Name+headerJSON.Value, "\r\n") {
+			return nil, errors.New("ParseException: bad header " + headerJSON.Name)
+		}
+		encoding.WriteString(headerJSON.Name + core.SIPSeparatorNames_COLON + core.SIPSeparatorNames_SP + headerJSON.Value + core.SIPSeparatorNames_NEWLINE)
+	}
+	encoding.WriteString(core.SIPHeaderNames_CONTENT_LENGTH + core.SIPSeparatorNames_COLON + core.SIPSeparatorNames_SP + strconv.Itoa(len(body)) + core.SIPSeparatorNames_NEWLINE)
+	encoding.WriteString(core.SIPSeparatorNames_NEWLINE)
+	encoding.Write(body)
+	return encoding.Bytes(), nil
+}
@@ -0,0 +1,409 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/**
+ * A BodyPart is a part of a multipart body (RFC 2046 section 5.1, RFC
+ * 5621): its content, with its Content-Type, Content-Disposition,
+ * Content-ID and other MIME headers. The content is kept as it was
+ * received, e.g. an ISUP part stays binary.
+ */
+type BodyPart struct {
+	contentType *header.ContentType
+
+	contentDisposition *header.ContentDisposition
+
+	contentID string
+
+	headers textproto.MIMEHeader
+
+	content []byte
+}
+
+/** Constructor.
+ *@param contentType -- the Content-Type of the part.
+ *@param content -- the content of the part.
+ */
+func NewBodyPart(contentType *header.ContentType, content []byte) *BodyPart {
+	this := &BodyPart{}
+	this.contentType = contentType
+	this.content = content
+	this.headers = make(textproto.MIMEHeader)
+	return this
+}
+
+func (this *BodyPart) GetContentType() *header.ContentType {
+	return this.contentType
+}
+
+func (this *BodyPart) SetContentType(contentType *header.ContentType) {
+	this.contentType = contentType
+}
+
+/** Get the Content-Disposition of the part, or nil if it has none.
+ */
+func (this *BodyPart) GetContentDisposition() *header.ContentDisposition {
+	return this.contentDisposition
+}
+
+func (this *BodyPart) SetContentDisposition(contentDisposition *header.ContentDisposition) {
+	this.contentDisposition = contentDisposition
+}
+
+/** Get the Content-ID of the part, with its angle brackets, or "" if it
+ * has none.
+ */
+func (this *BodyPart) GetContentID() string {
+	return this.contentID
+}
+
+func (this *BodyPart) SetContentID(contentID string) {
+	this.contentID = contentID
+}
+
+/** Get another MIME header of the part, e.g. Content-Transfer-Encoding,
+ * or "" if it has none.
+ */
+func (this *BodyPart) GetHeader(name string) string {
+	return this.headers.Get(name)
+}
+
+func (this *BodyPart) SetHeader(name, value string) {
+	this.headers.Set(name, value)
+}
+
+func (this *BodyPart) GetContent() []byte {
+	return this.content
+}
+
+func (this *BodyPart) SetContent(content []byte) {
+	this.content = content
+}
+
+/** Return true if the part has the given media type, e.g. "application"
+ * and "sdp".
+ */
+func (this *BodyPart) IsMediaType(mediaType, mediaSubType string) bool {
+	return this.contentType != nil &&
+		strings.EqualFold(this.contentType.GetContentType(), mediaType) &&
+		strings.EqualFold(this.contentType.GetContentSubType(), mediaSubType)
+}
+
+/**
+ * A MultipartBody is a multipart/mixed or multipart/alternative body of
+ * a message, e.g. an SDP offer with an ISUP or a PIDF-LO part. A part can
+ * be a multipart body in turn: ParseMultipartBody parses its content.
+ */
+type MultipartBody struct {
+	subType string
+
+	boundary string
+
+	parts []*BodyPart
+}
+
+/** Constructor: an empty body with a random boundary.
+ *@param subType -- the subtype of the body, e.g. "mixed" or "alternative".
+ */
+func NewMultipartBody(subType string) *MultipartBody {
+	this := &MultipartBody{}
+	this.subType = subType
+	this.boundary = multipart.NewWriter(ioutil.Discard).Boundary()
+	return this
+}
+
+/** Parse a multipart body.
+ *@param contentType -- the Content-Type of the body, with its boundary.
+ *@param content -- the body.
+ */
+func ParseMultipartBody(contentType header.ContentTypeHeader, content []byte) (body *MultipartBody, ParseException error) {
+	if contentType == nil {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: the body has no Content-Type", -1)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType.(*header.ContentType).EncodeBody())
+	if err != nil {
+		return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+mediaType+" is not a multipart body", -1)
+	}
+	if params["boundary"] == "" {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: the multipart body has no boundary", -1)
+	}
+	if !isBoundary(params["boundary"]) {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad boundary "+params["boundary"], -1)
+	}
+
+	body = &MultipartBody{}
+	body.subType = strings.TrimPrefix(mediaType, "multipart/")
+	body.boundary = params["boundary"]
+	reader := multipart.NewReader(bytes.NewReader(content), body.boundary)
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+		}
+		bodyPart, err := newBodyPartFromMIME(part)
+		if err != nil {
+			return nil, err
+		}
+		body.parts = append(body.parts, bodyPart)
+	}
+	return body, nil
+}
+
+/** Make a BodyPart of a part read by a multipart.Reader.
+ */
+func newBodyPartFromMIME(part *multipart.Part) (*BodyPart, error) {
+	content, err := ioutil.ReadAll(part)
+	if err != nil {
+		return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+	}
+	bodyPart := NewBodyPart(nil, content)
+	for name, values := range part.Header {
+		switch name {
+		case "Content-Type":
+			mediaType, params, err := mime.ParseMediaType(values[0])
+			if err != nil {
+				return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+			}
+			slash := strings.IndexByte(mediaType, '/')
+			if slash < 0 {
+				return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad part Content-Type "+values[0], -1)
+			}
+			bodyPart.contentType = header.NewContentTypeFromString(mediaType[:slash], mediaType[slash+1:])
+			setMIMEParameters(&bodyPart.contentType.Parameters, params)
+		case "Content-Disposition":
+			dispositionType, params, err := mime.ParseMediaType(values[0])
+			if err != nil {
+				return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+			}
+			bodyPart.contentDisposition = header.NewContentDisposition()
+			bodyPart.contentDisposition.SetDispositionType(dispositionType)
+			setMIMEParameters(&bodyPart.contentDisposition.Parameters, params)
+		case "Content-Id":
+			bodyPart.contentID = values[0]
+		default:
+			bodyPart.headers[name] = values
+		}
+	}
+	// The default Content-Type of a part (RFC 2046 section 5.1).
+	if bodyPart.contentType == nil {
+		bodyPart.contentType = header.NewContentTypeFromString("text", "plain")
+	}
+	return bodyPart, nil
+}
+
+/** Set the parameters of a MIME header, in the order of their names.
+ */
+func setMIMEParameters(parameters *header.Parameters, params map[string]string) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := params[name]; value != "" && strings.IndexAny(value, " \t\"(),/:;<=>?@[\\]{}") < 0 {
+			parameters.SetParameter(name, value)
+		} else {
+			parameters.SetQuotedParameter(name, value)
+		}
+	}
+}
+
+func (this *MultipartBody) GetSubType() string {
+	return this.subType
+}
+
+func (this *MultipartBody) GetBoundary() string {
+	return this.boundary
+}
+
+/** Set the boundary of the body, which must not occur in its parts.
+ *@throws InvalidArgumentException if it is not a boundary of RFC 2046
+ * section 5.1.1: 1 to 70 characters of bchars, not ending with a space.
+ */
+func (this *MultipartBody) SetBoundary(boundary string) (InvalidArgumentException error) {
+	if !isBoundary(boundary) {
+		return errors.New("InvalidArgumentException: bad boundary " + boundary)
+	}
+	this.boundary = boundary
+	return nil
+}
+
+/** Return true if the given string is a boundary of RFC 2046 section
+ * 5.1.1, which are the boundaries a multipart.Writer accepts.
+ */
+func isBoundary(boundary string) bool {
+	return multipart.NewWriter(ioutil.Discard).SetBoundary(boundary) == nil
+}
+
+/** Get the Content-Type of the body, with its boundary.
+ */
+func (this *MultipartBody) GetContentType() *header.ContentType {
+	contentType := header.NewContentTypeFromString("multipart", this.subType)
+	contentType.SetQuotedParameter("boundary", this.boundary)
+	return contentType
+}
+
+func (this *MultipartBody) GetParts() []*BodyPart {
+	return this.parts
+}
+
+func (this *MultipartBody) AddPart(part *BodyPart) {
+	this.parts = append(this.parts, part)
+}
+
+/** Remove the parts of the given media type.
+ */
+func (this *MultipartBody) RemoveParts(mediaType, mediaSubType string) {
+	parts := this.parts[:0]
+	for _, part := range this.parts {
+		if !part.IsMediaType(mediaType, mediaSubType) {
+			parts = append(parts, part)
+		}
+	}
+	this.parts = parts
+}
+
+/** Get the first part of the given media type, e.g. "application" and
+ * "sdp", or nil if there is none.
+ */
+func (this *MultipartBody) GetPart(mediaType, mediaSubType string) *BodyPart {
+	for _, part := range this.parts {
+		if part.IsMediaType(mediaType, mediaSubType) {
+			return part
+		}
+	}
+	return nil
+}
+
+/** Encode the body, with CRLF line terminators.
+ *@throws InvalidArgumentException if the boundary is not valid.
+ */
+func (this *MultipartBody) Bytes() (encoded []byte, InvalidArgumentException error) {
+	var encoding bytes.Buffer
+	writer := multipart.NewWriter(&encoding)
+	if err := writer.SetBoundary(this.boundary); err != nil {
+		return nil, errors.New("InvalidArgumentException: bad boundary " + this.boundary)
+	}
+	for _, part := range this.parts {
+		mimeHeader := make(textproto.MIMEHeader)
+		for name, values := range part.headers {
+			mimeHeader[name] = values
+		}
+		if part.contentType != nil {
+			mimeHeader["Content-Type"] = []string{part.contentType.EncodeBody()}
+		}
+		if part.contentDisposition != nil {
+			mimeHeader["Content-Disposition"] = []string{part.contentDisposition.EncodeBody()}
+		}
+		if part.contentID != "" {
+			mimeHeader["Content-ID"] = []string{part.contentID}
+		}
+		partWriter, err := writer.CreatePart(mimeHeader)
+		if err != nil {
+			return nil, err
+		}
+		partWriter.Write(part.content)
+	}
+	writer.Close()
+	return encoding.Bytes(), nil
+}
+
+func (this *MultipartBody) String() string {
+	encoded, _ := this.Bytes()
+	return string(encoded)
+}
+
+/** Get the multipart body of the message, or nil if its body is not a
+ * multipart body.
+ */
+func (this *SIPMessage) GetMultipartBody() (body *MultipartBody, ParseException error) {
+	contentType, ok := this.GetHeader(core.SIPHeaderNames_CONTENT_TYPE).(*header.ContentType)
+	if !ok || !strings.EqualFold(contentType.GetContentType(), "multipart") {
+		return nil, nil
+	}
+	return ParseMultipartBody(contentType, this.getContentBytes())
+}
+
+/** Set the body of the message to a multipart body, with its
+ * Content-Type.
+ *@throws InvalidArgumentException if the boundary of the body is not
+ * valid.
+ */
+func (this *SIPMessage) SetMultipartBody(body *MultipartBody) (InvalidArgumentException error) {
+	encoded, err := body.Bytes()
+	if err != nil {
+		return err
+	}
+	this.SetContent(encoded, body.GetContentType())
+	return nil
+}
+
+/** Get the SDP of the message: its body if it is application/sdp, or its
+ * first application/sdp part if it is a multipart body. It returns nil if
+ * the message has no SDP.
+ */
+func (this *SIPMessage) GetSDPPart() (sdp []byte, ParseException error) {
+	contentType, ok := this.GetHeader(core.SIPHeaderNames_CONTENT_TYPE).(*header.ContentType)
+	if !ok {
+		return nil, nil
+	}
+	if strings.EqualFold(contentType.GetContentType(), "application") && strings.EqualFold(contentType.GetContentSubType(), "sdp") {
+		return this.getContentBytes(), nil
+	}
+	body, err := this.GetMultipartBody()
+	if body == nil {
+		return nil, err
+	}
+	if part := body.GetPart("application", "sdp"); part != nil {
+		return part.GetContent(), nil
+	}
+	return nil, nil
+}
+
+/** Set the SDP of the message: it replaces the first application/sdp
+ * part of a multipart body, or is added to it, and is the body of the
+ * message otherwise.
+ */
+func (this *SIPMessage) SetSDPPart(sdp []byte) (ParseException error) {
+	body, err := this.GetMultipartBody()
+	if err != nil {
+		return err
+	}
+	if body == nil {
+		this.SetContent(sdp, header.NewContentTypeFromString("application", "sdp"))
+		return nil
+	}
+	if part := body.GetPart("application", "sdp"); part != nil {
+		part.SetContent(sdp)
+	} else {
+		body.AddPart(NewBodyPart(header.NewContentTypeFromString("application", "sdp"), sdp))
+	}
+	return this.SetMultipartBody(body)
+}
+
+/** Get the body of the message as bytes, as it was received.
+ */
+func (this *SIPMessage) getContentBytes() []byte {
+	if this.messageContentBytes != nil {
+		return this.messageContentBytes
+	}
+	return []byte(this.messageContent)
+}
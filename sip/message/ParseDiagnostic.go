@@ -0,0 +1,108 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/**
+ * A ParseDiagnostic records an error found in a message parsed in
+ * lenient mode, that did not stop the parsing: a header that does not
+ * parse or a start line that is not strictly well formed. The line and
+ * column count from 1; the line is that of the message header, the
+ * start line being line 1, and the column is counted in the text of the
+ * line, a folded header being unfolded.
+ */
+type ParseDiagnostic struct {
+	line   int
+	column int
+	text   string
+	err    error
+}
+
+func NewParseDiagnostic(line, column int, text string, err error) *ParseDiagnostic {
+	this := &ParseDiagnostic{}
+	this.line = line
+	this.column = column
+	this.text = text
+	this.err = err
+	return this
+}
+
+/** Get the line of the error in the message.
+ */
+func (this *ParseDiagnostic) GetLine() int {
+	return this.line
+}
+
+/** Get the column of the error in the line.
+ */
+func (this *ParseDiagnostic) GetColumn() int {
+	return this.column
+}
+
+/** Get the text of the line, without its line terminator.
+ */
+func (this *ParseDiagnostic) GetText() string {
+	return this.text
+}
+
+/** Get the parse error.
+ */
+func (this *ParseDiagnostic) GetError() error {
+	return this.err
+}
+
+func (this *ParseDiagnostic) String() string {
+	return "line " + strconv.Itoa(this.line) + ", column " + strconv.Itoa(this.column) + ": " + this.err.Error()
+}
+
+/** Add a diagnostic of the parsing of the message.
+ */
+func (this *SIPMessage) AddParseDiagnostic(diagnostic *ParseDiagnostic) {
+	this.parseDiagnostics = append(this.parseDiagnostics, diagnostic)
+}
+
+/** Get the diagnostics of the parsing of the message, in the order of
+ * the lines of the message. A message parsed in strict mode has none.
+ */
+func (this *SIPMessage) GetParseDiagnostics() []*ParseDiagnostic {
+	return this.parseDiagnostics
+}
+
+/** Attach a header line that could not be parsed: it is kept in the
+ * headers of the message as an Extension header, so that it is forwarded
+ * with the message, and added to the unrecognized headers. It is not
+ * attached under its name, where the typed accessors, e.g.
+ * GetRouteHeaders, expect a header of its type. A line without a header
+ * name is only added to the unrecognized headers.
+ */
+func (this *SIPMessage) AttachUnparsedHeader(line string) {
+	if extension := newUnparsedHeader(line); extension != nil {
+		this.headers.PushBack(extension)
+	}
+	this.AddUnparsed(line)
+}
+
+/** Make an Extension header of a header line that could not be parsed,
+ * or nil if the line has no header name.
+ */
+func newUnparsedHeader(line string) *header.Extension {
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 || strings.TrimSpace(line[:colon]) == "" {
+		return nil
+	}
+	extension := header.NewExtension(strings.TrimSpace(line[:colon]))
+	extension.SetValue(strings.Trim(line[colon+1:], core.SIPSeparatorNames_SP+"\t"))
+	return extension
+}
+
+/** Make the ParseError of a check of the headers of a message, which has
+ * no line or offset.
+ */
+func newHeaderError(kind core.ParseErrorKind, message, headerName string) error {
+	return core.NewParseError(kind, message, -1).At(headerName, 0, 0, -1)
+}
@@ -139,6 +139,11 @@ package message
  * <LI>BAD_EXTENSION - 420</LI>
  * <LI>EXTENSION_REQUIRED - 421
  * <LI>INTERVAL_TOO_BRIEF - 423
+ * <LI>USE_IDENTITY_HEADER - 428
+ * <LI>FLOW_FAILED - 430
+ * <LI>BAD_IDENTITY_INFO - 436
+ * <LI>UNSUPPORTED_CREDENTIAL - 437
+ * <LI>INVALID_IDENTITY_HEADER - 438
  * <LI>TEMPORARILY_UNAVAILABLE - 480</LI>
  * <LI>CALL_OR_TRANSACTION_DOES_NOT_EXIST - 481</LI>
  * <LI>LOOP_DETECTED - 482</LI>
@@ -151,6 +156,7 @@ package message
  * <LI><i>BAD EVENT - 489 (Extension RFC3265)<i></LI>
  * <LI>REQUEST_PENDING - 491
  * <LI>UNDECIPHERABLE - 493
+ * <LI><i>SECURITY_AGREEMENT_REQUIRED - 494 (Extension RFC3329)</i></LI>
  * </td>
  * </tr>
  * <tr>
@@ -186,10 +192,8 @@ type Response interface {
 	 * satisfy a request.  The Status-Code is intended for use by automata.
 	 *
 	 * @param statusCode the new integer value of the status code.
-	 * @throws ParseException which signals that an error has been reached
-	 * unexpectedly while parsing the statusCode value.
 	 */
-	SetStatusCode(statusCode int) (ParseException error)
+	SetStatusCode(statusCode int)
 
 	/**
 	 * Gets the integer value of the status code of Response, which identifies
@@ -207,10 +211,8 @@ type Response interface {
 	 * the reason phrase, implementations MAY choose other text.
 	 *
 	 * @param reasonPhrase the new string value of the reason phrase.
-	 * @throws ParseException which signals that an error has been reached
-	 * unexpectedly while parsing the reasonPhrase value.
 	 */
-	SetReasonPhrase(reasonPhrase string) (ParseException error)
+	SetReasonPhrase(reasonPhrase string)
 
 	/**
 	 * Gets the reason phrase of this Response message.
@@ -503,6 +505,37 @@ const EXTENSION_REQUIRED = 421
  */
 const INTERVAL_TOO_BRIEF = 423
 
+/**
+ * The request has no valid Identity header field and the server requires
+ * one to verify the identity of the caller (RFC 8224).
+ */
+const USE_IDENTITY_HEADER = 428
+
+/**
+ * An edge proxy could not forward a request over the flow named in the
+ * Route header field because the flow has failed (RFC 5626). The
+ * registrar removes the registration that uses the flow.
+ */
+const FLOW_FAILED = 430
+
+/**
+ * The credentials named in the info parameter of the Identity header field
+ * could not be fetched or parsed (RFC 8224).
+ */
+const BAD_IDENTITY_INFO = 436
+
+/**
+ * The verifier cannot validate the credentials of the Identity header
+ * field, e.g. they are not issued by a trusted authority (RFC 8224).
+ */
+const UNSUPPORTED_CREDENTIAL = 437
+
+/**
+ * The signature of the Identity header field does not verify or its claims
+ * do not match the request (RFC 8224).
+ */
+const INVALID_IDENTITY_HEADER = 438
+
 /**
  * The callee's end system was contacted successfully but the callee is
  * currently unavailable (for example, is not logged in, logged in but in a
@@ -625,6 +658,15 @@ const REQUEST_PENDING = 491
  */
 const UNDECIPHERABLE = 493
 
+/**
+ * The server requires a security mechanism to be agreed before it serves
+ * the request. The response carries the mechanisms the server supports in
+ * Security-Server headers (RFC 3329).
+ *
+ *
+ */
+const SECURITY_AGREEMENT_REQUIRED = 494
+
 /**
  * The server encountered an unexpected condition that prevented it from
  * fulfilling the request. The client MAY display the specific error
@@ -37,9 +37,13 @@ type SIPMessage struct {
 	messageContent       string
 	messageContentBytes  []byte
 	messageContentObject interface{}
+	encodedContent       []byte // The body with its Content-Encoding
 
 	// Table of headers indexed by name.
 	nameTable map[string]header.Header
+
+	// Errors found by a lenient parser.
+	parseDiagnostics []*ParseDiagnostic
 }
 
 /**
@@ -360,7 +364,9 @@ func (this *SIPMessage) String() string {
 
 	encoding.WriteString(this.contentLengthHeader.String() + core.SIPSeparatorNames_NEWLINE)
 
-	if this.messageContentObject != nil {
+	if this.encodedContent != nil {
+		encoding.Write(this.encodedContent)
+	} else if this.messageContentObject != nil {
 		mbody := this.GetContent()
 		encoding.WriteString(mbody)
 	} else if this.messageContent != "" || this.messageContentBytes != nil {
@@ -630,11 +636,13 @@ func (this *SIPMessage) AttachHeader3(h header.Header, replaceFlag, top bool) er
 		this.headers.PushBack(h)
 	} else {
 		if hs, ok := h.(header.SIPHeaderLister); ok {
-			hdrlist := this.nameTable[strings.ToLower(h.GetName())].(header.SIPHeaderLister)
-			if hdrlist != nil {
+			if hdrlist, ok := this.nameTable[strings.ToLower(h.GetName())].(header.SIPHeaderLister); ok {
 				hdrlist.Concatenate(hs, top)
 			} else {
+				// The original header, e.g. an unparsed header of the
+				// same name, was removed above.
 				this.nameTable[strings.ToLower(h.GetName())] = h
+				this.headers.PushBack(h)
 			}
 		} else {
 			this.nameTable[strings.ToLower(h.GetName())] = h
@@ -667,6 +675,7 @@ func (this *SIPMessage) AttachHeader3(h header.Header, replaceFlag, top bool) er
  *@param top -- flag that indicates which end of header list to process.
  */
 func (this *SIPMessage) RemoveHeader2(headerName string, top bool) {
+	this.parseLazyHeader(strings.ToLower(headerName))
 	toRemove := this.nameTable[strings.ToLower(headerName)]
 
 	// nothing to do then we are done.
@@ -834,6 +843,7 @@ func (this *SIPMessage) HasContent() bool {
  *@return an Iterator for the headers of this message.
  */
 func (this *SIPMessage) getHeaders() header.Lister {
+	this.parseLazyHeaders()
 	return this.headers
 }
 
@@ -842,6 +852,7 @@ func (this *SIPMessage) getHeaders() header.Lister {
  *@return header -- the first header of the given name.
  */
 func (this *SIPMessage) GetHeader(headerName string) header.Header {
+	this.parseLazyHeader(strings.ToLower(headerName))
 	sipHeader := this.nameTable[strings.ToLower(headerName)]
 	if sl, ok := sipHeader.(header.SIPHeaderLister); ok {
 		return sl.Front().Value.(header.Header)
@@ -870,7 +881,8 @@ func (this *SIPMessage) GetFrom() header.FromHeader {
  * @return List containing ErrorInfo headers.
  */
 func (this *SIPMessage) GetErrorInfoHeaders() *header.ErrorInfoList {
-	return this.GetSIPHeaderList(core.SIPHeaderNames_ERROR_INFO).(*header.ErrorInfoList)
+	hdrlist, _ := this.GetSIPHeaderList(core.SIPHeaderNames_ERROR_INFO).(*header.ErrorInfoList)
+	return hdrlist
 }
 
 /**
@@ -878,7 +890,8 @@ func (this *SIPMessage) GetErrorInfoHeaders() *header.ErrorInfoList {
  * @return List containing Contact headers.
  */
 func (this *SIPMessage) GetContactHeaders() *header.ContactList {
-	return this.GetSIPHeaderList(core.SIPHeaderNames_CONTACT).(*header.ContactList)
+	hdrlist, _ := this.GetSIPHeaderList(core.SIPHeaderNames_CONTACT).(*header.ContactList)
+	return hdrlist
 }
 
 /**
@@ -886,7 +899,8 @@ func (this *SIPMessage) GetContactHeaders() *header.ContactList {
  * @return List containing Via headers.
  */
 func (this *SIPMessage) GetViaHeaders() *header.ViaList {
-	return this.GetSIPHeaderList(core.SIPHeaderNames_VIA).(*header.ViaList)
+	hdrlist, _ := this.GetSIPHeaderList(core.SIPHeaderNames_VIA).(*header.ViaList)
+	return hdrlist
 }
 
 /** Get an iterator to the list of vial headers.
@@ -1011,7 +1025,8 @@ func (this *SIPMessage) GetProxyAuthorizationHeader() *header.ProxyAuthorization
  * @return List containing Route headers
  */
 func (this *SIPMessage) GetRouteHeaders() *header.RouteList {
-	return this.GetSIPHeaderList(core.SIPHeaderNames_ROUTE).(*header.RouteList)
+	hdrlist, _ := this.GetSIPHeaderList(core.SIPHeaderNames_ROUTE).(*header.RouteList)
+	return hdrlist
 }
 
 /** Get the CallID header (nil if one does not exist)
@@ -1060,7 +1075,8 @@ func (this *SIPMessage) GetCallIdentifier() string {
  * @return Record-Route header
  */
 func (this *SIPMessage) GetRecordRouteHeaders() *header.RecordRouteList {
-	return this.GetSIPHeaderList(core.SIPHeaderNames_RECORD_ROUTE).(*header.RecordRouteList)
+	hdrlist, _ := this.GetSIPHeaderList(core.SIPHeaderNames_RECORD_ROUTE).(*header.RecordRouteList)
+	return hdrlist
 }
 
 /**
@@ -1186,6 +1202,7 @@ func (this *SIPMessage) SetMessageContentFromString(t string, subType string, me
 	//     throw new IllegalArgumentException("messgeContent is nil");
 	ct := header.NewContentTypeFromString(t, subType)
 	this.SetHeader(ct)
+	this.clearEncodedContent()
 	this.messageContent = messageContent
 	this.messageContentBytes = nil
 	this.messageContentObject = nil
@@ -1204,12 +1221,15 @@ func (this *SIPMessage) SetMessageContentFromString(t string, subType string, me
 func (this *SIPMessage) SetContent(content interface{}, contentTypeHeader header.ContentTypeHeader) { //throws ParseException {
 	//if content == nil) throw new NullPointerException("nil content");
 	this.SetHeader(contentTypeHeader)
+	this.clearEncodedContent()
 	length := -1
 	if s, ok := content.(string); ok {
 		this.messageContent = s
+		this.messageContentBytes = nil
 		length = len(s)
 	} else if b, ok := content.([]byte); ok {
 		this.messageContentBytes = b
+		this.messageContent = ""
 		length = len(b)
 	} else {
 		panic("Don't support GenericObject")
@@ -1274,6 +1294,7 @@ func (this *SIPMessage) SetMessageContent(content string) {
 	//try {
 	this.contentLengthHeader.SetContentLength(len(content))
 	// } catch (InvalidArgumentException ex) {}
+	this.clearEncodedContent()
 	this.messageContent = content
 	this.messageContentBytes = nil
 	this.messageContentObject = nil
@@ -1288,6 +1309,7 @@ func (this *SIPMessage) SetMessageContentFromByte(content []byte) {
 	this.contentLengthHeader.SetContentLength(len(content))
 	//} catch (InvalidArgumentException ex) {}
 
+	this.clearEncodedContent()
 	this.messageContentBytes = content
 	this.messageContent = ""
 	this.messageContentObject = nil
@@ -1297,6 +1319,7 @@ func (this *SIPMessage) SetMessageContentFromByte(content []byte) {
  *
  */
 func (this *SIPMessage) RemoveContent() {
+	this.clearEncodedContent()
 	this.messageContent = ""
 	this.messageContentBytes = nil
 	this.messageContentObject = nil
@@ -1315,6 +1338,7 @@ func (this *SIPMessage) GetHeaders(headerName string) header.Lister {
 	//     ("nil headerName");
 	var sipHeader header.Header
 	var present bool
+	this.parseLazyHeader(strings.ToLower(headerName))
 	if sipHeader, present = this.nameTable[strings.ToLower(headerName)].(header.Header); !present {
 		// empty iterator
 		return list.New()
@@ -1329,11 +1353,17 @@ func (this *SIPMessage) GetHeaders(headerName string) header.Lister {
 	}
 }
 
+/** Get the list of headers of the given name, or nil if there is none or
+ * if the header is not a list.
+ */
 func (this *SIPMessage) GetSIPHeaderList(headerName string) header.SIPHeaderLister {
-	return this.nameTable[strings.ToLower(headerName)].(header.SIPHeaderLister)
+	this.parseLazyHeader(strings.ToLower(headerName))
+	hdrlist, _ := this.nameTable[strings.ToLower(headerName)].(header.SIPHeaderLister)
+	return hdrlist
 }
 
 func (this *SIPMessage) GetHeaderList(headerName string) header.Lister {
+	this.parseLazyHeader(strings.ToLower(headerName))
 	sipHeader := this.nameTable[strings.ToLower(headerName)]
 	if sipHeader == nil {
 		return nil
@@ -1353,6 +1383,8 @@ func (this *SIPMessage) GetHeaderList(headerName string) header.Lister {
  *@return true if the header is present in the message
  */
 func (this *SIPMessage) HasHeader(headerName string) bool {
+	// A lazy header none of whose lines parse is not a header of its name.
+	this.parseLazyHeader(strings.ToLower(headerName))
 	_, present := this.nameTable[strings.ToLower(headerName)]
 	return present
 }
@@ -1493,6 +1525,7 @@ func (this *SIPMessage) GetUnrecognizedHeaders() *list.List {
  * in the same order as are present in the message.
  */
 func (this *SIPMessage) GetHeaderNames() *list.List {
+	this.parseLazyHeaders()
 	return this.headers
 	// ListIterator li = this.headers.listIterator();
 	// LinkedList retval  = new LinkedList();
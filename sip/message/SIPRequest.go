@@ -3,7 +3,6 @@ package message
 import (
 	"bytes"
 	"container/list"
-	"errors"
 	"strings"
 
 	"github.com/use-go/gosips/core"
@@ -107,22 +106,23 @@ func (this *SIPRequest) SetRequestLine(requestLine *header.RequestLine) {
 func (this *SIPRequest) CheckHeaders() (ParseException error) {
 	prefix := "Missing Header "
 
-	/* Check for required headers */
+	/* Check for required headers: the fields, as the getters return
+	 * them in interfaces that are not nil when the headers are missing */
 
-	if this.GetCSeq() == nil {
-		return errors.New("ParseException:" + prefix + "CSeq")
+	if this.cSeqHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException:"+prefix+"CSeq", core.SIPHeaderNames_CSEQ)
 	}
-	if this.GetTo() == nil {
-		return errors.New("ParseException:" + prefix + "To")
+	if this.toHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException:"+prefix+"To", core.SIPHeaderNames_TO)
 	}
-	if this.GetFrom() == nil {
-		return errors.New("ParseException:" + prefix + "From")
+	if this.fromHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException:"+prefix+"From", core.SIPHeaderNames_FROM)
 	}
-	if this.GetViaHeaders() == nil {
-		return errors.New("ParseException:" + prefix + "Via")
+	if !this.HasHeader(core.SIPHeaderNames_VIA) {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException:"+prefix+"Via", core.SIPHeaderNames_VIA)
 	}
-	if this.GetMaxForwards() == nil {
-		return errors.New("ParseException:" + prefix + "MaxForwards")
+	if this.maxForwardsHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException:"+prefix+"MaxForwards", core.SIPHeaderNames_MAX_FORWARDS)
 	}
 
 	/*  BUGBUG
@@ -134,7 +134,7 @@ func (this *SIPRequest) CheckHeaders() (ParseException error) {
 	if this.requestLine != nil && this.requestLine.GetMethod() != "" &&
 		this.GetCSeq().GetMethod() != "" &&
 		strings.ToLower(this.requestLine.GetMethod()) != strings.ToLower(this.GetCSeq().GetMethod()) {
-		return errors.New("ParseException: CSEQ method mismatch with  Request-Line ")
+		return newHeaderError(core.ParseErrorKind_METHOD_MISMATCH, "ParseException: CSEQ method mismatch with  Request-Line ", core.SIPHeaderNames_CSEQ)
 	}
 
 	return nil
@@ -216,7 +216,7 @@ func (this *SIPRequest) SetRequestURI(uri address.URI) {
 // *@param method is the method to Set.
 // *@throws IllegalArgumentException if the method is nil
 // */
-func (this *SIPRequest) SetMethod(method string) {
+func (this *SIPRequest) SetMethod(method string) (ParseException error) {
 	//if method == nil
 	//  throw new IllegalArgumentException("nil method");
 	if this.requestLine == nil {
@@ -224,10 +224,9 @@ func (this *SIPRequest) SetMethod(method string) {
 	}
 	this.requestLine.SetMethod(method)
 	if this.cSeqHeader != nil {
-		//try{
-		this.cSeqHeader.SetMethod(method)
-		//}catch(ParseException e){}
+		return this.cSeqHeader.SetMethod(method)
 	}
+	return nil
 }
 
 // /** Get the method from the request line.
@@ -573,8 +572,9 @@ func (this *SIPRequest) CreateCancelRequest() *SIPRequest {
 	//SIPHeader	nextHeader;
 
 	newRequest := NewSIPRequest()
-	newRequest.SetRequestLine(this.requestLine)
-	newRequest.SetMethod(CANCEL)
+	// The request line and the CSeq are shared with this request, so
+	// new ones are created rather than modifying them in place.
+	newRequest.SetRequestLine(header.NewRequestLineFromString(this.GetRequestURI(), CANCEL))
 
 	for headerIterator := this.getHeaders().Front(); headerIterator != nil; headerIterator = headerIterator.Next() {
 		nextHeader := headerIterator.Value.(header.Header)
@@ -596,12 +596,7 @@ func (this *SIPRequest) CreateCancelRequest() *SIPRequest {
 			 **/
 			//nextHeader = (ViaList) ((ViaList) nextHeader).clone();
 		} else if cseq, ok := nextHeader.(*header.CSeq); ok { // CSeq method for a cancel request must be cancel.
-			//CSeq cseq = (CSeq) nextHeader.clone();
-			//try{
-			cseq.SetMethod(CANCEL)
-			//}
-			//catch(ParseException e){}
-			nextHeader = cseq
+			nextHeader = header.NewCSeq(cseq.GetSequenceNumber(), CANCEL)
 		}
 		//try {
 		newRequest.AttachHeader2(nextHeader, false)
@@ -629,8 +624,7 @@ func (this *SIPRequest) CreateAckRequest(responseToHeader *header.To) *SIPReques
 	// SIPHeader	nextHeader;
 
 	newRequest := NewSIPRequest()
-	newRequest.SetRequestLine(this.requestLine)
-	newRequest.SetMethod(ACK)
+	newRequest.SetRequestLine(header.NewRequestLineFromString(this.GetRequestURI(), ACK))
 	for headerIterator := this.getHeaders().Front(); headerIterator != nil; headerIterator = headerIterator.Next() {
 		nextHeader := headerIterator.Value.(header.Header)
 		if _, ok := nextHeader.(*header.RouteList); ok {
@@ -642,14 +636,9 @@ func (this *SIPRequest) CreateAckRequest(responseToHeader *header.To) *SIPReques
 			// Remove proxy auth header.
 			// Assigned by the Dialog if necessary.
 			continue
-		} else if cl, ok := nextHeader.(*header.ContentLength); ok {
+		} else if _, ok := nextHeader.(*header.ContentLength); ok {
 			// Adding content is responsibility of user.
-			//nextHeader = nextHeader.(*header.ContentLength)
-			//try{
-			cl.SetContentLength(0)
-			nextHeader = cl
-			//}
-			//catch(InvalidArgumentException e){}
+			nextHeader = header.NewContentLengthFromInt(0)
 		} else if _, ok := nextHeader.(*header.ContentType); ok {
 			// Content type header is removed since
 			// content length is 0. Bug fix from
@@ -661,12 +650,7 @@ func (this *SIPRequest) CreateAckRequest(responseToHeader *header.To) *SIPReques
 			// sequence number as was present in the
 			// original request, but the method parameter
 			// MUST be equal to "ACK".
-			//nextHeader = nextHeader.(*header.CSeq)
-			//try{
-			cseq.SetMethod(ACK)
-			nextHeader = cseq
-			//catch(ParseException e){}
-			// = cseq;
+			nextHeader = header.NewCSeq(cseq.GetSequenceNumber(), ACK)
 		} else if _, ok := nextHeader.(*header.To); ok {
 			if responseToHeader != nil {
 				nextHeader = responseToHeader
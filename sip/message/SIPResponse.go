@@ -3,7 +3,6 @@ package message
 import (
 	"bytes"
 	"container/list"
-	"errors"
 	"github.com/use-go/gosips/core"
 	"github.com/use-go/gosips/sip/address"
 	"github.com/use-go/gosips/sip/header"
@@ -121,6 +120,21 @@ func (this *SIPResponse) GetReasonPhraseFromInt(rc int) string {
 	case INTERVAL_TOO_BRIEF:
 		retval = "Interval too brief"
 
+	case USE_IDENTITY_HEADER:
+		retval = "Use Identity Header"
+
+	case FLOW_FAILED:
+		retval = "Flow Failed"
+
+	case BAD_IDENTITY_INFO:
+		retval = "Bad Identity Info"
+
+	case UNSUPPORTED_CREDENTIAL:
+		retval = "Unsupported Credential"
+
+	case INVALID_IDENTITY_HEADER:
+		retval = "Invalid Identity Header"
+
 	case CALL_OR_TRANSACTION_DOES_NOT_EXIST:
 		retval = "Call leg/Transaction does not exist"
 
@@ -157,6 +171,9 @@ func (this *SIPResponse) GetReasonPhraseFromInt(rc int) string {
 	case UNDECIPHERABLE:
 		retval = "Undecipherable"
 
+	case SECURITY_AGREEMENT_REQUIRED:
+		retval = "Security Agreement Required"
+
 	case NOT_IMPLEMENTED:
 		retval = "Not implemented"
 
@@ -296,17 +313,17 @@ func (this *SIPResponse) SetStatusLine(sl *header.StatusLine) {
 //     * headers.
 //     */
 func (this *SIPResponse) CheckHeaders() (ParseException error) {
-	if this.GetCSeq() == nil {
-		return errors.New("ParseException: CSeq")
+	if this.cSeqHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException: CSeq", core.SIPHeaderNames_CSEQ)
 	}
-	if this.GetTo() == nil {
-		return errors.New("ParseException: To")
+	if this.toHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException: To", core.SIPHeaderNames_TO)
 	}
-	if this.GetFrom() == nil {
-		return errors.New("ParseException: From")
+	if this.fromHeader == nil {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException: From", core.SIPHeaderNames_FROM)
 	}
-	if this.GetViaHeaders() == nil {
-		return errors.New("ParseException: Via")
+	if !this.HasHeader(core.SIPHeaderNames_VIA) {
+		return newHeaderError(core.ParseErrorKind_MISSING_HEADER, "ParseException: Via", core.SIPHeaderNames_VIA)
 	}
 	return nil
 }
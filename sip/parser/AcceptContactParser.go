@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Accept-Contact header (RFC 3841):
+ * <pre>
+ * Accept-Contact  =  ( "Accept-Contact" / "a" ) HCOLON ac-value
+ *                    *(COMMA ac-value)
+ * ac-value        =  "*" *(SEMI ac-params)
+ * </pre>
+ */
+type AcceptContactParser struct {
+	ParametersParser
+}
+
+/** Creates a new instance of AcceptContactParser
+ * @param acceptContact the header to parse
+ */
+func NewAcceptContactParser(acceptContact string) *AcceptContactParser {
+	this := &AcceptContactParser{}
+	this.ParametersParser.super(acceptContact)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewAcceptContactParserFromLexer(lexer core.Lexer) *AcceptContactParser {
+	this := &AcceptContactParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (AcceptContactList object)
+ * @throws SIPParseException if the message does not respect the spec.
+ */
+func (this *AcceptContactParser) Parse() (sh header.Header, ParseException error) {
+	acceptContactList := header.NewAcceptContactList()
+
+	var ch byte
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_ACCEPT_CONTACT)
+	for {
+		lexer.SPorHT()
+		if _, ParseException = lexer.Match('*'); ParseException != nil {
+			return nil, ParseException
+		}
+		acceptContact := header.NewAcceptContact()
+		if ParseException = this.ParametersParser.Parse(acceptContact); ParseException != nil {
+			return nil, ParseException
+		}
+		acceptContactList.PushBack(acceptContact)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+		} else if ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+	return acceptContactList, nil
+}
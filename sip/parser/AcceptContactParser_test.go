@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestAcceptContactParser(t *testing.T) {
+	var tvi = []string{
+		"Accept-Contact: *;audio;require\n",
+		"Accept-Contact: *;video;methods=\"INVITE,BYE\";explicit , *;+sip.instance=\"<urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6>\"\n",
+		"a: *;mobility=\"fixed\";+sip.foo=\"#>=2\"\n",
+	}
+	var tvo = []string{
+		"Accept-Contact: *;audio;require\n",
+		"Accept-Contact: *;video;methods=\"INVITE,BYE\";explicit,*;+sip.instance=\"<urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6>\"\n",
+		"Accept-Contact: *;mobility=\"fixed\";+sip.foo=\"#>=2\"\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewAcceptContactParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+
+	if _, err := NewAcceptContactParser("Accept-Contact: <sip:bob@biloxi.com>;audio\n").Parse(); err == nil {
+		t.Error("Accept-Contact without '*' accepted")
+	}
+}
@@ -89,10 +89,10 @@ func (this *AcceptEncodingParser) Parse() (sh header.Header, ParseException erro
 
 				var qv float64
 				if qv, ParseException = strconv.ParseFloat(value.GetTokenValue(), 32); ParseException != nil {
-					return nil, ParseException
+					return nil, this.WrapParseException(ParseException)
 				}
 				if ParseException = acceptEncoding.SetQValue(float32(qv)); ParseException != nil {
-					return nil, ParseException
+					return nil, this.WrapParseException(ParseException)
 				}
 				lexer.SPorHT()
 			}
@@ -79,11 +79,11 @@ func (this *AcceptLanguageParser) Parse() (sh header.Header, ParseException erro
 
 			var qv float64
 			if qv, ParseException = strconv.ParseFloat(value.GetTokenValue(), 32); ParseException != nil {
-				return nil, ParseException
+				return nil, this.WrapParseException(ParseException)
 			}
 
 			if ParseException = acceptLanguage.SetQValue(float32(qv)); ParseException != nil {
-				return nil, ParseException
+				return nil, this.WrapParseException(ParseException)
 			}
 
 			lexer.SPorHT()
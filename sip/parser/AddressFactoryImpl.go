@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+ * Implementation of the AddressImplFactory: the URIs and the addresses are
+ * parsed with the URL and address parsers. It has no state and can be used
+ * by several goroutines.
+ */
+type AddressFactoryImpl struct {
+}
+
+/** Constructor.
+ */
+func NewAddressFactoryImpl() *AddressFactoryImpl {
+	return &AddressFactoryImpl{}
+}
+
+/**
+ * Creates a URI based on given URI string. The URI string is parsed in
+ * order to create the new URI instance: a SipURI, a TelURL or a generic
+ * URI depending on its scheme.
+ *
+ * @param uri - the new string value of the URI.
+ * @throws ParseException if the URI string is malformed.
+ */
+func (this *AddressFactoryImpl) CreateURI(uriStr string) (uri address.URI, ParseException error) {
+	uriParser := NewURLParser(uriStr)
+	if uri, ParseException = uriParser.Parse(); ParseException != nil {
+		return nil, ParseException
+	}
+	if uriParser.GetLexer().HasMoreChars() {
+		return nil, uriParser.CreateParseException("unexpected characters after the URI")
+	}
+	return uri, nil
+}
+
+/**
+ * Creates a SipURI based on the given user and host components. The user
+ * component may be "". The characters of the user that are not legal in the
+ * user part of a URI are escaped.
+ *
+ * @param user - the new string value of the user, this value may be "".
+ * @param host - the new string value of the host.
+ * @throws ParseException if the URI string is malformed.
+ */
+func (this *AddressFactoryImpl) CreateSipURI(user, host string) (sipuri *address.SipURIImpl, ParseException error) {
+	var uriStr bytes.Buffer
+	uriStr.WriteString(core.SIPTransportNames_SIP + core.SIPSeparatorNames_COLON)
+	if user != "" {
+		uriStr.WriteString(escapeUser(user))
+		uriStr.WriteString(core.SIPSeparatorNames_AT)
+	}
+	uriStr.WriteString(host)
+
+	uri, err := this.CreateURI(uriStr.String())
+	if err != nil {
+		return nil, err
+	}
+	if sipuri, ok := uri.(*address.SipURIImpl); ok {
+		return sipuri, nil
+	}
+	return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+uriStr.String()+" is not a SIP URI", -1)
+}
+
+/**
+ * Creates a TelURL based on given URI string. The scheme should not be
+ * included in the phoneNumber string argument.
+ *
+ * @param uri - the new string value of the phoneNumber.
+ * @throws ParseException if the URI string is malformed.
+ */
+func (this *AddressFactoryImpl) CreateTelURL(phoneNumber string) (telurl *address.TelURLImpl, ParseException error) {
+	uri, err := this.CreateURI(core.SIPTransportNames_TEL + core.SIPSeparatorNames_COLON + phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if telurl, ok := uri.(*address.TelURLImpl); ok {
+		return telurl, nil
+	}
+	return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+phoneNumber+" is not a telephone number", -1)
+}
+
+/**
+ * Creates an Address with the new address string value. The address
+ * string is parsed in order to create the new Address instance. Create
+ * with a String value of "*" creates a wildcard address.
+ *
+ * @param address - the new string value of the address.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the address value.
+ */
+func (this *AddressFactoryImpl) CreateAddressFromString(addrStr string) (addr address.Address, ParseException error) {
+	if strings.TrimSpace(addrStr) == core.SIPSeparatorNames_STAR {
+		addressImpl := address.NewAddressImpl()
+		addressImpl.SetWildCardFlag()
+		return addressImpl, nil
+	}
+
+	addressParser := NewAddressParser(addrStr)
+	addressImpl, err := addressParser.Address()
+	if err != nil {
+		return nil, err
+	}
+	if addressParser.GetLexer().HasMoreChars() {
+		return nil, addressParser.CreateParseException("unexpected characters after the address")
+	}
+	return addressImpl, nil
+}
+
+/**
+ * Creates an Address with the new URI attribute value.
+ *
+ * @param uri - the URI value of the address.
+ */
+func (this *AddressFactoryImpl) CreateAddressFromURI(uri address.URI) (addr address.Address) {
+	addressImpl := address.NewAddressImpl()
+	addressImpl.SetAddressType(address.NAME_ADDR)
+	addressImpl.SetURI(uri)
+	return addressImpl
+}
+
+/**
+ * Creates an Address with the new display name and URI attribute
+ * values.
+ *
+ * @param displayName - the new string value of the display name of the
+ * address. A "" value does not set the display name.
+ * @param uri - the new URI value of the address.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the displayName value.
+ */
+func (this *AddressFactoryImpl) CreateAddressFromURIWithDisplayName(displayName string, uri address.URI) (addr address.Address, ParseException error) {
+	// The display name is encoded as a quoted string.
+	if strings.ContainsAny(displayName, "\"\\\r\n") {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad display name "+displayName, -1)
+	}
+	addressImpl := this.CreateAddressFromURI(uri).(*address.AddressImpl)
+	addressImpl.SetDisplayName(displayName)
+	return addressImpl, nil
+}
+
+/** Escape the characters of a user that are not legal in the user part of
+ * a SIP URI (RFC 3261 section 25.1).
+ */
+func escapeUser(user string) string {
+	uriParser := NewURLParser(user)
+	var escaped bytes.Buffer
+	for i := 0; i < len(user); i++ {
+		if ch := user[i]; uriParser.IsUnreserved(ch) || uriParser.IsUserUnreserved(ch) {
+			escaped.WriteByte(ch)
+		} else {
+			escaped.WriteString(fmt.Sprintf("%%%02X", ch))
+		}
+	}
+	return escaped.String()
+}
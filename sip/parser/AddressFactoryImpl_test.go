@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/use-go/gosips/sip/address"
+)
+
+func TestAddressFactoryImpl(t *testing.T) {
+	var addressFactory address.AddressImplFactory = NewAddressFactoryImpl()
+
+	sipuri, err := addressFactory.CreateSipURI("alice smith", "atlanta.example.com")
+	if err != nil || sipuri.String() != "sip:alice%20smith@atlanta.example.com" {
+		t.Fatalf("SIP URI %v %v", sipuri, err)
+	}
+	if telurl, err := addressFactory.CreateTelURL("+1-212-555-1212"); err != nil || telurl.String() != "tel:+1-212-555-1212" {
+		t.Fatalf("tel URL %v %v", telurl, err)
+	}
+	if _, err := addressFactory.CreateURI("sip:alice@atlanta.example.com>"); err == nil {
+		t.Fatal("URI with trailing characters accepted")
+	}
+
+	addr, err := addressFactory.CreateAddressFromURIWithDisplayName("Alice", sipuri)
+	if err != nil || addr.String() != "\"Alice\" <sip:alice%20smith@atlanta.example.com>" {
+		t.Fatalf("address %v %v", addr, err)
+	}
+	if _, err := addressFactory.CreateAddressFromURIWithDisplayName("Alice\"", sipuri); err == nil {
+		t.Fatal("bad display name accepted")
+	}
+	if addr, err := addressFactory.CreateAddressFromString("Bob <sip:bob@biloxi.example.com>"); err != nil || addr.GetDisplayName() != "Bob" {
+		t.Fatalf("address %v %v", addr, err)
+	}
+	if addr, err := addressFactory.CreateAddressFromString("*"); err != nil || !addr.IsWildcard() {
+		t.Fatalf("wildcard %v %v", addr, err)
+	}
+}
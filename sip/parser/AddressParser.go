@@ -73,6 +73,13 @@ func (this *AddressParser) NameAddr() (addr *address.AddressImpl, ParseException
 			if name, ParseException = lexer.GetNextTokenByDelim('<'); ParseException != nil {
 				return nil, ParseException
 			}
+			// An unquoted display name is a list of tokens (RFC 4475
+			// section 3.1.2.15).
+			for i := 0; i < len(name); i++ {
+				if !isTokenChar(name[i]) && name[i] != ' ' && name[i] != '\t' && name[i] != '\r' && name[i] != '\n' {
+					return nil, this.CreateParseException("unquoted display name")
+				}
+			}
 		}
 		addr.SetDisplayName(strings.TrimSpace(name))
 		lexer.Match('<')
@@ -120,6 +127,11 @@ func (this *AddressParser) Address() (retval *address.AddressImpl, ParseExceptio
 		if uri, ParseException = uriParser.UriReference(); ParseException != nil {
 			return nil, ParseException
 		}
+		// A URI with headers must be enclosed in angle brackets (RFC 3261
+		// section 20, RFC 4475 section 3.1.2.13).
+		if sipuri, ok := uri.(*address.SipURIImpl); ok && sipuri.GetHeaderNames() != nil && sipuri.GetHeaderNames().Len() > 0 {
+			return nil, this.CreateParseException("URI with headers not in angle brackets")
+		}
 		retval.SetAddressType(address.ADDRESS_SPEC)
 		retval.SetURI(uri)
 	} else {
@@ -128,3 +140,10 @@ func (this *AddressParser) Address() (retval *address.AddressImpl, ParseExceptio
 
 	return retval, nil
 }
+
+/** Return true if the char may be part of a token (RFC 3261 section 25.1).
+ */
+func isTokenChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+		strings.IndexByte("-.!%*_+`'~", ch) >= 0
+}
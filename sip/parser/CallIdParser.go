@@ -51,6 +51,9 @@ func (this *CallIDParser) Parse() (sh header.Header, ParseException error) {
 	rest := strings.TrimSpace(lexer.GetRest())
 
 	callID, ParseException := header.NewCallID(rest)
+	if ParseException != nil {
+		return nil, this.WrapParseException(ParseException)
+	}
 
-	return callID, ParseException
+	return callID, nil
 }
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/use-go/gosips/core"
 )
 
 func TestCallIdParser(t *testing.T) {
@@ -27,3 +30,11 @@ func TestCallIdParser(t *testing.T) {
 		testHeaderParser(t, shp, tvo[i])
 	}
 }
+
+func TestCallIdParserEmpty(t *testing.T) {
+	_, err := NewCallIDParser("Call-ID: \n").Parse()
+	var parseError *core.ParseError
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_BAD_SYNTAX {
+		t.Fatalf("empty Call-ID: %v", err)
+	}
+}
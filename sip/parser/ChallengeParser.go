@@ -46,8 +46,10 @@ func (this *ChallengeParser) ParseParameter(h header.AuthorizationHeader) (Parse
 	if nv, ParseException = this.NameValue('='); ParseException != nil {
 		return ParseException
 	}
-	ParseException = h.SetParameter(nv.GetName(), nv.GetValue().(string))
-	return ParseException
+	if ParseException = h.SetParameter(nv.GetName(), nv.GetValue().(string)); ParseException != nil {
+		return this.WrapParseException(ParseException)
+	}
+	return nil
 }
 
 /** parser the String message
@@ -43,8 +43,8 @@ func (this *ContactParser) Parse() (sh header.Header, ParseException error) {
 			}
 
 			if contact.HasParameter(header.ParameterNames_EXPIRES) {
-				if _, ParseException = strconv.Atoi(contact.GetParameter(header.ParameterNames_EXPIRES)); ParseException != nil {
-					return nil, ParseException
+				if _, ParseException = strconv.ParseInt(contact.GetParameter(header.ParameterNames_EXPIRES), 10, 32); ParseException != nil {
+					return nil, this.WrapParseException(ParseException)
 				}
 			}
 		}
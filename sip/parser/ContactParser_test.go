@@ -34,7 +34,7 @@ func TestContactParser(t *testing.T) {
 		"Contact: \"LittleGuy\" <sip:UserB@there.com;user=phone>" +
 			",<sip:+1-972-555-2222@gw1.wcom.com;user=phone>,<tel:+1-972-555-2222>" +
 			"\n",
-		"Contact: <*>\n",
+		"Contact: *\n",
 		"Contact: \"BigGuy\" <sip:utente@127.0.0.1;5000>;Expires=3600\n",
 	}
 
@@ -32,7 +32,7 @@ func (this *ContentLengthParser) Parse() (sh header.Header, ParseException error
 		return nil, ParseException
 	}
 	if ParseException = contentLength.SetContentLength(number); ParseException != nil {
-		return nil, ParseException
+		return nil, this.WrapParseException(ParseException)
 	}
 	lexer.SPorHT()
 	lexer.Match('\n')
@@ -39,10 +39,10 @@ func (this *DateParser) Parse() (sh header.Header, ParseException error) {
 	this.HeaderName(TokenTypes_DATE)
 	var t time.Time
 	if t, ParseException = time.Parse(time.RFC1123, strings.TrimSpace(lexer.GetRest())); ParseException != nil {
-		return nil, ParseException
+		return nil, this.WrapParseException(ParseException)
 	}
 	if t.Location().String() != "GMT" {
-		return nil, errors.New("GMT is only acceptable time zone")
+		return nil, this.WrapParseException(errors.New("GMT is only acceptable time zone"))
 	}
 	retval := header.NewDate()
 	retval.SetDate(&t)
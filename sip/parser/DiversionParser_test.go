@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestDiversionParser(t *testing.T) {
+	var tvi = []string{
+		"Diversion: <sip:bob@example.com>;reason=user-busy;counter=1;privacy=off\n",
+		"Diversion: <sip:carol@example.com>;reason=no-answer,<sip:bob@example.com>;reason=unconditional;counter=1\n",
+		"Diversion: \"Bob\" <tel:+15551234567> ; reason=deflection ; screen=yes\n",
+	}
+	var tvo = []string{
+		"Diversion: <sip:bob@example.com>;reason=user-busy;counter=1;privacy=off\n",
+		"Diversion: <sip:carol@example.com>;reason=no-answer,<sip:bob@example.com>;reason=unconditional;counter=1\n",
+		"Diversion: \"Bob\" <tel:+15551234567>;reason=deflection;screen=yes\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewDiversionParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
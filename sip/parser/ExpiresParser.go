@@ -45,7 +45,7 @@ func (this *ExpiresParser) Parse() (sh header.Header, ParseException error) {
 	lexer.Match('\n')
 	var delta int64
 	if delta, ParseException = strconv.ParseInt(nextId, 10, 32); ParseException != nil {
-		return nil, ParseException
+		return nil, this.WrapParseException(ParseException)
 	}
 	expires.SetExpires(int(delta))
 	return expires, ParseException
@@ -0,0 +1,460 @@
+package parser
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/**
+ * Implementation of the HeaderFactory: a header is encoded from its
+ * arguments and parsed by the parser registered for its name, so that it is
+ * the header a parsed message would have. It has no state and can be used
+ * by several goroutines.
+ */
+type HeaderFactoryImpl struct {
+}
+
+/** Constructor.
+ */
+func NewHeaderFactoryImpl() *HeaderFactoryImpl {
+	return &HeaderFactoryImpl{}
+}
+
+/**
+ * Creates a new Header based on the newly supplied name and value
+ * values, parsed by the parser registered for the name.
+ *
+ * @param name - the new string name of the Header value.
+ * @param value - the new string value of the Header.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the name or value parameters.
+ */
+func (this *HeaderFactoryImpl) CreateHeader(name, value string) (h header.Header, ParseException error) {
+	if name == "" || strings.ContainsAny(name, ": \t\r\n") {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad header name "+name, -1)
+	}
+	// A value on several lines would add headers to the message.
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, newFactoryError(name, "line terminator in the value")
+	}
+	if h, ParseException = parseHeaderLine(name + core.SIPSeparatorNames_COLON + core.SIPSeparatorNames_SP + value); ParseException != nil {
+		return nil, core.AsParseError(ParseException, core.ParseErrorKind_BAD_SYNTAX, -1).At(name, 0, 0, -1)
+	}
+	return h, nil
+}
+
+/** Create a header with a single value: the header of a header list, e.g.
+ * a Via rather than a ViaList.
+ */
+func (this *HeaderFactoryImpl) createHeaderValue(name, value string) (header.Header, error) {
+	h, err := this.CreateHeader(name, value)
+	if err != nil {
+		return nil, err
+	}
+	if headerList, ok := h.(header.SIPHeaderLister); ok {
+		if headerList.Len() != 1 {
+			return nil, newFactoryError(name, "one value expected")
+		}
+		h = headerList.Front().Value.(header.Header)
+	}
+	return h, nil
+}
+
+/**
+ * Creates a new CallIdHeader based on the newly supplied callId value.
+ *
+ * @param callId - the new string value of the call-id.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the callId value.
+ */
+func (this *HeaderFactoryImpl) CreateCallIdHeader(callId string) (callIdHeader header.CallIdHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_CALL_ID, callId)
+	if err != nil {
+		return nil, err
+	}
+	if callIdHeader, ok := h.(header.CallIdHeader); ok && callIdHeader.GetCallId() == callId {
+		return callIdHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_CALL_ID, "bad Call-ID "+callId)
+}
+
+/**
+ * Creates a new CSeqHeader based on the newly supplied sequence number and
+ * method values.
+ *
+ * @param sequenceNumber - the new integer value of the sequence number.
+ * @param method - the new string value of the method.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the method value or the sequence number is
+ * not between 0 and 2**31 - 1.
+ */
+func (this *HeaderFactoryImpl) CreateCSeqHeader(sequenceNumber int, method string) (cSeqHeader header.CSeqHeader, ParseException error) {
+	if sequenceNumber < 0 || sequenceNumber > math.MaxInt32 {
+		return nil, newFactoryError(core.SIPHeaderNames_CSEQ, "bad sequence number "+strconv.Itoa(sequenceNumber))
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_CSEQ, strconv.Itoa(sequenceNumber)+core.SIPSeparatorNames_SP+method)
+	if err != nil {
+		return nil, err
+	}
+	if cSeqHeader, ok := h.(header.CSeqHeader); ok && cSeqHeader.GetMethod() == method {
+		return cSeqHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_CSEQ, "bad method "+method)
+}
+
+/**
+ * Creates a new FromHeader based on the newly supplied address and
+ * tag values.
+ *
+ * @param addr - the new Address object of the address.
+ * @param tag - the new string value of the tag, or "" for no tag.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the tag value.
+ */
+func (this *HeaderFactoryImpl) CreateFromHeader(addr address.Address, tag string) (fromHeader header.FromHeader, ParseException error) {
+	value, err := encodeNameAddr(core.SIPHeaderNames_FROM, addr)
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		value += core.SIPSeparatorNames_SEMICOLON + header.ParameterNames_TAG + core.SIPSeparatorNames_EQUALS + tag
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_FROM, value)
+	if err != nil {
+		return nil, err
+	}
+	if fromHeader, ok := h.(header.FromHeader); ok && fromHeader.GetTag() == tag {
+		return fromHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_FROM, "bad tag "+tag)
+}
+
+/**
+ * Creates a new ToHeader based on the newly supplied address and
+ * tag values.
+ *
+ * @param addr - the new Address object of the address.
+ * @param tag - the new string value of the tag, or "" for no tag.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the tag value.
+ */
+func (this *HeaderFactoryImpl) CreateToHeader(addr address.Address, tag string) (toHeader header.ToHeader, ParseException error) {
+	value, err := encodeNameAddr(core.SIPHeaderNames_TO, addr)
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" {
+		value += core.SIPSeparatorNames_SEMICOLON + header.ParameterNames_TAG + core.SIPSeparatorNames_EQUALS + tag
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_TO, value)
+	if err != nil {
+		return nil, err
+	}
+	if toHeader, ok := h.(header.ToHeader); ok && toHeader.GetTag() == tag {
+		return toHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_TO, "bad tag "+tag)
+}
+
+/**
+ * Creates a new ViaHeader based on the newly supplied host, port, transport
+ * and branch values.
+ *
+ * @param host - the new string value of the host.
+ * @param port - the new integer value of the port, or 0 for no port.
+ * @param transport - the new string value of the transport.
+ * @param branch - the new string value of the branch, or "" for no branch.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the host, transport or branch value.
+ */
+func (this *HeaderFactoryImpl) CreateViaHeader(host string, port int, transport, branch string) (via *header.Via, ParseException error) {
+	if port < 0 || port > 65535 {
+		return nil, newFactoryError(core.SIPHeaderNames_VIA, "bad port "+strconv.Itoa(port))
+	}
+	var value bytes.Buffer
+	value.WriteString(header.SIPConstants_SIP_VERSION_STRING + core.SIPSeparatorNames_SLASH + transport + core.SIPSeparatorNames_SP)
+	// An IPv6 address is a reference in a Via.
+	if strings.IndexByte(host, ':') >= 0 && !strings.HasPrefix(host, "[") {
+		value.WriteString("[" + host + "]")
+	} else {
+		value.WriteString(host)
+	}
+	if port != 0 {
+		value.WriteString(core.SIPSeparatorNames_COLON + strconv.Itoa(port))
+	}
+	if branch != "" {
+		value.WriteString(core.SIPSeparatorNames_SEMICOLON + header.ParameterNames_BRANCH + core.SIPSeparatorNames_EQUALS + branch)
+	}
+
+	h, err := this.createHeaderValue(core.SIPHeaderNames_VIA, value.String())
+	if err != nil {
+		return nil, err
+	}
+	if via, ok := h.(*header.Via); ok && via.GetBranch() == branch {
+		return via, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_VIA, "bad branch "+branch)
+}
+
+/**
+ * Creates a new MaxForwardsHeader based on the newly supplied maxForwards
+ * value.
+ *
+ * @param maxForwards - the new integer value of the maxForwards, between
+ * 0 and 255.
+ * @throws ParseException if the maxForwards is out of range.
+ */
+func (this *HeaderFactoryImpl) CreateMaxForwardsHeader(maxForwards int) (maxForwardsHeader header.MaxForwardsHeader, ParseException error) {
+	if maxForwards < 0 || maxForwards > 255 {
+		return nil, newFactoryError(core.SIPHeaderNames_MAX_FORWARDS, "bad max forwards "+strconv.Itoa(maxForwards))
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_MAX_FORWARDS, strconv.Itoa(maxForwards))
+	if err != nil {
+		return nil, err
+	}
+	if maxForwardsHeader, ok := h.(header.MaxForwardsHeader); ok {
+		return maxForwardsHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_MAX_FORWARDS, "not a Max-Forwards header")
+}
+
+/**
+ * Creates a new ContactHeader based on the newly supplied address value.
+ *
+ * @param addr - the new Address value of the address.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the address value.
+ */
+func (this *HeaderFactoryImpl) CreateContactHeader(addr address.Address) (contactHeader header.ContactHeader, ParseException error) {
+	value := core.SIPSeparatorNames_STAR
+	if addr == nil || !addr.IsWildcard() {
+		var err error
+		if value, err = encodeNameAddr(core.SIPHeaderNames_CONTACT, addr); err != nil {
+			return nil, err
+		}
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_CONTACT, value)
+	if err != nil {
+		return nil, err
+	}
+	if contactHeader, ok := h.(header.ContactHeader); ok {
+		return contactHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_CONTACT, "not a Contact header")
+}
+
+/**
+ * Creates a new wildcard ContactHeader. This is used in Register requests
+ * to indicate to the server that it should remove all locations
+ * at which the user is currently available.
+ */
+func (this *HeaderFactoryImpl) CreateWildCardContactHeader() header.ContactHeader {
+	h, _ := this.createHeaderValue(core.SIPHeaderNames_CONTACT, core.SIPSeparatorNames_STAR)
+	return h.(header.ContactHeader)
+}
+
+/**
+ * Creates a new ContentTypeHeader based on the newly supplied contentType and
+ * contentSubType values.
+ *
+ * @param contentType - the new string content type value.
+ * @param contentSubType - the new string content sub-type value.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the content type or content subtype value.
+ */
+func (this *HeaderFactoryImpl) CreateContentTypeHeader(contentType, contentSubType string) (contentTypeHeader header.ContentTypeHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_CONTENT_TYPE, contentType+core.SIPSeparatorNames_SLASH+contentSubType)
+	if err != nil {
+		return nil, err
+	}
+	if contentTypeHeader, ok := h.(header.ContentTypeHeader); ok &&
+		contentTypeHeader.GetContentType() == contentType && contentTypeHeader.GetContentSubType() == contentSubType {
+		return contentTypeHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_CONTENT_TYPE, "bad media type "+contentType+core.SIPSeparatorNames_SLASH+contentSubType)
+}
+
+/**
+ * Creates a new ContentLengthHeader based on the newly supplied contentLength value.
+ *
+ * @param contentLength - the new integer value of the contentLength.
+ * @throws ParseException if the contentLength is negative.
+ */
+func (this *HeaderFactoryImpl) CreateContentLengthHeader(contentLength int) (contentLengthHeader header.ContentLengthHeader, ParseException error) {
+	if contentLength < 0 {
+		return nil, newFactoryError(core.SIPHeaderNames_CONTENT_LENGTH, "bad content length "+strconv.Itoa(contentLength))
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_CONTENT_LENGTH, strconv.Itoa(contentLength))
+	if err != nil {
+		return nil, err
+	}
+	if contentLengthHeader, ok := h.(header.ContentLengthHeader); ok {
+		return contentLengthHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_CONTENT_LENGTH, "not a Content-Length header")
+}
+
+/**
+ * Creates a new ExpiresHeader based on the newly supplied expires value.
+ *
+ * @param expires - the new integer value of the expires, in seconds.
+ * @throws ParseException if the expires is negative.
+ */
+func (this *HeaderFactoryImpl) CreateExpiresHeader(expires int) (expiresHeader header.ExpiresHeader, ParseException error) {
+	if expires < 0 {
+		return nil, newFactoryError(core.SIPHeaderNames_EXPIRES, "bad expires "+strconv.Itoa(expires))
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_EXPIRES, strconv.Itoa(expires))
+	if err != nil {
+		return nil, err
+	}
+	if expiresHeader, ok := h.(header.ExpiresHeader); ok {
+		return expiresHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_EXPIRES, "not an Expires header")
+}
+
+/**
+ * Creates a new RouteHeader based on the newly supplied address value.
+ *
+ * @param addr - the new Address object of the address.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the address value.
+ */
+func (this *HeaderFactoryImpl) CreateRouteHeader(addr address.Address) (routeHeader header.RouteHeader, ParseException error) {
+	value, err := encodeNameAddr(core.SIPHeaderNames_ROUTE, addr)
+	if err != nil {
+		return nil, err
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_ROUTE, value)
+	if err != nil {
+		return nil, err
+	}
+	if routeHeader, ok := h.(header.RouteHeader); ok {
+		return routeHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_ROUTE, "not a Route header")
+}
+
+/**
+ * Creates a new RecordRouteHeader based on the newly supplied address value.
+ *
+ * @param addr - the new Address object of the address.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the address value.
+ */
+func (this *HeaderFactoryImpl) CreateRecordRouteHeader(addr address.Address) (recordRouteHeader header.RecordRouteHeader, ParseException error) {
+	value, err := encodeNameAddr(core.SIPHeaderNames_RECORD_ROUTE, addr)
+	if err != nil {
+		return nil, err
+	}
+	h, err := this.createHeaderValue(core.SIPHeaderNames_RECORD_ROUTE, value)
+	if err != nil {
+		return nil, err
+	}
+	if recordRouteHeader, ok := h.(header.RecordRouteHeader); ok {
+		return recordRouteHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_RECORD_ROUTE, "not a Record-Route header")
+}
+
+/**
+ * Creates a new EventHeader based on the newly supplied eventType value.
+ *
+ * @param eventType - the new string value of the eventType.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the eventType value.
+ */
+func (this *HeaderFactoryImpl) CreateEventHeader(eventType string) (eventHeader header.EventHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_EVENT, eventType)
+	if err != nil {
+		return nil, err
+	}
+	if eventHeader, ok := h.(header.EventHeader); ok && eventHeader.GetEventType() == eventType {
+		return eventHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_EVENT, "bad event type "+eventType)
+}
+
+/**
+ * Creates a new AllowHeader based on the newly supplied method value.
+ *
+ * @param method - the new string value of the method.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the method value.
+ */
+func (this *HeaderFactoryImpl) CreateAllowHeader(method string) (allowHeader header.AllowHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_ALLOW, method)
+	if err != nil {
+		return nil, err
+	}
+	if allowHeader, ok := h.(header.AllowHeader); ok && allowHeader.GetMethod() == method {
+		return allowHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_ALLOW, "bad method "+method)
+}
+
+/**
+ * Creates a new SupportedHeader based on the newly supplied optionTag
+ * value.
+ *
+ * @param optionTag - the new string value of the optionTag.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the optionTag value.
+ */
+func (this *HeaderFactoryImpl) CreateSupportedHeader(optionTag string) (supportedHeader header.SupportedHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_SUPPORTED, optionTag)
+	if err != nil {
+		return nil, err
+	}
+	if supportedHeader, ok := h.(header.SupportedHeader); ok && supportedHeader.GetOptionTag() == optionTag {
+		return supportedHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_SUPPORTED, "bad option tag "+optionTag)
+}
+
+/**
+ * Creates a new RequireHeader based on the newly supplied optionTag
+ * value.
+ *
+ * @param optionTag - the new string value of the optionTag.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the optionTag value.
+ */
+func (this *HeaderFactoryImpl) CreateRequireHeader(optionTag string) (requireHeader header.RequireHeader, ParseException error) {
+	h, err := this.createHeaderValue(core.SIPHeaderNames_REQUIRE, optionTag)
+	if err != nil {
+		return nil, err
+	}
+	if requireHeader, ok := h.(header.RequireHeader); ok && requireHeader.GetOptionTag() == optionTag {
+		return requireHeader, nil
+	}
+	return nil, newFactoryError(core.SIPHeaderNames_REQUIRE, "bad option tag "+optionTag)
+}
+
+/** Encode an address as a name-addr, with its URI in angle brackets so
+ * that the parameters of the URI are not taken for those of the header.
+ */
+func encodeNameAddr(headerName string, addr address.Address) (string, error) {
+	if addr == nil || addr.GetURI() == nil {
+		return "", newFactoryError(headerName, "no address")
+	}
+	var encoding bytes.Buffer
+	if displayName := addr.GetDisplayName(); displayName != "" {
+		encoding.WriteString(core.SIPSeparatorNames_DOUBLE_QUOTE + displayName + core.SIPSeparatorNames_DOUBLE_QUOTE + core.SIPSeparatorNames_SP)
+	}
+	encoding.WriteString(core.SIPSeparatorNames_LESS_THAN + addr.GetURI().String() + core.SIPSeparatorNames_GREATER_THAN)
+	return encoding.String(), nil
+}
+
+/** The error of a header that the factory does not create.
+ */
+func newFactoryError(headerName, exceptionString string) error {
+	return core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+headerName+": "+exceptionString, -1).At(headerName, 0, 0, -1)
+}
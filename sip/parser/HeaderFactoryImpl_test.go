@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+func TestHeaderFactoryImpl(t *testing.T) {
+	var headerFactory header.HeaderFactory = NewHeaderFactoryImpl()
+	addressFactory := NewAddressFactoryImpl()
+	sipuri, _ := addressFactory.CreateSipURI("bob", "biloxi.example.com;transport=tcp")
+	addr, _ := addressFactory.CreateAddressFromURIWithDisplayName("Bob", sipuri)
+
+	to, err := headerFactory.CreateToHeader(addr, "a6c85cf")
+	if err != nil || to.GetTag() != "a6c85cf" {
+		t.Fatalf("To %v %v", to, err)
+	}
+	if encoded := to.(*header.To).String(); encoded != "To: \"Bob\" <sip:bob@biloxi.example.com;transport=tcp>;tag=a6c85cf\r\n" {
+		t.Fatalf("To %q", encoded)
+	}
+	via, err := headerFactory.CreateViaHeader("2001:db8::9", 5060, "TCP", "z9hG4bK776asdhds")
+	if err != nil || via.GetBranch() != "z9hG4bK776asdhds" || via.GetTransport() != "TCP" || via.GetPort() != 5060 {
+		t.Fatalf("Via %v %v", via, err)
+	}
+	if cseq, err := headerFactory.CreateCSeqHeader(314159, "INVITE"); err != nil || cseq.GetSequenceNumber() != 314159 {
+		t.Fatalf("CSeq %v %v", cseq, err)
+	}
+	if contact := headerFactory.CreateWildCardContactHeader(); contact.(*header.Contact).GetWildCardFlag() != true {
+		t.Fatalf("Contact %v", contact)
+	}
+	if h, err := headerFactory.CreateHeader("X-Vendor-Trace", "1234"); err != nil || h.(*header.Extension).GetValue() != "1234" {
+		t.Fatalf("extension %v %v", h, err)
+	}
+
+	// The arguments are parsed.
+	tvs := []struct {
+		name       string
+		create     func() error
+		headerName string
+	}{
+		{"tag", func() error { _, err := headerFactory.CreateFromHeader(addr, "a;b"); return err }, core.SIPHeaderNames_FROM},
+		{"method", func() error { _, err := headerFactory.CreateCSeqHeader(1, "IN VITE"); return err }, core.SIPHeaderNames_CSEQ},
+		{"sequence number", func() error { _, err := headerFactory.CreateCSeqHeader(-1, "INVITE"); return err }, core.SIPHeaderNames_CSEQ},
+		{"Max-Forwards", func() error { _, err := headerFactory.CreateMaxForwardsHeader(256); return err }, core.SIPHeaderNames_MAX_FORWARDS},
+		{"media type", func() error { _, err := headerFactory.CreateContentTypeHeader("application", "sdp;x=y"); return err }, core.SIPHeaderNames_CONTENT_TYPE},
+		{"line terminator", func() error { _, err := headerFactory.CreateCallIdHeader("a84b4c76e66710\r\nX: y"); return err }, core.SIPHeaderNames_CALL_ID},
+		{"address", func() error { _, err := headerFactory.CreateRouteHeader(nil); return err }, core.SIPHeaderNames_ROUTE},
+	}
+	for _, tv := range tvs {
+		var parseError *core.ParseError
+		if err := tv.create(); !errors.As(err, &parseError) || parseError.GetHeaderName() != tv.headerName {
+			t.Errorf("%s: %v", tv.name, err)
+		}
+	}
+}
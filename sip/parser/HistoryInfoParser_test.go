@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestHistoryInfoParser(t *testing.T) {
+	var tvi = []string{
+		"History-Info: <sip:bob@example.com?Reason=SIP%3Bcause%3D302>;index=1,<sip:carol@example.com>;index=1.1;mp=1\n",
+		"History-Info: <sip:bob@192.0.2.4>;index=1.1.2;rc=1.1\n",
+		"History-Info: \"Office\" <sip:office@example.com> ; index=1.2 ; np=1\n",
+		"History-Info: <sip:vm@example.com;target=sip:bob%40example.com;cause=486>;index=1.3;mp=1.2\n",
+	}
+	var tvo = []string{
+		"History-Info: <sip:bob@example.com?Reason=SIP%3Bcause%3D302>;index=1,<sip:carol@example.com>;index=1.1;mp=1\n",
+		"History-Info: <sip:bob@192.0.2.4>;index=1.1.2;rc=1.1\n",
+		"History-Info: \"Office\" <sip:office@example.com>;index=1.2;np=1\n",
+		"History-Info: <sip:vm@example.com;target=sip:bob%40example.com;cause=486>;index=1.3;mp=1.2\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewHistoryInfoParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+
+	if _, err := NewHistoryInfoParser("History-Info: <sip:bob@example.com>;index=1..2\n").Parse(); err == nil {
+		t.Error("bad index accepted")
+	}
+}
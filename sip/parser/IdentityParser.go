@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** Parser for the Identity header (RFC 8224). The info parameter is a
+ * URI in angle brackets, which the generic parameter parser does not
+ * accept, so the parameters are parsed here. A header without info, as
+ * in RFC 4474 where the certificate is in Identity-Info, is accepted and
+ * left to the verifier to reject.
+ */
+type IdentityParser struct {
+	HeaderParser
+}
+
+/** Creates a new instance of IdentityParser
+ * @param identity the header to parse
+ */
+func NewIdentityParser(identity string) *IdentityParser {
+	this := &IdentityParser{}
+	this.HeaderParser.super(identity)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewIdentityParserFromLexer(lexer core.Lexer) *IdentityParser {
+	this := &IdentityParser{}
+	this.HeaderParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return Header (Identity object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *IdentityParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_IDENTITY)
+	lexer.SPorHT()
+
+	identity := header.NewIdentity()
+	if ParseException = identity.SetSignedIdentityDigest(strings.TrimSpace(lexer.ByteStringNoSemicolon())); ParseException != nil {
+		return nil, this.CreateParseException(ParseException.Error())
+	}
+
+	for ch, _ := lexer.LookAheadK(0); ch == ';'; ch, _ = lexer.LookAheadK(0) {
+		lexer.ConsumeK(1)
+		lexer.SPorHT()
+		name := lexer.Ttoken()
+		if name == "" {
+			return nil, this.CreateParseException("expecting a parameter")
+		}
+		lexer.SPorHT()
+		value := ""
+		if ch, _ = lexer.LookAheadK(0); ch == '=' {
+			lexer.ConsumeK(1)
+			lexer.SPorHT()
+			if value, ParseException = this.parameterValue(); ParseException != nil {
+				return nil, ParseException
+			}
+		}
+		identity.SetParameter(name, value)
+		lexer.SPorHT()
+	}
+	if _, ParseException = lexer.Match('\n'); ParseException != nil {
+		return nil, ParseException
+	}
+	return identity, nil
+}
+
+/** A value is a URI in angle brackets, a quoted string or a token.
+ */
+func (this *IdentityParser) parameterValue() (value string, ParseException error) {
+	lexer := this.GetLexer()
+	ch, _ := lexer.LookAheadK(0)
+	switch ch {
+	case '<':
+		lexer.ConsumeK(1)
+		if value, ParseException = lexer.GetString('>'); ParseException != nil {
+			return "", this.CreateParseException("unterminated URI")
+		}
+		return "<" + value + ">", nil
+	case '"':
+		if value, ParseException = lexer.QuotedString(); ParseException != nil {
+			return "", ParseException
+		}
+		return "\"" + value + "\"", nil
+	}
+	if value = lexer.Ttoken(); value == "" {
+		return "", this.CreateParseException("expecting a parameter value")
+	}
+	return value, nil
+}
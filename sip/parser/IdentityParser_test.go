@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestIdentityParser(t *testing.T) {
+	var tvi = []string{
+		"Identity: eyJhbGciOiJFUzI1NiIsInBwdCI6InNoYWtlbiIsInR5cCI6InBhc3Nwb3J0In0.eyJhdHRlc3QiOiJBIn0.c2ln;info=<https://cert.example.org/passport.cer>;alg=ES256;ppt=shaken\n",
+		"Identity: a.b.c ; info=<https://biloxi.example.org/biloxi.cer> ; alg=ES256\n",
+		"y: a.b.c;info=<https://cert.example.org/passport.cer>;ppt=\"shaken\"\n",
+	}
+	var tvo = []string{
+		"Identity: eyJhbGciOiJFUzI1NiIsInBwdCI6InNoYWtlbiIsInR5cCI6InBhc3Nwb3J0In0.eyJhdHRlc3QiOiJBIn0.c2ln;info=<https://cert.example.org/passport.cer>;alg=ES256;ppt=shaken\n",
+		"Identity: a.b.c;info=<https://biloxi.example.org/biloxi.cer>;alg=ES256\n",
+		"Identity: a.b.c;info=<https://cert.example.org/passport.cer>;ppt=\"shaken\"\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewIdentityParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+
+	if _, err := NewIdentityParser("Identity: ;info=<https://cert.example.org/passport.cer>\n").Parse(); err == nil {
+		t.Error("Identity without a digest accepted")
+	}
+}
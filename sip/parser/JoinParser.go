@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Join header (RFC 3911).
+ */
+type JoinParser struct {
+	ParametersParser
+}
+
+/** Constructor
+ * @param String Join message to parse to set
+ */
+func NewJoinParser(join string) *JoinParser {
+	this := &JoinParser{}
+	this.ParametersParser.super(join)
+	return this
+}
+
+func NewJoinParserFromLexer(lexer core.Lexer) *JoinParser {
+	this := &JoinParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return Header (Join object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *JoinParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_JOIN)
+	lexer.SPorHT()
+
+	join := header.NewJoin()
+	if ParseException = join.SetCallId(strings.TrimSpace(lexer.ByteStringNoSemicolon())); ParseException != nil {
+		return nil, this.CreateParseException(ParseException.Error())
+	}
+	if ParseException = this.ParametersParser.Parse(join); ParseException != nil {
+		return nil, ParseException
+	}
+	if join.GetToTag() == "" || join.GetFromTag() == "" {
+		return nil, this.CreateParseException("missing to-tag or from-tag")
+	}
+
+	lexer.SPorHT()
+	lexer.Match('\n')
+
+	return join, nil
+}
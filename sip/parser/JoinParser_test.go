@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestJoinParser(t *testing.T) {
+	var tvi = []string{
+		"Join: 12345600@atlanta.example.com;from-tag=1234567;to-tag=23431\n",
+		"Join: 98732@sip.example.com ; to-tag=ff87ff ; from-tag=r33th4x0r\n",
+	}
+	var tvo = []string{
+		"Join: 12345600@atlanta.example.com;from-tag=1234567;to-tag=23431\n",
+		"Join: 98732@sip.example.com;to-tag=ff87ff;from-tag=r33th4x0r\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewJoinParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
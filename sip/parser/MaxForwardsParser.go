@@ -36,7 +36,7 @@ func (this *MaxForwardsParser) Parse() (sh header.Header, ParseException error)
 		return nil, ParseException
 	}
 	if ParseException = contentLength.SetMaxForwards(number); ParseException != nil {
-		return nil, ParseException
+		return nil, this.WrapParseException(ParseException)
 	}
 	lexer.SPorHT()
 	lexer.Match('\n')
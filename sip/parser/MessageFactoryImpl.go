@@ -0,0 +1,363 @@
+package parser
+
+import (
+	"bytes"
+	"container/list"
+	"reflect"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * Implementation of the MessageFactory. The requests it creates have the
+ * mandatory headers of RFC 3261 section 8.1.1, and the responses the headers
+ * of their request of section 8.2.6.2, copied so that they are not shared
+ * with the request. It has no state and can be used by several goroutines.
+ */
+type MessageFactoryImpl struct {
+}
+
+/** The headers of a request that are copied in the responses to it, in
+ * their order in the responses.
+ */
+var responseHeaderNames = []string{
+	core.SIPHeaderNames_VIA,
+	core.SIPHeaderNames_RECORD_ROUTE,
+	core.SIPHeaderNames_FROM,
+	core.SIPHeaderNames_TO,
+	core.SIPHeaderNames_CALL_ID,
+	core.SIPHeaderNames_CSEQ,
+	core.SIPHeaderNames_TIMESTAMP,
+}
+
+/** Constructor.
+ */
+func NewMessageFactoryImpl() *MessageFactoryImpl {
+	return &MessageFactoryImpl{}
+}
+
+/**
+ * Creates a new Request message of type specified by the method paramater,
+ * containing the URI of the Request, the mandatory headers of the message.
+ * This new Request does not contain a body.
+ *
+ * @param requestURI - the new URI object of the requestURI value of this Message.
+ * @param method - the new string of the method value of this Message.
+ * @param callId - the new CallIdHeader object of the callId value of this Message.
+ * @param cSeq - the new CSeqHeader object of the cSeq value of this Message.
+ * @param from - the new FromHeader object of the from value of this Message.
+ * @param to - the new ToHeader object of the to value of this Message.
+ * @param via - the new List object of the ViaHeaders of this Message.
+ * @param maxForwards - the new MaxForwardsHeader object of the
+ * max forwards value of this Message.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the method, or a mandatory header is missing.
+ */
+func (this *MessageFactoryImpl) CreateRequest(requestURI address.URI, method string, callId header.CallIdHeader, cSeq header.CSeqHeader,
+	from header.FromHeader, to header.ToHeader, via *list.List, maxForwards header.MaxForwardsHeader) (request message.Request, ParseException error) {
+	if isNilValue(requestURI) {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: no Request-URI", -1)
+	}
+	// The method and the Request-URI are checked as those of a parsed request.
+	line := method + core.SIPSeparatorNames_SP + requestURI.String() + core.SIPSeparatorNames_SP + header.SIPConstants_SIP_VERSION_STRING
+	requestLine, err := NewRequestLineParser(line + core.SIPSeparatorNames_NEWLINE).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkRequestLine(line, requestLine); err != nil {
+		return nil, err
+	}
+	if requestLine.GetMethod() != method {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad method "+method, -1)
+	}
+
+	viaList, err := newViaList(via)
+	if err != nil {
+		return nil, err
+	}
+	for _, mandatory := range []struct {
+		name string
+		h    header.Header
+	}{
+		{core.SIPHeaderNames_MAX_FORWARDS, maxForwards},
+		{core.SIPHeaderNames_TO, to},
+		{core.SIPHeaderNames_FROM, from},
+		{core.SIPHeaderNames_CALL_ID, callId},
+		{core.SIPHeaderNames_CSEQ, cSeq},
+	} {
+		if isNilValue(mandatory.h) {
+			return nil, newMissingHeaderError(mandatory.name)
+		}
+	}
+	if cSeq.GetMethod() != method {
+		return nil, core.NewParseError(core.ParseErrorKind_METHOD_MISMATCH, "ParseException: CSeq method mismatch with Request-Line", -1).At(core.SIPHeaderNames_CSEQ, 0, 0, -1)
+	}
+
+	sipRequest := message.NewSIPRequest()
+	sipRequest.SetRequestLine(requestLine)
+	sipRequest.SetVia(viaList)
+	sipRequest.SetMaxForwards(maxForwards)
+	sipRequest.SetTo(to)
+	sipRequest.SetFrom(from)
+	sipRequest.SetCallId(callId)
+	sipRequest.SetCSeq(cSeq)
+	if err := sipRequest.CheckHeaders(); err != nil {
+		return nil, err
+	}
+	return sipRequest, nil
+}
+
+/**
+ * Creates a new Request message of type specified by the method paramater,
+ * containing the URI of the Request, the mandatory headers of the message
+ * with a body in the form of a string or a byte array and body content
+ * type.
+ *
+ * @param contentType - the new ContentTypeHeader object of the content type
+ * value of this Message.
+ * @param content - the new string or byte array of the body content value
+ * of this Message.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the method or the body, or a mandatory header
+ * is missing.
+ */
+func (this *MessageFactoryImpl) CreateRequestWithContent(requestURI address.URI, method string, callId header.CallIdHeader, cSeq header.CSeqHeader,
+	from header.FromHeader, to header.ToHeader, via *list.List, maxForwards header.MaxForwardsHeader,
+	contentType header.ContentTypeHeader, content interface{}) (request message.Request, ParseException error) {
+	if request, ParseException = this.CreateRequest(requestURI, method, callId, cSeq, from, to, via, maxForwards); ParseException != nil {
+		return nil, ParseException
+	}
+	if ParseException = setMessageContent(request, contentType, content); ParseException != nil {
+		return nil, ParseException
+	}
+	return request, nil
+}
+
+/**
+ * Create a new SIP Request object based on a specific string value. This
+ * method parses the supplied string into a SIP Request. The request string
+ * "" creates an empty request.
+ *
+ * @param requestParam - the new string value of the Request.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the requestParam value.
+ */
+func (this *MessageFactoryImpl) CreateRequestFromString(requestParam string) (request message.Request, ParseException error) {
+	if requestParam == "" {
+		return message.NewSIPRequest(), nil
+	}
+	sipmsg, err := NewStringMsgParser().ParseSIPMessage(requestParam)
+	if err != nil {
+		return nil, err
+	}
+	if sipRequest, ok := sipmsg.(*message.SIPRequest); ok {
+		return sipRequest, nil
+	}
+	return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: the message is not a request", 0)
+}
+
+/**
+ * Creates a new Response message of type specified by the statusCode
+ * paramater, based on a specific Request message: it has copies of the
+ * From, To, Call-ID, CSeq, Via, Timestamp and Record-Route headers of the
+ * request. This new Response does not contain a body.
+ *
+ * @param statusCode - the new integer of the statusCode value of this Message.
+ * @param request - the received Reqest object upon which to base the Response.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the statusCode value.
+ */
+func (this *MessageFactoryImpl) CreateResponse(statusCode int, request message.Request) (response message.Response, ParseException error) {
+	if isNilValue(request) {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: no request", -1)
+	}
+	sipResponse, err := newSIPResponse(statusCode)
+	if err != nil {
+		return nil, err
+	}
+	for _, headerName := range responseHeaderNames {
+		values := request.GetHeaders(headerName)
+		if values.Len() == 0 {
+			continue
+		}
+		// The values are parsed again, so that the response has its own
+		// headers, e.g. for the To tag of the response.
+		var value bytes.Buffer
+		for e := values.Front(); e != nil; e = e.Next() {
+			if value.Len() > 0 {
+				value.WriteString(core.SIPSeparatorNames_COMMA)
+			}
+			value.WriteString(e.Value.(header.Header).EncodeBody())
+		}
+		h, err := parseHeaderLine(headerName + core.SIPSeparatorNames_COLON + core.SIPSeparatorNames_SP + value.String())
+		if err != nil {
+			return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1).At(headerName, 0, 0, -1)
+		}
+		sipResponse.AttachHeader(h)
+	}
+	if err := sipResponse.CheckHeaders(); err != nil {
+		return nil, err
+	}
+	return sipResponse, nil
+}
+
+/**
+ * Creates a new Response message of type specified by the statusCode
+ * paramater, based on a specific Request with a new body in the form of a
+ * string or a byte array and body content type.
+ *
+ * @param statusCode - the new integer of the statusCode value of this Message.
+ * @param request - the received Reqest object upon which to base the Response.
+ * @param contentType - the new ContentTypeHeader object of the content type
+ * value of this Message.
+ * @param content - the new string or byte array of the body content value
+ * of this Message.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the statusCode value or the body.
+ */
+func (this *MessageFactoryImpl) CreateResponseWithContent(statusCode int, request message.Request, contentType header.ContentTypeHeader, content interface{}) (response message.Response, ParseException error) {
+	if response, ParseException = this.CreateResponse(statusCode, request); ParseException != nil {
+		return nil, ParseException
+	}
+	if ParseException = setMessageContent(response, contentType, content); ParseException != nil {
+		return nil, ParseException
+	}
+	return response, nil
+}
+
+/**
+ * Creates a new Response message of type specified by the statusCode
+ * paramater, containing the mandatory headers of the message. This new
+ * Response does not contain a body.
+ *
+ * @param statusCode - the new integer of the statusCode value of this Message.
+ * @param callId - the new CallIdHeader object of the callId value of this Message.
+ * @param cSeq - the new CSeqHeader object of the cSeq value of this Message.
+ * @param from - the new FromHeader object of the from value of this Message.
+ * @param to - the new ToHeader object of the to value of this Message.
+ * @param via - the new List object of the ViaHeaders of this Message.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the statusCode, or a mandatory header is
+ * missing.
+ */
+func (this *MessageFactoryImpl) CreateResponseFromHeaders(statusCode int, callId header.CallIdHeader, cSeq header.CSeqHeader,
+	from header.FromHeader, to header.ToHeader, via *list.List) (response message.Response, ParseException error) {
+	sipResponse, err := newSIPResponse(statusCode)
+	if err != nil {
+		return nil, err
+	}
+	viaList, err := newViaList(via)
+	if err != nil {
+		return nil, err
+	}
+	for _, mandatory := range []struct {
+		name string
+		h    header.Header
+	}{
+		{core.SIPHeaderNames_TO, to},
+		{core.SIPHeaderNames_FROM, from},
+		{core.SIPHeaderNames_CALL_ID, callId},
+		{core.SIPHeaderNames_CSEQ, cSeq},
+	} {
+		if isNilValue(mandatory.h) {
+			return nil, newMissingHeaderError(mandatory.name)
+		}
+	}
+
+	sipResponse.SetVia(viaList)
+	sipResponse.SetTo(to)
+	sipResponse.SetFrom(from)
+	sipResponse.SetCallId(callId)
+	sipResponse.SetCSeq(cSeq)
+	if err := sipResponse.CheckHeaders(); err != nil {
+		return nil, err
+	}
+	return sipResponse, nil
+}
+
+/**
+ * Create a new SIP Response object based on a specific string value. This
+ * method parses the supplied string into a SIP Response. The response
+ * string "" creates an empty response.
+ *
+ * @param responseParam - the new string value of the Response.
+ * @throws ParseException which signals that an error has been reached
+ * unexpectedly while parsing the responseParam value.
+ */
+func (this *MessageFactoryImpl) CreateResponseFromString(responseParam string) (response message.Response, ParseException error) {
+	if responseParam == "" {
+		return message.NewSIPResponse(), nil
+	}
+	sipmsg, err := NewStringMsgParser().ParseSIPMessage(responseParam)
+	if err != nil {
+		return nil, err
+	}
+	if sipResponse, ok := sipmsg.(*message.SIPResponse); ok {
+		return sipResponse, nil
+	}
+	return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: the message is not a response", 0)
+}
+
+/** Create a response with a status code of RFC 3261, between 100 and 699,
+ * and its reason phrase.
+ */
+func newSIPResponse(statusCode int) (*message.SIPResponse, error) {
+	if statusCode < 100 || statusCode > 699 {
+		return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad status code", -1)
+	}
+	sipResponse := message.NewSIPResponse()
+	sipResponse.SetStatusCode(statusCode)
+	sipResponse.SetReasonPhrase(sipResponse.GetReasonPhraseFromInt(statusCode))
+	return sipResponse, nil
+}
+
+/** Make the ViaList of a message of a list of ViaHeaders, which must not
+ * be empty.
+ */
+func newViaList(via *list.List) (*header.ViaList, error) {
+	if via == nil || via.Len() == 0 {
+		return nil, newMissingHeaderError(core.SIPHeaderNames_VIA)
+	}
+	viaList := header.NewViaList()
+	for e := via.Front(); e != nil; e = e.Next() {
+		viaHeader, ok := e.Value.(*header.Via)
+		if !ok || viaHeader == nil {
+			return nil, newFactoryError(core.SIPHeaderNames_VIA, "not a Via header")
+		}
+		viaList.PushBack(viaHeader)
+	}
+	return viaList, nil
+}
+
+/** Set the body of a message created by the factory.
+ */
+func setMessageContent(sipmsg message.Message, contentType header.ContentTypeHeader, content interface{}) (ParseException error) {
+	if isNilValue(contentType) {
+		return newMissingHeaderError(core.SIPHeaderNames_CONTENT_TYPE)
+	}
+	switch content.(type) {
+	case string, []byte:
+		sipmsg.SetContent(content, contentType)
+		return nil
+	default:
+		return core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: the content is not a string or a byte array", -1)
+	}
+}
+
+func newMissingHeaderError(headerName string) error {
+	return core.NewParseError(core.ParseErrorKind_MISSING_HEADER, "ParseException: Missing Header "+headerName, -1).At(headerName, 0, 0, -1)
+}
+
+/** Return true if a value is nil, or a nil pointer in an interface, as the
+ * getters of the messages return for the headers they do not have.
+ */
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"container/list"
+	"errors"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+func TestMessageFactoryImpl(t *testing.T) {
+	var messageFactory message.MessageFactory = NewMessageFactoryImpl()
+	headerFactory := NewHeaderFactoryImpl()
+	addressFactory := NewAddressFactoryImpl()
+
+	requestURI, _ := addressFactory.CreateSipURI("bob", "biloxi.example.com")
+	fromAddress, _ := addressFactory.CreateAddressFromString("Alice <sip:alice@atlanta.example.com>")
+	toAddress := addressFactory.CreateAddressFromURI(requestURI)
+	callId, _ := headerFactory.CreateCallIdHeader("a84b4c76e66710@pc33.atlanta.example.com")
+	cSeq, _ := headerFactory.CreateCSeqHeader(314159, message.INVITE)
+	from, _ := headerFactory.CreateFromHeader(fromAddress, "9fxced76sl")
+	to, _ := headerFactory.CreateToHeader(toAddress, "")
+	via, _ := headerFactory.CreateViaHeader("pc33.atlanta.example.com", 0, "UDP", "z9hG4bKnashds8")
+	vias := list.New()
+	vias.PushBack(via)
+	maxForwards, _ := headerFactory.CreateMaxForwardsHeader(70)
+	contentType, _ := headerFactory.CreateContentTypeHeader("application", "sdp")
+	sdp := "v=0\r\no=alice 2890844526 2890844526 IN IP4 pc33.atlanta.example.com\r\n"
+
+	request, err := messageFactory.CreateRequestWithContent(requestURI, message.INVITE, callId, cSeq, from, to, vias, maxForwards, contentType, sdp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "INVITE sip:bob@biloxi.example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.example.com;branch=z9hG4bKnashds8\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: <sip:bob@biloxi.example.com>\r\n" +
+		"From: \"Alice\" <sip:alice@atlanta.example.com>;tag=9fxced76sl\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.example.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 68\r\n" +
+		"\r\n" + sdp
+	if request.String() != expected {
+		t.Fatalf("request\n%s", request.String())
+	}
+	if parsed, err := messageFactory.CreateRequestFromString(expected); err != nil || parsed.String() != expected {
+		t.Fatalf("parsed request %v\n%v", err, parsed)
+	}
+
+	// The mandatory headers.
+	bye, _ := headerFactory.CreateCSeqHeader(314160, message.BYE)
+	tvs := []struct {
+		name       string
+		create     func() (message.Request, error)
+		kind       core.ParseErrorKind
+		headerName string
+	}{
+		{"Call-ID", func() (message.Request, error) {
+			return messageFactory.CreateRequest(requestURI, message.INVITE, nil, cSeq, from, to, vias, maxForwards)
+		}, core.ParseErrorKind_MISSING_HEADER, core.SIPHeaderNames_CALL_ID},
+		{"Via", func() (message.Request, error) {
+			return messageFactory.CreateRequest(requestURI, message.INVITE, callId, cSeq, from, to, list.New(), maxForwards)
+		}, core.ParseErrorKind_MISSING_HEADER, core.SIPHeaderNames_VIA},
+		{"typed nil", func() (message.Request, error) {
+			return messageFactory.CreateRequest(requestURI, message.INVITE, callId, cSeq, from, (*header.To)(nil), vias, maxForwards)
+		}, core.ParseErrorKind_MISSING_HEADER, core.SIPHeaderNames_TO},
+		{"CSeq method", func() (message.Request, error) {
+			return messageFactory.CreateRequest(requestURI, message.INVITE, callId, bye, from, to, vias, maxForwards)
+		}, core.ParseErrorKind_METHOD_MISMATCH, core.SIPHeaderNames_CSEQ},
+		{"method", func() (message.Request, error) {
+			return messageFactory.CreateRequest(requestURI, "IN VITE", callId, cSeq, from, to, vias, maxForwards)
+		}, core.ParseErrorKind_BAD_SYNTAX, ""},
+	}
+	for _, tv := range tvs {
+		var parseError *core.ParseError
+		if _, err := tv.create(); !errors.As(err, &parseError) || parseError.GetKind() != tv.kind || parseError.GetHeaderName() != tv.headerName {
+			t.Errorf("%s: %v", tv.name, err)
+		}
+	}
+
+	// The response has copies of the headers of the request.
+	response, err := messageFactory.CreateResponse(message.RINGING, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.(*message.SIPResponse).SetToTag("8321234356")
+	expected = "SIP/2.0 180 Ringing\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.example.com;branch=z9hG4bKnashds8\r\n" +
+		"From: \"Alice\" <sip:alice@atlanta.example.com>;tag=9fxced76sl\r\n" +
+		"To: <sip:bob@biloxi.example.com>;tag=8321234356\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.example.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n"
+	if string(response.(*message.SIPResponse).EncodeAsBytes()) != expected {
+		t.Fatalf("response %q", response.(*message.SIPResponse).EncodeAsBytes())
+	}
+	if request.(*message.SIPRequest).HasToTag() {
+		t.Fatal("To tag of the response set in the request")
+	}
+	if _, err := messageFactory.CreateResponse(99, request); err == nil {
+		t.Fatal("bad status code accepted")
+	}
+	if _, err := messageFactory.CreateResponseFromHeaders(message.OK, callId, cSeq, from, to, nil); err == nil {
+		t.Fatal("response without Via accepted")
+	}
+	if response, err := messageFactory.CreateResponseFromHeaders(message.OK, callId, cSeq, from, to, vias); err != nil || response.GetStatusCode() != message.OK {
+		t.Fatalf("response %v %v", response, err)
+	}
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for a list of P-Asserted-Identity headers.
+ */
+type PAssertedIdentityParser struct {
+	AddressParametersParser
+}
+
+/** Constructor
+ * @param String P-Asserted-Identity message to parse to set
+ */
+func NewPAssertedIdentityParser(identity string) *PAssertedIdentityParser {
+	this := &PAssertedIdentityParser{}
+	this.AddressParametersParser.super(identity)
+	return this
+}
+
+func NewPAssertedIdentityParserFromLexer(lexer core.Lexer) *PAssertedIdentityParser {
+	this := &PAssertedIdentityParser{}
+	this.AddressParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message and generate the P-Asserted-Identity List Object
+ * @return SIPHeader the P-Asserted-Identity List object
+ * @throws ParseException if errors occur during the parsing
+ */
+func (this *PAssertedIdentityParser) Parse() (sh header.Header, ParseException error) {
+	identityList := header.NewPAssertedIdentityList()
+
+	var ch byte
+	lexer := this.GetLexer()
+	lexer.Match(TokenTypes_P_ASSERTED_IDENTITY)
+	lexer.SPorHT()
+	lexer.Match(':')
+	lexer.SPorHT()
+	for {
+		identity := header.NewPAssertedIdentity()
+		if ParseException = this.AddressParametersParser.Parse(identity); ParseException != nil {
+			return nil, ParseException
+		}
+		identityList.PushBack(identity)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+			lexer.SPorHT()
+		} else if ch, _ = lexer.LookAheadK(0); ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+
+	return identityList, nil
+}
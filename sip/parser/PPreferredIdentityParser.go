@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for a list of P-Preferred-Identity headers.
+ */
+type PPreferredIdentityParser struct {
+	AddressParametersParser
+}
+
+/** Constructor
+ * @param String P-Preferred-Identity message to parse to set
+ */
+func NewPPreferredIdentityParser(identity string) *PPreferredIdentityParser {
+	this := &PPreferredIdentityParser{}
+	this.AddressParametersParser.super(identity)
+	return this
+}
+
+func NewPPreferredIdentityParserFromLexer(lexer core.Lexer) *PPreferredIdentityParser {
+	this := &PPreferredIdentityParser{}
+	this.AddressParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message and generate the P-Preferred-Identity List Object
+ * @return SIPHeader the P-Preferred-Identity List object
+ * @throws ParseException if errors occur during the parsing
+ */
+func (this *PPreferredIdentityParser) Parse() (sh header.Header, ParseException error) {
+	identityList := header.NewPPreferredIdentityList()
+
+	var ch byte
+	lexer := this.GetLexer()
+	lexer.Match(TokenTypes_P_PREFERRED_IDENTITY)
+	lexer.SPorHT()
+	lexer.Match(':')
+	lexer.SPorHT()
+	for {
+		identity := header.NewPPreferredIdentity()
+		if ParseException = this.AddressParametersParser.Parse(identity); ParseException != nil {
+			return nil, ParseException
+		}
+		identityList.PushBack(identity)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+			lexer.SPorHT()
+		} else if ch, _ = lexer.LookAheadK(0); ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+
+	return identityList, nil
+}
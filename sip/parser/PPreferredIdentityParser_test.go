@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestPPreferredIdentityParser(t *testing.T) {
+	var tvi = []string{
+		"P-Preferred-Identity: \"Alice\" <sip:alice@atlanta.com>\n",
+		"P-Preferred-Identity: <sip:alice@atlanta.com>, <tel:+15551234567>\n",
+	}
+	var tvo = []string{
+		"P-Preferred-Identity: \"Alice\" <sip:alice@atlanta.com>\n",
+		"P-Preferred-Identity: <sip:alice@atlanta.com>,<tel:+15551234567>\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewPPreferredIdentityParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
@@ -0,0 +1,310 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * The limits of the parsing of a message, against the messages crafted to
+ * exhaust the memory or the CPU of a server on the internet, e.g. with
+ * thousands of header lines or a huge Content-Length. A limit of 0 is no
+ * limit. The header of a message is checked before its headers are
+ * parsed, even in lenient mode. A message that exceeds a limit fails to
+ * parse with a core.ParseError of kind:
+ *
+ *	ParseErrorKind_MESSAGE_TOO_LARGE for the size of the message,
+ *	ParseErrorKind_BODY_TOO_LARGE for the size of the body,
+ *	ParseErrorKind_LIMIT_EXCEEDED for the other limits,
+ *
+ * and GetStatusCode gives the response of the transport to it.
+ */
+type ParserLimits struct {
+	maxMessageSize int
+
+	maxBodySize int
+
+	maxHeaders int
+
+	maxHeaderLineLength int
+
+	maxVias int
+
+	maxRoutes int
+
+	maxParameters int
+
+	maxURILength int
+
+	statusCodes map[core.ParseErrorKind]int
+}
+
+/** Constructor: the defaults of a server on the internet. A message
+ * has at most the size of a UDP datagram, and a request that exceeds a
+ * limit of its header is dropped.
+ */
+func NewParserLimits() *ParserLimits {
+	this := &ParserLimits{}
+	this.maxMessageSize = 65535
+	this.maxHeaders = 256
+	this.maxHeaderLineLength = 8192
+	this.maxVias = 70
+	this.maxRoutes = 70
+	this.maxParameters = 64
+	this.maxURILength = 4096
+	this.statusCodes = map[core.ParseErrorKind]int{
+		core.ParseErrorKind_MESSAGE_TOO_LARGE: message.MESSAGE_TOO_LARGE,
+		core.ParseErrorKind_BODY_TOO_LARGE:    message.REQUEST_ENTITY_TOO_LARGE,
+		core.ParseErrorKind_LIMIT_EXCEEDED:    0,
+	}
+	return this
+}
+
+/** Get the maximum size of a message, header and body.
+ */
+func (this *ParserLimits) GetMaxMessageSize() int {
+	return this.maxMessageSize
+}
+
+func (this *ParserLimits) SetMaxMessageSize(maxMessageSize int) {
+	this.maxMessageSize = maxMessageSize
+}
+
+/** Get the maximum size of the body of a message, given by its
+ * Content-Length.
+ */
+func (this *ParserLimits) GetMaxBodySize() int {
+	return this.maxBodySize
+}
+
+func (this *ParserLimits) SetMaxBodySize(maxBodySize int) {
+	this.maxBodySize = maxBodySize
+}
+
+/** Get the maximum number of header lines of a message.
+ */
+func (this *ParserLimits) GetMaxHeaders() int {
+	return this.maxHeaders
+}
+
+func (this *ParserLimits) SetMaxHeaders(maxHeaders int) {
+	this.maxHeaders = maxHeaders
+}
+
+/** Get the maximum length of a line of the header of a message, the start
+ * line or a header with its continuation lines.
+ */
+func (this *ParserLimits) GetMaxHeaderLineLength() int {
+	return this.maxHeaderLineLength
+}
+
+func (this *ParserLimits) SetMaxHeaderLineLength(maxHeaderLineLength int) {
+	this.maxHeaderLineLength = maxHeaderLineLength
+}
+
+/** Get the maximum number of Via of a message, in all its Via headers.
+ */
+func (this *ParserLimits) GetMaxVias() int {
+	return this.maxVias
+}
+
+func (this *ParserLimits) SetMaxVias(maxVias int) {
+	this.maxVias = maxVias
+}
+
+/** Get the maximum number of routes of a message, in all its Route
+ * headers, and in all its Record-Route headers.
+ */
+func (this *ParserLimits) GetMaxRoutes() int {
+	return this.maxRoutes
+}
+
+func (this *ParserLimits) SetMaxRoutes(maxRoutes int) {
+	this.maxRoutes = maxRoutes
+}
+
+/** Get the maximum number of parameters of a header value, with the
+ * parameters of its URI.
+ */
+func (this *ParserLimits) GetMaxParameters() int {
+	return this.maxParameters
+}
+
+func (this *ParserLimits) SetMaxParameters(maxParameters int) {
+	this.maxParameters = maxParameters
+}
+
+/** Get the maximum length of the Request-URI, and of the URIs in angle
+ * brackets of the headers.
+ */
+func (this *ParserLimits) GetMaxURILength() int {
+	return this.maxURILength
+}
+
+func (this *ParserLimits) SetMaxURILength(maxURILength int) {
+	this.maxURILength = maxURILength
+}
+
+/** Get the status code of the response to a request that fails to parse
+ * because it exceeds a limit, or 0 if the request is dropped or the error
+ * is not that of a limit. A response that exceeds a limit is dropped.
+ */
+func (this *ParserLimits) GetStatusCode(err error) int {
+	var parseError *core.ParseError
+	if errors.As(err, &parseError) {
+		return this.statusCodes[parseError.GetKind()]
+	}
+	return 0
+}
+
+/** Set the status code of the response to a request that exceeds a limit
+ * of a kind, e.g. message.SECURITY_AGREEMENT_REQUIRED, or 0 to drop it.
+ */
+func (this *ParserLimits) SetStatusCode(kind core.ParseErrorKind, statusCode int) {
+	switch kind {
+	case core.ParseErrorKind_MESSAGE_TOO_LARGE, core.ParseErrorKind_BODY_TOO_LARGE, core.ParseErrorKind_LIMIT_EXCEEDED:
+		this.statusCodes[kind] = statusCode
+	}
+}
+
+/** Check the size of a message, given the size of its header and its
+ * Content-Length.
+ */
+func (this *ParserLimits) checkSize(headerSize, contentLength int) (ParseException error) {
+	if this.maxBodySize > 0 && contentLength > this.maxBodySize {
+		return core.NewParseError(core.ParseErrorKind_BODY_TOO_LARGE, "ParseException: body too large", this.maxBodySize)
+	}
+	if this.maxMessageSize > 0 && headerSize+contentLength > this.maxMessageSize {
+		return core.NewParseError(core.ParseErrorKind_MESSAGE_TOO_LARGE, "ParseException: message too large", this.maxMessageSize)
+	}
+	return nil
+}
+
+/** Get the size of a decoded body: the size of the body, or of the
+ * message if the size of the body is not limited.
+ */
+func (this *ParserLimits) getMaxContentSize() int {
+	if this.maxBodySize > 0 {
+		return this.maxBodySize
+	}
+	return this.maxMessageSize
+}
+
+/** Check the lines of the header of a message, the start line first,
+ * before they are parsed.
+ *@return the index of the line that exceeds a limit and the column of the
+ * error, from 1.
+ */
+func (this *ParserLimits) checkHeaders(lines []string) (i, column int, ParseException error) {
+	if this.maxHeaders > 0 && len(lines)-1 > this.maxHeaders {
+		return this.maxHeaders + 1, 1, this.limitExceeded("too many headers")
+	}
+
+	vias, routes, recordRoutes := 0, 0, 0
+	for k, line := range lines {
+		if this.maxHeaderLineLength > 0 && len(line) > this.maxHeaderLineLength {
+			return k, this.maxHeaderLineLength + 1, this.limitExceeded("header line too long")
+		}
+		if k == 0 {
+			if column, err := this.checkRequestURI(line); err != nil {
+				return 0, column, err
+			}
+			continue
+		}
+
+		headerName, _ := getHeaderName(line)
+		elements, column, err := this.checkHeaderValue(line)
+		if err != nil {
+			return k, column, err
+		}
+		switch headerName {
+		case core.SIPHeaderNames_VIA:
+			if vias += elements; this.maxVias > 0 && vias > this.maxVias {
+				return k, 1, this.limitExceeded("too many Via")
+			}
+		case core.SIPHeaderNames_ROUTE:
+			if routes += elements; this.maxRoutes > 0 && routes > this.maxRoutes {
+				return k, 1, this.limitExceeded("too many Route")
+			}
+		case core.SIPHeaderNames_RECORD_ROUTE:
+			if recordRoutes += elements; this.maxRoutes > 0 && recordRoutes > this.maxRoutes {
+				return k, 1, this.limitExceeded("too many Record-Route")
+			}
+		}
+	}
+	return 0, 0, nil
+}
+
+/** Check the length of the Request-URI of a request line.
+ */
+func (this *ParserLimits) checkRequestURI(line string) (column int, ParseException error) {
+	if this.maxURILength <= 0 || strings.HasPrefix(line, "SIP/") {
+		return 0, nil
+	}
+	start := strings.IndexByte(line, ' ') + 1
+	if start == 0 {
+		return 0, nil
+	}
+	end := strings.IndexByte(line[start:], ' ')
+	if end < 0 {
+		end = len(line) - start
+	}
+	if end > this.maxURILength {
+		return start + this.maxURILength + 1, this.limitExceeded("Request-URI too long")
+	}
+	return 0, nil
+}
+
+/** Check a header line: the number of parameters of each of its values
+ * and the length of the URIs in angle brackets. The commas and the
+ * semicolons in quoted strings are not counted.
+ *@return the number of values of the header, separated by commas.
+ */
+func (this *ParserLimits) checkHeaderValue(line string) (elements, column int, ParseException error) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return 0, 0, nil
+	}
+	elements = 1
+	parameters, uriStart := 0, -1
+	quoted := false
+	for i := colon + 1; i < len(line); i++ {
+		ch := line[i]
+		if quoted {
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				quoted = false
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			quoted = true
+		case '<':
+			uriStart = i + 1
+		case '>':
+			uriStart = -1
+		case ',':
+			if uriStart < 0 {
+				elements++
+				parameters = 0
+			}
+		case ';':
+			if parameters++; this.maxParameters > 0 && parameters > this.maxParameters {
+				return elements, i + 1, this.limitExceeded("too many parameters")
+			}
+		}
+		if uriStart >= 0 && this.maxURILength > 0 && i-uriStart >= this.maxURILength {
+			return elements, i + 1, this.limitExceeded("URI too long")
+		}
+	}
+	return elements, 0, nil
+}
+
+func (this *ParserLimits) limitExceeded(exceptionString string) error {
+	return core.NewParseError(core.ParseErrorKind_LIMIT_EXCEEDED, "ParseException: "+exceptionString, -1)
+}
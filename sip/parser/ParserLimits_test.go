@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/message"
+)
+
+func TestParserLimits(t *testing.T) {
+	limits := NewParserLimits()
+	smp := NewStringMsgParser()
+	smp.SetLimits(limits)
+
+	if _, err := smp.ParseSIPMessage(benchmarkInvite); err != nil {
+		t.Fatal(err)
+	}
+
+	manyVias := "Via: SIP/2.0/UDP a.example.com" + strings.Repeat(", SIP/2.0/UDP a.example.com", 70) + "\r\nMax-Forwards:"
+	tvs := []struct {
+		name       string
+		msg        string
+		kind       core.ParseErrorKind
+		headerName string
+		statusCode int
+	}{
+		{"headers", strings.Replace(benchmarkInvite, "Supported:", strings.Repeat("X-Filler: x\r\n", 256)+"Supported:", 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, "X-Filler", 0},
+		{"line length", strings.Replace(benchmarkInvite, "1234567890abcdef", strings.Repeat("a", 8192), 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, "X-Vendor-Trace", 0},
+		{"Vias", strings.Replace(benchmarkInvite, "Max-Forwards:", manyVias, 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, core.SIPHeaderNames_VIA, 0},
+		{"parameters", strings.Replace(benchmarkInvite, ";tag=9fxced76sl", strings.Repeat(";p", 65), 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, core.SIPHeaderNames_FROM, 0},
+		{"Request-URI", strings.Replace(benchmarkInvite, "sip:bob@", "sip:"+strings.Repeat("b", 4096)+"@", 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, "", 0},
+		{"URI", strings.Replace(benchmarkInvite, "<sip:p1.example.com;lr>", "<sip:"+strings.Repeat("p", 4096)+".example.com;lr>", 1),
+			core.ParseErrorKind_LIMIT_EXCEEDED, core.SIPHeaderNames_ROUTE, 0},
+		{"Content-Length", strings.Replace(benchmarkInvite, "Content-Length: 151", "Content-Length: 2000000000", 1),
+			core.ParseErrorKind_MESSAGE_TOO_LARGE, core.SIPHeaderNames_CONTENT_LENGTH, message.MESSAGE_TOO_LARGE},
+	}
+	for _, tv := range tvs {
+		_, err := smp.ParseSIPMessage(tv.msg)
+		var parseError *core.ParseError
+		if !errors.As(err, &parseError) || parseError.GetKind() != tv.kind || parseError.GetHeaderName() != tv.headerName {
+			t.Errorf("%s: %v", tv.name, err)
+			continue
+		}
+		if statusCode := limits.GetStatusCode(err); statusCode != tv.statusCode {
+			t.Errorf("%s: status code %d", tv.name, statusCode)
+		}
+	}
+
+	// The body size and the status codes.
+	limits.SetMaxBodySize(100)
+	limits.SetStatusCode(core.ParseErrorKind_LIMIT_EXCEEDED, message.SECURITY_AGREEMENT_REQUIRED)
+	_, err := smp.ParseSIPMessage(benchmarkInvite)
+	if statusCode := limits.GetStatusCode(err); statusCode != message.REQUEST_ENTITY_TOO_LARGE {
+		t.Errorf("body too large: %v %d", err, statusCode)
+	}
+	_, err = smp.ParseSIPMessage(tvs[0].msg)
+	if statusCode := limits.GetStatusCode(err); statusCode != message.SECURITY_AGREEMENT_REQUIRED {
+		t.Errorf("too many headers: %v %d", err, statusCode)
+	}
+	if statusCode := limits.GetStatusCode(errors.New("ParseException: Expecting")); statusCode != 0 {
+		t.Errorf("syntax error: %d", statusCode)
+	}
+
+	// No limits.
+	smp.SetLimits(nil)
+	if _, err := smp.ParseSIPMessage(tvs[0].msg); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,357 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** A ParserConstructor creates the parser of a header line, e.g.
+ * "Event: presence\n".
+ */
+type ParserConstructor func(line string) Parser
+
+/** The token types given to the names of the headers registered by the
+ * applications. They are below TokenTypes_ID, the token type of an
+ * unknown identifier.
+ */
+const (
+	TokenTypes_EXTENSION_START = TokenTypes_START + 1024
+	TokenTypes_EXTENSION_END   = TokenTypes_ID
+)
+
+/** A parserRegistration is the parser of a header name and its compact
+ * form, with the type of the header it returns.
+ */
+type parserRegistration struct {
+	name        string
+	compactForm string
+	headerType  reflect.Type
+	constructor ParserConstructor
+	tokenType   int
+
+	// The registration of the name it replaced, if any.
+	previous *parserRegistration
+}
+
+/**
+ * The parser registry maps the names of the headers, full and compact, to
+ * their parsers. The built-in headers are registered when the package is
+ * loaded; an application registers the parsers of its own headers, e.g.
+ * P-headers or vendor headers, with RegisterParser so that they are parsed
+ * into typed headers rather than Extension headers. A header name that is
+ * registered again gets the new parser, until UnregisterParser restores
+ * the previous one.
+ */
+type headerParserRegistry struct {
+	mutex sync.RWMutex
+
+	registrations map[string]*parserRegistration
+
+	// The keywords of the registered names, for the lexer.
+	keywords map[string]int
+
+	nextTokenType int
+}
+
+var parserRegistry = newParserRegistry()
+
+func newParserRegistry() *headerParserRegistry {
+	this := &headerParserRegistry{}
+	this.registrations = make(map[string]*parserRegistration)
+	this.keywords = make(map[string]int)
+	this.nextTokenType = TokenTypes_EXTENSION_START
+	return this
+}
+
+/** Register the parsers of the built-in headers.
+ */
+func init() {
+	registerParser(core.SIPHeaderNames_REPLY_TO, "", reflect.TypeOf((*header.ReplyTo)(nil)),
+		func(line string) Parser { return NewReplyToParser(line) })
+	registerParser(core.SIPHeaderNames_IN_REPLY_TO, "", reflect.TypeOf((*header.InReplyToList)(nil)),
+		func(line string) Parser { return NewInReplyToParser(line) })
+	registerParser(core.SIPHeaderNames_ACCEPT_ENCODING, "", reflect.TypeOf((*header.AcceptEncodingList)(nil)),
+		func(line string) Parser { return NewAcceptEncodingParser(line) })
+	registerParser(core.SIPHeaderNames_ACCEPT_LANGUAGE, "", reflect.TypeOf((*header.AcceptLanguageList)(nil)),
+		func(line string) Parser { return NewAcceptLanguageParser(line) })
+	registerParser(core.SIPHeaderNames_TO, core.SIPHeaderNames_T, reflect.TypeOf((*header.To)(nil)),
+		func(line string) Parser { return NewToParser(line) })
+	registerParser(core.SIPHeaderNames_FROM, core.SIPHeaderNames_F, reflect.TypeOf((*header.From)(nil)),
+		func(line string) Parser { return NewFromParser(line) })
+	registerParser(core.SIPHeaderNames_CSEQ, "", reflect.TypeOf((*header.CSeq)(nil)),
+		func(line string) Parser { return NewCSeqParser(line) })
+	registerParser(core.SIPHeaderNames_VIA, core.SIPHeaderNames_V, reflect.TypeOf((*header.ViaList)(nil)),
+		func(line string) Parser { return NewViaParser(line) })
+	registerParser(core.SIPHeaderNames_CONTACT, core.SIPHeaderNames_M, reflect.TypeOf((*header.ContactList)(nil)),
+		func(line string) Parser { return NewContactParser(line) })
+	registerParser(core.SIPHeaderNames_CONTENT_TYPE, core.SIPHeaderNames_C, reflect.TypeOf((*header.ContentType)(nil)),
+		func(line string) Parser { return NewContentTypeParser(line) })
+	registerParser(core.SIPHeaderNames_CONTENT_LENGTH, core.SIPHeaderNames_L, reflect.TypeOf((*header.ContentLength)(nil)),
+		func(line string) Parser { return NewContentLengthParser(line) })
+	registerParser(core.SIPHeaderNames_AUTHORIZATION, "", reflect.TypeOf((*header.Authorization)(nil)),
+		func(line string) Parser { return NewAuthorizationParser(line) })
+	registerParser(core.SIPHeaderNames_WWW_AUTHENTICATE, "", reflect.TypeOf((*header.WWWAuthenticate)(nil)),
+		func(line string) Parser { return NewWWWAuthenticateParser(line) })
+	registerParser(core.SIPHeaderNames_CALL_ID, core.SIPHeaderNames_I, reflect.TypeOf((*header.CallID)(nil)),
+		func(line string) Parser { return NewCallIDParser(line) })
+	registerParser(core.SIPHeaderNames_ROUTE, "", reflect.TypeOf((*header.RouteList)(nil)),
+		func(line string) Parser { return NewRouteParser(line) })
+	registerParser(core.SIPHeaderNames_RECORD_ROUTE, "", reflect.TypeOf((*header.RecordRouteList)(nil)),
+		func(line string) Parser { return NewRecordRouteParser(line) })
+	registerParser(core.SIPHeaderNames_DATE, "", reflect.TypeOf((*header.Date)(nil)),
+		func(line string) Parser { return NewDateParser(line) })
+	registerParser(core.SIPHeaderNames_PROXY_AUTHORIZATION, "", reflect.TypeOf((*header.ProxyAuthorization)(nil)),
+		func(line string) Parser { return NewProxyAuthorizationParser(line) })
+	registerParser(core.SIPHeaderNames_PROXY_AUTHENTICATE, "", reflect.TypeOf((*header.ProxyAuthenticate)(nil)),
+		func(line string) Parser { return NewProxyAuthenticateParser(line) })
+	registerParser(core.SIPHeaderNames_RETRY_AFTER, "", reflect.TypeOf((*header.RetryAfter)(nil)),
+		func(line string) Parser { return NewRetryAfterParser(line) })
+	registerParser(core.SIPHeaderNames_REQUIRE, "", reflect.TypeOf((*header.RequireList)(nil)),
+		func(line string) Parser { return NewRequireParser(line) })
+	registerParser(core.SIPHeaderNames_PROXY_REQUIRE, "", reflect.TypeOf((*header.ProxyRequireList)(nil)),
+		func(line string) Parser { return NewProxyRequireParser(line) })
+	registerParser(core.SIPHeaderNames_TIMESTAMP, "", reflect.TypeOf((*header.TimeStamp)(nil)),
+		func(line string) Parser { return NewTimeStampParser(line) })
+	registerParser(core.SIPHeaderNames_UNSUPPORTED, "", reflect.TypeOf((*header.UnsupportedList)(nil)),
+		func(line string) Parser { return NewUnsupportedParser(line) })
+	registerParser(core.SIPHeaderNames_USER_AGENT, "", reflect.TypeOf((*header.UserAgent)(nil)),
+		func(line string) Parser { return NewUserAgentParser(line) })
+	registerParser(core.SIPHeaderNames_SUPPORTED, core.SIPHeaderNames_K, reflect.TypeOf((*header.SupportedList)(nil)),
+		func(line string) Parser { return NewSupportedParser(line) })
+	registerParser(core.SIPHeaderNames_SERVER, "", reflect.TypeOf((*header.Server)(nil)),
+		func(line string) Parser { return NewServerParser(line) })
+	registerParser(core.SIPHeaderNames_SUBJECT, core.SIPHeaderNames_S, reflect.TypeOf((*header.Subject)(nil)),
+		func(line string) Parser { return NewSubjectParser(line) })
+	registerParser(core.SIPHeaderNames_SUBSCRIPTION_STATE, "", reflect.TypeOf((*header.SubscriptionState)(nil)),
+		func(line string) Parser { return NewSubscriptionStateParser(line) })
+	registerParser(core.SIPHeaderNames_MAX_FORWARDS, "", reflect.TypeOf((*header.MaxForwards)(nil)),
+		func(line string) Parser { return NewMaxForwardsParser(line) })
+	registerParser(core.SIPHeaderNames_MIME_VERSION, "", reflect.TypeOf((*header.MimeVersion)(nil)),
+		func(line string) Parser { return NewMimeVersionParser(line) })
+	registerParser(core.SIPHeaderNames_MIN_EXPIRES, "", reflect.TypeOf((*header.MinExpires)(nil)),
+		func(line string) Parser { return NewMinExpiresParser(line) })
+	registerParser(core.SIPHeaderNames_ORGANIZATION, "", reflect.TypeOf((*header.Organization)(nil)),
+		func(line string) Parser { return NewOrganizationParser(line) })
+	registerParser(core.SIPHeaderNames_PRIORITY, "", reflect.TypeOf((*header.Priority)(nil)),
+		func(line string) Parser { return NewPriorityParser(line) })
+	registerParser(core.SIPHeaderNames_RACK, "", reflect.TypeOf((*header.RAck)(nil)),
+		func(line string) Parser { return NewRAckParser(line) })
+	registerParser(core.SIPHeaderNames_RSEQ, "", reflect.TypeOf((*header.RSeq)(nil)),
+		func(line string) Parser { return NewRSeqParser(line) })
+	registerParser(core.SIPHeaderNames_REASON, "", reflect.TypeOf((*header.ReasonList)(nil)),
+		func(line string) Parser { return NewReasonParser(line) })
+	registerParser(core.SIPHeaderNames_WARNING, "", reflect.TypeOf((*header.WarningList)(nil)),
+		func(line string) Parser { return NewWarningParser(line) })
+	registerParser(core.SIPHeaderNames_EXPIRES, "", reflect.TypeOf((*header.Expires)(nil)),
+		func(line string) Parser { return NewExpiresParser(line) })
+	registerParser(core.SIPHeaderNames_EVENT, core.SIPHeaderNames_O, reflect.TypeOf((*header.Event)(nil)),
+		func(line string) Parser { return NewEventParser(line) })
+	registerParser(core.SIPHeaderNames_ERROR_INFO, "", reflect.TypeOf((*header.ErrorInfoList)(nil)),
+		func(line string) Parser { return NewErrorInfoParser(line) })
+	registerParser(core.SIPHeaderNames_CONTENT_LANGUAGE, "", reflect.TypeOf((*header.ContentLanguageList)(nil)),
+		func(line string) Parser { return NewContentLanguageParser(line) })
+	registerParser(core.SIPHeaderNames_CONTENT_ENCODING, core.SIPHeaderNames_E, reflect.TypeOf((*header.ContentEncodingList)(nil)),
+		func(line string) Parser { return NewContentEncodingParser(line) })
+	registerParser(core.SIPHeaderNames_CONTENT_DISPOSITION, "", reflect.TypeOf((*header.ContentDisposition)(nil)),
+		func(line string) Parser { return NewContentDispositionParser(line) })
+	registerParser(core.SIPHeaderNames_CALL_INFO, "", reflect.TypeOf((*header.CallInfoList)(nil)),
+		func(line string) Parser { return NewCallInfoParser(line) })
+	registerParser(core.SIPHeaderNames_AUTHENTICATION_INFO, "", reflect.TypeOf((*header.AuthenticationInfo)(nil)),
+		func(line string) Parser { return NewAuthenticationInfoParser(line) })
+	registerParser(core.SIPHeaderNames_ALLOW, "", reflect.TypeOf((*header.AllowList)(nil)),
+		func(line string) Parser { return NewAllowParser(line) })
+	registerParser(core.SIPHeaderNames_ALLOW_EVENTS, core.SIPHeaderNames_U, reflect.TypeOf((*header.AllowEventsList)(nil)),
+		func(line string) Parser { return NewAllowEventsParser(line) })
+	registerParser(core.SIPHeaderNames_ALERT_INFO, "", reflect.TypeOf((*header.AlertInfoList)(nil)),
+		func(line string) Parser { return NewAlertInfoParser(line) })
+	registerParser(core.SIPHeaderNames_ACCEPT, "", reflect.TypeOf((*header.AcceptList)(nil)),
+		func(line string) Parser { return NewAcceptParser(line) })
+	registerParser(core.SIPHeaderNames_REFER_TO, core.SIPHeaderNames_R, reflect.TypeOf((*header.ReferTo)(nil)),
+		func(line string) Parser { return NewReferToParser(line) })
+	registerParser(core.SIPHeaderNames_PATH, "", reflect.TypeOf((*header.PathList)(nil)),
+		func(line string) Parser { return NewPathParser(line) })
+	registerParser(core.SIPHeaderNames_SERVICE_ROUTE, "", reflect.TypeOf((*header.ServiceRouteList)(nil)),
+		func(line string) Parser { return NewServiceRouteParser(line) })
+	registerParser(core.SIPHeaderNames_P_ASSERTED_IDENTITY, "", reflect.TypeOf((*header.PAssertedIdentityList)(nil)),
+		func(line string) Parser { return NewPAssertedIdentityParser(line) })
+	registerParser(core.SIPHeaderNames_P_PREFERRED_IDENTITY, "", reflect.TypeOf((*header.PPreferredIdentityList)(nil)),
+		func(line string) Parser { return NewPPreferredIdentityParser(line) })
+	registerParser(core.SIPHeaderNames_PRIVACY, "", reflect.TypeOf((*header.Privacy)(nil)),
+		func(line string) Parser { return NewPrivacyParser(line) })
+	registerParser(core.SIPHeaderNames_REPLACES, "", reflect.TypeOf((*header.Replaces)(nil)),
+		func(line string) Parser { return NewReplacesParser(line) })
+	registerParser(core.SIPHeaderNames_JOIN, "", reflect.TypeOf((*header.Join)(nil)),
+		func(line string) Parser { return NewJoinParser(line) })
+	registerParser(core.SIPHeaderNames_REFERRED_BY, core.SIPHeaderNames_B, reflect.TypeOf((*header.ReferredBy)(nil)),
+		func(line string) Parser { return NewReferredByParser(line) })
+	registerParser(core.SIPHeaderNames_HISTORY_INFO, "", reflect.TypeOf((*header.HistoryInfoList)(nil)),
+		func(line string) Parser { return NewHistoryInfoParser(line) })
+	registerParser(core.SIPHeaderNames_DIVERSION, "", reflect.TypeOf((*header.DiversionList)(nil)),
+		func(line string) Parser { return NewDiversionParser(line) })
+	registerParser(core.SIPHeaderNames_IDENTITY, core.SIPHeaderNames_Y, reflect.TypeOf((*header.Identity)(nil)),
+		func(line string) Parser { return NewIdentityParser(line) })
+	registerParser(core.SIPHeaderNames_SECURITY_CLIENT, "", reflect.TypeOf((*header.SecurityClientList)(nil)),
+		func(line string) Parser { return NewSecurityClientParser(line) })
+	registerParser(core.SIPHeaderNames_SECURITY_SERVER, "", reflect.TypeOf((*header.SecurityServerList)(nil)),
+		func(line string) Parser { return NewSecurityServerParser(line) })
+	registerParser(core.SIPHeaderNames_SECURITY_VERIFY, "", reflect.TypeOf((*header.SecurityVerifyList)(nil)),
+		func(line string) Parser { return NewSecurityVerifyParser(line) })
+	registerParser(core.SIPHeaderNames_ACCEPT_CONTACT, core.SIPHeaderNames_A, reflect.TypeOf((*header.AcceptContactList)(nil)),
+		func(line string) Parser { return NewAcceptContactParser(line) })
+	registerParser(core.SIPHeaderNames_REJECT_CONTACT, core.SIPHeaderNames_J, reflect.TypeOf((*header.RejectContactList)(nil)),
+		func(line string) Parser { return NewRejectContactParser(line) })
+	registerParser(core.SIPHeaderNames_REQUEST_DISPOSITION, core.SIPHeaderNames_D, reflect.TypeOf((*header.RequestDisposition)(nil)),
+		func(line string) Parser { return NewRequestDispositionParser(line) })
+	registerParser(core.SIPHeaderNames_TARGET_DIALOG, "", reflect.TypeOf((*header.TargetDialog)(nil)),
+		func(line string) Parser { return NewTargetDialogParser(line) })
+}
+
+/** Register the parser of a header. The compact form may be empty. The
+ * header type is the type of the header returned by the parser, e.g.
+ * reflect.TypeOf((*MyHeader)(nil)), and must implement header.Header.
+ * The header name is given a token type for the lexer, so that the parser
+ * can match it with HeaderName(LookupTokenType(name)).
+ * @throws IllegalArgumentException if an argument is missing or if the
+ * header type is not a header.
+ */
+func RegisterParser(name, compactForm string, headerType reflect.Type, constructor ParserConstructor) (IllegalArgumentException error) {
+	if strings.TrimSpace(name) == "" || constructor == nil || headerType == nil {
+		return errors.New("IllegalArgumentException: the name, header type or constructor is null")
+	}
+	if !headerType.Implements(reflect.TypeOf((*header.Header)(nil)).Elem()) {
+		return errors.New("IllegalArgumentException: " + headerType.String() + " is not a header")
+	}
+	return parserRegistry.register(name, compactForm, headerType, constructor, true)
+}
+
+/** Remove the parser of a header registered with RegisterParser, e.g. at
+ * the end of a test. The parser it replaced, if any, is restored; a header
+ * that had none is parsed as an Extension header again.
+ * @throws IllegalArgumentException if the header was not registered with
+ * RegisterParser.
+ */
+func UnregisterParser(name string) (IllegalArgumentException error) {
+	return parserRegistry.unregister(name)
+}
+
+/** Get the parser constructor of a header name, full or compact, or nil if
+ * it is not registered.
+ */
+func LookupParser(name string) ParserConstructor {
+	if registration := parserRegistry.lookup(name); registration != nil {
+		return registration.constructor
+	}
+	return nil
+}
+
+/** Get the type of the header returned by the parser of a header name, or
+ * nil if it is not registered. The header lists, e.g. *header.ContactList,
+ * are the types of the headers that may be repeated.
+ */
+func LookupHeaderType(name string) reflect.Type {
+	if registration := parserRegistry.lookup(name); registration != nil {
+		return registration.headerType
+	}
+	return nil
+}
+
+/** Get the token type of a header name registered with RegisterParser, or
+ * 0 if it is not registered.
+ */
+func LookupTokenType(name string) int {
+	if registration := parserRegistry.lookup(name); registration != nil {
+		return registration.tokenType
+	}
+	return 0
+}
+
+/** Register the parser of a built-in header, whose name is already a
+ * keyword of the lexer.
+ */
+func registerParser(name, compactForm string, headerType reflect.Type, constructor ParserConstructor) {
+	parserRegistry.register(name, compactForm, headerType, constructor, false)
+}
+
+func (this *headerParserRegistry) register(name, compactForm string, headerType reflect.Type, constructor ParserConstructor, keyword bool) (IllegalArgumentException error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	registration := &parserRegistration{}
+	registration.name = strings.TrimSpace(name)
+	registration.compactForm = strings.TrimSpace(compactForm)
+	registration.headerType = headerType
+	registration.constructor = constructor
+	registration.previous = this.registrations[strings.ToLower(registration.name)]
+	if keyword {
+		if registration.tokenType = this.keywords[strings.ToUpper(registration.name)]; registration.tokenType == 0 {
+			if this.nextTokenType >= TokenTypes_EXTENSION_END {
+				return errors.New("IllegalArgumentException: too many registered headers")
+			}
+			registration.tokenType = this.nextTokenType
+			this.nextTokenType++
+		}
+		this.keywords[strings.ToUpper(registration.name)] = registration.tokenType
+		if registration.compactForm != "" {
+			this.keywords[strings.ToUpper(registration.compactForm)] = registration.tokenType
+		}
+	}
+
+	this.registrations[strings.ToLower(registration.name)] = registration
+	if registration.compactForm != "" {
+		this.registrations[strings.ToLower(registration.compactForm)] = registration
+	}
+	if keyword {
+		resetLexerTables()
+	}
+	return nil
+}
+
+func (this *headerParserRegistry) unregister(name string) (IllegalArgumentException error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	registration := this.registrations[strings.ToLower(strings.TrimSpace(name))]
+	if registration == nil || registration.tokenType == 0 {
+		return errors.New("IllegalArgumentException: " + name + " is not registered with RegisterParser")
+	}
+	for _, n := range []string{registration.name, registration.compactForm} {
+		if n != "" && this.registrations[strings.ToLower(n)] == registration {
+			delete(this.registrations, strings.ToLower(n))
+			delete(this.keywords, strings.ToUpper(n))
+		}
+	}
+	if previous := registration.previous; previous != nil {
+		for _, n := range []string{previous.name, previous.compactForm} {
+			if n == "" {
+				continue
+			}
+			this.registrations[strings.ToLower(n)] = previous
+			if previous.tokenType != 0 {
+				this.keywords[strings.ToUpper(n)] = previous.tokenType
+			}
+		}
+	}
+	resetLexerTables()
+	return nil
+}
+
+func (this *headerParserRegistry) lookup(name string) *parserRegistration {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.registrations[strings.ToLower(strings.TrimSpace(name))]
+}
+
+/** Add the keywords of the registered header names to a lexer.
+ */
+func (this *headerParserRegistry) addKeywords(lexer *SIPLexer) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	for name, tokenType := range this.keywords {
+		lexer.AddKeyword(name, tokenType)
+	}
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** A P-Charging-Vector header as an application would define it. */
+type pChargingVector struct {
+	*header.SIPHeader
+
+	value string
+}
+
+func (this *pChargingVector) String() string {
+	return this.GetHeaderName() + core.SIPSeparatorNames_COLON +
+		core.SIPSeparatorNames_SP + this.EncodeBody() + core.SIPSeparatorNames_NEWLINE
+}
+
+func (this *pChargingVector) EncodeBody() string {
+	return this.value
+}
+
+type pChargingVectorParser struct {
+	*HeaderParser
+}
+
+func (this *pChargingVectorParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(LookupTokenType("P-Charging-Vector"))
+	lexer.SPorHT()
+	pcv := &pChargingVector{SIPHeader: header.NewSIPHeader("P-Charging-Vector")}
+	pcv.value = strings.TrimSpace(lexer.GetRest())
+	return pcv, nil
+}
+
+func TestParserRegistry(t *testing.T) {
+	err := RegisterParser("P-Charging-Vector", "z", reflect.TypeOf((*pChargingVector)(nil)),
+		func(line string) Parser { return &pChargingVectorParser{NewHeaderParser(line)} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := UnregisterParser("P-Charging-Vector"); err != nil {
+			t.Error(err)
+		}
+		if LookupParser("P-Charging-Vector") != nil || LookupParser("z") != nil || LookupTokenType("P-Charging-Vector") != 0 {
+			t.Error("P-Charging-Vector still registered")
+		}
+	})
+	if LookupTokenType("p-charging-vector") == 0 || LookupTokenType("Z") != LookupTokenType("P-Charging-Vector") {
+		t.Fatal("no token type for P-Charging-Vector")
+	}
+
+	msg, err := NewStringMsgParser().ParseSIPMessage("INVITE sip:bob@biloxi.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: Bob <sip:bob@biloxi.com>\r\n" +
+		"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"P-Charging-Vector: icid-value=1234bc9876e;icid-generated-at=192.0.6.8\r\n" +
+		"Content-Length: 0\r\n\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcv, ok := msg.(*message.SIPRequest).GetHeader("P-Charging-Vector").(*pChargingVector)
+	if !ok {
+		t.Fatal("P-Charging-Vector not parsed into its type")
+	}
+	if pcv.EncodeBody() != "icid-value=1234bc9876e;icid-generated-at=192.0.6.8" {
+		t.Fatalf("unexpected %s", pcv.EncodeBody())
+	}
+
+	shp, err := CreateParser("z: icid-value=1234bc9876e\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sh, err := shp.Parse(); err != nil {
+		t.Fatal(err)
+	} else if reflect.TypeOf(sh) != LookupHeaderType("z") {
+		t.Fatalf("compact form parsed into %T", sh)
+	}
+}
+
+func TestParserRegistryBuiltin(t *testing.T) {
+	for _, line := range []string{
+		"o: presence\n",
+		"u: presence, dialog\n",
+		"m: <sip:alice@pc33.atlanta.com>\n",
+		"Target-Dialog: 98732@sip.example.com;local-tag=ff87ff;remote-tag=r33th4x0r\n",
+		"Organization: Boxes by Bob\n",
+	} {
+		shp, err := CreateParser(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sh, err := shp.Parse()
+		if err != nil {
+			t.Fatalf("%s: %s", strings.TrimSpace(line), err)
+		}
+		if headerType := LookupHeaderType(sh.GetName()); reflect.TypeOf(sh) != headerType {
+			t.Errorf("%s: parsed into %T, registered %v", strings.TrimSpace(line), sh, headerType)
+		}
+	}
+
+	if shp, _ := CreateParser("X-Unknown: 1\n"); reflect.TypeOf(shp) != reflect.TypeOf((*HeaderParser)(nil)) {
+		t.Error("unregistered header not parsed as an extension")
+	}
+}
+
+func TestParserRegistryRejected(t *testing.T) {
+	if RegisterParser("", "", reflect.TypeOf((*header.Extension)(nil)),
+		func(line string) Parser { return NewHeaderParser(line) }) == nil {
+		t.Error("parser without a name registered")
+	}
+	if RegisterParser("X-Test", "", reflect.TypeOf((*header.Extension)(nil)), nil) == nil {
+		t.Error("nil constructor registered")
+	}
+	if RegisterParser("X-Test", "", reflect.TypeOf(""),
+		func(line string) Parser { return NewHeaderParser(line) }) == nil {
+		t.Error("non header type registered")
+	}
+	if UnregisterParser(core.SIPHeaderNames_VIA) == nil || LookupParser(core.SIPHeaderNames_VIA) == nil {
+		t.Error("built-in parser unregistered")
+	}
+}
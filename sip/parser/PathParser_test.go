@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestPathParser(t *testing.T) {
+	var tvi = []string{
+		"Path: <sip:P3.EXAMPLEHOME.COM;lr>,<sip:P1.EXAMPLEVISITED.COM;lr>\n",
+		"Path: <sip:P1.EXAMPLEVISITED.COM;lr>\n",
+		"Path: <sip:Tc4AoiEe4TQuyG6fNMM-3A@edge.example.com;lr;ob>\n",
+	}
+	var tvo = []string{
+		"Path: <sip:P3.EXAMPLEHOME.COM;lr>,<sip:P1.EXAMPLEVISITED.COM;lr>\n",
+		"Path: <sip:P1.EXAMPLEVISITED.COM;lr>\n",
+		"Path: <sip:Tc4AoiEe4TQuyG6fNMM-3A@edge.example.com;lr;ob>\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewPathParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
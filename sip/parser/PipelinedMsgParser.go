@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** The listener of a PipelinedMsgParser: the messages read from the
+ * stream are passed to it in order.
+ */
+type SIPMessageListener interface {
+	/** Called with each message parsed from the stream.
+	 */
+	ProcessMessage(sipMessage message.Message)
+
+	/** Called with a message that could be read from the stream but not
+	 * parsed. The stream is still in sync, so the parser goes on with the
+	 * next message.
+	 *@param err -- the parse error.
+	 *@param messageText -- the raw message, valid only during the call.
+	 */
+	HandleParseException(err error, messageText []byte)
+}
+
+/**
+ * Parse the SIP messages of a byte stream, e.g. a TCP, TLS or WebSocket
+ * connection. The stream may deliver the messages in chunks of any size:
+ * the parser buffers the header of a message until its empty line, then
+ * reads the number of body bytes given by its Content-Length before
+ * parsing it (RFC 3261 section 18.3).
+ *
+ * A message without a Content-Length or larger than the maximum message
+ * size breaks the framing of the stream. ProcessInput then returns an
+ * error and the connection should be closed.
+ */
+type PipelinedMsgParser struct {
+	sipMessageListener SIPMessageListener
+
+	reader *bufio.Reader
+
+	maxMessageSize int
+
+	smp *StringMsgParser
+}
+
+/** The buffers the messages are read into, shared by the parsers of all
+ * the connections: a message is parsed into strings, so its buffer can be
+ * reused once it is parsed.
+ */
+var messageBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+/** Constructor
+ * @param sipMessageListener the listener the messages are passed to.
+ * @param reader the stream to read the messages from.
+ * @param maxMessageSize the maximum size of a message, header and body,
+ * or 0 for no limit.
+ */
+func NewPipelinedMsgParser(sipMessageListener SIPMessageListener, reader io.Reader, maxMessageSize int) *PipelinedMsgParser {
+	this := &PipelinedMsgParser{}
+	this.sipMessageListener = sipMessageListener
+	this.reader = bufio.NewReader(reader)
+	this.maxMessageSize = maxMessageSize
+	this.smp = NewStringMsgParser()
+	return this
+}
+
+/** Parse the headers of the messages lazily.
+ * @see StringMsgParser.SetLazyParsing
+ */
+func (this *PipelinedMsgParser) SetLazyParsing(lazyParsing bool) {
+	this.smp.SetLazyParsing(lazyParsing)
+}
+
+/** Keep the headers that do not parse, with a diagnostic.
+ * @see StringMsgParser.SetLenientParsing
+ */
+func (this *PipelinedMsgParser) SetLenientParsing(lenientParsing bool) {
+	this.smp.SetLenientParsing(lenientParsing)
+}
+
+/** Set the limits of the messages, or nil for no limits. The maximum
+ * message size of the limits, if any, replaces that of the parser.
+ * @see StringMsgParser.SetLimits
+ */
+func (this *PipelinedMsgParser) SetLimits(limits *ParserLimits) {
+	this.smp.SetLimits(limits)
+	if limits != nil && limits.GetMaxMessageSize() > 0 {
+		this.maxMessageSize = limits.GetMaxMessageSize()
+	}
+}
+
+/** Read and parse the messages of the stream until it ends. It returns
+ * nil at the end of the stream, io.ErrUnexpectedEOF if the stream ends in
+ * the middle of a message and the error of the reader or of the framing
+ * of a message otherwise.
+ */
+func (this *PipelinedMsgParser) ProcessInput() error {
+	messageBuffer := messageBufferPool.Get().(*bytes.Buffer)
+	defer messageBufferPool.Put(messageBuffer)
+
+	for {
+		messageBuffer.Reset()
+		messageText, err := this.readMessage(messageBuffer)
+		if err != nil {
+			return err
+		}
+		if messageText == nil {
+			return nil
+		}
+
+		sipMessage, err := this.smp.ParseSIPMessageFromByte(messageText)
+		if err != nil {
+			this.sipMessageListener.HandleParseException(err, messageText)
+		} else if sipMessage != nil {
+			this.sipMessageListener.ProcessMessage(sipMessage)
+		}
+	}
+}
+
+/** Read the next message of the stream, header and body, into a buffer.
+ * It returns nil at the end of the stream.
+ */
+func (this *PipelinedMsgParser) readMessage(messageText *bytes.Buffer) ([]byte, error) {
+	contentLength := -1
+
+	// Skip the CRLFs between the messages, e.g. keep-alives (RFC 5626).
+	for {
+		line, err := this.readLine(0)
+		if err == io.EOF && len(line) == 0 {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, this.unexpected(err)
+		}
+		if !isEmptyLine(line) {
+			messageText.Write(line)
+			break
+		}
+	}
+
+	for lineNumber := 2; ; lineNumber++ {
+		line, err := this.readLine(messageText.Len())
+		if err != nil {
+			return nil, this.unexpected(err)
+		}
+		offset := messageText.Len()
+		messageText.Write(line)
+		if isEmptyLine(line) {
+			break
+		}
+		if length, ok, err := getContentLength(line); err != nil {
+			return nil, err.(*core.ParseError).At(core.SIPHeaderNames_CONTENT_LENGTH, lineNumber, 1, offset)
+		} else if ok {
+			contentLength = length
+		}
+	}
+
+	if contentLength < 0 {
+		return nil, core.NewParseError(core.ParseErrorKind_MISSING_HEADER, "ParseException: missing Content-Length on a stream transport", messageText.Len()).At(core.SIPHeaderNames_CONTENT_LENGTH, 0, 0, messageText.Len())
+	}
+	if this.maxMessageSize > 0 && messageText.Len()+contentLength > this.maxMessageSize {
+		return nil, core.NewParseError(core.ParseErrorKind_MESSAGE_TOO_LARGE, "ParseException: message too large", this.maxMessageSize)
+	}
+	if contentLength > 0 {
+		if _, err := io.CopyN(messageText, this.reader, int64(contentLength)); err != nil {
+			return nil, this.unexpected(err)
+		}
+	}
+	return messageText.Bytes(), nil
+}
+
+/** Read a line, with its line terminator. The line is limited by the
+ * maximum message size, given the size of the message read so far.
+ */
+func (this *PipelinedMsgParser) readLine(size int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := this.reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if this.maxMessageSize > 0 && size+len(line) > this.maxMessageSize {
+			return nil, core.NewParseError(core.ParseErrorKind_MESSAGE_TOO_LARGE, "ParseException: message too large", this.maxMessageSize)
+		}
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+/** Turn an end of stream within a message into io.ErrUnexpectedEOF.
+ */
+func (this *PipelinedMsgParser) unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func isEmptyLine(line []byte) bool {
+	return len(line) == 1 && line[0] == '\n' || len(line) == 2 && line[0] == '\r' && line[1] == '\n'
+}
+
+/** Get the value of a Content-Length header line, full or compact.
+ * @return ok is false if the line is not a Content-Length header.
+ */
+func getContentLength(line []byte) (contentLength int, ok bool, ParseException error) {
+	colon := bytes.IndexByte(line, ':')
+	if colon < 0 {
+		return 0, false, nil
+	}
+	name := strings.TrimSpace(string(line[:colon]))
+	if !strings.EqualFold(name, core.SIPHeaderNames_CONTENT_LENGTH) && !strings.EqualFold(name, core.SIPHeaderNames_L) {
+		return 0, false, nil
+	}
+	contentLength, err := strconv.Atoi(strings.TrimSpace(string(line[colon+1:])))
+	if err != nil || contentLength < 0 {
+		return 0, false, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: bad Content-Length "+strings.TrimSpace(string(line[colon+1:])), colon+1)
+	}
+	return contentLength, true, nil
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/use-go/gosips/sip/message"
+)
+
+type testMessageListener struct {
+	messages []message.Message
+	errors   []error
+}
+
+func (this *testMessageListener) ProcessMessage(sipMessage message.Message) {
+	this.messages = append(this.messages, sipMessage)
+}
+
+func (this *testMessageListener) HandleParseException(err error, messageText []byte) {
+	this.errors = append(this.errors, err)
+}
+
+const pipelinedInvite = "INVITE sip:bob@biloxi.com SIP/2.0\r\n" +
+	"Via: SIP/2.0/TCP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"To: Bob <sip:bob@biloxi.com>\r\n" +
+	"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 314159 INVITE\r\n" +
+	"Content-Type: application/sdp\r\n" +
+	"Content-Length: 14\r\n\r\n" +
+	"v=0\r\no=alice\r\n"
+
+const pipelinedBye = "BYE sip:alice@pc33.atlanta.com SIP/2.0\r\n" +
+	"Via: SIP/2.0/TCP 192.0.2.4;branch=z9hG4bKnashds10\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"From: Bob <sip:bob@biloxi.com>;tag=a6c85cf\r\n" +
+	"To: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 231 BYE\r\n" +
+	"l: 0\r\n\r\n"
+
+func TestPipelinedMsgParser(t *testing.T) {
+	listener := &testMessageListener{}
+	stream := "\r\n\r\n" + pipelinedInvite + pipelinedBye + "\r\n\r\n"
+	parser := NewPipelinedMsgParser(listener, iotest.OneByteReader(strings.NewReader(stream)), 4096)
+	if err := parser.ProcessInput(); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.messages) != 2 || len(listener.errors) != 0 {
+		t.Fatalf("expected 2 messages, got %d and %d errors", len(listener.messages), len(listener.errors))
+	}
+	invite := listener.messages[0].(*message.SIPRequest)
+	if invite.GetMethod() != message.INVITE || invite.GetMessageContent() != "v=0\r\no=alice\r\n" {
+		t.Fatalf("unexpected INVITE %s", invite.String())
+	}
+	if bye := listener.messages[1].(*message.SIPRequest); bye.GetMethod() != message.BYE {
+		t.Fatalf("unexpected BYE %s", bye.String())
+	}
+}
+
+func TestPipelinedMsgParserBadMessage(t *testing.T) {
+	listener := &testMessageListener{}
+	bad := strings.Replace(pipelinedBye, "CSeq: 231 BYE", "CSeq: 231 INVITE", 1)
+	parser := NewPipelinedMsgParser(listener, strings.NewReader(bad+pipelinedInvite), 0)
+	if err := parser.ProcessInput(); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.errors) != 1 || len(listener.messages) != 1 {
+		t.Fatalf("expected 1 error and 1 message, got %d and %d", len(listener.errors), len(listener.messages))
+	}
+}
+
+func TestPipelinedMsgParserRejected(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		stream         string
+		maxMessageSize int
+	}{
+		{"no Content-Length", strings.Replace(pipelinedBye, "l: 0\r\n", "", 1), 0},
+		{"bad Content-Length", strings.Replace(pipelinedBye, "l: 0", "l: -1", 1), 0},
+		{"large header", pipelinedInvite, 128},
+		{"large body", pipelinedInvite, len(pipelinedInvite) - 1},
+		{"truncated header", pipelinedInvite[:100], 0},
+		{"truncated body", pipelinedInvite[:len(pipelinedInvite)-1], 0},
+	} {
+		listener := &testMessageListener{}
+		parser := NewPipelinedMsgParser(listener, strings.NewReader(test.stream), test.maxMessageSize)
+		if err := parser.ProcessInput(); err == nil {
+			t.Errorf("%s: accepted", test.name)
+		} else if strings.HasPrefix(test.name, "truncated") && err != io.ErrUnexpectedEOF {
+			t.Errorf("%s: unexpected %v", test.name, err)
+		}
+		if len(listener.messages) != 0 {
+			t.Errorf("%s: message delivered", test.name)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Privacy header (RFC 3323).
+ */
+type PrivacyParser struct {
+	HeaderParser
+}
+
+/** Constructor
+ * @param String Privacy message to parse to set
+ */
+func NewPrivacyParser(privacy string) *PrivacyParser {
+	this := &PrivacyParser{}
+	this.HeaderParser.super(privacy)
+	return this
+}
+
+func NewPrivacyParserFromLexer(lexer core.Lexer) *PrivacyParser {
+	this := &PrivacyParser{}
+	this.HeaderParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (Privacy object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *PrivacyParser) Parse() (sh header.Header, ParseException error) {
+	privacy := header.NewPrivacy()
+
+	var ch byte
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_PRIVACY)
+
+	for {
+		lexer.SPorHT()
+		if _, ParseException = lexer.Match(TokenTypes_ID); ParseException != nil {
+			return nil, ParseException
+		}
+		token := lexer.GetNextToken()
+		privacy.AddPrivValue(token.GetTokenValue())
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ';' {
+			lexer.Match(';')
+		} else if ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+
+	return privacy, nil
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** ReferredBy Header parser.
+ */
+type ReferredByParser struct {
+	AddressParametersParser
+}
+
+/** Creates new ReferredByParser
+ * @param String to set
+ */
+func NewReferredByParser(referredBy string) *ReferredByParser {
+	this := &ReferredByParser{}
+	this.AddressParametersParser.super(referredBy)
+	return this
+}
+
+func NewReferredByParserFromLexer(lexer core.Lexer) *ReferredByParser {
+	this := &ReferredByParser{}
+	this.AddressParametersParser.superFromLexer(lexer)
+	return this
+}
+
+func (this *ReferredByParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_REFERRED_BY)
+	referredBy := header.NewReferredBy()
+	if ParseException = this.AddressParametersParser.Parse(referredBy); ParseException != nil {
+		return nil, ParseException
+	}
+	lexer.Match('\n')
+	return referredBy, nil
+}
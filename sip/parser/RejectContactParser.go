@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Reject-Contact header (RFC 3841):
+ * <pre>
+ * Reject-Contact  =  ( "Reject-Contact" / "j" ) HCOLON rc-value
+ *                    *(COMMA rc-value)
+ * rc-value        =  "*" *(SEMI rc-params)
+ * </pre>
+ */
+type RejectContactParser struct {
+	ParametersParser
+}
+
+/** Creates a new instance of RejectContactParser
+ * @param rejectContact the header to parse
+ */
+func NewRejectContactParser(rejectContact string) *RejectContactParser {
+	this := &RejectContactParser{}
+	this.ParametersParser.super(rejectContact)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewRejectContactParserFromLexer(lexer core.Lexer) *RejectContactParser {
+	this := &RejectContactParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (RejectContactList object)
+ * @throws SIPParseException if the message does not respect the spec.
+ */
+func (this *RejectContactParser) Parse() (sh header.Header, ParseException error) {
+	rejectContactList := header.NewRejectContactList()
+
+	var ch byte
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_REJECT_CONTACT)
+	for {
+		lexer.SPorHT()
+		if _, ParseException = lexer.Match('*'); ParseException != nil {
+			return nil, ParseException
+		}
+		rejectContact := header.NewRejectContact()
+		if ParseException = this.ParametersParser.Parse(rejectContact); ParseException != nil {
+			return nil, ParseException
+		}
+		rejectContactList.PushBack(rejectContact)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+		} else if ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+	return rejectContactList, nil
+}
@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestRejectContactParser(t *testing.T) {
+	var tvi = []string{
+		"Reject-Contact: *;actor=\"msg-taker\";video\n",
+		"j: *;automata , *;class=\"business\"\n",
+	}
+	var tvo = []string{
+		"Reject-Contact: *;actor=\"msg-taker\";video\n",
+		"Reject-Contact: *;automata,*;class=\"business\"\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewRejectContactParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Replaces header (RFC 3891).
+ */
+type ReplacesParser struct {
+	ParametersParser
+}
+
+/** Constructor
+ * @param String Replaces message to parse to set
+ */
+func NewReplacesParser(replaces string) *ReplacesParser {
+	this := &ReplacesParser{}
+	this.ParametersParser.super(replaces)
+	return this
+}
+
+func NewReplacesParserFromLexer(lexer core.Lexer) *ReplacesParser {
+	this := &ReplacesParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return Header (Replaces object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *ReplacesParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_REPLACES)
+	lexer.SPorHT()
+
+	replaces := header.NewReplaces()
+	if ParseException = replaces.SetCallId(strings.TrimSpace(lexer.ByteStringNoSemicolon())); ParseException != nil {
+		return nil, this.CreateParseException(ParseException.Error())
+	}
+	if ParseException = this.ParametersParser.Parse(replaces); ParseException != nil {
+		return nil, ParseException
+	}
+	if replaces.IsEarlyOnly() {
+		// early-only is a flag: encode it without "=".
+		replaces.SetEarlyOnly(false)
+		replaces.SetEarlyOnly(true)
+	}
+	if replaces.GetToTag() == "" || replaces.GetFromTag() == "" {
+		return nil, this.CreateParseException("missing to-tag or from-tag")
+	}
+
+	lexer.SPorHT()
+	lexer.Match('\n')
+
+	return replaces, nil
+}
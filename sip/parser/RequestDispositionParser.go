@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Request-Disposition header (RFC 3841).
+ */
+type RequestDispositionParser struct {
+	HeaderParser
+}
+
+/** Constructor
+ * @param String Request-Disposition message to parse to set
+ */
+func NewRequestDispositionParser(requestDisposition string) *RequestDispositionParser {
+	this := &RequestDispositionParser{}
+	this.HeaderParser.super(requestDisposition)
+	return this
+}
+
+func NewRequestDispositionParserFromLexer(lexer core.Lexer) *RequestDispositionParser {
+	this := &RequestDispositionParser{}
+	this.HeaderParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (RequestDisposition object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *RequestDispositionParser) Parse() (sh header.Header, ParseException error) {
+	requestDisposition := header.NewRequestDisposition()
+
+	var ch byte
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_REQUEST_DISPOSITION)
+
+	for {
+		lexer.SPorHT()
+		if _, ParseException = lexer.Match(TokenTypes_ID); ParseException != nil {
+			return nil, ParseException
+		}
+		token := lexer.GetNextToken()
+		requestDisposition.AddDirective(token.GetTokenValue())
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+		} else if ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+
+	return requestDisposition, nil
+}
@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestRequestDispositionParser(t *testing.T) {
+	var tvi = []string{
+		"Request-Disposition: proxy\n",
+		"Request-Disposition: proxy,recurse , parallel\n",
+		"d: redirect, no-fork\n",
+	}
+	var tvo = []string{
+		"Request-Disposition: proxy\n",
+		"Request-Disposition: proxy, recurse, parallel\n",
+		"Request-Disposition: redirect, no-fork\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewRequestDispositionParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
@@ -1,8 +1,10 @@
 package parser
 
 import (
-	"github.com/use-go/gosips/core"
 	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
 )
 
 /** SIPLexer class for the parser.
@@ -55,9 +57,48 @@ func (this *SIPLexer) GetHeaderValue(line string) string {
 
 	return headerValue
 }
+
+/** The keyword tables of the lexers. A table is built by the first lexer
+ * that selects it and then shared by all the lexers. Registering a parser
+ * adds a keyword, so it drops the tables.
+ */
+var lexerTables = struct {
+	sync.RWMutex
+	tables     map[string]core.LexerMap
+	generation int
+}{tables: make(map[string]core.LexerMap)}
+
+func lookupLexerTable(lexerName string) (table core.LexerMap, generation int) {
+	lexerTables.RLock()
+	defer lexerTables.RUnlock()
+	return lexerTables.tables[lexerName], lexerTables.generation
+}
+
+func storeLexerTable(lexerName string, table core.LexerMap, generation int) {
+	lexerTables.Lock()
+	defer lexerTables.Unlock()
+	if generation == lexerTables.generation {
+		lexerTables.tables[lexerName] = table
+	}
+}
+
+func resetLexerTables() {
+	lexerTables.Lock()
+	defer lexerTables.Unlock()
+	lexerTables.tables = make(map[string]core.LexerMap)
+	lexerTables.generation++
+}
+
 func (this *SIPLexer) SelectLexer(lexerName string) {
 	this.CoreLexer.SelectLexer(lexerName)
 	if this.CurrentLexer() == nil {
+		table, generation := lookupLexerTable(lexerName)
+		if table != nil {
+			this.UseLexer(lexerName, table)
+			return
+		}
+		defer func() { storeLexerTable(lexerName, this.CurrentLexer(), generation) }()
+
 		this.AddLexer(lexerName)
 		if lexerName == "method_keywordLexer" {
 			this.AddKeyword(strings.ToUpper(core.SIPTransportNames_SIP), TokenTypes_SIP)
@@ -120,6 +161,24 @@ func (this *SIPLexer) SelectLexer(lexerName string) {
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_WWW_AUTHENTICATE), TokenTypes_WWW_AUTHENTICATE)
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_CALL_INFO), TokenTypes_CALL_INFO)
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_CONTENT_DISPOSITION), TokenTypes_CONTENT_DISPOSITION)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_PATH), TokenTypes_PATH)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_SERVICE_ROUTE), TokenTypes_SERVICE_ROUTE)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_P_ASSERTED_IDENTITY), TokenTypes_P_ASSERTED_IDENTITY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_P_PREFERRED_IDENTITY), TokenTypes_P_PREFERRED_IDENTITY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_PRIVACY), TokenTypes_PRIVACY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_REPLACES), TokenTypes_REPLACES)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_JOIN), TokenTypes_JOIN)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_REFERRED_BY), TokenTypes_REFERRED_BY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_HISTORY_INFO), TokenTypes_HISTORY_INFO)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_DIVERSION), TokenTypes_DIVERSION)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_IDENTITY), TokenTypes_IDENTITY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_SECURITY_CLIENT), TokenTypes_SECURITY_CLIENT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_SECURITY_SERVER), TokenTypes_SECURITY_SERVER)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_SECURITY_VERIFY), TokenTypes_SECURITY_VERIFY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_ACCEPT_CONTACT), TokenTypes_ACCEPT_CONTACT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_REJECT_CONTACT), TokenTypes_REJECT_CONTACT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_REQUEST_DISPOSITION), TokenTypes_REQUEST_DISPOSITION)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_TARGET_DIALOG), TokenTypes_TARGET_DIALOG)
 			// And now the dreaded short forms....
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_K), TokenTypes_SUPPORTED)
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_C), TokenTypes_CONTENT_TYPE)
@@ -132,6 +191,15 @@ func (this *SIPLexer) SelectLexer(lexerName string) {
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_T), TokenTypes_TO)
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_V), TokenTypes_VIA)
 			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_R), TokenTypes_REFER_TO)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_B), TokenTypes_REFERRED_BY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_Y), TokenTypes_IDENTITY)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_A), TokenTypes_ACCEPT_CONTACT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_J), TokenTypes_REJECT_CONTACT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_D), TokenTypes_REQUEST_DISPOSITION)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_O), TokenTypes_EVENT)
+			this.AddKeyword(strings.ToUpper(core.SIPHeaderNames_U), TokenTypes_ALLOW_EVENTS)
+			// The headers registered by the applications.
+			parserRegistry.addKeywords(this)
 		} else if lexerName == "status_lineLexer" {
 			this.AddKeyword(strings.ToUpper(core.SIPTransportNames_SIP), TokenTypes_SIP)
 		} else if lexerName == "request_lineLexer" {
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"errors"
 	"github.com/use-go/gosips/core"
 )
 
@@ -76,6 +75,24 @@ const TokenTypes_EVENT = TokenTypes_START + 63
 const TokenTypes_AUTHENTICATION_INFO = TokenTypes_START + 64
 const TokenTypes_ALLOW_EVENTS = TokenTypes_START + 65
 const TokenTypes_REFER_TO = TokenTypes_START + 66
+const TokenTypes_PATH = TokenTypes_START + 67
+const TokenTypes_SERVICE_ROUTE = TokenTypes_START + 68
+const TokenTypes_P_ASSERTED_IDENTITY = TokenTypes_START + 69
+const TokenTypes_P_PREFERRED_IDENTITY = TokenTypes_START + 70
+const TokenTypes_PRIVACY = TokenTypes_START + 71
+const TokenTypes_REPLACES = TokenTypes_START + 72
+const TokenTypes_JOIN = TokenTypes_START + 73
+const TokenTypes_REFERRED_BY = TokenTypes_START + 74
+const TokenTypes_HISTORY_INFO = TokenTypes_START + 75
+const TokenTypes_DIVERSION = TokenTypes_START + 76
+const TokenTypes_IDENTITY = TokenTypes_START + 77
+const TokenTypes_SECURITY_CLIENT = TokenTypes_START + 78
+const TokenTypes_SECURITY_SERVER = TokenTypes_START + 79
+const TokenTypes_SECURITY_VERIFY = TokenTypes_START + 80
+const TokenTypes_ACCEPT_CONTACT = TokenTypes_START + 81
+const TokenTypes_REJECT_CONTACT = TokenTypes_START + 82
+const TokenTypes_REQUEST_DISPOSITION = TokenTypes_START + 83
+const TokenTypes_TARGET_DIALOG = TokenTypes_START + 84
 const TokenTypes_ALPHA = core.CORELEXER_ALPHA
 const TokenTypes_DIGIT = core.CORELEXER_DIGIT
 const TokenTypes_ID = core.CORELEXER_ID
@@ -124,19 +141,24 @@ type SIPParser struct {
 func NewSIPParser(buffer string) *SIPParser {
 	this := &SIPParser{}
 
-	this.CoreParser.Super(buffer)
 	this.CoreParser.SetLexer(NewSIPLexer("CharLexer", buffer))
 
 	return this
 }
 
 func (this *SIPParser) super(buffer string) {
-	this.CoreParser.Super(buffer)
 	this.CoreParser.SetLexer(NewSIPLexer("CharLexer", buffer))
 }
 
 func (this *SIPParser) CreateParseException(exceptionString string) (ParseException error) {
-	return errors.New("ParseException: " + this.GetLexer().GetBuffer() + ":" + exceptionString) // + this.GetLexer().GetPtr());
+	return core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+this.GetLexer().GetBuffer()+":"+exceptionString, this.GetLexer().GetPtr())
+}
+
+/** Make a ParseError at the current position of an error of a conversion
+ * or of a header setter, with the same text.
+ */
+func (this *SIPParser) WrapParseException(err error) (ParseException error) {
+	return core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, this.GetLexer().GetPtr())
 }
 
 func (this *SIPParser) SipVersion() (s string, ParseException error) {
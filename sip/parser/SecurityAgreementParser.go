@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the body of the Security-Client, Security-Server and
+ * Security-Verify headers (RFC 3329 section 2.2):
+ * <pre>
+ * security-client  =  "Security-Client" HCOLON
+ *                     sec-mechanism *(COMMA sec-mechanism)
+ * sec-mechanism    =  mechanism-name *(SEMI mech-parameters)
+ * mech-parameters  =  ( preference / digest-algorithm /
+ *                       digest-qop / digest-verify / extension )
+ * </pre>
+ */
+type SecurityAgreementParser struct {
+	ParametersParser
+}
+
+/** Parse the mechanisms of a header into its list, creating each header
+ * with newHeader.
+ */
+func (this *SecurityAgreementParser) parse(headerName int, list header.SIPHeaderLister,
+	newHeader func() header.SecurityAgreementHeader) (sh header.Header, ParseException error) {
+	var ch byte
+	lexer := this.GetLexer()
+	this.HeaderName(headerName)
+	lexer.SPorHT()
+	for ch, _ = lexer.LookAheadK(0); ch != '\n'; ch, _ = lexer.LookAheadK(0) {
+		securityAgreement := newHeader()
+		if _, ParseException = lexer.Match(TokenTypes_ID); ParseException != nil {
+			return nil, ParseException
+		}
+		token := lexer.GetNextToken()
+		securityAgreement.SetMechanismName(token.GetTokenValue())
+		lexer.SPorHT()
+		if ParseException = this.ParametersParser.Parse(securityAgreement); ParseException != nil {
+			return nil, ParseException
+		}
+		list.PushBack(securityAgreement)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+			lexer.SPorHT()
+		}
+	}
+	if list.Len() == 0 {
+		return nil, this.CreateParseException("expecting a mechanism")
+	}
+	return list, nil
+}
+
+/** SIPParser for the Security-Client header.
+ */
+type SecurityClientParser struct {
+	SecurityAgreementParser
+}
+
+/** Creates a new instance of SecurityClientParser
+ * @param securityClient the header to parse
+ */
+func NewSecurityClientParser(securityClient string) *SecurityClientParser {
+	this := &SecurityClientParser{}
+	this.ParametersParser.super(securityClient)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewSecurityClientParserFromLexer(lexer core.Lexer) *SecurityClientParser {
+	this := &SecurityClientParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (SecurityClientList object)
+ * @throws SIPParseException if the message does not respect the spec.
+ */
+func (this *SecurityClientParser) Parse() (sh header.Header, ParseException error) {
+	return this.parse(TokenTypes_SECURITY_CLIENT, header.NewSecurityClientList(), func() header.SecurityAgreementHeader {
+		return header.NewSecurityClient()
+	})
+}
+
+/** SIPParser for the Security-Server header.
+ */
+type SecurityServerParser struct {
+	SecurityAgreementParser
+}
+
+/** Creates a new instance of SecurityServerParser
+ * @param securityServer the header to parse
+ */
+func NewSecurityServerParser(securityServer string) *SecurityServerParser {
+	this := &SecurityServerParser{}
+	this.ParametersParser.super(securityServer)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewSecurityServerParserFromLexer(lexer core.Lexer) *SecurityServerParser {
+	this := &SecurityServerParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (SecurityServerList object)
+ * @throws SIPParseException if the message does not respect the spec.
+ */
+func (this *SecurityServerParser) Parse() (sh header.Header, ParseException error) {
+	return this.parse(TokenTypes_SECURITY_SERVER, header.NewSecurityServerList(), func() header.SecurityAgreementHeader {
+		return header.NewSecurityServer()
+	})
+}
+
+/** SIPParser for the Security-Verify header.
+ */
+type SecurityVerifyParser struct {
+	SecurityAgreementParser
+}
+
+/** Creates a new instance of SecurityVerifyParser
+ * @param securityVerify the header to parse
+ */
+func NewSecurityVerifyParser(securityVerify string) *SecurityVerifyParser {
+	this := &SecurityVerifyParser{}
+	this.ParametersParser.super(securityVerify)
+	return this
+}
+
+/** Constructor
+ * @param lexer the lexer to use to parse the header
+ */
+func NewSecurityVerifyParserFromLexer(lexer core.Lexer) *SecurityVerifyParser {
+	this := &SecurityVerifyParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return SIPHeader (SecurityVerifyList object)
+ * @throws SIPParseException if the message does not respect the spec.
+ */
+func (this *SecurityVerifyParser) Parse() (sh header.Header, ParseException error) {
+	return this.parse(TokenTypes_SECURITY_VERIFY, header.NewSecurityVerifyList(), func() header.SecurityAgreementHeader {
+		return header.NewSecurityVerify()
+	})
+}
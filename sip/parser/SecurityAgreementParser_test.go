@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestSecurityClientParser(t *testing.T) {
+	var tvi = []string{
+		"Security-Client: digest\n",
+		"Security-Client: tls;q=0.2, digest ; d-alg=MD5 ; d-qop=auth-int;q=0.1\n",
+		"Security-Client: ipsec-3gpp;alg=hmac-sha-1-96;spi-c=1111;spi-s=2222;port-c=5062;port-s=5064\n",
+	}
+	var tvo = []string{
+		"Security-Client: digest\n",
+		"Security-Client: tls;q=0.2,digest;d-alg=MD5;d-qop=auth-int;q=0.1\n",
+		"Security-Client: ipsec-3gpp;alg=hmac-sha-1-96;spi-c=1111;spi-s=2222;port-c=5062;port-s=5064\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewSecurityClientParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
+
+func TestSecurityServerParser(t *testing.T) {
+	var tvi = []string{
+		"Security-Server: ipsec-ike;q=0.1\n",
+		"Security-Server: tls;q=0.2,digest;d-qop=auth-int;q=0.1\n",
+	}
+	var tvo = []string{
+		"Security-Server: ipsec-ike;q=0.1\n",
+		"Security-Server: tls;q=0.2,digest;d-qop=auth-int;q=0.1\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewSecurityServerParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
+
+func TestSecurityVerifyParser(t *testing.T) {
+	var tvi = []string{
+		"Security-Verify: tls;q=0.2\n",
+		"Security-Verify: digest;d-alg=MD5;d-ver=\"0123456789abcdef\";q=0.1\n",
+	}
+	var tvo = []string{
+		"Security-Verify: tls;q=0.2\n",
+		"Security-Verify: digest;d-alg=MD5;d-ver=\"0123456789abcdef\";q=0.1\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewSecurityVerifyParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+
+	if _, err := NewSecurityVerifyParser("Security-Verify: \n").Parse(); err == nil {
+		t.Error("Security-Verify without a mechanism accepted")
+	}
+}
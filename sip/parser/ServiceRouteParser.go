@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for a list of ServiceRoute headers.
+ */
+type ServiceRouteParser struct {
+	AddressParametersParser
+}
+
+/** Constructor
+ * @param String serviceRoute message to parse to set
+ */
+func NewServiceRouteParser(serviceRoute string) *ServiceRouteParser {
+	this := &ServiceRouteParser{}
+	this.AddressParametersParser.super(serviceRoute)
+	return this
+}
+
+func NewServiceRouteParserFromLexer(lexer core.Lexer) *ServiceRouteParser {
+	this := &ServiceRouteParser{}
+	this.AddressParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message and generate the ServiceRoute List Object
+ * @return SIPHeader the ServiceRoute List object
+ * @throws ParseException if errors occur during the parsing
+ */
+func (this *ServiceRouteParser) Parse() (sh header.Header, ParseException error) {
+	serviceRouteList := header.NewServiceRouteList()
+
+	var ch byte
+	lexer := this.GetLexer()
+	lexer.Match(TokenTypes_SERVICE_ROUTE)
+	lexer.SPorHT()
+	lexer.Match(':')
+	lexer.SPorHT()
+	for {
+		serviceRoute := header.NewServiceRoute()
+		if ParseException = this.AddressParametersParser.Parse(serviceRoute); ParseException != nil {
+			return nil, ParseException
+		}
+		serviceRouteList.PushBack(serviceRoute)
+		lexer.SPorHT()
+		if ch, _ = lexer.LookAheadK(0); ch == ',' {
+			lexer.Match(',')
+			lexer.SPorHT()
+		} else if ch, _ = lexer.LookAheadK(0); ch == '\n' {
+			break
+		} else {
+			return nil, this.CreateParseException("unexpected char")
+		}
+	}
+
+	return serviceRouteList, nil
+}
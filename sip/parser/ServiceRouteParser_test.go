@@ -0,0 +1,21 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestServiceRouteParser(t *testing.T) {
+	var tvi = []string{
+		"Service-Route: <sip:P2.HOME.EXAMPLE.COM;lr>,<sip:HSP.HOME.EXAMPLE.COM;lr>\n",
+		"Service-Route: <sip:orig@scscf1.home1.net;lr>\n",
+	}
+	var tvo = []string{
+		"Service-Route: <sip:P2.HOME.EXAMPLE.COM;lr>,<sip:HSP.HOME.EXAMPLE.COM;lr>\n",
+		"Service-Route: <sip:orig@scscf1.home1.net;lr>\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewServiceRouteParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+}
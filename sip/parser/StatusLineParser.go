@@ -3,6 +3,7 @@ package parser
 import (
 	"github.com/use-go/gosips/core"
 	"github.com/use-go/gosips/sip/header"
+	"strconv"
 	"strings"
 )
 
@@ -27,8 +28,14 @@ func NewStatusLineParserFromLexer(lexer core.Lexer) *StatusLineParser {
 }
 
 func (this *StatusLineParser) StatusCode() (scode int, ParseException error) {
-	scode, ParseException = this.GetLexer().Number()
-	return scode, ParseException
+	if scode, ParseException = this.GetLexer().Number(); ParseException != nil {
+		return 0, ParseException
+	}
+	// The Status-Code is 3DIGIT, of a class from 1xx to 6xx.
+	if scode < 100 || scode > 699 {
+		return 0, this.CreateParseException("bad status code " + strconv.Itoa(scode))
+	}
+	return scode, nil
 }
 
 func (this *StatusLineParser) ReasonPhrase() string {
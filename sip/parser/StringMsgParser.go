@@ -2,7 +2,7 @@ package parser
 
 import (
 	"bytes"
-	"errors"
+	"encoding/json"
 	"github.com/use-go/gosips/core"
 	"github.com/use-go/gosips/sip/address"
 	"github.com/use-go/gosips/sip/header"
@@ -36,7 +36,7 @@ type StringMsgParser struct {
 
 	parseExceptionListener ParseExceptionListener
 
-	messageHeaders map[int]string // Message headers
+	messageHeaders []string // Message headers, without their line terminator
 
 	bufferPointer int
 
@@ -51,6 +51,16 @@ type StringMsgParser struct {
 	currentLine int
 
 	currentHeader string
+
+	lazyParsing bool
+
+	lenientParsing bool
+
+	lineNumbers []int // The line numbers of the message headers
+
+	lineOffsets []int // The offsets of the message headers in the message
+
+	limits *ParserLimits
 }
 
 /**
@@ -64,6 +74,45 @@ func NewStringMsgParser() *StringMsgParser {
 	return this
 }
 
+/** Set the limits of the messages, or nil for no limits. A message that
+ * exceeds a limit fails to parse, even in lenient mode.
+ */
+func (this *StringMsgParser) SetLimits(limits *ParserLimits) {
+	this.limits = limits
+}
+
+func (this *StringMsgParser) GetLimits() *ParserLimits {
+	return this.limits
+}
+
+/** Set lazy parsing: the headers the stack does not need to route or
+ * match a message are parsed the first time they are accessed, and the
+ * headers that are never accessed are forwarded as they were received.
+ */
+func (this *StringMsgParser) SetLazyParsing(lazyParsing bool) {
+	this.lazyParsing = lazyParsing
+}
+
+func (this *StringMsgParser) IsLazyParsing() bool {
+	return this.lazyParsing
+}
+
+/** Set lenient parsing: a header that does not parse is kept as an
+ * unparsed header and recorded with its line and column in the parse
+ * diagnostics of the message, instead of failing the message. The extra
+ * white space and the headers that RFC 4475 allows a liberal element to
+ * accept in the request line are recorded the same way. The start line
+ * and the headers the stack needs, Via, From, To, CSeq, Call-ID,
+ * Content-Length and Max-Forwards, must still parse.
+ */
+func (this *StringMsgParser) SetLenientParsing(lenientParsing bool) {
+	this.lenientParsing = lenientParsing
+}
+
+func (this *StringMsgParser) IsLenientParsing() bool {
+	return this.lenientParsing
+}
+
 /** Get the message body.
  */
 func (this *StringMsgParser) GetMessageBody() string {
@@ -204,15 +253,102 @@ func (this *StringMsgParser) ParseSIPMessageFromByte(msgBuffer []byte) (message.
 		if f < len(msgBuffer) {
 			f += 2
 		} else {
-			return nil, errors.New("ParseException: Message not terminated")
+			return nil, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: Message not terminated", len(msgBuffer)-s)
 		}
 	}
 
-	// Encode the body as a UTF-8 string.
-	var messageString string
-	messageString = string(msgBuffer[s:f]) //, "UTF-8");
+	if this.limits != nil {
+		if err := this.limits.checkSize(f-s, 0); err != nil {
+			return nil, err
+		}
+	}
 
 	this.bufferPointer = f
+	this.currentMessage = string(msgBuffer[s:f])
+
+	var sipmsg message.Message
+	var err error
+	if this.splitHeaders(this.currentMessage) {
+		sipmsg, err = this.parseMessageHeaders(this.currentMessage)
+	} else {
+		// The message has no empty line: clean it up the slow way.
+		this.currentMessage = cookMessage(this.currentMessage)
+		sipmsg, err = this.ParseMessage(this.currentMessage)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if this.readBody && this.findHeader(core.SIPHeaderNames_CONTENT_LENGTH) == 0 && len(msgBuffer) > f {
+		// Without a Content-Length the body of a datagram is the rest of
+		// it (RFC 3261 section 18.3), e.g. an RFC 2543 request.
+		sipmsg.GetContentLength().SetContentLength(len(msgBuffer) - f)
+	}
+
+	if this.limits != nil && sipmsg.GetContentLength() != nil {
+		if err = this.limits.checkSize(f-s, sipmsg.GetContentLength().GetContentLength()); err != nil {
+			return nil, this.locateError(err, core.SIPHeaderNames_CONTENT_LENGTH, this.findHeader(core.SIPHeaderNames_CONTENT_LENGTH), 1)
+		}
+	}
+
+	if this.readBody && sipmsg.GetContentLength() != nil && sipmsg.GetContentLength().GetContentLength() != 0 {
+		this.contentLength = sipmsg.GetContentLength().GetContentLength()
+
+		endIndex := this.bufferPointer + this.contentLength
+		// guard against bad specifications.
+		if endIndex > len(this.currentMessageBytes) {
+			err = core.NewParseError(core.ParseErrorKind_CONTENT_LENGTH_OVERFLOW, "Content Length Larger Than Message", -1)
+			return nil, this.locateError(err, core.SIPHeaderNames_CONTENT_LENGTH, this.findHeader(core.SIPHeaderNames_CONTENT_LENGTH), 1)
+		}
+
+		body := this.GetBodyAsBytes()
+		sipmsg.SetMessageContentFromByte(body)
+		if err = this.decodeContent(sipmsg); err != nil {
+			return nil, err
+		}
+	}
+
+	return sipmsg, nil
+}
+
+/** Decode a body with a gzip or deflate Content-Encoding, up to the size
+ * of the body in the limits. A body that does not decode is an error, or
+ * is kept as it was received with a diagnostic in lenient mode; a body
+ * that exceeds a limit is an error even in lenient mode.
+ */
+func (this *StringMsgParser) decodeContent(sipmsg message.Message) error {
+	var maxSize int
+	if this.limits != nil {
+		maxSize = this.limits.getMaxContentSize()
+	}
+	var err error
+	if request, ok := sipmsg.(*message.SIPRequest); ok {
+		err = request.DecodeContent(maxSize)
+	} else {
+		err = sipmsg.(*message.SIPResponse).DecodeContent(maxSize)
+	}
+	if err == nil {
+		return nil
+	}
+	i := this.findHeader(core.SIPHeaderNames_CONTENT_ENCODING)
+	err = this.locateError(err, core.SIPHeaderNames_CONTENT_ENCODING, i, 1)
+	parseError := err.(*core.ParseError)
+	if !this.lenientParsing || parseError.GetKind() != core.ParseErrorKind_BAD_SYNTAX {
+		return err
+	}
+	diagnostic := message.NewParseDiagnostic(parseError.GetLine(), parseError.GetColumn(), this.messageHeaders[i], err)
+	if request, ok := sipmsg.(*message.SIPRequest); ok {
+		request.AddParseDiagnostic(diagnostic)
+	} else {
+		sipmsg.(*message.SIPResponse).AddParseDiagnostic(diagnostic)
+	}
+	return nil
+}
+
+/** Clean up the header of a message for ParseMessage: drop the CRs, turn
+ * the blank lines into empty lines and join the continuation lines.
+ */
+func cookMessage(messageString string) string {
 	message := []byte(messageString)
 	length := len(message)
 	// Get rid of CR to make it uniform for the parser.
@@ -269,37 +405,57 @@ func (this *StringMsgParser) ParseSIPMessageFromByte(msgBuffer []byte) (message.
 	cooked_message1.WriteString("\n\n")
 	cooked_message1.WriteString(message1[length:])
 
-	// Separate the string out into substrings for
-	// error reporting.
-	this.currentMessage = cooked_message1.String()
-
-	sipmsg, err := this.ParseMessage(this.currentMessage)
-	if err != nil {
-		return nil, err
-	}
-
-	if this.readBody && sipmsg.GetContentLength() != nil && sipmsg.GetContentLength().GetContentLength() != 0 {
-		this.contentLength = sipmsg.GetContentLength().GetContentLength()
+	return cooked_message1.String()
+}
 
-		endIndex := this.bufferPointer + this.contentLength
-		// guard against bad specifications.
-		if endIndex > len(this.currentMessageBytes) {
-			return nil, errors.New("Content Length Larger Than Message")
+/** Split the header of a message into its lines in a single pass, for
+ * parseMessageHeaders. The lines are substrings of the message unless
+ * they have a CR in the middle or continuation lines. It returns false
+ * if the header is not terminated by an empty line or starts with a
+ * continuation line, which cookMessage handles.
+ */
+func (this *StringMsgParser) splitHeaders(messageString string) bool {
+	this.messageHeaders = this.messageHeaders[:0]
+	this.lineNumbers = this.lineNumbers[:0]
+	this.lineOffsets = this.lineOffsets[:0]
+	for ptr, lineNumber := 0, 1; ptr < len(messageString); lineNumber++ {
+		offset := ptr
+		var line string
+		if end := strings.IndexByte(messageString[ptr:], '\n'); end >= 0 {
+			line = messageString[ptr : ptr+end]
+			ptr += end + 1
+		} else {
+			line = messageString[ptr:]
+			ptr = len(messageString)
+		}
+		if strings.IndexByte(line, '\r') >= 0 {
+			line = strings.Replace(line, "\r", "", -1)
 		}
 
-		body := this.GetBodyAsBytes()
-		sipmsg.SetMessageContentFromByte(body)
+		if strings.TrimSpace(line) == "" {
+			// The end of the header.
+			return len(this.messageHeaders) > 0
+		} else if line[0] == ' ' || line[0] == '\t' {
+			// A continuation line: the line terminator and the first
+			// white space are dropped, as cookMessage does.
+			if len(this.messageHeaders) == 0 {
+				return false
+			}
+			this.messageHeaders[len(this.messageHeaders)-1] += line[1:]
+		} else {
+			this.messageHeaders = append(this.messageHeaders, line)
+			this.lineNumbers = append(this.lineNumbers, lineNumber)
+			this.lineOffsets = append(this.lineOffsets, offset)
+		}
 	}
-
-	return sipmsg, nil
+	return false
 }
 
 /**
  * Parse a buffer containing one or more SIP Messages  and return an array of
- * SIPMessage parsed structures. Note that the current limitation is that
- * this does not handle content encoding properly. The message content is
- * just assumed to be encoded using the same encoding as the sip message
- * itself (i.e. binary encodings such as gzip are not supported).
+ * SIPMessage parsed structures. A body with a gzip or deflate
+ * Content-Encoding is decoded, the received body being available from
+ * GetRawContent; other content encodings are not supported.
  * @param sipMessages a String containing the messages to be parsed.
  *   This can consist of multiple SIP Messages concatenated toGether.
  * @return a SIPMessage structure (request or response)
@@ -314,19 +470,32 @@ func (this *StringMsgParser) ParseSIPMessage(sipMessage string) (message.Message
 	return this.ParseSIPMessageFromByte([]byte(sipMessage))
 }
 
+/** Parse the JSON form of a message, e.g. of SIPRequest.MarshalJSON, to
+ * an equivalent message.
+ * @see message.SIPMessageJSON
+ */
+func (this *StringMsgParser) ParseJSONMessage(data []byte) (message.Message, error) {
+	messageJSON := &message.SIPMessageJSON{}
+	if err := json.Unmarshal(data, messageJSON); err != nil {
+		return nil, core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1)
+	}
+	msgBuffer, err := messageJSON.EncodeAsBytes()
+	if err != nil {
+		return nil, err
+	}
+	return this.ParseSIPMessageFromByte(msgBuffer)
+}
+
 /** This is called repeatedly by parseSIPMessage to parse
  * the contents of a message buffer. This assumes the message
  * already has continuations etc. taken care of.
  * prior to its being called.
  */
 func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Message, error) {
-	var err error
-
-	sip_message_size := 0 // # of lines in the sip message
-	var sipmsg message.Message
-
 	tokenizer := core.NewStringTokenizer(currentMessage)
-	this.messageHeaders = make(map[int]string) // A list of headers for error reporting
+	this.messageHeaders = this.messageHeaders[:0] // A list of headers for error reporting
+	this.lineNumbers = this.lineNumbers[:0]
+	this.lineOffsets = this.lineOffsets[:0]
 
 	for tokenizer.HasMoreChars() {
 		nexttok := tokenizer.NextToken()
@@ -335,22 +504,46 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 			if nextnexttok == "\n" {
 				break
 			} else {
-				this.messageHeaders[sip_message_size] = nextnexttok
+				this.messageHeaders = append(this.messageHeaders, strings.TrimSuffix(nextnexttok, "\n"))
 			}
 		} else {
-			this.messageHeaders[sip_message_size] = nexttok
+			this.messageHeaders = append(this.messageHeaders, strings.TrimSuffix(nexttok, "\n"))
 		}
+	}
+
+	return this.parseMessageHeaders(currentMessage)
+}
 
-		sip_message_size++
+/** Parse the start line and the headers of a message, split into lines
+ * in messageHeaders. In lazy mode, only the headers the stack needs are
+ * parsed here.
+ */
+func (this *StringMsgParser) parseMessageHeaders(currentMessage string) (message.Message, error) {
+	var err error
+	var sipmsg message.Message
+
+	if this.limits != nil {
+		if i, column, err := this.limits.checkHeaders(this.messageHeaders); err != nil {
+			headerName := ""
+			if i > 0 {
+				headerName, _ = getHeaderName(this.messageHeaders[i])
+			}
+			return nil, this.locateError(err, headerName, i, column)
+		}
 	}
 
 	this.currentLine = 0
-	this.currentHeader = this.messageHeaders[this.currentLine]
+	this.currentHeader = ""
+	if len(this.messageHeaders) > 0 {
+		this.currentHeader = this.messageHeaders[this.currentLine] + "\n"
+	}
 	firstLine := this.currentHeader
 	if !strings.HasPrefix(firstLine, header.SIPConstants_SIP_VERSION_STRING) {
 		sipmsg = message.NewSIPRequest()
 		var rl *header.RequestLine
-		if rl, err = NewRequestLineParser(firstLine + "\n").Parse(); err != nil {
+		rlParser := NewRequestLineParser(firstLine + "\n")
+		if rl, err = rlParser.Parse(); err != nil {
+			err = this.locateError(err, "", 0, getErrorColumn(rlParser, this.messageHeaders[0]))
 			if this.parseExceptionListener != nil {
 				if err = this.parseExceptionListener.HandleException(err, sipmsg, firstLine, currentMessage); err != nil {
 					return nil, err
@@ -360,10 +553,21 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 			}
 		}
 		sipmsg.(*message.SIPRequest).SetRequestLine(rl)
+		if rl != nil {
+			if column, err := checkRequestLine(this.messageHeaders[0], rl); err != nil {
+				err = this.locateError(err, "", 0, column)
+				if !this.lenientParsing {
+					return nil, err
+				}
+				sipmsg.(*message.SIPRequest).AddParseDiagnostic(message.NewParseDiagnostic(1, column, this.messageHeaders[0], err))
+			}
+		}
 	} else {
 		sipmsg = message.NewSIPResponse()
 		var sl *header.StatusLine
-		if sl, err = NewStatusLineParser(firstLine + "\n").Parse(); err != nil {
+		slParser := NewStatusLineParser(firstLine + "\n")
+		if sl, err = slParser.Parse(); err != nil {
+			err = this.locateError(err, "", 0, getErrorColumn(slParser, this.messageHeaders[0]))
 			if this.parseExceptionListener != nil {
 				if err = this.parseExceptionListener.HandleException(err, sipmsg, firstLine, currentMessage); err != nil {
 					return nil, err
@@ -381,12 +585,27 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 			continue
 		}
 
+		headerName, essential := getHeaderName(hdrstring)
+		if this.lazyParsing && headerName != "" && !essential {
+			if request, ok := sipmsg.(*message.SIPRequest); ok {
+				request.AttachLazyHeader(headerName, hdrstring, parseHeaderLine)
+			} else {
+				sipmsg.(*message.SIPResponse).AttachLazyHeader(headerName, hdrstring, parseHeaderLine)
+			}
+			continue
+		}
+
+		hdrstring += "\n"
 		var hdrParser Parser
 		if hdrParser, err = CreateParser(hdrstring + "\n"); err != nil {
+			err = this.locateError(err, headerName, i, 1)
 			if this.parseExceptionListener != nil {
 				if err = this.parseExceptionListener.HandleException(err, sipmsg, hdrstring, currentMessage); err != nil {
 					return nil, err
 				}
+			} else if this.lenientParsing && !essential {
+				this.attachBadHeader(sipmsg, i, err)
+				continue
 			} else {
 				return nil, err
 			}
@@ -394,10 +613,14 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 
 		var sipHeader header.Header
 		if sipHeader, err = hdrParser.Parse(); err != nil {
+			err = this.locateError(err, headerName, i, getErrorColumn(hdrParser, this.messageHeaders[i]))
 			if this.parseExceptionListener != nil {
 				if err = this.parseExceptionListener.HandleException(err, sipmsg, hdrstring, currentMessage); err != nil {
 					return nil, err
 				}
+			} else if this.lenientParsing && !essential {
+				this.attachBadHeader(sipmsg, i, err)
+				continue
 			} else {
 				return nil, err
 			}
@@ -406,7 +629,8 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 		if _, ok := sipmsg.(*message.SIPRequest); ok {
 			if cseq, present := sipHeader.(*header.CSeq); present {
 				if cseq.GetMethod() != sipmsg.(*message.SIPRequest).GetMethod() {
-					return nil, errors.New("Start Line and CSeq Method Mismatch")
+					err = core.NewParseError(core.ParseErrorKind_METHOD_MISMATCH, "Start Line and CSeq Method Mismatch", -1)
+					return nil, this.locateError(err, core.SIPHeaderNames_CSEQ, i, 1)
 				}
 			}
 			sipmsg.(*message.SIPRequest).AttachHeader2(sipHeader, false)
@@ -417,6 +641,132 @@ func (this *StringMsgParser) ParseMessage(currentMessage string) (message.Messag
 	return sipmsg, nil
 }
 
+/** The headers the stack needs to route and match a message: they are
+ * parsed with the message in lazy mode, and must parse in lenient mode.
+ */
+var essentialHeaders = map[string]bool{
+	core.SIPHeaderNames_VIA:            true,
+	core.SIPHeaderNames_FROM:           true,
+	core.SIPHeaderNames_TO:             true,
+	core.SIPHeaderNames_CSEQ:           true,
+	core.SIPHeaderNames_CALL_ID:        true,
+	core.SIPHeaderNames_CONTENT_LENGTH: true,
+	core.SIPHeaderNames_MAX_FORWARDS:   true,
+}
+
+/** Get the name of the header of a line, or "" if it has none, and
+ * whether it is an essential header. A compact name is expanded to the
+ * full name of the header.
+ */
+func getHeaderName(line string) (headerName string, essential bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon < 0 {
+		return "", false
+	}
+	headerName = strings.TrimSpace(line[:colon])
+	if headerName == "" {
+		return "", false
+	}
+	if registration := parserRegistry.lookup(headerName); registration != nil {
+		headerName = registration.name
+	}
+	return headerName, essentialHeaders[headerName]
+}
+
+/** Keep a header line that does not parse, in lenient mode, and record
+ * the error.
+ *@param i -- the index of the line in messageHeaders.
+ *@param err -- the error, located by locateError.
+ */
+func (this *StringMsgParser) attachBadHeader(sipmsg message.Message, i int, err error) {
+	line := this.messageHeaders[i]
+	parseError := err.(*core.ParseError)
+	diagnostic := message.NewParseDiagnostic(parseError.GetLine(), parseError.GetColumn(), line, err)
+	if request, ok := sipmsg.(*message.SIPRequest); ok {
+		request.AttachUnparsedHeader(line)
+		request.AddParseDiagnostic(diagnostic)
+	} else {
+		sipmsg.(*message.SIPResponse).AttachUnparsedHeader(line)
+		sipmsg.(*message.SIPResponse).AddParseDiagnostic(diagnostic)
+	}
+}
+
+/** Locate an error of a line of the message: return a ParseError with
+ * the name of the header, the line and the column of the error and its
+ * offset in the message. The offset is only known for the messages split
+ * by splitHeaders, and is that of the column in the line of the header
+ * if the header is folded.
+ *@param i -- the index of the line in messageHeaders.
+ *@param column -- the column of the error in the line, from 1.
+ */
+func (this *StringMsgParser) locateError(err error, headerName string, i, column int) error {
+	lineNumber, offset := i+1, -1
+	if i < len(this.lineNumbers) {
+		lineNumber = this.lineNumbers[i]
+	}
+	if i < len(this.lineOffsets) {
+		offset = this.lineOffsets[i] + column - 1
+	}
+	return core.AsParseError(err, core.ParseErrorKind_BAD_SYNTAX, -1).At(headerName, lineNumber, column, offset)
+}
+
+/** Get the index in messageHeaders of the first line of a header, or 0,
+ * the start line, if there is none.
+ */
+func (this *StringMsgParser) findHeader(headerName string) int {
+	for i := 1; i < len(this.messageHeaders); i++ {
+		if name, _ := getHeaderName(this.messageHeaders[i]); name == headerName {
+			return i
+		}
+	}
+	return 0
+}
+
+/** Get the column where a header parser stopped on an error, from 1.
+ */
+func getErrorColumn(hdrParser interface{}, line string) int {
+	column := 1
+	if p, ok := hdrParser.(interface{ GetLexer() core.Lexer }); ok {
+		column = p.GetLexer().GetPtr() + 1
+	}
+	if column > len(line) {
+		column = len(line)
+	}
+	return column
+}
+
+/** Check the request line of a message against the grammar of RFC 3261,
+ * which the request line parser does not enforce: the elements are
+ * separated by a single SP, and the Request-URI has no headers (RFC 4475
+ * sections 3.1.2.9 to 3.1.2.11).
+ *@return the column of the error, from 1.
+ */
+func checkRequestLine(line string, rl *header.RequestLine) (column int, ParseException error) {
+	for i, spaces := 0, 0; i < len(line); i++ {
+		if line[i] == '\t' || line[i] == ' ' && (i == 0 || i == len(line)-1 || line[i-1] == ' ' || spaces == 2) {
+			return i + 1, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+line+":extra white space in the request line", i)
+		}
+		if line[i] == ' ' {
+			spaces++
+		}
+	}
+	if uri, ok := rl.GetUri().(*address.SipURIImpl); ok && uri.GetHeaderNames() != nil && uri.GetHeaderNames().Len() > 0 {
+		column = strings.IndexByte(line, '?') + 1
+		return column, core.NewParseError(core.ParseErrorKind_BAD_SYNTAX, "ParseException: "+line+":headers in the Request-URI", column-1)
+	}
+	return 0, nil
+}
+
+/** Parse a header line of a message parsed in lazy mode.
+ */
+func parseHeaderLine(line string) (header.Header, error) {
+	hdrParser, err := CreateParser(line + "\n\n")
+	if err != nil {
+		return nil, err
+	}
+	return hdrParser.Parse()
+}
+
 /**
  * Parse an address (nameaddr or address spec)  and return and address
  * structure.
@@ -1,8 +1,17 @@
 package parser
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
 )
 
 func TestStringMsgParser(t *testing.T) {
@@ -176,3 +185,527 @@ func TestStringMsgParser(t *testing.T) {
 
 
     }*/
+
+/** A request as received by an edge proxy. */
+const benchmarkInvite = "INVITE sip:bob@biloxi.example.com SIP/2.0\r\n" +
+	"Via: SIP/2.0/TCP client.atlanta.example.com:5060;branch=z9hG4bK74bf9\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"Route: <sip:p1.example.com;lr>, <sip:p2.example.com;lr>\r\n" +
+	"From: Alice <sip:alice@atlanta.example.com>;tag=9fxced76sl\r\n" +
+	"To: Bob <sip:bob@biloxi.example.com>\r\n" +
+	"Call-ID: 3848276298220188511@atlanta.example.com\r\n" +
+	"CSeq: 1 INVITE\r\n" +
+	"Contact: <sip:alice@client.atlanta.example.com;transport=tcp>\r\n" +
+	"Allow: INVITE, ACK, CANCEL, OPTIONS, BYE, REFER, NOTIFY\r\n" +
+	"Supported: replaces, timer\r\n" +
+	"User-Agent: SoftPhone/1.0\r\n" +
+	"P-Asserted-Identity: <sip:alice@atlanta.example.com>\r\n" +
+	"X-Vendor-Trace: 1234567890abcdef\r\n" +
+	"Content-Type: application/sdp\r\n" +
+	"Content-Length: 151\r\n" +
+	"\r\n" +
+	"v=0\r\n" +
+	"o=alice 2890844526 2890844526 IN IP4 client.atlanta.example.com\r\n" +
+	"s=-\r\n" +
+	"c=IN IP4 192.0.2.101\r\n" +
+	"t=0 0\r\n" +
+	"m=audio 49172 RTP/AVP 0\r\n" +
+	"a=rtpmap:0 PCMU/8000\r\n"
+
+func BenchmarkParseSIPMessage(b *testing.B) {
+	msgBuffer := []byte(benchmarkInvite)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewStringMsgParser().ParseSIPMessageFromByte(msgBuffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSIPMessageLazy(b *testing.B) {
+	msgBuffer := []byte(benchmarkInvite)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		smp := NewStringMsgParser()
+		smp.SetLazyParsing(true)
+		if _, err := smp.ParseSIPMessageFromByte(msgBuffer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStringMsgParserLazy(t *testing.T) {
+	msg := strings.Replace(benchmarkInvite, "Supported:", "Expires: soon\r\nSupported:", 1)
+
+	smp := NewStringMsgParser()
+	smp.SetLazyParsing(true)
+	sipmsg, err := smp.ParseSIPMessageFromByte([]byte(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := sipmsg.(*message.SIPRequest)
+
+	// The headers the stack needs are parsed with the message.
+	if request.GetCSeq().GetSequenceNumber() != 1 || request.GetFrom().GetTag() != "9fxced76sl" {
+		t.Fatal("CSeq or From not parsed")
+	}
+
+	// An untouched header is encoded as it was received.
+	if encoded := request.String(); !strings.Contains(encoded, "Route: <sip:p1.example.com;lr>, <sip:p2.example.com;lr>\r\n") {
+		t.Fatalf("Route not encoded as received:\n%s", encoded)
+	}
+
+	// A header is parsed on its first access.
+	if contact, ok := request.GetHeader("Contact").(*header.Contact); !ok || contact.GetAddress().GetURI().String() != "sip:alice@client.atlanta.example.com;transport=tcp" {
+		t.Fatalf("Contact is %v", request.GetHeader("Contact"))
+	}
+
+	// An unknown header stays an extension header.
+	if vendor, ok := request.GetHeader("X-Vendor-Trace").(*header.Extension); !ok || vendor.GetValue() != "1234567890abcdef" {
+		t.Fatalf("X-Vendor-Trace is %v", request.GetHeader("X-Vendor-Trace"))
+	}
+
+	// A header that does not parse is kept as an unrecognized header.
+	if _, ok := request.GetHeader("Expires").(*header.Expires); ok {
+		t.Fatal("bad Expires parsed")
+	}
+	if request.GetUnrecognizedHeaders() == nil || request.GetUnrecognizedHeaders().Len() != 1 {
+		t.Fatal("bad Expires not unrecognized")
+	}
+
+	// The message encodes the same as in eager mode.
+	eager, err := NewStringMsgParser().ParseSIPMessageFromByte([]byte(benchmarkInvite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	smp = NewStringMsgParser()
+	smp.SetLazyParsing(true)
+	lazy, err := smp.ParseSIPMessageFromByte([]byte(benchmarkInvite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy.(*message.SIPRequest).GetHeaderNames()
+	if lazy.String() != eager.String() {
+		t.Fatalf("lazy:\n%s\neager:\n%s", lazy.String(), eager.String())
+	}
+}
+
+func TestStringMsgParserLazyBadList(t *testing.T) {
+	msg := strings.NewReplacer(
+		"Route: <sip:p1.example.com;lr>, <sip:p2.example.com;lr>\r\n", "Route: <<<garbage\r\n"+
+			"Record-Route: <sip:p1.example.com;lr>\r\nRecord-Route: <<<garbage\r\n",
+		"Contact: <sip:alice@client.atlanta.example.com;transport=tcp>", "Contact: <<<garbage").Replace(benchmarkInvite)
+
+	smp := NewStringMsgParser()
+	smp.SetLazyParsing(true)
+	sipmsg, err := smp.ParseSIPMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := sipmsg.(*message.SIPRequest)
+
+	// A list header none of whose lines parse is absent.
+	if request.HasHeader("Route") || request.GetRouteHeaders() != nil || request.GetContactHeaders() != nil {
+		t.Fatal("bad Route or Contact found")
+	}
+	// The lines that parse are kept.
+	if rr := request.GetRecordRouteHeaders(); rr == nil || rr.Len() != 1 {
+		t.Fatalf("Record-Route is %v", rr)
+	}
+	if request.GetUnrecognizedHeaders().Len() != 3 {
+		t.Fatalf("%d unrecognized headers", request.GetUnrecognizedHeaders().Len())
+	}
+	// The bad lines are forwarded as they were received.
+	encoded := request.String()
+	for _, line := range []string{"\r\nRoute: <<<garbage\r\n", "\r\nRecord-Route: <<<garbage\r\n", "\r\nContact: <<<garbage\r\n"} {
+		if !strings.Contains(encoded, line) {
+			t.Fatalf("%q not forwarded:\n%s", strings.TrimSpace(line), encoded)
+		}
+	}
+}
+
+func TestStringMsgParserLenient(t *testing.T) {
+	msg := strings.Replace(benchmarkInvite, "Supported:", "Expires: soon\r\nSupported:", 1)
+
+	if _, err := NewStringMsgParser().ParseSIPMessage(msg); err == nil {
+		t.Fatal("bad Expires accepted in strict mode")
+	}
+
+	smp := NewStringMsgParser()
+	smp.SetLenientParsing(true)
+	sipmsg, err := smp.ParseSIPMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := sipmsg.(*message.SIPRequest)
+
+	// The bad header is recorded and forwarded as it was received.
+	diagnostics := request.GetParseDiagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].GetLine() != 11 || diagnostics[0].GetText() != "Expires: soon" {
+		t.Fatalf("diagnostics %v", diagnostics)
+	}
+	if !strings.Contains(request.String(), "\r\nExpires: soon\r\n") {
+		t.Fatalf("Expires not forwarded:\n%s", request.String())
+	}
+	if request.GetUnrecognizedHeaders().Len() != 1 {
+		t.Fatal("Expires not unrecognized")
+	}
+
+	// A bad list header does not get in the way of the list accessors.
+	msg = strings.NewReplacer(
+		"Route: <sip:p1.example.com;lr>, <sip:p2.example.com;lr>\r\n", "Route: <<<garbage\r\n"+
+			"Record-Route: <sip:p1.example.com;lr>\r\nRecord-Route: <<<garbage\r\n",
+		"Contact: <sip:alice@client.atlanta.example.com;transport=tcp>", "Contact: <<<garbage").Replace(benchmarkInvite)
+	if sipmsg, err = smp.ParseSIPMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	request = sipmsg.(*message.SIPRequest)
+	if len(request.GetParseDiagnostics()) != 3 {
+		t.Fatalf("diagnostics %v", request.GetParseDiagnostics())
+	}
+	if request.HasHeader("Route") || request.GetRouteHeaders() != nil || request.GetContactHeaders() != nil {
+		t.Fatal("bad Route or Contact found")
+	}
+	if rr := request.GetRecordRouteHeaders(); rr == nil || rr.Len() != 1 {
+		t.Fatalf("Record-Route is %v", rr)
+	}
+	if !strings.Contains(request.String(), "\r\nRoute: <<<garbage\r\n") {
+		t.Fatalf("Route not forwarded:\n%s", request.String())
+	}
+
+	// The headers the stack needs must still parse.
+	msg = strings.Replace(benchmarkInvite, "CSeq: 1 INVITE", "CSeq: one INVITE", 1)
+	if _, err := smp.ParseSIPMessage(msg); err == nil {
+		t.Fatal("bad CSeq accepted in lenient mode")
+	}
+}
+
+func TestStringMsgParserParseError(t *testing.T) {
+	var parseError *core.ParseError
+
+	// A header parser gives the offset of the error in the header.
+	_, err := NewExpiresParser("Expires: soon\n").Parse()
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_BAD_SYNTAX || parseError.GetOffset() < 0 {
+		t.Fatalf("Expires: %v", err)
+	}
+
+	// The message parser locates the error in the message.
+	msg := strings.Replace(benchmarkInvite, "Supported:", "Expires: soon\r\nSupported:", 1)
+	_, err = NewStringMsgParser().ParseSIPMessage(msg)
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_BAD_SYNTAX ||
+		parseError.GetHeaderName() != core.SIPHeaderNames_EXPIRES || parseError.GetLine() != 11 ||
+		parseError.GetOffset() != strings.Index(msg, "Expires: soon")+parseError.GetColumn()-1 {
+		t.Fatalf("bad Expires: %v", err)
+	}
+
+	// In lenient mode, the diagnostics hold the same errors.
+	smp := NewStringMsgParser()
+	smp.SetLenientParsing(true)
+	sipmsg, err := smp.ParseSIPMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := sipmsg.(*message.SIPRequest).GetParseDiagnostics()
+	if len(diagnostics) != 1 || !errors.As(diagnostics[0].GetError(), &parseError) ||
+		parseError.GetLine() != diagnostics[0].GetLine() || parseError.GetColumn() != diagnostics[0].GetColumn() {
+		t.Fatalf("diagnostics %v", diagnostics)
+	}
+
+	msg = strings.Replace(benchmarkInvite, "CSeq: 1 INVITE", "CSeq: 1 BYE", 1)
+	_, err = NewStringMsgParser().ParseSIPMessage(msg)
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_METHOD_MISMATCH ||
+		parseError.GetHeaderName() != core.SIPHeaderNames_CSEQ || parseError.GetLine() != 8 ||
+		parseError.GetOffset() != strings.Index(msg, "CSeq:") {
+		t.Fatalf("CSeq mismatch: %v", err)
+	}
+
+	msg = benchmarkInvite[:len(benchmarkInvite)-10]
+	_, err = NewStringMsgParser().ParseSIPMessage(msg)
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_CONTENT_LENGTH_OVERFLOW ||
+		parseError.GetHeaderName() != core.SIPHeaderNames_CONTENT_LENGTH || parseError.GetLine() != 16 ||
+		parseError.GetOffset() != strings.Index(msg, "Content-Length:") {
+		t.Fatalf("truncated body: %v", err)
+	}
+
+	// The checks of the headers of a message.
+	msg = strings.Replace(benchmarkInvite, "Max-Forwards: 70\r\n", "", 1)
+	if sipmsg, err = NewStringMsgParser().ParseSIPMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+	err = sipmsg.(*message.SIPRequest).CheckHeaders()
+	if !errors.As(err, &parseError) || parseError.GetKind() != core.ParseErrorKind_MISSING_HEADER ||
+		parseError.GetHeaderName() != core.SIPHeaderNames_MAX_FORWARDS {
+		t.Fatalf("missing Max-Forwards: %v", err)
+	}
+}
+
+func TestStringMsgParserMultipart(t *testing.T) {
+	isup := "\x01\x00\x49\x00\x00\x03\x02\x00\x07\x04\x10\x00\x33\x63\x21\x43\x00"
+	sdp := "v=0\r\no=alice 1 1 IN IP4 192.0.2.101\r\ns=-\r\nc=IN IP4 192.0.2.101\r\nt=0 0\r\nm=audio 49172 RTP/AVP 0\r\n"
+	body := "--unique-boundary-1\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"\r\n" +
+		sdp +
+		"\r\n--unique-boundary-1\r\n" +
+		"Content-Type: application/ISUP; version=nxv3; base=etsi121\r\n" +
+		"Content-Disposition: signal; handling=optional\r\n" +
+		"Content-ID: <isup@atlanta.example.com>\r\n" +
+		"\r\n" +
+		isup +
+		"\r\n--unique-boundary-1--\r\n"
+	msg := benchmarkInvite[:strings.Index(benchmarkInvite, "Content-Type:")] +
+		"Content-Type: multipart/mixed; boundary=unique-boundary-1\r\n" +
+		"Content-Length: " + strconv.Itoa(len(body)) + "\r\n" +
+		"\r\n" + body
+
+	sipmsg, err := NewStringMsgParser().ParseSIPMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := sipmsg.(*message.SIPRequest)
+	multipart, err := request.GetMultipartBody()
+	if err != nil || multipart == nil || len(multipart.GetParts()) != 2 || multipart.GetSubType() != "mixed" {
+		t.Fatalf("multipart body %v %v", multipart, err)
+	}
+	part := multipart.GetPart("application", "isup")
+	if part == nil || string(part.GetContent()) != isup || part.GetContentID() != "<isup@atlanta.example.com>" ||
+		part.GetContentDisposition() == nil || part.GetContentDisposition().GetHandling() != "optional" ||
+		part.GetContentType().GetParameter("version") != "nxv3" {
+		t.Fatalf("ISUP part %v", part)
+	}
+	if sdpPart, err := request.GetSDPPart(); err != nil || string(sdpPart) != sdp {
+		t.Fatalf("SDP part %q %v", sdpPart, err)
+	}
+
+	// Replace the SDP: the ISUP part is kept.
+	answer := strings.Replace(sdp, "49172", "3456", 1)
+	if err := request.SetSDPPart([]byte(answer)); err != nil {
+		t.Fatal(err)
+	}
+	if sipmsg, err = NewStringMsgParser().ParseSIPMessage(request.String()); err != nil {
+		t.Fatal(err)
+	}
+	request = sipmsg.(*message.SIPRequest)
+	if sdpPart, err := request.GetSDPPart(); err != nil || string(sdpPart) != answer {
+		t.Fatalf("new SDP part %q %v", sdpPart, err)
+	}
+	if multipart, _ = request.GetMultipartBody(); multipart.GetPart("application", "isup") == nil ||
+		string(multipart.GetPart("application", "isup").GetContent()) != isup {
+		t.Fatal("ISUP part lost")
+	}
+
+	// Build a body with a location.
+	pidf := "<?xml version=\"1.0\"?><presence xmlns=\"urn:ietf:params:xml:ns:pidf\" entity=\"pres:alice@atlanta.example.com\"/>"
+	multipart = message.NewMultipartBody("mixed")
+	multipart.AddPart(message.NewBodyPart(header.NewContentTypeFromString("application", "sdp"), []byte(sdp)))
+	location := message.NewBodyPart(header.NewContentTypeFromString("application", "pidf+xml"), []byte(pidf))
+	location.SetContentID("<alice@atlanta.example.com>")
+	multipart.AddPart(location)
+	if err = multipart.SetBoundary("bad boundary "); err == nil {
+		t.Fatal("boundary ending with a space accepted")
+	}
+	if err = multipart.SetBoundary(strings.Repeat("b", 71)); err == nil {
+		t.Fatal("boundary of 71 characters accepted")
+	}
+	if err = multipart.SetBoundary("simple boundary"); err != nil || multipart.GetBoundary() != "simple boundary" {
+		t.Fatalf("boundary not set: %v", err)
+	}
+	if err = request.SetMultipartBody(multipart); err != nil {
+		t.Fatal(err)
+	}
+	if sipmsg, err = NewStringMsgParser().ParseSIPMessage(request.String()); err != nil {
+		t.Fatal(err)
+	}
+	request = sipmsg.(*message.SIPRequest)
+	if multipart, err = request.GetMultipartBody(); err != nil || len(multipart.GetParts()) != 2 ||
+		string(multipart.GetPart("application", "pidf+xml").GetContent()) != pidf ||
+		multipart.GetPart("application", "pidf+xml").GetContentID() != "<alice@atlanta.example.com>" {
+		t.Fatalf("PIDF-LO body %v %v", multipart, err)
+	}
+
+	var parseError *core.ParseError
+	if _, err = message.ParseMultipartBody(header.NewContentTypeFromString("multipart", "mixed"), []byte(body)); !errors.As(err, &parseError) ||
+		parseError.GetKind() != core.ParseErrorKind_BAD_SYNTAX {
+		t.Fatalf("multipart body without a boundary: %v", err)
+	}
+	contentType := header.NewContentTypeFromString("multipart", "mixed")
+	contentType.SetQuotedParameter("boundary", "bad boundary ")
+	if _, err = message.ParseMultipartBody(contentType, []byte(body)); !errors.As(err, &parseError) {
+		t.Fatalf("multipart body with a bad boundary: %v", err)
+	}
+}
+
+func TestStringMsgParserContentEncoding(t *testing.T) {
+	pidf := "<?xml version=\"1.0\"?>\r\n<presence xmlns=\"urn:ietf:params:xml:ns:pidf\" entity=\"pres:alice@atlanta.example.com\">" +
+		strings.Repeat("<tuple id=\"t\"><status><basic>open</basic></status></tuple>", 20) + "</presence>\r\n"
+	var gz bytes.Buffer
+	writer := gzip.NewWriter(&gz)
+	writer.Write([]byte(pidf))
+	writer.Close()
+	head := benchmarkInvite[:strings.Index(benchmarkInvite, "Content-Type:")] +
+		"Content-Type: application/pidf+xml\r\n" +
+		"Content-Encoding: gzip\r\n"
+	msg := head + "Content-Length: " + strconv.Itoa(gz.Len()) + "\r\n\r\n" + gz.String()
+
+	sipmsg, err := NewStringMsgParser().ParseSIPMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := sipmsg.(*message.SIPRequest)
+	if request.GetContent() != pidf || !bytes.Equal(request.GetRawContent(), gz.Bytes()) {
+		t.Fatalf("content %q", request.GetContent())
+	}
+	// The message is forwarded as it was received.
+	if !strings.HasSuffix(request.String(), "\r\n\r\n"+gz.String()) {
+		t.Fatal("encoded body not forwarded")
+	}
+
+	// A body that does not decode.
+	bad := head + "Content-Length: 10\r\n\r\n0123456789"
+	var parseError *core.ParseError
+	if _, err = NewStringMsgParser().ParseSIPMessage(bad); !errors.As(err, &parseError) ||
+		parseError.GetHeaderName() != core.SIPHeaderNames_CONTENT_ENCODING || parseError.GetLine() != 16 {
+		t.Fatalf("bad gzip body: %v", err)
+	}
+	smp := NewStringMsgParser()
+	smp.SetLenientParsing(true)
+	if sipmsg, err = smp.ParseSIPMessage(bad); err != nil {
+		t.Fatal(err)
+	}
+	if request := sipmsg.(*message.SIPRequest); request.GetContent() != "0123456789" || len(request.GetParseDiagnostics()) != 1 {
+		t.Fatalf("bad gzip body in lenient mode %q", request.GetContent())
+	}
+
+	// A body that expands beyond the limits fails even in lenient mode.
+	var bomb bytes.Buffer
+	writer = gzip.NewWriter(&bomb)
+	writer.Write(make([]byte, 1<<20))
+	writer.Close()
+	msg = head + "Content-Length: " + strconv.Itoa(bomb.Len()) + "\r\n\r\n" + bomb.String()
+	if _, err = smp.ParseSIPMessage(msg); !errors.As(err, &parseError) ||
+		parseError.GetKind() != core.ParseErrorKind_BODY_TOO_LARGE || parseError.GetLine() != 16 {
+		t.Fatalf("gzip bomb: %v", err)
+	}
+	limits := NewParserLimits()
+	limits.SetMaxBodySize(len(pidf) - 1)
+	smp.SetLimits(limits)
+	if _, err = smp.ParseSIPMessage(head + "Content-Length: " + strconv.Itoa(gz.Len()) + "\r\n\r\n" + gz.String()); !errors.As(err, &parseError) ||
+		parseError.GetKind() != core.ParseErrorKind_BODY_TOO_LARGE {
+		t.Fatalf("decoded body beyond the limit: %v", err)
+	}
+	limits.SetMaxBodySize(len(pidf))
+	if sipmsg, err = smp.ParseSIPMessage(head + "Content-Length: " + strconv.Itoa(gz.Len()) + "\r\n\r\n" + gz.String()); err != nil ||
+		sipmsg.(*message.SIPRequest).GetContent() != pidf {
+		t.Fatalf("decoded body at the limit: %v", err)
+	}
+	smp.SetLimits(nil)
+	stacked := strings.Replace(msg, "Content-Encoding: gzip", "Content-Encoding: gzip, gzip, gzip", 1)
+	if _, err = smp.ParseSIPMessage(stacked); !errors.As(err, &parseError) ||
+		parseError.GetKind() != core.ParseErrorKind_LIMIT_EXCEEDED {
+		t.Fatalf("stacked content codings: %v", err)
+	}
+
+	// Compress a body for a peer that accepts it.
+	peer, err := NewStringMsgParser().ParseSIPMessage(strings.Replace(benchmarkInvite, "Supported:", "Accept-Encoding: deflate;q=0.5, gzip\r\nSupported:", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sipmsg, err = NewStringMsgParser().ParseSIPMessage(benchmarkInvite); err != nil {
+		t.Fatal(err)
+	}
+	request = sipmsg.(*message.SIPRequest)
+	request.SetContent(pidf, header.NewContentTypeFromString("application", "pidf+xml"))
+	if coding, err := request.CompressContentFor(peer, 4096); coding != "" || err != nil {
+		t.Fatalf("small body compressed with %s %v", coding, err)
+	}
+	if coding, err := request.CompressContentFor(peer, 100); coding != message.ContentCoding_GZIP || err != nil {
+		t.Fatalf("body compressed with %q %v", coding, err)
+	}
+	if len(request.GetRawContent()) >= len(pidf) || request.GetContent() != pidf {
+		t.Fatal("body not compressed")
+	}
+	if sipmsg, err = NewStringMsgParser().ParseSIPMessage(request.String()); err != nil {
+		t.Fatal(err)
+	}
+	if sipmsg.(*message.SIPRequest).GetContent() != pidf {
+		t.Fatal("compressed body does not decode")
+	}
+
+	// New content is not encoded.
+	request.SetContent("v=0\r\n", header.NewContentTypeFromString("application", "sdp"))
+	if request.HasHeader(core.SIPHeaderNames_CONTENT_ENCODING) || string(request.GetRawContent()) != "v=0\r\n" {
+		t.Fatal("Content-Encoding of the new content")
+	}
+}
+
+func TestStringMsgParserJSON(t *testing.T) {
+	response := "SIP/2.0 200 OK\r\n" +
+		"Via: SIP/2.0/UDP server10.biloxi.example.com;branch=z9hG4bK4b43c2ff8.1;received=192.0.2.3, SIP/2.0/UDP bigbox3.site3.atlanta.example.com;branch=z9hG4bK77ef4c2312983.1\r\n" +
+		"To: Bob <sip:bob@biloxi.example.com>;tag=a6c85cf\r\n" +
+		"From: Alice <sip:alice@atlanta.example.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Contact: <sip:bob@192.0.2.4>;expires=3600\r\n" +
+		"Content-Type: application/ISUP\r\n" +
+		"Content-Length: 4\r\n" +
+		"\r\n" +
+		"\x01\x00\xff\xfe"
+
+	for _, lazy := range []bool{false, true} {
+		for _, msg := range []string{benchmarkInvite, response} {
+			smp := NewStringMsgParser()
+			smp.SetLazyParsing(lazy)
+			sipmsg, err := smp.ParseSIPMessage(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(sipmsg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := smp.ParseJSONMessage(data)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, data)
+			}
+			if decoded.String() != sipmsg.String() {
+				t.Fatalf("decoded message\n%s\nis not\n%s", decoded.String(), sipmsg.String())
+			}
+		}
+	}
+
+	sipmsg, err := NewStringMsgParser().ParseSIPMessage(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messageJSON := sipmsg.(*message.SIPResponse).ToJSON()
+	if messageJSON.Type != "response" || messageJSON.StatusCode != 200 || len(messageJSON.Headers) != 8 ||
+		messageJSON.BodyEncoding != message.SIPMessageJSON_BASE64 {
+		t.Fatalf("response %+v", messageJSON)
+	}
+	if via := messageJSON.Headers[0].Via; via == nil || via.Branch != "z9hG4bK4b43c2ff8.1" || via.Received != "192.0.2.3" ||
+		via.Host != "server10.biloxi.example.com" || messageJSON.Headers[1].Via == nil {
+		t.Fatalf("Via %+v", messageJSON.Headers[0])
+	}
+	if to := messageJSON.Headers[2].Address; to == nil || to.DisplayName != "Bob" || to.URI != "sip:bob@biloxi.example.com" || to.Tag != "a6c85cf" {
+		t.Fatalf("To %+v", messageJSON.Headers[2])
+	}
+	if cseq := messageJSON.Headers[5].CSeq; cseq == nil || cseq.SequenceNumber != 314159 || cseq.Method != "INVITE" {
+		t.Fatalf("CSeq %+v", messageJSON.Headers[5])
+	}
+	if contact := messageJSON.Headers[6].Address; contact == nil || contact.Parameters["expires"] != "3600" {
+		t.Fatalf("Contact %+v", messageJSON.Headers[6])
+	}
+
+	if _, err := NewStringMsgParser().ParseJSONMessage([]byte(`{"type":"request","method":"INVITE","requestURI":"sip:bob@biloxi.example.com","headers":[{"name":"Via","value":"a\r\nX: b"}]}`)); err == nil {
+		t.Fatal("header with a line terminator accepted")
+	}
+	for _, bad := range []string{
+		`{"type":"request","method":"INVITE sip:a@b SIP/2.0\r\nX: b\r\nINVITE","requestURI":"sip:bob@biloxi.example.com"}`,
+		`{"type":"request","method":"INVITE","requestURI":"sip:bob@biloxi.example.com SIP/2.0\r\nX: b\r\nsip:a@b"}`,
+		`{"type":"response","statusCode":200,"reasonPhrase":"OK\r\nX: b"}`,
+	} {
+		if _, err := NewStringMsgParser().ParseJSONMessage([]byte(bad)); err == nil {
+			t.Fatalf("start line with a line terminator accepted: %s", bad)
+		}
+	}
+}
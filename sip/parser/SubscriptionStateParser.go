@@ -71,7 +71,7 @@ func (this *SubscriptionStateParser) Parse() (sh header.Header, ParseException e
 
 			var expires int
 			if expires, ParseException = strconv.Atoi(value); ParseException != nil {
-				return nil, ParseException
+				return nil, this.WrapParseException(ParseException)
 			}
 			subscriptionState.SetExpires(expires)
 		} else if strings.ToLower(value) == "retry-after" {
@@ -83,7 +83,7 @@ func (this *SubscriptionStateParser) Parse() (sh header.Header, ParseException e
 
 			var retryAfter int
 			if retryAfter, ParseException = strconv.Atoi(value); ParseException != nil {
-				return nil, ParseException
+				return nil, this.WrapParseException(ParseException)
 			}
 			subscriptionState.SetRetryAfter(retryAfter)
 		} else {
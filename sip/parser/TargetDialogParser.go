@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** SIPParser for the Target-Dialog header (RFC 4538).
+ */
+type TargetDialogParser struct {
+	ParametersParser
+}
+
+/** Constructor
+ * @param String Target-Dialog message to parse to set
+ */
+func NewTargetDialogParser(targetDialog string) *TargetDialogParser {
+	this := &TargetDialogParser{}
+	this.ParametersParser.super(targetDialog)
+	return this
+}
+
+func NewTargetDialogParserFromLexer(lexer core.Lexer) *TargetDialogParser {
+	this := &TargetDialogParser{}
+	this.ParametersParser.superFromLexer(lexer)
+	return this
+}
+
+/** parse the String message
+ * @return Header (TargetDialog object)
+ * @throws ParseException if the message does not respect the spec.
+ */
+func (this *TargetDialogParser) Parse() (sh header.Header, ParseException error) {
+	lexer := this.GetLexer()
+	this.HeaderName(TokenTypes_TARGET_DIALOG)
+	lexer.SPorHT()
+
+	targetDialog := header.NewTargetDialog()
+	if ParseException = targetDialog.SetCallId(strings.TrimSpace(lexer.ByteStringNoSemicolon())); ParseException != nil {
+		return nil, this.CreateParseException(ParseException.Error())
+	}
+	if ParseException = this.ParametersParser.Parse(targetDialog); ParseException != nil {
+		return nil, ParseException
+	}
+	if targetDialog.GetLocalTag() == "" || targetDialog.GetRemoteTag() == "" {
+		return nil, this.CreateParseException("missing local-tag or remote-tag")
+	}
+
+	lexer.SPorHT()
+	lexer.Match('\n')
+
+	return targetDialog, nil
+}
@@ -0,0 +1,25 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestTargetDialogParser(t *testing.T) {
+	var tvi = []string{
+		"Target-Dialog: fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544\n",
+		"Target-Dialog: 98732@sip.example.com ;remote-tag=r33th4x0r ;local-tag=ff87ff\n",
+	}
+	var tvo = []string{
+		"Target-Dialog: fa77as7dad8-sd98ajzz@host.example.com;local-tag=kkaz-;remote-tag=6544\n",
+		"Target-Dialog: 98732@sip.example.com;remote-tag=r33th4x0r;local-tag=ff87ff\n",
+	}
+
+	for i := 0; i < len(tvi); i++ {
+		shp := NewTargetDialogParser(tvi[i])
+		testHeaderParser(t, shp, tvo[i])
+	}
+
+	if _, err := NewTargetDialogParser("Target-Dialog: 425928@bobster.example.org;local-tag=7743\n").Parse(); err == nil {
+		t.Error("Target-Dialog without remote-tag accepted")
+	}
+}
@@ -23,8 +23,11 @@
 package parser
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/use-go/gosips/sip/message"
 )
 
 func TestTorture1(t *testing.T) {
@@ -62,6 +65,31 @@ func TestTorture1(t *testing.T) {
 	}
 }
 
+/** 3.1.1.11 (mpart01): the second body part is binary, with NULs, and is
+ * framed by its boundary only.
+ */
+func TestTorture1Multipart(t *testing.T) {
+	sm, err := NewStringMsgParser().ParseSIPMessage(torture1_i[10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := sm.(*message.SIPRequest).GetMultipartBody()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := body.GetParts()
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(parts))
+	}
+	if !parts[0].IsMediaType("text", "plain") || string(parts[0].GetContent()) != "Hello" {
+		t.Fatalf("bad text part %q", parts[0].GetContent())
+	}
+	if content := parts[1].GetContent(); !parts[1].IsMediaType("application", "octet-stream") ||
+		len(content) != 342 || content[0] != 0x30 || bytes.IndexByte(content, 0) < 0 {
+		t.Fatalf("bad binary part % X", content)
+	}
+}
+
 var torture1_i = []string{
 	"INVITE sip:vivekg@chair-dnrc.example.com;unknownparam SIP/2.0\r\n" +
 		"TO :\r\n" +
@@ -304,7 +332,7 @@ var torture1_i = []string{
 		"Content-Type: application/octet-stream\r\n" +
 		"Content-Transfer-Encoding: binary\r\n" +
 		"\r\n" +
-		"\x30\x82\x01\x52\x06\x09\x2A\x86\r\n" +
+		"\x30\x82\x01\x52\x06\x09\x2A\x86" +
 		"\x48\x86\xF7\x0D\x01\x07\x02\xA0\x82\x01\x43\x30\x82\x01\x3F\x02" +
 		"\x01\x01\x31\x09\x30\x07\x06\x05\x2B\x0E\x03\x02\x1A\x30\x0B\x06" +
 		"\x09\x2A\x86\x48\x86\xF7\x0D\x01\x07\x01\x31\x82\x01\x20\x30\x82" +
@@ -325,7 +353,7 @@ var torture1_i = []string{
 		"\x93\xD1\x0C\x42\x10\x2E\x7B\x72\x89\xD2\x9C\xC0\xC9\xAE\x2E\xFB" +
 		"\xC7\xC0\xCF\xF9\x17\x2F\x3B\x02\x7E\x4F\xC0\x27\xE1\x54\x6D\xE4" +
 		"\xB6\xAA\x3A\xBB\x3E\x66\xCC\xCB\x5D\xD6\xC6\x4B\x83\x83\x14\x9C" +
-		"\xB8\xE6\xFF\x18\x2D\x94\x4F\xE5\x7B\x65\xBC\x99\xD0\x05" +
+		"\xB8\xE6\xFF\x18\x2D\x94\x4F\xE5\x7B\x65\xBC\x99\xD0\x05\r\n" +
 		"--7a9cbec02ceef655--\r\n",
 
 	"SIP/2.0 200 = 2**3 * 5**2 \xD0\xBD\xD0\xBE\x20\xD1\x81\xD1\x82\xD0\xBE\x20\xD0\xB4\xD0\xB5\xD0\xB2\xD1\x8F\xD0\xBD\xD0\xBE\xD1\x81\xD1\x82\xD0\xBE\x20\xD0\xB4\xD0\xB5\xD0\xB2\xD1\x8F\xD1\x82\xD1\x8C\x20\x2D\x20\xD0\xBF\xD1\x80\xD0\xBE\xD1\x81\xD1\x82\xD0\xBE\xD0\xB5\r\n" +
@@ -565,7 +593,7 @@ var torture1_o = []string{
 		"Content-Type: application/octet-stream\r\n" +
 		"Content-Transfer-Encoding: binary\r\n" +
 		"\r\n" +
-		"\x30\x82\x01\x52\x06\x09\x2A\x86\r\n" +
+		"\x30\x82\x01\x52\x06\x09\x2A\x86" +
 		"\x48\x86\xF7\x0D\x01\x07\x02\xA0\x82\x01\x43\x30\x82\x01\x3F\x02" +
 		"\x01\x01\x31\x09\x30\x07\x06\x05\x2B\x0E\x03\x02\x1A\x30\x0B\x06" +
 		"\x09\x2A\x86\x48\x86\xF7\x0D\x01\x07\x01\x31\x82\x01\x20\x30\x82" +
@@ -586,7 +614,7 @@ var torture1_o = []string{
 		"\x93\xD1\x0C\x42\x10\x2E\x7B\x72\x89\xD2\x9C\xC0\xC9\xAE\x2E\xFB" +
 		"\xC7\xC0\xCF\xF9\x17\x2F\x3B\x02\x7E\x4F\xC0\x27\xE1\x54\x6D\xE4" +
 		"\xB6\xAA\x3A\xBB\x3E\x66\xCC\xCB\x5D\xD6\xC6\x4B\x83\x83\x14\x9C" +
-		"\xB8\xE6\xFF\x18\x2D\x94\x4F\xE5\x7B\x65\xBC\x99\xD0\x05" +
+		"\xB8\xE6\xFF\x18\x2D\x94\x4F\xE5\x7B\x65\xBC\x99\xD0\x05\r\n" +
 		"--7a9cbec02ceef655--\r\n",
 
 	"SIP/2.0 200 = 2**3 * 5**2 \xD0\xBD\xD0\xBE\x20\xD1\x81\xD1\x82\xD0\xBE\x20\xD0\xB4\xD0\xB5\xD0\xB2\xD1\x8F\xD0\xBD\xD0\xBE\xD1\x81\xD1\x82\xD0\xBE\x20\xD0\xB4\xD0\xB5\xD0\xB2\xD1\x8F\xD1\x82\xD1\x8C\x20\x2D\x20\xD0\xBF\xD1\x80\xD0\xBE\xD1\x81\xD1\x82\xD0\xBE\xD0\xB5\r\n" +
@@ -23,8 +23,11 @@
 package parser
 
 import (
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/use-go/gosips/sip/message"
 )
 
 func TestTorture2(t *testing.T) {
@@ -62,6 +65,74 @@ func TestTorture2(t *testing.T) {
 	}
 }
 
+/** The invalid messages that are accepted, by Call-ID prefix, with the
+ * line and column of their diagnostic in lenient mode. RFC 4475 allows a
+ * liberal element to accept them; badaspec is accepted in strict mode too,
+ * as the parsers ignore white space within angle brackets.
+ */
+var torture2_lenient = map[string]string{
+	"lwsstart": "1:8",
+	"trws":     "1:46",
+	"escruri":  "1:28",
+	"baddate":  "8:7",
+	"regbadct": "8:61",
+	"badaspec": "",
+}
+
+func TestTorture2Lenient(t *testing.T) {
+	for _, msg := range torture2_i {
+		callId := msg[strings.Index(msg, "Call-ID: ")+len("Call-ID: "):]
+		callId = callId[:strings.IndexByte(callId, '.')]
+		expected, accepted := torture2_lenient[callId]
+
+		if _, err := NewStringMsgParser().ParseSIPMessage(msg); (err == nil) != (accepted && expected == "") {
+			t.Errorf("%s: strict parsing returned %v", callId, err)
+		}
+
+		smp := NewStringMsgParser()
+		smp.SetLenientParsing(true)
+		sm, err := smp.ParseSIPMessage(msg)
+		if !accepted {
+			if err == nil {
+				t.Errorf("%s: accepted in lenient mode", callId)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", callId, err)
+			continue
+		}
+
+		var diagnostics []*message.ParseDiagnostic
+		if request, ok := sm.(*message.SIPRequest); ok {
+			diagnostics = request.GetParseDiagnostics()
+		}
+		var positions []string
+		for _, diagnostic := range diagnostics {
+			t.Log(diagnostic)
+			positions = append(positions, strconv.Itoa(diagnostic.GetLine())+":"+strconv.Itoa(diagnostic.GetColumn()))
+		}
+		if strings.Join(positions, ",") != expected {
+			t.Errorf("%s: diagnostics at %v, expected %s", callId, positions, expected)
+		}
+	}
+}
+
+func TestTortureLenientValid(t *testing.T) {
+	for _, tvi := range [][]string{torture1_i, torture3_i} {
+		for _, msg := range tvi {
+			smp := NewStringMsgParser()
+			smp.SetLenientParsing(true)
+			sm, err := smp.ParseSIPMessage(msg)
+			if err != nil {
+				t.Error(err)
+			} else if request, ok := sm.(*message.SIPRequest); ok && len(request.GetParseDiagnostics()) > 0 {
+				t.Errorf("diagnostics for a valid message: %v", request.GetParseDiagnostics()[0])
+			}
+		}
+	}
+}
+
 var torture2_i = []string{
 	"INVITE sip:user@example.com SIP/2.0\r\n" +
 		"To: sip:j.user@example.com\r\n" +
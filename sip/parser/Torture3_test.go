@@ -1,7 +1,7 @@
 /*%% -------------------------------------------------------------------
 %%
-%% torture_test: RFC4475 Transaction (3.2.1) and Application (3.3.1 to 3.3.15)
-%% Torture Tests
+%% torture_test: RFC4475 Transaction (3.2.1), Application (3.3.1 to 3.3.15)
+%% and Backward Compatibility (3.4.1) Torture Tests
 %%
 %% Copyright (c) 2013 Carlos Gonzalez Florido.  All Rights Reserved.
 %%
@@ -280,6 +280,22 @@ var torture3_i = []string{
 		"m=audio 49217 RTP/AVP 0 12\r\n" +
 		"m=video 3227 RTP/AVP 31\r\n" +
 		"a=rtpmap:31 LPC\r\n",
+
+	"INVITE sip:UserB@example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP iftgw.example.com\r\n" +
+		"From: <sip:+13035551111@ift.client.example.net;user=phone>\r\n" +
+		"Record-Route: <sip:UserB@example.com;maddr=ss1.example.com>\r\n" +
+		"To: sip:+16505552222@ss1.example.net;user=phone\r\n" +
+		"Call-ID: 1717@ift.client.example.com\r\n" +
+		"CSeq: 56 INVITE\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=mhandley 29739 7272939 IN IP4 192.0.2.5\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 192.0.2.5\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49217 RTP/AVP 0\r\n",
 }
 
 var torture3_o = []string{
@@ -499,4 +515,21 @@ var torture3_o = []string{
 		"m=audio 49217 RTP/AVP 0 12\r\n" +
 		"m=video 3227 RTP/AVP 31\r\n" +
 		"a=rtpmap:31 LPC\r\n",
+
+	"INVITE sip:UserB@example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP iftgw.example.com\r\n" +
+		"From: <sip:+13035551111@ift.client.example.net;user=phone>\r\n" +
+		"Record-Route: <sip:UserB@example.com;maddr=ss1.example.com>\r\n" +
+		"To: <sip:+16505552222@ss1.example.net>;user=phone\r\n" +
+		"Call-ID: 1717@ift.client.example.com\r\n" +
+		"CSeq: 56 INVITE\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 105\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=mhandley 29739 7272939 IN IP4 192.0.2.5\r\n" +
+		"s=-\r\n" +
+		"c=IN IP4 192.0.2.5\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 49217 RTP/AVP 0\r\n",
 }
@@ -0,0 +1,317 @@
+/*%% -------------------------------------------------------------------
+%%
+%% torture6_test: RFC5118 IPv6 torture tests (4.1 to 4.11)
+%%
+%% -------------------------------------------------------------------*/
+
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTorture6(t *testing.T) {
+	tvi := torture6_i
+	tvo := torture6_o
+
+	for i := 0; i < len(tvi); i++ {
+		smp := NewStringMsgParser()
+		if sm, err := smp.ParseSIPMessage(tvi[i]); err != nil {
+			t.Log(tvo[i])
+			if strings.Contains(tvo[i], "Invalid:") {
+				t.Log(err)
+			} else {
+				t.Fail()
+			}
+		} else {
+			d := sm.String()
+			s := tvo[i]
+
+			if strings.TrimSpace(d) != strings.TrimSpace(s) {
+				t.Log("golden = " + s)
+				t.Log("failed = " + d)
+				t.Fail()
+			}
+		}
+	}
+}
+
+var torture6_i = []string{
+	// ipv6-good
+	"REGISTER sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// ipv6-bad
+	"REGISTER sip:2001:db8::10 SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// port-ambiguous
+	"REGISTER sip:[2001:db8::10:5070] SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// port-unambiguous
+	"REGISTER sip:[2001:db8::10]:5070 SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// via-received-param-with-delim
+	"BYE sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: sip:user@example.com;tag=bd76ya\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];received=[2001:db8::9:255];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 321 BYE\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// via-received-param-no-delim
+	"OPTIONS sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];received=2001:db8::9:255;branch=z9hG4bKas3\r\n" +
+		"Call-ID: SSG95523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::9:1]>\r\n" +
+		"CSeq: 921 OPTIONS\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// ipv6-in-sdp
+	"INVITE sip:user@[2001:db8::10] SIP/2.0\r\n" +
+		"To: sip:user@[2001:db8::10]\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::20];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::20]>\r\n" +
+		"CSeq: 8612 INVITE\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 237\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=assistant 971731711378798081 0 IN IP6 2001:db8::20\r\n" +
+		"s=Live video feed\r\n" +
+		"e=<assistant@example.com>\r\n" +
+		"c=IN IP6 2001:db8::20\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 6000 RTP/AVP 0\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"m=video 6024 RTP/AVP 107\r\n" +
+		"a=rtpmap:107 H263-1998/90000\r\n",
+
+	// mult-ip-in-header
+	"BYE sip:user@host.example.net SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1]:6050;branch=z9hG4bKas3-111\r\n" +
+		"Via: SIP/2.0/UDP 192.0.2.1;branch=z9hG4bKjhja8781hjuaij65144\r\n" +
+		"Via: SIP/2.0/TCP [2001:db8::9:255];branch=z9hG4bK451jj;received=192.0.2.200\r\n" +
+		"Call-ID: 997077@lau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 89187 BYE\r\n" +
+		"To: sip:user@example.net;tag=9817--94\r\n" +
+		"From: sip:user@example.com;tag=81x2\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// ipv4-mapped-ipv6
+	"INVITE sip:user@example.com SIP/2.0\r\n" +
+		"To: sip:user@example.com\r\n" +
+		"From: sip:user@east.example.com;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [::ffff:192.0.2.10]:19823;branch=z9hG4bKbh19\r\n" +
+		"Via: SIP/2.0/UDP 192.0.2.10:19823;branch=z9hG4bKbh19\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"T. desk phone\" <sip:ted@[::ffff:192.0.2.10]:19823>\r\n" +
+		"CSeq: 612 INVITE\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 253\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=assistant 971731711378798081 0 IN IP6 ::ffff:192.0.2.10\r\n" +
+		"s=Call me soon, please!\r\n" +
+		"e=<assistant@example.com>\r\n" +
+		"c=IN IP6 ::ffff:192.0.2.10\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 6000 RTP/AVP 0\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"m=video 6024 RTP/AVP 107\r\n" +
+		"a=rtpmap:107 H263-1998/90000\r\n",
+
+	// ipv6-correct-abnf-2-colons
+	"OPTIONS sip:user@[2001:db8::192.0.2.1] SIP/2.0\r\n" +
+		"To: sip:user@[2001:db8::192.0.2.1]\r\n" +
+		"From: sip:user@example.com;tag=810x2\r\n" +
+		"Via: SIP/2.0/UDP lab1.east.example.com;branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: G9559905523997077@hlau_4100\r\n" +
+		"CSeq: 689 OPTIONS\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	// ipv6-correct-abnf-3-colons
+	"OPTIONS sip:user@[2001:db8:::192.0.2.1] SIP/2.0\r\n" +
+		"To: sip:user@[2001:db8:::192.0.2.1]\r\n" +
+		"From: sip:user@example.com;tag=810x2\r\n" +
+		"Via: SIP/2.0/UDP lab1.east.example.com;branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: G9559905523997077@hlau_4100\r\n" +
+		"CSeq: 689 OPTIONS\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+}
+
+var torture6_o = []string{
+	"REGISTER sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: <sip:user@example.com>\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"Invalid: REGISTER sip:2001:db8::10 SIP/2.0\r\n",
+
+	"REGISTER sip:[2001:db8::10:5070] SIP/2.0\r\n" +
+		"To: <sip:user@example.com>\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"REGISTER sip:[2001:db8::10]:5070 SIP/2.0\r\n" +
+		"To: <sip:user@example.com>\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::1]>\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 98176 REGISTER\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"BYE sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: <sip:user@example.com>;tag=bd76ya\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];received=[2001:db8::9:255];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 321 BYE\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"OPTIONS sip:[2001:db8::10] SIP/2.0\r\n" +
+		"To: <sip:user@example.com>\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1];received=2001:db8::9:255;branch=z9hG4bKas3\r\n" +
+		"Call-ID: SSG95523997077@hlau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::9:1]>\r\n" +
+		"CSeq: 921 OPTIONS\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"INVITE sip:user@[2001:db8::10] SIP/2.0\r\n" +
+		"To: <sip:user@[2001:db8::10]>\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::20];branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"Caller\" <sip:caller@[2001:db8::20]>\r\n" +
+		"CSeq: 8612 INVITE\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 237\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=assistant 971731711378798081 0 IN IP6 2001:db8::20\r\n" +
+		"s=Live video feed\r\n" +
+		"e=<assistant@example.com>\r\n" +
+		"c=IN IP6 2001:db8::20\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 6000 RTP/AVP 0\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"m=video 6024 RTP/AVP 107\r\n" +
+		"a=rtpmap:107 H263-1998/90000\r\n",
+
+	"BYE sip:user@host.example.net SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP [2001:db8::9:1]:6050;branch=z9hG4bKas3-111,SIP/2.0/UDP 192.0.2.1;branch=z9hG4bKjhja8781hjuaij65144,SIP/2.0/TCP [2001:db8::9:255];branch=z9hG4bK451jj;received=192.0.2.200\r\n" +
+		"Call-ID: 997077@lau_4100\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"CSeq: 89187 BYE\r\n" +
+		"To: <sip:user@example.net>;tag=9817--94\r\n" +
+		"From: <sip:user@example.com>;tag=81x2\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"INVITE sip:user@example.com SIP/2.0\r\n" +
+		"To: <sip:user@example.com>\r\n" +
+		"From: <sip:user@east.example.com>;tag=81x2\r\n" +
+		"Via: SIP/2.0/UDP [::ffff:192.0.2.10]:19823;branch=z9hG4bKbh19,SIP/2.0/UDP 192.0.2.10:19823;branch=z9hG4bKbh19\r\n" +
+		"Call-ID: SSG9559905523997077@hlau_4100\r\n" +
+		"Contact: \"T. desk phone\" <sip:ted@[::ffff:192.0.2.10]:19823>\r\n" +
+		"CSeq: 612 INVITE\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Type: application/sdp\r\n" +
+		"Content-Length: 253\r\n" +
+		"\r\n" +
+		"v=0\r\n" +
+		"o=assistant 971731711378798081 0 IN IP6 ::ffff:192.0.2.10\r\n" +
+		"s=Call me soon, please!\r\n" +
+		"e=<assistant@example.com>\r\n" +
+		"c=IN IP6 ::ffff:192.0.2.10\r\n" +
+		"t=0 0\r\n" +
+		"m=audio 6000 RTP/AVP 0\r\n" +
+		"a=rtpmap:0 PCMU/8000\r\n" +
+		"m=video 6024 RTP/AVP 107\r\n" +
+		"a=rtpmap:107 H263-1998/90000\r\n",
+
+	"OPTIONS sip:user@[2001:db8::192.0.2.1] SIP/2.0\r\n" +
+		"To: <sip:user@[2001:db8::192.0.2.1]>\r\n" +
+		"From: <sip:user@example.com>;tag=810x2\r\n" +
+		"Via: SIP/2.0/UDP lab1.east.example.com;branch=z9hG4bKas3-111\r\n" +
+		"Call-ID: G9559905523997077@hlau_4100\r\n" +
+		"CSeq: 689 OPTIONS\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Content-Length: 0\r\n" +
+		"\r\n",
+
+	"Invalid: OPTIONS sip:user@[2001:db8:::192.0.2.1] SIP/2.0\r\n",
+}
@@ -43,7 +43,7 @@ func (this *UserAgentParser) Parse() (sh header.Header, ParseException error) {
 	lexer := this.GetLexer()
 	this.HeaderName(TokenTypes_USER_AGENT)
 	if ch, _ = lexer.LookAheadK(0); ch == '\n' {
-		return nil, errors.New("empty header")
+		return nil, this.WrapParseException(errors.New("empty header"))
 	}
 
 	//  mandatory token: product[/product-version] | (comment)
@@ -145,8 +145,12 @@ func (this *ViaParser) NameValue() (nv *core.NameValue, ParseException error) {
 		var str string
 		if strings.ToLower(name.GetTokenValue()) == core.SIPParameters_RECEIVED {
 			// Allow for IPV6 Addresses.
-			// these could have : in them!
-			str = lexer.ByteStringNoSemicolon()
+			// these could have : in them! A comma ends the Via.
+			start := lexer.GetPtr()
+			for la, err := lexer.LookAheadK(0); err == nil && la != ';' && la != ',' && la != '\n' && la != ' ' && la != '\t'; la, err = lexer.LookAheadK(0) {
+				lexer.ConsumeK(1)
+			}
+			str = lexer.GetBuffer()[start:lexer.GetPtr()]
 		} else {
 			if la, _ = lexer.LookAheadK(0); la == '"' {
 				if str, ParseException = lexer.QuotedString(); ParseException != nil {
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"testing"
+
+	"github.com/use-go/gosips/sip/header"
 )
 
 func TestViaParser(t *testing.T) {
@@ -26,6 +28,8 @@ func TestViaParser(t *testing.T) {
 		"Via: SIP/2.0/UDP ss1.wcom.com:5060;branch=2d4790.1\n",
 		"Via: SIP/2.0/UDP first.example.com:4000;ttl=16" +
 			";maddr=224.2.0.1 ;branch=a7c6a8dlze.1 (Acme server)\n",
+		"Via: SIP/2.0/UDP a.example.com;received=192.0.2.1" +
+			", SIP/2.0/UDP b.example.com;received=2001:db8::9,SIP/2.0/TCP c.example.com\n",
 	}
 	var tvo = []string{
 		"Via: SIP/2.0/UDP 127.0.0.1:5070;branch=z9hG4bK-d87543-4dade06d0bdb11ee-1--d87543-;rport\r\n",
@@ -48,6 +52,8 @@ func TestViaParser(t *testing.T) {
 		"Via: SIP/2.0/UDP ss1.wcom.com:5060;branch=2d4790.1\n",
 		"Via: SIP/2.0/UDP first.example.com:4000 (Acme server);ttl=16" +
 			";maddr=224.2.0.1;branch=a7c6a8dlze.1\n",
+		"Via: SIP/2.0/UDP a.example.com;received=192.0.2.1" +
+			",SIP/2.0/UDP b.example.com;received=2001:db8::9,SIP/2.0/TCP c.example.com\n",
 	}
 
 	for i := 0; i < len(tvi); i++ {
@@ -55,3 +61,14 @@ func TestViaParser(t *testing.T) {
 		testHeaderParser(t, shp, tvo[i])
 	}
 }
+
+func TestViaParserReceived(t *testing.T) {
+	sh, err := NewViaParser("Via: SIP/2.0/UDP a.example.com;received=192.0.2.1,SIP/2.0/UDP b.example.com\n").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vias := sh.(*header.ViaList)
+	if vias.Len() != 2 || vias.Front().Value.(*header.Via).GetReceived() != "192.0.2.1" {
+		t.Fatalf("received ends at the comma: %s", vias.String())
+	}
+}
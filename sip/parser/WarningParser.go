@@ -51,10 +51,10 @@ func (this *WarningParser) Parse() (sh header.Header, ParseException error) {
 
 		var code int
 		if code, ParseException = strconv.Atoi(token.GetTokenValue()); ParseException != nil {
-			return nil, ParseException
+			return nil, this.WrapParseException(ParseException)
 		}
 		if ParseException = warning.SetCode(code); ParseException != nil {
-			return nil, ParseException
+			return nil, this.WrapParseException(ParseException)
 		}
 
 		lexer.SPorHT()
@@ -86,7 +86,7 @@ func (this *WarningParser) Parse() (sh header.Header, ParseException error) {
 			tok := lexer.GetNextToken()
 
 			if code, ParseException = strconv.Atoi(tok.GetTokenValue()); ParseException != nil {
-				return nil, ParseException
+				return nil, this.WrapParseException(ParseException)
 			}
 			warning.SetCode(code)
 
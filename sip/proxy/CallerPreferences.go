@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"sort"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/registrar"
+)
+
+/** A feature predicate of a request: an Accept-Contact or a Reject-Contact
+ * header, or an implicit preference.
+ */
+type featurePredicate struct {
+	featureSet header.FeatureSet
+
+	require bool
+
+	explicit bool
+}
+
+/** A target with the score of the caller preferences of a request.
+ */
+type scoredTarget struct {
+	binding *registrar.Binding
+
+	q float32
+
+	qa float64
+}
+
+/**
+ * Apply the caller preferences of a request to its targets (RFC 3841
+ * section 7.2). Each target is matched by the feature set its Contact was
+ * registered with (RFC 3840):
+ * <ul>
+ * <li>a target whose feature set explicitly matches a Reject-Contact
+ * predicate is dropped;
+ * <li>a target that does not support the method of the request, or for a
+ * SUBSCRIBE its event package, is dropped unless an Accept-Contact states
+ * its own methods or events (the implicit preferences of section 7.2.2);
+ * <li>a target that does not match an Accept-Contact predicate with
+ * require, or that matches it only implicitly when it is explicit too, is
+ * dropped. The others score 1 for a predicate they match explicitly, the
+ * share of its features they list for one they match implicitly and 0
+ * otherwise; their score Qa is the mean of those scores.
+ * </ul>
+ * A target registered without feature parameters is immune: it is kept
+ * and scores 1. The targets are returned ordered by the q value of their
+ * Contact, 1 when absent, then by Qa.
+ */
+func ApplyCallerPreferences(request *message.SIPRequest, targets []*registrar.Binding) []*registrar.Binding {
+	accepts := getPredicates(request, core.SIPHeaderNames_ACCEPT_CONTACT)
+	rejects := getPredicates(request, core.SIPHeaderNames_REJECT_CONTACT)
+	implicits := getImplicitPredicates(request, accepts)
+
+	var scored []*scoredTarget
+	for _, binding := range targets {
+		featureSet := binding.GetContact().GetFeatureSet()
+		qa, ok := scoreTarget(featureSet, accepts, rejects, implicits)
+		if !ok {
+			continue
+		}
+		q := binding.GetContact().GetQValue()
+		if q < 0 {
+			q = 1
+		}
+		scored = append(scored, &scoredTarget{binding, q, qa})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].q != scored[j].q {
+			return scored[i].q > scored[j].q
+		}
+		return scored[i].qa > scored[j].qa
+	})
+
+	selected := make([]*registrar.Binding, len(scored))
+	for i, target := range scored {
+		selected[i] = target.binding
+	}
+	return selected
+}
+
+/** Score a target; ok is false when the target is dropped.
+ */
+func scoreTarget(featureSet header.FeatureSet, accepts, rejects, implicits []*featurePredicate) (qa float64, ok bool) {
+	if len(featureSet) == 0 {
+		return 1, true
+	}
+	for _, reject := range rejects {
+		if len(reject.featureSet) == 0 {
+			continue
+		}
+		if matches, explicit := featureSet.Matches(reject.featureSet); matches && explicit == len(reject.featureSet) {
+			return 0, false
+		}
+	}
+	for _, implicit := range implicits {
+		if matches, _ := featureSet.Matches(implicit.featureSet); !matches {
+			return 0, false
+		}
+	}
+	if len(accepts) == 0 {
+		return 1, true
+	}
+	var total float64
+	for _, accept := range accepts {
+		matches, explicit := featureSet.Matches(accept.featureSet)
+		switch {
+		case !matches:
+			if accept.require {
+				return 0, false
+			}
+		case explicit == len(accept.featureSet):
+			total += 1
+		case accept.explicit:
+			if accept.require {
+				return 0, false
+			}
+		default:
+			total += float64(explicit) / float64(len(accept.featureSet))
+		}
+	}
+	return total / float64(len(accepts)), true
+}
+
+/** Get the predicates of the Accept-Contact or Reject-Contact headers of
+ * a request.
+ */
+func getPredicates(request *message.SIPRequest, headerName string) (predicates []*featurePredicate) {
+	if !request.HasHeader(headerName) {
+		return nil
+	}
+	for e := request.GetSIPHeaderList(headerName).Front(); e != nil; e = e.Next() {
+		switch h := e.Value.(type) {
+		case *header.AcceptContact:
+			predicates = append(predicates, &featurePredicate{h.GetFeatureSet(), h.IsRequire(), h.IsExplicit()})
+		case *header.RejectContact:
+			predicates = append(predicates, &featurePredicate{h.GetFeatureSet(), false, false})
+		}
+	}
+	return predicates
+}
+
+/** Get the implicit preferences of a request (RFC 3841 section 7.2.2): the
+ * method of the request and the event package of a SUBSCRIBE, unless an
+ * Accept-Contact predicate already states the methods or events.
+ */
+func getImplicitPredicates(request *message.SIPRequest, accepts []*featurePredicate) (implicits []*featurePredicate) {
+	if !hasFeature(accepts, header.FeatureTag_SIP_PREFIX+header.FeatureTag_METHODS) {
+		implicits = append(implicits, &featurePredicate{header.FeatureSet{
+			header.FeatureTag_SIP_PREFIX + header.FeatureTag_METHODS: {request.GetMethod()},
+		}, true, false})
+	}
+	if request.GetMethod() != message.SUBSCRIBE || hasFeature(accepts, header.FeatureTag_SIP_PREFIX+header.FeatureTag_EVENTS) {
+		return implicits
+	}
+	if event, ok := request.GetHeader(core.SIPHeaderNames_EVENT).(*header.Event); ok && event.GetEventType() != "" {
+		implicits = append(implicits, &featurePredicate{header.FeatureSet{
+			header.FeatureTag_SIP_PREFIX + header.FeatureTag_EVENTS: {event.GetEventType()},
+		}, true, false})
+	}
+	return implicits
+}
+
+func hasFeature(predicates []*featurePredicate, tag string) bool {
+	for _, predicate := range predicates {
+		if _, ok := predicate.featureSet[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/registrar"
+)
+
+func registerFeatures(t *testing.T, reg *registrar.Registrar, host, features string) {
+	request := parseTestRequest(t, "REGISTER sip:example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP "+host+";branch=z9hG4bKnashds7\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"From: Alice <sip:alice@example.com>;tag=456248\r\n"+
+		"To: Alice <sip:alice@example.com>\r\n"+
+		"Call-ID: 1@"+host+"\r\n"+
+		"CSeq: 1 REGISTER\r\n"+
+		"Contact: <sip:alice@"+host+">"+features+"\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	if response := reg.ProcessRegister(request); response.GetStatusCode() != message.OK {
+		t.Fatalf("REGISTER failed with %d", response.GetStatusCode())
+	}
+}
+
+/** Register a desk phone and a video endpoint for alice.
+ */
+func newCallerPreferencesProxy(t *testing.T) *Proxy {
+	locationService := registrar.NewLocationService()
+	reg := registrar.NewRegistrar(locationService)
+	registerFeatures(t, reg, "192.0.2.2", ";audio;mobility=\"fixed\";methods=\"INVITE,ACK,BYE,CANCEL\"")
+	registerFeatures(t, reg, "192.0.2.3", ";audio;video;methods=\"INVITE,ACK,BYE,CANCEL,MESSAGE\";+sip.screens=\"#2\"")
+	return NewProxy(locationService)
+}
+
+func routeTargets(t *testing.T, proxy *Proxy, request string) []string {
+	requests, response := proxy.Route(parseTestRequest(t, request))
+	if response != nil {
+		t.Fatalf("request rejected with %d", response.GetStatusCode())
+	}
+	var targets []string
+	for _, forwarded := range requests {
+		targets = append(targets, strings.TrimPrefix(forwarded.GetRequestURI().String(), "sip:alice@"))
+	}
+	return targets
+}
+
+func withHeaders(request string, headers string) string {
+	return strings.Replace(request, "Content-Length", headers+"Content-Length", 1)
+}
+
+func TestCallerPreferences(t *testing.T) {
+	proxy := newCallerPreferencesProxy(t)
+	invite := testInvite("sip:alice@example.com", 70)
+
+	for _, test := range []struct {
+		name    string
+		headers string
+		targets string
+	}{
+		{"no preferences", "", "192.0.2.2,192.0.2.3"},
+		{"video required", "Accept-Contact: *;video;require;explicit\r\n", "192.0.2.3"},
+		{"video preferred", "Accept-Contact: *;video\r\n", "192.0.2.3,192.0.2.2"},
+		{"video rejected", "Reject-Contact: *;video\r\n", "192.0.2.2"},
+		{"fixed required", "a: *;mobility=\"fixed\";require\r\n", "192.0.2.2,192.0.2.3"},
+		{"fixed explicit", "a: *;mobility=\"fixed\";require;explicit\r\n", "192.0.2.2"},
+		{"not fixed", "j: *;mobility=\"!mobile\"\r\n", "192.0.2.3"},
+		{"two screens", "Accept-Contact: *;+sip.screens=\"#>=2\";require;explicit\r\n", "192.0.2.3"},
+		{"three screens", "Accept-Contact: *;+sip.screens=\"#>=3\";require\r\n", "192.0.2.2"},
+		{"three screens explicit", "Accept-Contact: *;+sip.screens=\"#>=3\";require;explicit\r\n", ""},
+		{"no-fork", "Request-Disposition: no-fork\r\nAccept-Contact: *;video\r\n", "192.0.2.3"},
+	} {
+		if test.targets == "" {
+			if _, response := proxy.Route(parseTestRequest(t, withHeaders(invite, test.headers))); response == nil ||
+				response.GetStatusCode() != message.TEMPORARILY_UNAVAILABLE {
+				t.Errorf("%s: expected 480", test.name)
+			}
+			continue
+		}
+		if targets := strings.Join(routeTargets(t, proxy, withHeaders(invite, test.headers)), ","); targets != test.targets {
+			t.Errorf("%s: routed to %s, expected %s", test.name, targets, test.targets)
+		}
+	}
+
+	messageRequest := strings.Replace(strings.Replace(invite, "INVITE sip", "MESSAGE sip", 1), "314159 INVITE", "314159 MESSAGE", 1)
+	if targets := strings.Join(routeTargets(t, proxy, messageRequest), ","); targets != "192.0.2.3" {
+		t.Errorf("MESSAGE routed to %s", targets)
+	}
+}
+
+func TestCallerPreferencesRedirect(t *testing.T) {
+	proxy := newCallerPreferencesProxy(t)
+	request := withHeaders(testInvite("sip:alice@example.com", 70), "Request-Disposition: redirect\r\nAccept-Contact: *;video\r\n")
+	requests, response := proxy.Route(parseTestRequest(t, request))
+	if requests != nil || response == nil || response.GetStatusCode() != message.MOVED_TEMPORARILY {
+		t.Fatal("request not redirected")
+	}
+	contacts := response.GetContactHeaders()
+	if contacts.Len() != 2 || contacts.Front().Value.(*header.Contact).GetAddress().GetURI().String() != "sip:alice@192.0.2.3" {
+		t.Fatalf("bad redirect:\n%s", response.String())
+	}
+}
+
+func TestFeatureSet(t *testing.T) {
+	contact := header.NewContact()
+	contact.SetFeature("sip.audio", header.FeatureValue_TRUE)
+	contact.SetFeature("sip.methods", "INVITE", "BYE")
+	contact.SetFeature("sip.instance", "<urn:uuid:1>")
+	contact.SetFeature("org.example.level", "#3")
+	if body := contact.GetParameters().String(); body != "audio;methods=\"INVITE,BYE\";+sip.instance=\"<urn:uuid:1>\";+org.example.level=\"#3\"" {
+		t.Fatalf("bad feature parameters %s", body)
+	}
+	featureSet := contact.GetFeatureSet()
+	for _, test := range []struct {
+		predicate header.FeatureSet
+		matches   bool
+		explicit  int
+	}{
+		{header.FeatureSet{"sip.audio": {"TRUE"}}, true, 1},
+		{header.FeatureSet{"sip.audio": {"FALSE"}}, false, 1},
+		{header.FeatureSet{"sip.methods": {"bye"}}, true, 1},
+		{header.FeatureSet{"sip.methods": {"!INVITE"}}, true, 1},
+		{header.FeatureSet{"sip.methods": {"MESSAGE"}}, false, 1},
+		{header.FeatureSet{"sip.video": {"TRUE"}, "sip.audio": {"TRUE"}}, true, 1},
+		{header.FeatureSet{"sip.instance": {"<urn:uuid:1>"}}, true, 1},
+		{header.FeatureSet{"sip.instance": {"<URN:uuid:1>"}}, false, 1},
+		{header.FeatureSet{"org.example.level": {"#1:5"}}, true, 1},
+		{header.FeatureSet{"org.example.level": {"#<=2"}}, false, 1},
+	} {
+		if matches, explicit := featureSet.Matches(test.predicate); matches != test.matches || explicit != test.explicit {
+			t.Errorf("%v: got %v %d", test.predicate, matches, explicit)
+		}
+	}
+	if header.GetFeatureTag("+sip.audio") != "sip.audio" || header.GetFeatureTag("audio") != "sip.audio" ||
+		header.GetFeatureTag("expires") != "" || header.GetFeatureParameterName("sip.video") != "video" ||
+		header.GetFeatureParameterName("sip.instance") != "+sip.instance" {
+		t.Error("bad feature tag names")
+	}
+}
@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+	"github.com/use-go/gosips/sip/registrar"
+	"github.com/use-go/gosips/sip/stack"
+)
+
+/** Max-Forwards of a request that arrives without one.
+ */
+const Proxy_MAX_FORWARDS = 70
+
+/**
+ * The routing logic of a proxy for the domain of a registrar (RFC 3261
+ * section 16). The targets of a request are the bindings of its Request-URI
+ * in the location service, except for a GRUU, which reaches only the
+ * instance it designates (RFC 5627 section 5.2). The Path a target was
+ * registered through is preloaded as the route set of the request sent to
+ * it (RFC 3327 section 5.3).
+ */
+type Proxy struct {
+	locationService *registrar.LocationService
+}
+
+/** Create a proxy that looks up the targets of requests in a location
+ * service.
+ */
+func NewProxy(locationService *registrar.LocationService) *Proxy {
+	this := &Proxy{}
+	this.locationService = locationService
+	return this
+}
+
+/** Get the location service of the proxy.
+ */
+func (this *Proxy) GetLocationService() *registrar.LocationService {
+	return this.locationService
+}
+
+/** Get the bindings a Request-URI resolves to. A GRUU resolves to the
+ * bindings of its instance only. When there is no target the status code
+ * of the response to send is returned: 404 for an unknown GRUU and 480
+ * when nobody is registered.
+ */
+func (this *Proxy) GetTargets(requestURI address.URI) (targets []*registrar.Binding, statusCode int) {
+	if sipURI, ok := requestURI.(*address.SipURIImpl); ok && sipURI.IsGruu() {
+		bindings, known := this.locationService.ResolveGruu(sipURI)
+		if !known {
+			return nil, message.NOT_FOUND
+		}
+		if len(bindings) == 0 {
+			return nil, message.TEMPORARILY_UNAVAILABLE
+		}
+		return bindings, 0
+	}
+	bindings := this.locationService.GetBindings(registrar.GetAOR(requestURI))
+	if len(bindings) == 0 {
+		return nil, message.TEMPORARILY_UNAVAILABLE
+	}
+	return bindings, 0
+}
+
+/** Route a request: return a copy of it for each target, with the
+ * Request-URI set to the contact of the target and Max-Forwards
+ * decremented (RFC 3261 section 16.6), or the response to send when the
+ * request cannot be forwarded. The targets are selected and ordered by the
+ * caller preferences of the request (RFC 3841), and its
+ * Request-Disposition is honored: with no-fork only the best target is
+ * tried, and with redirect the targets are returned in a 302.
+ */
+func (this *Proxy) Route(request *message.SIPRequest) (requests []*message.SIPRequest, response *message.SIPResponse) {
+	if request.HasHeader(core.SIPHeaderNames_MAX_FORWARDS) &&
+		request.GetMaxForwards().GetMaxForwards() <= 0 {
+		return nil, this.createResponse(request, message.TOO_MANY_HOPS)
+	}
+	targets, statusCode := this.GetTargets(request.GetRequestURI())
+	if len(targets) == 0 {
+		return nil, this.createResponse(request, statusCode)
+	}
+	if targets = ApplyCallerPreferences(request, targets); len(targets) == 0 {
+		return nil, this.createResponse(request, message.TEMPORARILY_UNAVAILABLE)
+	}
+	if requestDisposition, ok := request.GetHeader(core.SIPHeaderNames_REQUEST_DISPOSITION).(*header.RequestDisposition); ok {
+		if requestDisposition.HasDirective(header.RequestDisposition_REDIRECT) {
+			return nil, this.createRedirect(request, targets)
+		}
+		if requestDisposition.HasDirective(header.RequestDisposition_NO_FORK) {
+			targets = targets[:1]
+		}
+	}
+	for i, target := range targets {
+		forwarded, err := this.createForwardedRequest(request, target, i+1)
+		if err != nil {
+			return nil, this.createResponse(request, message.SERVER_INTERNAL_ERROR)
+		}
+		requests = append(requests, forwarded)
+	}
+	return requests, nil
+}
+
+/** Create the copy of a request forwarded to a target. The Path of the
+ * target is pushed on top of the Route headers of the request. When the
+ * request uses History-Info the change of its Request-URI to the contact
+ * is recorded with rc (RFC 7044 section 10.3).
+ */
+func (this *Proxy) CreateForwardedRequest(request *message.SIPRequest, target *registrar.Binding) (forwarded *message.SIPRequest, ParseException error) {
+	return this.createForwardedRequest(request, target, 1)
+}
+
+func (this *Proxy) createForwardedRequest(request *message.SIPRequest, target *registrar.Binding, branch int) (forwarded *message.SIPRequest, ParseException error) {
+	forwarded, err := CopyRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	uri, err := parser.NewURLParser(target.GetContactURI().String()).Parse()
+	if err != nil {
+		return nil, err
+	}
+	if UsesHistoryInfo(forwarded) {
+		if err = NewRetargeter().RetargetBranch(forwarded, uri, header.ParameterNames_RC, 0, branch); err != nil {
+			return nil, err
+		}
+	} else {
+		forwarded.SetRequestURI(uri)
+	}
+
+	if path := target.GetPath(); len(path) > 0 {
+		routeList := header.NewRouteList()
+		for _, addr := range path {
+			routeList.PushBack(header.NewRouteFromAddress(addr))
+		}
+		forwarded.AttachHeader3(routeList, false, true)
+	}
+
+	if forwarded.HasHeader(core.SIPHeaderNames_MAX_FORWARDS) {
+		forwarded.GetMaxForwards().DecrementMaxForwards()
+	} else {
+		maxForwards := header.NewMaxForwards()
+		maxForwards.SetMaxForwards(Proxy_MAX_FORWARDS)
+		forwarded.SetMaxForwards(maxForwards)
+	}
+	return forwarded, nil
+}
+
+/** Make a deep copy of a request. The headers of a parsed message are
+ * shared by the messages derived from it, so every branch of a proxy gets
+ * a copy of its own.
+ */
+func CopyRequest(request *message.SIPRequest) (copy *message.SIPRequest, ParseException error) {
+	msg, err := parser.NewStringMsgParser().ParseSIPMessage(request.String())
+	if err != nil {
+		return nil, err
+	}
+	return msg.(*message.SIPRequest), nil
+}
+
+/** Create a 302 listing the contacts of the targets, best first.
+ */
+func (this *Proxy) createRedirect(request *message.SIPRequest, targets []*registrar.Binding) *message.SIPResponse {
+	response := this.createResponse(request, message.MOVED_TEMPORARILY)
+	contactList := header.NewContactList()
+	for _, target := range targets {
+		contact := header.NewContact()
+		contact.SetAddress(target.GetContact().GetAddress())
+		if q := target.GetContact().GetQValue(); q >= 0 {
+			contact.SetQValue(q)
+		}
+		contactList.PushBack(contact)
+	}
+	response.SetHeader(contactList)
+	return response
+}
+
+func (this *Proxy) createResponse(request *message.SIPRequest, statusCode int) *message.SIPResponse {
+	response := request.CreateResponse(statusCode)
+	stack.SetResponseToTag(response, stack.GenerateTag())
+	return response
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+	"github.com/use-go/gosips/sip/registrar"
+)
+
+func parseTestRequest(t *testing.T, s string) *message.SIPRequest {
+	msg, err := parser.NewStringMsgParser().ParseSIPMessage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.(*message.SIPRequest)
+}
+
+func register(t *testing.T, reg *registrar.Registrar, host, instance string) *header.Contact {
+	request := parseTestRequest(t, "REGISTER sip:example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP "+host+";branch=z9hG4bKnashds7\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"From: Alice <sip:alice@example.com>;tag=456248\r\n"+
+		"To: Alice <sip:alice@example.com>\r\n"+
+		"Call-ID: 1@"+host+"\r\n"+
+		"CSeq: 1 REGISTER\r\n"+
+		"Supported: gruu\r\n"+
+		"Contact: <sip:alice@"+host+">;+sip.instance=\""+instance+"\"\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	response := reg.ProcessRegister(request)
+	for e := response.GetContactHeaders().Front(); e != nil; e = e.Next() {
+		if contact := e.Value.(*header.Contact); contact.GetSipInstance() == instance {
+			return contact
+		}
+	}
+	t.Fatal("instance not registered")
+	return nil
+}
+
+func testInvite(requestURI string, maxForwards int) string {
+	return "INVITE " + requestURI + " SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+		"Max-Forwards: " + strconv.Itoa(maxForwards) + "\r\n" +
+		"To: Alice <sip:alice@example.com>\r\n" +
+		"From: Bob <sip:bob@atlanta.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Content-Length: 0\r\n\r\n"
+}
+
+func TestRouteToGruu(t *testing.T) {
+	locationService := registrar.NewLocationService()
+	reg := registrar.NewRegistrar(locationService)
+	desk := register(t, reg, "192.0.2.2", "<urn:uuid:00000000-0000-1000-8000-000000000001>")
+	register(t, reg, "192.0.2.3", "<urn:uuid:00000000-0000-1000-8000-000000000002>")
+	proxy := NewProxy(locationService)
+
+	requests, response := proxy.Route(parseTestRequest(t, testInvite("sip:alice@example.com", 70)))
+	if response != nil || len(requests) != 2 {
+		t.Fatal("AOR not forked to both instances")
+	}
+
+	for _, gruu := range []string{desk.GetPubGruu(), desk.GetTempGruu()} {
+		invite := parseTestRequest(t, testInvite(gruu, 70))
+		requests, response = proxy.Route(invite)
+		if response != nil || len(requests) != 1 {
+			t.Fatalf("%s not routed to one instance", gruu)
+		}
+		if requests[0].GetRequestURI().String() != "sip:alice@192.0.2.2" {
+			t.Fatal("routed to " + requests[0].GetRequestURI().String())
+		}
+		if requests[0].GetMaxForwards().GetMaxForwards() != 69 || invite.GetMaxForwards().GetMaxForwards() != 70 {
+			t.Fatal("bad Max-Forwards")
+		}
+	}
+}
+
+func TestRouteErrors(t *testing.T) {
+	locationService := registrar.NewLocationService()
+	reg := registrar.NewRegistrar(locationService)
+	register(t, reg, "192.0.2.2", "<urn:uuid:00000000-0000-1000-8000-000000000001>")
+	proxy := NewProxy(locationService)
+
+	for _, test := range []struct {
+		requestURI  string
+		maxForwards int
+		statusCode  int
+	}{
+		{"sip:alice@example.com", 0, message.TOO_MANY_HOPS},
+		{"sip:carol@example.com", 70, message.TEMPORARILY_UNAVAILABLE},
+		{"sip:alice@example.com;gr=urn:uuid:00000000-0000-1000-8000-000000000009", 70, message.TEMPORARILY_UNAVAILABLE},
+		{"sip:tgruu.unknown@example.com;gr", 70, message.NOT_FOUND},
+	} {
+		_, response := proxy.Route(parseTestRequest(t, testInvite(test.requestURI, test.maxForwards)))
+		if response == nil || response.GetStatusCode() != test.statusCode {
+			t.Errorf("%s: expected %d", test.requestURI, test.statusCode)
+		}
+	}
+}
+
+func TestRouteThroughPath(t *testing.T) {
+	locationService := registrar.NewLocationService()
+	reg := registrar.NewRegistrar(locationService)
+	reg.ProcessRegister(parseTestRequest(t, "REGISTER sip:example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP p1.example.net;branch=z9hG4bKnashds8\r\n"+
+		"Via: SIP/2.0/UDP 192.0.2.2;branch=z9hG4bKnashds7\r\n"+
+		"Max-Forwards: 69\r\n"+
+		"From: Alice <sip:alice@example.com>;tag=456248\r\n"+
+		"To: Alice <sip:alice@example.com>\r\n"+
+		"Call-ID: 1@192.0.2.2\r\n"+
+		"CSeq: 1 REGISTER\r\n"+
+		"Path: <sip:p1.example.net;lr>\r\n"+
+		"Contact: <sip:alice@192.0.2.2>\r\n"+
+		"Content-Length: 0\r\n\r\n"))
+	proxy := NewProxy(locationService)
+
+	invite := parseTestRequest(t, "INVITE sip:alice@example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"Route: <sip:home.example.com;lr>\r\n"+
+		"To: Alice <sip:alice@example.com>\r\n"+
+		"From: Bob <sip:bob@atlanta.com>;tag=1928301774\r\n"+
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n"+
+		"CSeq: 314159 INVITE\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	requests, response := proxy.Route(invite)
+	if response != nil || len(requests) != 1 {
+		t.Fatal("request not routed")
+	}
+	routes := requests[0].GetRouteHeaders()
+	if routes.Len() != 2 || routes.Front().Value.(*header.Route).GetAddress().GetURI().String() != "sip:p1.example.net;lr" {
+		t.Fatal("Path not preloaded " + requests[0].String())
+	}
+	if invite.GetRouteHeaders().Len() != 1 {
+		t.Fatal("original request modified")
+	}
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/stack"
+)
+
+/** The option tag of History-Info (RFC 7044 section 4.1).
+ */
+const Retarget_HISTINFO_OPTION_TAG = "histinfo"
+
+/**
+ * A Retargeter changes the Request-URI of a request in a proxy or a B2BUA
+ * and records the change in History-Info (RFC 7044 section 10.3):
+ * <ul>
+ * <li>the Request-URI the request arrived with gets an entry if it has
+ * none yet, with the Reason for leaving it when the retargeting follows a
+ * response;
+ * <li>the new target gets an entry whose index is a child of that entry,
+ * tagged rc, mp or np after the way the target was found.
+ * </ul>
+ * When Diversion is enabled a change of the target user (mp) is also
+ * recorded in a Diversion header for the legacy elements (RFC 5806).
+ */
+type Retargeter struct {
+	diversion bool
+}
+
+/** Create a Retargeter that records History-Info only.
+ */
+func NewRetargeter() *Retargeter {
+	return &Retargeter{}
+}
+
+/** Also add a Diversion header when the target user changes.
+ */
+func (this *Retargeter) SetDiversion(diversion bool) {
+	this.diversion = diversion
+}
+
+/** Retarget a request that is not forked: see RetargetBranch.
+ */
+func (this *Retargeter) Retarget(request *message.SIPRequest, target address.URI, mode string, cause int) (ParseException error) {
+	return this.RetargetBranch(request, target, mode, cause, 1)
+}
+
+/** Set the Request-URI of a request to a target and record it. The mode
+ * is header.ParameterNames_RC, MP or NP; cause is the response code that
+ * caused the retargeting, or 0. When a request is forked, each branch is
+ * a copy of the request retargeted with its own branch number, starting
+ * at 1.
+ */
+func (this *Retargeter) RetargetBranch(request *message.SIPRequest, target address.URI, mode string, cause int, branch int) (ParseException error) {
+	if mode != header.ParameterNames_RC && mode != header.ParameterNames_MP && mode != header.ParameterNames_NP {
+		return errors.New("ParseException: bad retargeting mode " + mode)
+	}
+	if branch < 1 {
+		return errors.New("ParseException: bad branch " + strconv.Itoa(branch))
+	}
+	entries := GetHistoryInfo(request)
+	parent := findHistoryInfo(entries, request.GetRequestURI())
+	if parent == nil {
+		index := "1"
+		if len(entries) > 0 {
+			index = entries[len(entries)-1].GetIndex() + core.SIPSeparatorNames_DOT + "1"
+		}
+		parent = newHistoryInfo(request.GetRequestURI())
+		if err := parent.SetIndex(index); err != nil {
+			return err
+		}
+		entries = append(entries, parent)
+	}
+	if cause > 0 {
+		parent.SetReason(cause, message.NewSIPResponse().GetReasonPhraseFromInt(cause))
+	}
+
+	entry := newHistoryInfo(target)
+	if err := entry.SetIndex(parent.GetIndex() + core.SIPSeparatorNames_DOT + strconv.Itoa(branch)); err != nil {
+		return err
+	}
+	entry.SetParameter(mode, parent.GetIndex())
+	entries = append(entries, entry)
+
+	historyInfoList := header.NewHistoryInfoList()
+	for _, historyInfo := range entries {
+		historyInfoList.PushBack(historyInfo)
+	}
+	request.SetHeader(historyInfoList)
+
+	if this.diversion && mode == header.ParameterNames_MP {
+		diversion := header.NewDiversionFromAddress(newTargetAddress(request.GetRequestURI()))
+		diversion.SetReason(GetDiversionReason(cause))
+		diversion.SetCounter(1)
+		diversionList := header.NewDiversionList()
+		diversionList.PushBack(diversion)
+		for _, previous := range GetDiversions(request) {
+			diversionList.PushBack(previous)
+		}
+		request.SetHeader(diversionList)
+	}
+	request.SetRequestURI(stripURI(target))
+	return nil
+}
+
+/** Return true if History-Info is recorded for a request: it already has
+ * History-Info entries or its sender supports it (RFC 7044 section 10.1).
+ */
+func UsesHistoryInfo(request *message.SIPRequest) bool {
+	return request.HasHeader(core.SIPHeaderNames_HISTORY_INFO) ||
+		stack.HasOptionTag(&request.SIPMessage, Retarget_HISTINFO_OPTION_TAG)
+}
+
+/** Get the History-Info entries of a request, oldest first.
+ */
+func GetHistoryInfo(request *message.SIPRequest) []*header.HistoryInfo {
+	if !request.HasHeader(core.SIPHeaderNames_HISTORY_INFO) {
+		return nil
+	}
+	var entries []*header.HistoryInfo
+	for e := request.GetSIPHeaderList(core.SIPHeaderNames_HISTORY_INFO).Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*header.HistoryInfo))
+	}
+	return entries
+}
+
+/** Get the Diversion headers of a request, the latest diversion first.
+ */
+func GetDiversions(request *message.SIPRequest) []*header.Diversion {
+	if !request.HasHeader(core.SIPHeaderNames_DIVERSION) {
+		return nil
+	}
+	var diversions []*header.Diversion
+	for e := request.GetSIPHeaderList(core.SIPHeaderNames_DIVERSION).Front(); e != nil; e = e.Next() {
+		diversions = append(diversions, e.Value.(*header.Diversion))
+	}
+	return diversions
+}
+
+/** Get the target a request was first sent to, before any retargeting:
+ * the first History-Info entry, else the oldest diverting party, else the
+ * To. A voicemail server uses it to find the mailbox of the called party.
+ */
+func GetOriginalTarget(request *message.SIPRequest) address.URI {
+	if entries := GetHistoryInfo(request); len(entries) > 0 {
+		return stripURI(entries[0].GetAddress().GetURI())
+	}
+	if diversions := GetDiversions(request); len(diversions) > 0 {
+		return diversions[len(diversions)-1].GetAddress().GetURI()
+	}
+	return request.GetTo().GetAddress().GetURI()
+}
+
+/** Get the target the request was last diverted from: the parent of the
+ * last History-Info entry reached by a change of the target user (mp),
+ * else the latest diverting party, or nil if the request was not
+ * diverted.
+ */
+func GetDivertingTarget(request *message.SIPRequest) address.URI {
+	entries := GetHistoryInfo(request)
+	for i := len(entries) - 1; i >= 0; i-- {
+		parentIndex := entries[i].GetMp()
+		if parentIndex == "" {
+			continue
+		}
+		for _, parent := range entries {
+			if parent.GetIndex() == parentIndex {
+				return stripURI(parent.GetAddress().GetURI())
+			}
+		}
+	}
+	if diversions := GetDiversions(request); len(diversions) > 0 {
+		return diversions[0].GetAddress().GetURI()
+	}
+	return nil
+}
+
+/** Map the response code that caused a diversion to its Diversion reason
+ * (RFC 5806 section 4); 0 is an unconditional diversion.
+ */
+func GetDiversionReason(cause int) string {
+	switch cause {
+	case 0:
+		return header.Diversion_UNCONDITIONAL
+	case message.BUSY_HERE, message.BUSY_EVERYWHERE:
+		return header.Diversion_USER_BUSY
+	case message.REQUEST_TIMEOUT, message.TEMPORARILY_UNAVAILABLE:
+		return header.Diversion_NO_ANSWER
+	case message.SERVICE_UNAVAILABLE, message.NOT_FOUND:
+		return header.Diversion_UNAVAILABLE
+	case message.MOVED_TEMPORARILY, message.MOVED_PERMANENTLY:
+		return header.Diversion_DEFLECTION
+	case message.DECLINE:
+		return header.Diversion_DO_NOT_DISTURB
+	}
+	return header.Diversion_UNKNOWN
+}
+
+/** Find the latest entry for a Request-URI.
+ */
+func findHistoryInfo(entries []*header.HistoryInfo, uri address.URI) *header.HistoryInfo {
+	target := stripURI(uri).String()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].GetAddress() != nil && stripURI(entries[i].GetAddress().GetURI()).String() == target {
+			return entries[i]
+		}
+	}
+	return nil
+}
+
+func newHistoryInfo(uri address.URI) *header.HistoryInfo {
+	return header.NewHistoryInfoFromAddress(newTargetAddress(uri))
+}
+
+func newTargetAddress(uri address.URI) address.Address {
+	addr := address.NewAddressImpl()
+	addr.SetURI(uri.Clone().(address.URI))
+	return addr
+}
+
+/** Copy a URI without the headers embedded in it.
+ */
+func stripURI(uri address.URI) address.URI {
+	stripped := uri.Clone().(address.URI)
+	if sipURI, ok := stripped.(*address.SipURIImpl); ok {
+		sipURI.RemoveHeaders()
+	}
+	return stripped
+}
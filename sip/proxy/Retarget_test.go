@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+	"github.com/use-go/gosips/sip/registrar"
+)
+
+func parseTestURI(t *testing.T, s string) address.URI {
+	uri, err := parser.NewURLParser(s).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uri
+}
+
+func TestRetargetForwarding(t *testing.T) {
+	request := parseTestRequest(t, strings.Replace(testInvite("sip:bob@example.com", 70),
+		"Content-Length", "Supported: histinfo\r\nContent-Length", 1))
+	retargeter := NewRetargeter()
+	retargeter.SetDiversion(true)
+
+	if err := retargeter.Retarget(request, parseTestURI(t, "sip:carol@example.com"), header.ParameterNames_MP, message.BUSY_HERE); err != nil {
+		t.Fatal(err)
+	}
+	if err := retargeter.Retarget(request, parseTestURI(t, "sip:vm@example.com"), header.ParameterNames_MP, message.REQUEST_TIMEOUT); err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := request.String()
+	if request.GetRequestURI().String() != "sip:vm@example.com" {
+		t.Fatalf("Request-URI not retargeted: %s", request.GetRequestURI())
+	}
+	if !strings.Contains(encoded, "History-Info: <sip:bob@example.com?Reason=SIP%3Bcause%3D486%3Btext%3D%22Busy%20here%22>;index=1,"+
+		"<sip:carol@example.com?Reason=SIP%3Bcause%3D408%3Btext%3D%22Request%20timeout%22>;index=1.1;mp=1,"+
+		"<sip:vm@example.com>;index=1.1.1;mp=1.1\r\n") {
+		t.Fatalf("bad History-Info:\n%s", encoded)
+	}
+	if !strings.Contains(encoded, "Diversion: <sip:carol@example.com>;reason=no-answer;counter=1,"+
+		"<sip:bob@example.com>;reason=user-busy;counter=1\r\n") {
+		t.Fatalf("bad Diversion:\n%s", encoded)
+	}
+
+	entries := GetHistoryInfo(request)
+	if entries[0].GetCause() != message.BUSY_HERE || entries[1].GetMp() != "1" {
+		t.Fatal("History-Info entries not readable")
+	}
+	if target := GetOriginalTarget(request); target.String() != "sip:bob@example.com" {
+		t.Fatalf("bad original target %s", target)
+	}
+	if target := GetDivertingTarget(request); target.String() != "sip:carol@example.com" {
+		t.Fatalf("bad diverting target %s", target)
+	}
+
+	// The same request as received by the voicemail server.
+	received := parseTestRequest(t, encoded)
+	if GetOriginalTarget(received).String() != "sip:bob@example.com" ||
+		GetDivertingTarget(received).String() != "sip:carol@example.com" {
+		t.Fatal("targets lost in transit")
+	}
+	received.RemoveHeader("History-Info")
+	if GetOriginalTarget(received).String() != "sip:bob@example.com" ||
+		GetDivertingTarget(received).String() != "sip:carol@example.com" {
+		t.Fatal("targets not found in Diversion")
+	}
+}
+
+func TestRetargetBadMode(t *testing.T) {
+	request := parseTestRequest(t, testInvite("sip:bob@example.com", 70))
+	if err := NewRetargeter().Retarget(request, parseTestURI(t, "sip:carol@example.com"), "xx", 0); err == nil {
+		t.Fatal("bad mode accepted")
+	}
+	if request.HasHeader("History-Info") || request.GetRequestURI().String() != "sip:bob@example.com" {
+		t.Fatal("request changed")
+	}
+}
+
+func TestRouteHistoryInfo(t *testing.T) {
+	locationService := registrar.NewLocationService()
+	reg := registrar.NewRegistrar(locationService)
+	register(t, reg, "192.0.2.2", "<urn:uuid:00000000-0000-1000-8000-000000000001>")
+	register(t, reg, "192.0.2.3", "<urn:uuid:00000000-0000-1000-8000-000000000002>")
+	proxy := NewProxy(locationService)
+
+	requests, _ := proxy.Route(parseTestRequest(t, testInvite("sip:alice@example.com", 70)))
+	if len(requests) != 2 || requests[0].HasHeader("History-Info") {
+		t.Fatal("History-Info added for a request that does not use it")
+	}
+
+	request := parseTestRequest(t, strings.Replace(testInvite("sip:alice@example.com", 70),
+		"Content-Length", "Supported: histinfo\r\nContent-Length", 1))
+	requests, _ = proxy.Route(request)
+	for i, forwarded := range requests {
+		entries := GetHistoryInfo(forwarded)
+		if len(entries) != 2 || entries[0].GetAddress().GetURI().String() != "sip:alice@example.com" ||
+			entries[0].GetIndex() != "1" {
+			t.Fatalf("bad History-Info:\n%s", forwarded.String())
+		}
+		if entries[1].GetIndex() != "1."+string('1'+byte(i)) || entries[1].GetRc() != "1" ||
+			entries[1].GetAddress().GetURI().String() != forwarded.GetRequestURI().String() {
+			t.Fatalf("bad History-Info for branch %d:\n%s", i+1, forwarded.String())
+		}
+	}
+	if request.HasHeader("History-Info") {
+		t.Fatal("History-Info added to the received request")
+	}
+}
@@ -0,0 +1,270 @@
+package proxy
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** The anonymous identity of RFC 3323 section 4.1.1.1.
+ */
+const (
+	TrustDomain_ANONYMOUS_DISPLAY_NAME = "Anonymous"
+	TrustDomain_ANONYMOUS_USER         = "anonymous"
+	TrustDomain_ANONYMOUS_HOST         = "anonymous.invalid"
+)
+
+/** The headers a user may fill with information that reveals it, removed
+ * for user privacy (RFC 3323 section 5.3).
+ */
+var trustDomainUserHeaders = []string{
+	core.SIPHeaderNames_SUBJECT,
+	core.SIPHeaderNames_CALL_INFO,
+	core.SIPHeaderNames_ORGANIZATION,
+	core.SIPHeaderNames_USER_AGENT,
+	core.SIPHeaderNames_REPLY_TO,
+	core.SIPHeaderNames_IN_REPLY_TO,
+}
+
+/**
+ * A TrustDomain is the policy of a proxy at the boundary of a Trust Domain
+ * for asserted identities (RFC 3325) and privacy (RFC 3323).
+ *
+ * A P-Asserted-Identity is only believed when it comes from a trusted
+ * element, and only passed on to trusted elements unless the domain is
+ * configured to assert identities to untrusted ones and the user did not
+ * ask for id privacy.
+ *
+ * When a request leaves the Trust Domain the privacy asked for in its
+ * Privacy header is applied:
+ * <ul>
+ * <li>user: the From is replaced by the anonymous identity, keeping its
+ * tag, and the headers the user may have filled are removed.
+ * <li>header: the Contact is replaced by the contact of the privacy
+ * service and the Via headers are hidden. They are returned to the caller,
+ * which restores them in the responses with RestoreVias.
+ * <li>id: the P-Asserted-Identity is removed.
+ * </ul>
+ * Session privacy needs a media relay and is not provided here.
+ */
+type TrustDomain struct {
+	mutex sync.RWMutex
+
+	hosts map[string]bool
+
+	networks []*net.IPNet
+
+	assertToUntrusted bool
+
+	contact address.Address
+}
+
+/** Create an empty Trust Domain: no element is trusted.
+ */
+func NewTrustDomain() *TrustDomain {
+	this := &TrustDomain{}
+	this.hosts = make(map[string]bool)
+	return this
+}
+
+/** Trust a host, given by name or address.
+ */
+func (this *TrustDomain) AddTrustedHost(host string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.hosts[strings.ToLower(stripBrackets(host))] = true
+}
+
+/** Trust the hosts of a network, e.g. "10.0.0.0/8".
+ */
+func (this *TrustDomain) AddTrustedNetwork(cidr string) (ParseException error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return errors.New("ParseException: bad network " + cidr)
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.networks = append(this.networks, network)
+	return nil
+}
+
+/** Return true if a host, given by name or address, is part of the Trust
+ * Domain.
+ */
+func (this *TrustDomain) IsTrusted(host string) bool {
+	host = strings.ToLower(stripBrackets(host))
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	if this.hosts[host] {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range this.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/** Pass the P-Asserted-Identity to untrusted elements when the user did
+ * not ask for id privacy. By default it is always removed.
+ */
+func (this *TrustDomain) SetAssertToUntrusted(assertToUntrusted bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.assertToUntrusted = assertToUntrusted
+}
+
+/** Set the Contact put in the requests that ask for header privacy. It
+ * should route back to the privacy service, so that the dialog still
+ * works; the anonymous URI is used when it is not set.
+ */
+func (this *TrustDomain) SetContact(contact address.Address) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.contact = contact
+}
+
+/** Process a request received from a host: an identity asserted by an
+ * untrusted element is removed (RFC 3325 section 5).
+ */
+func (this *TrustDomain) ProcessIncoming(request *message.SIPRequest, source string) {
+	if !this.IsTrusted(source) {
+		request.RemoveHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY)
+	}
+}
+
+/** Assert the identities of the authenticated user of a request. They
+ * replace the P-Preferred-Identity of the user agent (RFC 3325 section 6).
+ */
+func (this *TrustDomain) AssertIdentity(request *message.SIPRequest, identities ...address.Address) {
+	request.RemoveHeader(core.SIPHeaderNames_P_PREFERRED_IDENTITY)
+	if len(identities) == 0 {
+		request.RemoveHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY)
+		return
+	}
+	identityList := header.NewPAssertedIdentityList()
+	for _, identity := range identities {
+		identityList.PushBack(header.NewPAssertedIdentityFromAddress(identity))
+	}
+	request.SetHeader(identityList)
+}
+
+/** Process a request sent to the next hop. Within the Trust Domain the
+ * request is left untouched; when the next hop is untrusted the asserted
+ * identity and the privacy policy are applied. The Via headers hidden for
+ * header privacy are returned, so that the caller can add its own Via and
+ * restore them in the responses.
+ *
+ * @throws SipException if the request asks for a privacy that cannot be
+ * provided and marks it critical (RFC 3323 section 4.2).
+ */
+func (this *TrustDomain) ProcessOutgoing(request *message.SIPRequest, nextHop string) (hiddenVias *header.ViaList, SipException error) {
+	if this.IsTrusted(nextHop) {
+		return nil, nil
+	}
+	this.mutex.RLock()
+	assertToUntrusted, contact := this.assertToUntrusted, this.contact
+	this.mutex.RUnlock()
+
+	privacy := GetPrivacy(request)
+	if privacy != nil && privacy.HasPrivValue(header.Privacy_CRITICAL) && privacy.HasPrivValue(header.Privacy_SESSION) {
+		return nil, errors.New("SipException: session privacy is not available")
+	}
+
+	request.RemoveHeader(core.SIPHeaderNames_P_PREFERRED_IDENTITY)
+	if !assertToUntrusted || (privacy != nil && privacy.HasPrivValue(header.Privacy_ID)) {
+		request.RemoveHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY)
+	}
+	if privacy == nil || privacy.HasPrivValue(header.Privacy_NONE) {
+		return nil, nil
+	}
+
+	if privacy.HasPrivValue(header.Privacy_USER) {
+		AnonymizeFrom(request)
+		for _, name := range trustDomainUserHeaders {
+			request.RemoveHeader(name)
+		}
+	}
+	if privacy.HasPrivValue(header.Privacy_HEADER) {
+		if request.HasHeader(core.SIPHeaderNames_CONTACT) {
+			if contact == nil {
+				contact = NewAnonymousAddress()
+			}
+			contactList := header.NewContactList()
+			anonymousContact := header.NewContact()
+			anonymousContact.SetAddress(contact)
+			contactList.PushBack(anonymousContact)
+			request.SetHeader(contactList)
+		}
+		if request.HasHeader(core.SIPHeaderNames_VIA) {
+			hiddenVias = request.GetViaHeaders()
+			request.RemoveHeader(core.SIPHeaderNames_VIA)
+		}
+	}
+	return hiddenVias, nil
+}
+
+/** Restore below the Via headers of a response the Via headers hidden
+ * from the request by ProcessOutgoing.
+ */
+func RestoreVias(response *message.SIPResponse, hiddenVias *header.ViaList) {
+	if hiddenVias == nil || hiddenVias.Len() == 0 {
+		return
+	}
+	viaList := header.NewViaList()
+	if response.HasHeader(core.SIPHeaderNames_VIA) {
+		for e := response.GetViaHeaders().Front(); e != nil; e = e.Next() {
+			viaList.PushBack(e.Value)
+		}
+	}
+	for e := hiddenVias.Front(); e != nil; e = e.Next() {
+		viaList.PushBack(e.Value)
+	}
+	response.SetHeader(viaList)
+}
+
+/** Get the Privacy header of a request, or nil.
+ */
+func GetPrivacy(request *message.SIPRequest) *header.Privacy {
+	privacy, _ := request.GetHeader(core.SIPHeaderNames_PRIVACY).(*header.Privacy)
+	return privacy
+}
+
+/** Replace the From of a request by the anonymous identity, keeping its
+ * tag (RFC 3323 section 4.1.1.3).
+ */
+func AnonymizeFrom(request *message.SIPRequest) {
+	from := header.NewFrom()
+	from.SetAddress(NewAnonymousAddress())
+	if tag := request.GetFromTag(); tag != "" {
+		from.SetTag(tag)
+	}
+	request.SetFrom(from)
+}
+
+/** Create the anonymous address
+ * "Anonymous" &lt;sip:anonymous@anonymous.invalid&gt;.
+ */
+func NewAnonymousAddress() address.Address {
+	uri := address.NewSipURIImpl()
+	uri.SetUser(TrustDomain_ANONYMOUS_USER)
+	uri.SetHostString(TrustDomain_ANONYMOUS_HOST)
+	addr := address.NewAddressImpl()
+	addr.SetDisplayName(TrustDomain_ANONYMOUS_DISPLAY_NAME)
+	addr.SetURI(uri)
+	return addr
+}
+
+/** Remove the brackets of an IPv6 reference. */
+func stripBrackets(host string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
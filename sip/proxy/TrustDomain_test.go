@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/message"
+)
+
+func testPrivateInvite(privacy string) string {
+	return "INVITE sip:bob@biloxi.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP proxy.atlanta.com;branch=z9hG4bK77ef4c2312983.1\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+		"Max-Forwards: 69\r\n" +
+		"To: Bob <sip:bob@biloxi.com>\r\n" +
+		"From: \"Alice\" <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Contact: <sip:alice@pc33.atlanta.com>\r\n" +
+		"P-Asserted-Identity: \"Alice\" <sip:alice@atlanta.com>\r\n" +
+		"Subject: lunch\r\n" +
+		"Organization: Atlanta\r\n" +
+		privacy +
+		"Content-Length: 0\r\n\r\n"
+}
+
+func newTestTrustDomain(t *testing.T) *TrustDomain {
+	trustDomain := NewTrustDomain()
+	trustDomain.AddTrustedHost("proxy.atlanta.com")
+	if err := trustDomain.AddTrustedNetwork("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	return trustDomain
+}
+
+func TestTrustDomainIsTrusted(t *testing.T) {
+	trustDomain := newTestTrustDomain(t)
+	for host, trusted := range map[string]bool{
+		"proxy.atlanta.com": true,
+		"PROXY.atlanta.com": true,
+		"10.1.2.3":          true,
+		"192.0.2.1":         false,
+		"pc33.atlanta.com":  false,
+	} {
+		if trustDomain.IsTrusted(host) != trusted {
+			t.Errorf("%s: expected trusted %v", host, trusted)
+		}
+	}
+	if err := trustDomain.AddTrustedNetwork("10.0.0.0"); err == nil {
+		t.Error("bad network accepted")
+	}
+}
+
+func TestTrustDomainIncoming(t *testing.T) {
+	trustDomain := newTestTrustDomain(t)
+
+	request := parseTestRequest(t, testPrivateInvite(""))
+	trustDomain.ProcessIncoming(request, "10.0.0.1")
+	if !request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) {
+		t.Fatal("identity asserted by a trusted element removed")
+	}
+	trustDomain.ProcessIncoming(request, "192.0.2.1")
+	if request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) {
+		t.Fatal("identity asserted by an untrusted element kept")
+	}
+
+	request = parseTestRequest(t, strings.Replace(testPrivateInvite(""),
+		"P-Asserted-Identity", "P-Preferred-Identity", 1))
+	trustDomain.AssertIdentity(request, request.GetFrom().GetAddress())
+	if request.HasHeader(core.SIPHeaderNames_P_PREFERRED_IDENTITY) {
+		t.Fatal("P-Preferred-Identity kept")
+	}
+	if !strings.Contains(request.String(), "P-Asserted-Identity: \"Alice\" <sip:alice@atlanta.com>\r\n") {
+		t.Fatalf("identity not asserted:\n%s", request.String())
+	}
+}
+
+func TestTrustDomainOutgoing(t *testing.T) {
+	trustDomain := newTestTrustDomain(t)
+
+	request := parseTestRequest(t, testPrivateInvite("Privacy: id;header;user\r\n"))
+	if hidden, err := trustDomain.ProcessOutgoing(request, "10.0.0.1"); hidden != nil || err != nil {
+		t.Fatal("privacy applied within the Trust Domain")
+	}
+	if !request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) || request.GetFrom().GetAddress().GetDisplayName() != "Alice" {
+		t.Fatal("request modified within the Trust Domain")
+	}
+
+	hidden, err := trustDomain.ProcessOutgoing(request, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) {
+		t.Fatal("identity passed to an untrusted element")
+	}
+	if from := request.GetFrom(); from.GetAddress().String() != "\"Anonymous\" <sip:anonymous@anonymous.invalid>" ||
+		request.GetFromTag() != "1928301774" {
+		t.Fatalf("From not anonymized: %s", from.String())
+	}
+	if request.HasHeader(core.SIPHeaderNames_SUBJECT) || request.HasHeader(core.SIPHeaderNames_ORGANIZATION) {
+		t.Fatal("user headers kept")
+	}
+	if strings.Contains(request.String(), "pc33.atlanta.com>") {
+		t.Fatalf("Contact not anonymized:\n%s", request.String())
+	}
+	if request.HasHeader(core.SIPHeaderNames_VIA) || hidden == nil || hidden.Len() != 2 {
+		t.Fatal("Via headers not hidden")
+	}
+
+	response := parseTestRequest(t, testPrivateInvite("")).CreateResponse(message.OK)
+	response.RemoveHeader(core.SIPHeaderNames_VIA)
+	RestoreVias(response, hidden)
+	if response.GetViaHeaders().Len() != 2 || response.GetTopmostVia().GetHost() != "proxy.atlanta.com" {
+		t.Fatal("Via headers not restored")
+	}
+}
+
+func TestTrustDomainAssertToUntrusted(t *testing.T) {
+	trustDomain := newTestTrustDomain(t)
+	trustDomain.SetAssertToUntrusted(true)
+
+	request := parseTestRequest(t, testPrivateInvite(""))
+	trustDomain.ProcessOutgoing(request, "192.0.2.1")
+	if !request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) {
+		t.Fatal("identity not passed without privacy")
+	}
+
+	request = parseTestRequest(t, testPrivateInvite("Privacy: id\r\n"))
+	if hidden, _ := trustDomain.ProcessOutgoing(request, "192.0.2.1"); hidden != nil {
+		t.Fatal("Via headers hidden without header privacy")
+	}
+	if request.HasHeader(core.SIPHeaderNames_P_ASSERTED_IDENTITY) {
+		t.Fatal("identity passed with id privacy")
+	}
+	if request.GetFrom().GetAddress().GetDisplayName() != "Alice" {
+		t.Fatal("From anonymized without user privacy")
+	}
+
+	request = parseTestRequest(t, testPrivateInvite("Privacy: session;critical\r\n"))
+	if _, err := trustDomain.ProcessOutgoing(request, "192.0.2.1"); err == nil {
+		t.Fatal("critical session privacy accepted")
+	}
+}
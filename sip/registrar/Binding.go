@@ -0,0 +1,140 @@
+package registrar
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+)
+
+/**
+ * A Binding maps an address-of-record to a contact address of one of its
+ * user agents, as created by a REGISTER (RFC 3261 section 10). A user agent
+ * that registers with a +sip.instance Contact parameter is identified by its
+ * instance id (RFC 5627), and by its reg-id as well when it uses SIP
+ * Outbound (RFC 5626).
+ */
+type Binding struct {
+	aor string
+
+	contact *header.Contact
+
+	instanceId string
+
+	regId int
+
+	callId string
+
+	cseq int
+
+	expires time.Time
+
+	path []address.Address
+}
+
+/** Create a binding of an address-of-record to a Contact.
+ */
+func NewBinding(aor string, contact *header.Contact, callId string, cseq int, expires time.Time) *Binding {
+	this := &Binding{}
+	this.aor = aor
+	this.contact = contact
+	this.instanceId = contact.GetSipInstance()
+	this.regId = contact.GetRegId()
+	this.callId = callId
+	this.cseq = cseq
+	this.expires = expires
+	return this
+}
+
+/** Get the address-of-record of the binding.
+ */
+func (this *Binding) GetAOR() string {
+	return this.aor
+}
+
+/** Get the Contact the binding was registered with.
+ */
+func (this *Binding) GetContact() *header.Contact {
+	return this.contact
+}
+
+/** Get the contact address of the binding.
+ */
+func (this *Binding) GetContactURI() address.URI {
+	return this.contact.GetAddress().GetURI()
+}
+
+/** Get the instance id of the user agent (RFC 5627), e.g.
+ * "<urn:uuid:...>", or an empty string.
+ */
+func (this *Binding) GetInstanceId() string {
+	return this.instanceId
+}
+
+/** Get the reg-id of the binding (RFC 5626), or -1.
+ */
+func (this *Binding) GetRegId() int {
+	return this.regId
+}
+
+/** Get the Path the binding was registered through (RFC 3327): the route
+ * set preloaded in the requests sent to the contact.
+ */
+func (this *Binding) GetPath() []address.Address {
+	return this.path
+}
+
+/** Set the Path of the binding.
+ */
+func (this *Binding) SetPath(path []address.Address) {
+	this.path = path
+}
+
+/** Get the Call-ID of the REGISTER that last refreshed the binding.
+ */
+func (this *Binding) GetCallId() string {
+	return this.callId
+}
+
+/** Get the CSeq number of the REGISTER that last refreshed the binding.
+ */
+func (this *Binding) GetCSeq() int {
+	return this.cseq
+}
+
+/** Get the time the binding expires.
+ */
+func (this *Binding) GetExpires() time.Time {
+	return this.expires
+}
+
+/** Get the number of seconds left before the binding expires.
+ */
+func (this *Binding) GetExpiresIn(now time.Time) int {
+	seconds := int(this.expires.Sub(now) / time.Second)
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+/** Return true if the binding has expired.
+ */
+func (this *Binding) IsExpired(now time.Time) bool {
+	return !now.Before(this.expires)
+}
+
+/** Get the key that identifies the binding among the bindings of its
+ * address-of-record: the instance id and reg-id when present, else the
+ * contact address (RFC 5626 section 6, RFC 3261 section 10.3).
+ */
+func (this *Binding) GetKey() string {
+	if this.instanceId != "" {
+		if this.regId > 0 {
+			return this.instanceId + ";reg-id=" + strconv.Itoa(this.regId)
+		}
+		return this.instanceId
+	}
+	return this.GetContactURI().String()
+}
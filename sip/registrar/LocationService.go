@@ -0,0 +1,234 @@
+package registrar
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/use-go/gosips/sip/address"
+)
+
+/**
+ * The LocationService holds the bindings created by the registrar and
+ * answers the lookups of the proxy. Besides the bindings of each
+ * address-of-record it remembers the temporary GRUUs minted by the
+ * registrar (RFC 5627 section 6), so that a request addressed to a GRUU
+ * reaches the one instance it was minted for.
+ */
+type LocationService struct {
+	mutex sync.RWMutex
+
+	bindings map[string]map[string]*Binding
+
+	tempGruus map[string]*tempGruu
+}
+
+/** A temporary GRUU stays valid as long as the instance keeps refreshing
+ * its registration with the Call-ID it was minted for.
+ */
+type tempGruu struct {
+	aor string
+
+	instanceId string
+
+	callId string
+}
+
+/** Create an empty location service.
+ */
+func NewLocationService() *LocationService {
+	this := &LocationService{}
+	this.bindings = make(map[string]map[string]*Binding)
+	this.tempGruus = make(map[string]*tempGruu)
+	return this
+}
+
+/** Get the canonical address-of-record of a URI: the scheme, user and
+ * host of a SIP URI with the parameters, headers and port dropped.
+ */
+func GetAOR(uri address.URI) string {
+	sipURI, ok := uri.(*address.SipURIImpl)
+	if !ok {
+		return uri.String()
+	}
+	aor := strings.ToLower(sipURI.GetScheme()) + ":"
+	if user := sipURI.GetUser(); user != "" {
+		aor += user + "@"
+	}
+	return aor + strings.ToLower(sipURI.GetHost())
+}
+
+/** Get the bindings of an address-of-record that have not expired,
+ * ordered by key.
+ */
+func (this *LocationService) GetBindings(aor string) []*Binding {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.getBindings(aor, time.Now())
+}
+
+/** Get the binding of an address-of-record with the given key, or nil.
+ */
+func (this *LocationService) GetBinding(aor, key string) *Binding {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	binding := this.bindings[aor][key]
+	if binding == nil || binding.IsExpired(time.Now()) {
+		return nil
+	}
+	return binding
+}
+
+/** Get the live bindings of one instance of an address-of-record. An
+ * instance that uses SIP Outbound has a binding per reg-id.
+ */
+func (this *LocationService) GetInstanceBindings(aor, instanceId string) []*Binding {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	var bindings []*Binding
+	for _, binding := range this.getBindings(aor, time.Now()) {
+		if binding.GetInstanceId() == instanceId {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
+/** Add a binding, replacing the binding with the same key. When an
+ * instance registers with a new Call-ID, e.g. after a reboot, the
+ * temporary GRUUs minted for it before are invalidated (RFC 5627
+ * section 6).
+ */
+func (this *LocationService) AddBinding(binding *Binding) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	bindings := this.bindings[binding.GetAOR()]
+	if bindings == nil {
+		bindings = make(map[string]*Binding)
+		this.bindings[binding.GetAOR()] = bindings
+	}
+	bindings[binding.GetKey()] = binding
+
+	if binding.GetInstanceId() == "" {
+		return
+	}
+	for user, gruu := range this.tempGruus {
+		if gruu.aor == binding.GetAOR() && gruu.instanceId == binding.GetInstanceId() && gruu.callId != binding.GetCallId() {
+			delete(this.tempGruus, user)
+		}
+	}
+}
+
+/** Remove the binding of an address-of-record with the given key.
+ */
+func (this *LocationService) RemoveBinding(aor, key string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	delete(this.bindings[aor], key)
+	if len(this.bindings[aor]) == 0 {
+		delete(this.bindings, aor)
+	}
+	this.purgeTempGruus(time.Now())
+}
+
+/** Remove all the bindings of an address-of-record.
+ */
+func (this *LocationService) RemoveBindings(aor string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	delete(this.bindings, aor)
+	this.purgeTempGruus(time.Now())
+}
+
+/** Remember a temporary GRUU minted for an instance registered with a
+ * Call-ID. The user part of the GRUU is the key.
+ */
+func (this *LocationService) AddTempGruu(user, aor, instanceId, callId string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.tempGruus[user] = &tempGruu{aor: aor, instanceId: instanceId, callId: callId}
+}
+
+/** Resolve a GRUU to the bindings of the instance it designates. The
+ * known result is false when the URI is not a GRUU minted here, and the
+ * bindings are empty when the GRUU is valid but the instance is not
+ * registered (RFC 5627 section 5.2: 404 and 480 respectively).
+ */
+func (this *LocationService) ResolveGruu(uri *address.SipURIImpl) (bindings []*Binding, known bool) {
+	if !uri.IsGruu() {
+		return nil, false
+	}
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	now := time.Now()
+
+	if instance := uri.GetGrParam(); instance != "" {
+		// A public GRUU is the AOR with the instance id in gr, and
+		// stays valid for as long as the AOR exists.
+		return this.filterInstance(GetAOR(uri), "<"+instance+">", "", now), true
+	}
+
+	gruu := this.tempGruus[uri.GetUser()]
+	if gruu == nil {
+		return nil, false
+	}
+	return this.filterInstance(gruu.aor, gruu.instanceId, gruu.callId, now), true
+}
+
+/** Remove the expired bindings and the temporary GRUUs that are no longer
+ * valid.
+ */
+func (this *LocationService) Purge() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	now := time.Now()
+	for aor, bindings := range this.bindings {
+		for key, binding := range bindings {
+			if binding.IsExpired(now) {
+				delete(bindings, key)
+			}
+		}
+		if len(bindings) == 0 {
+			delete(this.bindings, aor)
+		}
+	}
+	this.purgeTempGruus(now)
+}
+
+/** Remove the temporary GRUUs of the instances that are no longer
+ * registered with the Call-ID the GRUUs were minted for. Called with the
+ * mutex held.
+ */
+func (this *LocationService) purgeTempGruus(now time.Time) {
+	for user, gruu := range this.tempGruus {
+		if len(this.filterInstance(gruu.aor, gruu.instanceId, gruu.callId, now)) == 0 {
+			delete(this.tempGruus, user)
+		}
+	}
+}
+
+/** Called with the mutex held. */
+func (this *LocationService) getBindings(aor string, now time.Time) []*Binding {
+	var bindings []*Binding
+	for _, binding := range this.bindings[aor] {
+		if !binding.IsExpired(now) {
+			bindings = append(bindings, binding)
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].GetKey() < bindings[j].GetKey()
+	})
+	return bindings
+}
+
+/** Called with the mutex held. */
+func (this *LocationService) filterInstance(aor, instanceId, callId string, now time.Time) []*Binding {
+	var bindings []*Binding
+	for _, binding := range this.getBindings(aor, now) {
+		if binding.GetInstanceId() == instanceId && (callId == "" || binding.GetCallId() == callId) {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
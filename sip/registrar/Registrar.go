@@ -0,0 +1,325 @@
+package registrar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+	"github.com/use-go/gosips/sip/stack"
+)
+
+/** Option tags of GRUU (RFC 5627) and Path (RFC 3327) and the default
+ * registration intervals in seconds.
+ */
+const (
+	Registrar_GRUU_OPTION_TAG = "gruu"
+	Registrar_PATH_OPTION_TAG = "path"
+	Registrar_DEFAULT_EXPIRES = 3600
+	Registrar_MIN_EXPIRES     = 60
+	Registrar_MAX_EXPIRES     = 86400
+)
+
+/** Number of random bytes in the user part of a temporary GRUU: enough
+ * that a GRUU cannot be guessed (RFC 5627 section 3.2).
+ */
+const Registrar_TEMP_GRUU_BYTES = 16
+
+/**
+ * A Registrar processes REGISTER requests (RFC 3261 section 10.3) and
+ * keeps the bindings in a LocationService. When the user agent supports
+ * GRUU and registers with a +sip.instance, the registrar mints a public
+ * GRUU and a temporary GRUU for the instance and returns them in the
+ * pub-gruu and temp-gruu parameters of its Contact (RFC 5627).
+ *
+ * The Path of a REGISTER is stored with its bindings (RFC 3327) and the
+ * Service-Route of the registrar, if any, is returned in the 200 response
+ * (RFC 3608).
+ *
+ * The Registrar is a Handler, so it can be installed in a ServeMux for the
+ * REGISTER method.
+ */
+type Registrar struct {
+	locationService *LocationService
+
+	minExpires int
+
+	maxExpires int
+
+	defaultExpires int
+
+	serviceRoute []address.Address
+}
+
+/** Create a registrar that stores its bindings in a location service.
+ */
+func NewRegistrar(locationService *LocationService) *Registrar {
+	this := &Registrar{}
+	this.locationService = locationService
+	this.minExpires = Registrar_MIN_EXPIRES
+	this.maxExpires = Registrar_MAX_EXPIRES
+	this.defaultExpires = Registrar_DEFAULT_EXPIRES
+	return this
+}
+
+/** Get the location service of the registrar.
+ */
+func (this *Registrar) GetLocationService() *LocationService {
+	return this.locationService
+}
+
+/** Set the shortest, longest and default registration intervals in
+ * seconds.
+ */
+func (this *Registrar) SetExpires(minExpires, maxExpires, defaultExpires int) {
+	this.minExpires = minExpires
+	this.maxExpires = maxExpires
+	this.defaultExpires = defaultExpires
+}
+
+/** Set the Service-Route returned to the user agents that register: the
+ * route set they preload in the requests they send (RFC 3608).
+ */
+func (this *Registrar) SetServiceRoute(serviceRoute []address.Address) {
+	this.serviceRoute = serviceRoute
+}
+
+/** Get the Service-Route returned to the user agents that register.
+ */
+func (this *Registrar) GetServiceRoute() []address.Address {
+	return this.serviceRoute
+}
+
+/** Answer a REGISTER on its server transaction.
+ */
+func (this *Registrar) ServeSIP(st sip.ServerTransaction, request *message.SIPRequest) {
+	st.SendResponse(this.ProcessRegister(request))
+}
+
+/** Process a REGISTER and return the response: 200 with the current
+ * bindings of the address-of-record, or the error response.
+ */
+func (this *Registrar) ProcessRegister(request *message.SIPRequest) *message.SIPResponse {
+	if request.GetMethod() != message.REGISTER {
+		return this.createResponse(request, message.METHOD_NOT_ALLOWED)
+	}
+	to, ok := request.GetTo().(*header.To)
+	if !ok || to == nil || to.GetAddress() == nil {
+		return this.createResponse(request, message.BAD_REQUEST)
+	}
+	aor := GetAOR(to.GetAddress().GetURI())
+	if !request.HasHeader(core.SIPHeaderNames_CALL_ID) || !request.HasHeader(core.SIPHeaderNames_CSEQ) {
+		return this.createResponse(request, message.BAD_REQUEST)
+	}
+	callId := request.GetCallIdentifier()
+	cseq := request.GetCSeq().GetSequenceNumber()
+	now := time.Now()
+
+	var contacts []*header.Contact
+	if request.HasHeader(core.SIPHeaderNames_CONTACT) {
+		for e := request.GetContactHeaders().Front(); e != nil; e = e.Next() {
+			contacts = append(contacts, e.Value.(*header.Contact))
+		}
+	}
+
+	for _, contact := range contacts {
+		if !isWildcard(contact) {
+			continue
+		}
+		// "Contact: *" removes all the bindings; it must come alone with
+		// Expires: 0 (RFC 3261 section 10.3 step 6).
+		if len(contacts) != 1 || this.getRequestExpires(request) != 0 {
+			return this.createResponse(request, message.BAD_REQUEST)
+		}
+		for _, binding := range this.locationService.GetBindings(aor) {
+			if binding.GetCallId() == callId && binding.GetCSeq() >= cseq {
+				return this.createResponse(request, message.SERVER_INTERNAL_ERROR)
+			}
+		}
+		this.locationService.RemoveBindings(aor)
+		return this.createOKResponse(request, aor, nil, now)
+	}
+
+	for _, contact := range contacts {
+		expires := this.getContactExpires(request, contact)
+		if expires > 0 && expires < this.minExpires {
+			response := this.createResponse(request, message.INTERVAL_TOO_BRIEF)
+			minExpires := header.NewMinExpires()
+			minExpires.SetExpires(this.minExpires)
+			response.SetMinExpiresHeader(minExpires)
+			return response
+		}
+	}
+
+	var path []address.Address
+	if request.HasHeader(core.SIPHeaderNames_PATH) {
+		for e := request.GetSIPHeaderList(core.SIPHeaderNames_PATH).Front(); e != nil; e = e.Next() {
+			path = append(path, e.Value.(*header.Path).GetAddress())
+		}
+	}
+
+	// The bindings are checked before any of them is applied, so that the
+	// update is all or none (RFC 3261 section 10.3 step 7).
+	bindings := make([]*Binding, 0, len(contacts))
+	for _, contact := range contacts {
+		expires := this.getContactExpires(request, contact)
+		if expires > this.maxExpires {
+			expires = this.maxExpires
+		}
+		binding := NewBinding(aor, contact, callId, cseq, now.Add(time.Duration(expires)*time.Second))
+		binding.SetPath(path)
+		if existing := this.locationService.GetBinding(aor, binding.GetKey()); existing != nil &&
+			existing.GetCallId() == callId && existing.GetCSeq() >= cseq {
+			// An out of order REGISTER.
+			return this.createResponse(request, message.SERVER_INTERNAL_ERROR)
+		}
+		bindings = append(bindings, binding)
+	}
+
+	minted := make(map[string]string)
+	for _, binding := range bindings {
+		// A Contact with expires 0 removes its binding.
+		if binding.IsExpired(now) {
+			this.locationService.RemoveBinding(aor, binding.GetKey())
+			continue
+		}
+		this.locationService.AddBinding(binding)
+		if binding.GetInstanceId() != "" && stack.HasOptionTag(&request.SIPMessage, Registrar_GRUU_OPTION_TAG) {
+			if gruu, ok := this.mintTempGruu(binding); ok {
+				minted[binding.GetKey()] = gruu
+			}
+		}
+	}
+	return this.createOKResponse(request, aor, minted, now)
+}
+
+/** Create the public GRUU of an instance of an address-of-record: the
+ * AOR with the instance id in the gr parameter (RFC 5627 section 3.1).
+ */
+func CreatePubGruu(aor, instanceId string) string {
+	instance := strings.TrimSuffix(strings.TrimPrefix(instanceId, "<"), ">")
+	return aor + ";" + core.SIPTransportNames_GR + "=" + instance
+}
+
+/** Mint a new temporary GRUU for the instance of a binding. Every
+ * registration gets a new one and the previous ones stay valid while the
+ * instance keeps its Call-ID (RFC 5627 section 3.2). The GRUU has the
+ * scheme, host and port of the address-of-record; none is minted for an
+ * address-of-record that is not a SIP URI.
+ */
+func (this *Registrar) mintTempGruu(binding *Binding) (gruu string, ok bool) {
+	scheme, aor := core.SIPTransportNames_SIP, binding.GetAOR()
+	if strings.HasPrefix(aor, core.SIPTransportNames_SIPS+":") {
+		// The URL parser only knows the sip scheme.
+		scheme = core.SIPTransportNames_SIPS
+		aor = core.SIPTransportNames_SIP + aor[len(core.SIPTransportNames_SIPS):]
+	}
+	parsed, err := parser.NewURLParser(aor).Parse()
+	if err != nil {
+		return "", false
+	}
+	aorURI, ok := parsed.(*address.SipURIImpl)
+	if !ok || aorURI.GetHostPort() == nil {
+		return "", false
+	}
+	b := make([]byte, Registrar_TEMP_GRUU_BYTES)
+	rand.Read(b)
+	user := "tgruu." + hex.EncodeToString(b)
+	uri := address.NewSipURIImpl()
+	uri.SetScheme(scheme)
+	uri.SetUser(user)
+	uri.SetHostPort(aorURI.GetHostPort())
+	uri.SetGrParam("")
+	this.locationService.AddTempGruu(user, binding.GetAOR(), binding.GetInstanceId(), binding.GetCallId())
+	return uri.String(), true
+}
+
+/** Get the registration interval asked for by a Contact: its expires
+ * parameter, else the Expires header, else the default.
+ */
+func (this *Registrar) getContactExpires(request *message.SIPRequest, contact *header.Contact) int {
+	if contact.HasParameter(header.ParameterNames_EXPIRES) {
+		if expires, err := strconv.Atoi(contact.GetParameter(header.ParameterNames_EXPIRES)); err == nil && expires >= 0 {
+			return expires
+		}
+	}
+	if expires := this.getRequestExpires(request); expires >= 0 {
+		return expires
+	}
+	return this.defaultExpires
+}
+
+/** Get the value of the Expires header, or -1 if it is absent.
+ */
+func (this *Registrar) getRequestExpires(request *message.SIPRequest) int {
+	if !request.HasHeader(core.SIPHeaderNames_EXPIRES) {
+		return -1
+	}
+	return request.GetExpires().GetExpires()
+}
+
+func (this *Registrar) createResponse(request *message.SIPRequest, statusCode int) *message.SIPResponse {
+	response := request.CreateResponse(statusCode)
+	stack.SetResponseToTag(response, stack.GenerateTag())
+	return response
+}
+
+/** Create the 200 response listing the current bindings. Each Contact
+ * carries its remaining expires and, for the instances that asked for
+ * GRUUs, the pub-gruu and the temp-gruu minted by this registration.
+ */
+func (this *Registrar) createOKResponse(request *message.SIPRequest, aor string, minted map[string]string, now time.Time) *message.SIPResponse {
+	response := this.createResponse(request, message.OK)
+	if request.HasHeader(core.SIPHeaderNames_PATH) && stack.HasOptionTag(&request.SIPMessage, Registrar_PATH_OPTION_TAG) {
+		response.SetHeader(request.GetSIPHeaderList(core.SIPHeaderNames_PATH))
+	}
+	if len(this.serviceRoute) > 0 {
+		serviceRouteList := header.NewServiceRouteList()
+		for _, addr := range this.serviceRoute {
+			serviceRouteList.PushBack(header.NewServiceRouteFromAddress(addr))
+		}
+		response.SetHeader(serviceRouteList)
+	}
+	bindings := this.locationService.GetBindings(aor)
+	if len(bindings) == 0 {
+		return response
+	}
+	contactList := header.NewContactList()
+	for _, binding := range bindings {
+		contact := header.NewContact()
+		contact.SetAddress(binding.GetContact().GetAddress())
+		for e := binding.GetContact().GetParameters().Front(); e != nil; e = e.Next() {
+			nv := e.Value.(*core.NameValue)
+			switch strings.ToLower(nv.GetName()) {
+			case header.ParameterNames_EXPIRES, header.ParameterNames_PUB_GRUU, header.ParameterNames_TEMP_GRUU:
+				continue
+			}
+			contact.SetParameterFromNameValue(nv.Clone().(*core.NameValue))
+		}
+		contact.SetExpires(binding.GetExpiresIn(now))
+		if tempGruu, ok := minted[binding.GetKey()]; ok {
+			contact.SetPubGruu(CreatePubGruu(aor, binding.GetInstanceId()))
+			contact.SetTempGruu(tempGruu)
+		}
+		contactList.PushBack(contact)
+	}
+	response.SetHeader(contactList)
+	return response
+}
+
+/** The parser marks "Contact: *" on the address of the Contact.
+ */
+func isWildcard(contact *header.Contact) bool {
+	if contact.GetWildCardFlag() {
+		return true
+	}
+	addr, ok := contact.GetAddress().(*address.AddressImpl)
+	return ok && addr.IsWildcard()
+}
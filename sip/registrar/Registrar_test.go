@@ -0,0 +1,178 @@
+package registrar
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+const testInstance = "<urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6>"
+
+func testRegister(t *testing.T, callId string, cseq int, contact string, extra string) *message.SIPRequest {
+	s := "REGISTER sip:example.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP 192.0.2.2;branch=z9hG4bKnashds7" + strconv.Itoa(cseq) + "\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"From: Alice <sip:alice@example.com>;tag=456248\r\n" +
+		"To: Alice <sip:alice@example.com>\r\n" +
+		"Call-ID: " + callId + "\r\n" +
+		"CSeq: " + strconv.Itoa(cseq) + " REGISTER\r\n" +
+		"Contact: " + contact + "\r\n" +
+		extra +
+		"Content-Length: 0\r\n\r\n"
+	msg, err := parser.NewStringMsgParser().ParseSIPMessage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.(*message.SIPRequest)
+}
+
+func firstContact(t *testing.T, response *message.SIPResponse) *header.Contact {
+	if response.GetStatusCode() != message.OK || !response.HasHeader("Contact") {
+		t.Fatalf("bad response %s", response.String())
+	}
+	return response.GetContactHeaders().Front().Value.(*header.Contact)
+}
+
+func TestRegisterAndRemove(t *testing.T) {
+	reg := NewRegistrar(NewLocationService())
+	response := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 1, "<sip:alice@192.0.2.2>", "Expires: 600\r\n"))
+	if contact := firstContact(t, response); contact.GetExpires() != 600 {
+		t.Fatal("bad expires " + contact.String())
+	}
+	if !response.HasToTag() {
+		t.Fatal("no To tag")
+	}
+	if len(reg.GetLocationService().GetBindings("sip:alice@example.com")) != 1 {
+		t.Fatal("binding not stored")
+	}
+
+	if r := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 1, "<sip:alice@192.0.2.2>", "")); r.GetStatusCode() != message.SERVER_INTERNAL_ERROR {
+		t.Fatal("out of order REGISTER accepted")
+	}
+	// The out of order Contact rejects the whole REGISTER.
+	if r := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 1, "<sip:alice@192.0.2.3>, <sip:alice@192.0.2.2>", "")); r.GetStatusCode() != message.SERVER_INTERNAL_ERROR {
+		t.Fatal("out of order REGISTER accepted")
+	}
+	if len(reg.GetLocationService().GetBindings("sip:alice@example.com")) != 1 {
+		t.Fatal("rejected REGISTER partially applied")
+	}
+	if r := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 2, "<sip:alice@192.0.2.2>", "Expires: 10\r\n")); r.GetStatusCode() != message.INTERVAL_TOO_BRIEF {
+		t.Fatal("short interval accepted")
+	}
+	if r := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 3, "*", "")); r.GetStatusCode() != message.BAD_REQUEST {
+		t.Fatal("wildcard without Expires: 0 accepted")
+	}
+	if r := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 4, "*", "Expires: 0\r\n")); r.GetStatusCode() != message.OK || r.HasHeader("Contact") {
+		t.Fatal("bindings not removed " + r.String())
+	}
+	if len(reg.GetLocationService().GetBindings("sip:alice@example.com")) != 0 {
+		t.Fatal("binding not removed")
+	}
+}
+
+func TestRegisterGruu(t *testing.T) {
+	reg := NewRegistrar(NewLocationService())
+	contact := "<sip:alice@192.0.2.2>;+sip.instance=\"" + testInstance + "\""
+
+	noGruu := firstContact(t, reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 1, contact, "")))
+	if noGruu.GetPubGruu() != "" || noGruu.GetTempGruu() != "" {
+		t.Fatal("GRUU minted without Supported: gruu")
+	}
+
+	first := firstContact(t, reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 2, contact, "Supported: gruu\r\n")))
+	if first.GetPubGruu() != "sip:alice@example.com;gr=urn:uuid:f81d4fae-7dec-11d0-a765-00a0c91e6bf6" {
+		t.Fatal("bad pub-gruu " + first.GetPubGruu())
+	}
+	if first.GetSipInstance() != testInstance {
+		t.Fatal("instance not returned " + first.String())
+	}
+	second := firstContact(t, reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 3, contact, "Supported: gruu\r\n")))
+	if second.GetTempGruu() == first.GetTempGruu() {
+		t.Fatal("temp-gruu not minted again")
+	}
+
+	for _, gruu := range []string{first.GetPubGruu(), first.GetTempGruu(), second.GetTempGruu()} {
+		uri, err := parser.NewURLParser(gruu).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bindings, known := reg.GetLocationService().ResolveGruu(uri.(*address.SipURIImpl))
+		if !known || len(bindings) != 1 || bindings[0].GetInstanceId() != testInstance {
+			t.Fatalf("%s not resolved", gruu)
+		}
+	}
+
+	// A registration with a new Call-ID invalidates the temporary GRUUs.
+	third := firstContact(t, reg.ProcessRegister(testRegister(t, "2@192.0.2.2", 1, contact, "Supported: gruu\r\n")))
+	uri, _ := parser.NewURLParser(first.GetTempGruu()).Parse()
+	if _, known := reg.GetLocationService().ResolveGruu(uri.(*address.SipURIImpl)); known {
+		t.Fatal("old temp-gruu still valid")
+	}
+
+	// Removing the binding forgets its temporary GRUUs.
+	reg.ProcessRegister(testRegister(t, "2@192.0.2.2", 2, contact+";expires=0", ""))
+	uri, _ = parser.NewURLParser(third.GetTempGruu()).Parse()
+	if _, known := reg.GetLocationService().ResolveGruu(uri.(*address.SipURIImpl)); known {
+		t.Fatal("temp-gruu of a removed binding still valid")
+	}
+}
+
+func TestMintTempGruu(t *testing.T) {
+	reg := NewRegistrar(NewLocationService())
+	contacts, err := parser.NewContactParser("Contact: <sip:192.0.2.2>;+sip.instance=\"" + testInstance + "\"\n").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contact := contacts.(*header.ContactList).Front().Value.(*header.Contact)
+	for aor, hostPort := range map[string]string{
+		"sip:example.com":           "example.com",
+		"sips:bob@example.com":      "example.com",
+		"sip:carol@[2001:db8::1]":   "[2001:db8::1]",
+		"sip:dave@example.com:5070": "example.com:5070",
+	} {
+		binding := NewBinding(aor, contact, "1@192.0.2.2", 1, time.Now().Add(time.Hour))
+		gruu, ok := reg.mintTempGruu(binding)
+		if !ok {
+			t.Fatalf("no temp-gruu for %s", aor)
+		}
+		scheme := aor[:strings.Index(aor, ":")]
+		if !strings.HasPrefix(gruu, scheme+":tgruu.") || !strings.HasSuffix(gruu, "@"+hostPort+";gr") {
+			t.Fatalf("bad temp-gruu %s for %s", gruu, aor)
+		}
+	}
+	binding := NewBinding("tel:+1-201-555-0123", contact, "1@192.0.2.2", 1, time.Now().Add(time.Hour))
+	if _, ok := reg.mintTempGruu(binding); ok {
+		t.Fatal("temp-gruu minted for a tel URL")
+	}
+}
+
+func TestRegisterPathAndServiceRoute(t *testing.T) {
+	reg := NewRegistrar(NewLocationService())
+	serviceRoute, err := parser.NewServiceRouteParser("Service-Route: <sip:orig@scscf.example.com;lr>\n").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.SetServiceRoute([]address.Address{serviceRoute.(*header.ServiceRouteList).Front().Value.(*header.ServiceRoute).GetAddress()})
+
+	response := reg.ProcessRegister(testRegister(t, "1@192.0.2.2", 1, "<sip:alice@192.0.2.2>",
+		"Path: <sip:p2.example.com;lr>,<sip:p1.example.net;lr>\r\nSupported: path\r\n"))
+	firstContact(t, response)
+	if !response.HasHeader("Path") || response.GetSIPHeaderList("Path").Len() != 2 {
+		t.Fatal("Path not echoed " + response.String())
+	}
+	if !response.HasHeader("Service-Route") {
+		t.Fatal("no Service-Route " + response.String())
+	}
+
+	bindings := reg.GetLocationService().GetBindings("sip:alice@example.com")
+	if len(bindings) != 1 || len(bindings[0].GetPath()) != 2 ||
+		bindings[0].GetPath()[0].GetURI().String() != "sip:p2.example.com;lr" {
+		t.Fatal("Path not stored")
+	}
+}
@@ -0,0 +1,285 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+/** Default number of workers of an EventDispatcher.
+ */
+const EventDispatcher_DEFAULT_WORKERS = 8
+
+/** Default number of events an EventDispatcher holds before Dispatch
+ * blocks.
+ */
+const EventDispatcher_DEFAULT_MAX_PENDING = 1024
+
+/**
+ * A snapshot of the counters of an EventDispatcher.
+ */
+type DispatcherMetrics struct {
+	/** Events accepted by Dispatch or TryDispatch. */
+	Dispatched uint64
+	/** Events the listener has finished processing. */
+	Completed uint64
+	/** Events refused by TryDispatch because the dispatcher was full. */
+	Rejected uint64
+	/** Calls to Dispatch that had to wait for room. */
+	Blocked uint64
+	/** Calls to Dispatch from an event that went over the limit instead
+	 * of waiting for room. */
+	Reentrant uint64
+	/** Events whose handler panicked. */
+	Panics uint64
+	/** Events queued or running. */
+	Pending int
+	/** Highest value Pending has reached. */
+	MaxPending int
+	/** Keys with events queued or running. */
+	Queues int
+	/** Longest queue a single key has reached. */
+	MaxQueueDepth int
+}
+
+type dispatchQueue struct {
+	key   string
+	tasks []func()
+}
+
+/**
+ * EventDispatcher runs the events of the stack on a bounded pool of
+ * goroutines. Events submitted with the same key run one at a time, in
+ * the order they were submitted; events with different keys run in
+ * parallel. When the number of pending events reaches the limit, Dispatch
+ * blocks until a worker catches up, which pushes back on the transport
+ * that feeds the stack. An event that dispatches another one does not
+ * wait, since its worker would wait for itself.
+ */
+type EventDispatcher struct {
+	mutex   sync.Mutex
+	notFull *sync.Cond
+	drained *sync.Cond
+
+	queues map[string]*dispatchQueue
+	ready  chan *dispatchQueue
+
+	maxPending int
+	closed     bool
+	workers    sync.WaitGroup
+	workerIds  map[uint64]bool
+
+	growthThreshold int
+	growthHandler   func(key string, depth int)
+
+	metrics DispatcherMetrics
+}
+
+/** Create a dispatcher with the given number of workers that holds at most
+ * maxPending events. Values that are not positive select the defaults.
+ */
+func NewEventDispatcher(workers, maxPending int) *EventDispatcher {
+	if workers <= 0 {
+		workers = EventDispatcher_DEFAULT_WORKERS
+	}
+	if maxPending <= 0 {
+		maxPending = EventDispatcher_DEFAULT_MAX_PENDING
+	}
+	this := &EventDispatcher{}
+	this.notFull = sync.NewCond(&this.mutex)
+	this.drained = sync.NewCond(&this.mutex)
+	this.queues = make(map[string]*dispatchQueue)
+	this.workerIds = make(map[uint64]bool)
+	// A queue is in the ready channel at most once and only while it has
+	// pending events, so the channel never fills up.
+	this.ready = make(chan *dispatchQueue, maxPending)
+	this.maxPending = maxPending
+	this.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go this.work()
+	}
+	return this
+}
+
+/** Set a handler called whenever the queue of a key grows to threshold
+ * events or more. The handler is called on the goroutine that dispatches
+ * the event and must not block.
+ */
+func (this *EventDispatcher) SetQueueGrowthHandler(threshold int, handler func(key string, depth int)) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.growthThreshold = threshold
+	this.growthHandler = handler
+}
+
+/** Queue an event for a key, blocking while the dispatcher is full. A
+ * call from an event, e.g. a listener that sends a request, goes over the
+ * limit instead: if every worker waited for room, none would be left to
+ * make it.
+ */
+func (this *EventDispatcher) Dispatch(key string, task func()) (DispatcherException error) {
+	return this.dispatch(key, task, true)
+}
+
+/** Queue an event for a key, failing instead of blocking when the
+ * dispatcher is full.
+ */
+func (this *EventDispatcher) TryDispatch(key string, task func()) (DispatcherException error) {
+	return this.dispatch(key, task, false)
+}
+
+/** Get a snapshot of the counters of the dispatcher.
+ */
+func (this *EventDispatcher) GetMetrics() DispatcherMetrics {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	metrics := this.metrics
+	metrics.Queues = len(this.queues)
+	return metrics
+}
+
+/** Wait for the pending events to run and stop the workers. Events
+ * dispatched after Close are refused.
+ */
+func (this *EventDispatcher) Close() {
+	this.mutex.Lock()
+	if this.closed {
+		this.mutex.Unlock()
+		return
+	}
+	this.closed = true
+	this.notFull.Broadcast()
+	for this.metrics.Pending > 0 {
+		this.drained.Wait()
+	}
+	close(this.ready)
+	this.mutex.Unlock()
+	this.workers.Wait()
+}
+
+func (this *EventDispatcher) dispatch(key string, task func(), wait bool) (DispatcherException error) {
+	this.mutex.Lock()
+	if !this.closed && this.metrics.Pending >= this.maxPending {
+		if !wait {
+			this.metrics.Rejected++
+			this.mutex.Unlock()
+			return errors.New("DispatcherException: Too many pending events")
+		}
+		if this.workerIds[goroutineId()] {
+			this.metrics.Reentrant++
+		} else {
+			this.metrics.Blocked++
+			for !this.closed && this.metrics.Pending >= this.maxPending {
+				this.notFull.Wait()
+			}
+		}
+	}
+	if this.closed {
+		this.mutex.Unlock()
+		return errors.New("DispatcherException: Dispatcher is closed")
+	}
+
+	q := this.queues[key]
+	schedule := q == nil
+	if schedule {
+		q = &dispatchQueue{key: key}
+		this.queues[key] = q
+	}
+	q.tasks = append(q.tasks, task)
+	depth := len(q.tasks)
+
+	this.metrics.Dispatched++
+	this.metrics.Pending++
+	if this.metrics.Pending > this.metrics.MaxPending {
+		this.metrics.MaxPending = this.metrics.Pending
+	}
+	if depth > this.metrics.MaxQueueDepth {
+		this.metrics.MaxQueueDepth = depth
+	}
+	var growthHandler func(key string, depth int)
+	if this.growthHandler != nil && this.growthThreshold > 0 && depth >= this.growthThreshold {
+		growthHandler = this.growthHandler
+	}
+	this.mutex.Unlock()
+
+	if schedule {
+		this.ready <- q
+	}
+	if growthHandler != nil {
+		growthHandler(key, depth)
+	}
+	return nil
+}
+
+func (this *EventDispatcher) work() {
+	defer this.workers.Done()
+	id := goroutineId()
+	this.mutex.Lock()
+	this.workerIds[id] = true
+	this.mutex.Unlock()
+	for q := range this.ready {
+		this.runOne(q)
+	}
+	this.mutex.Lock()
+	delete(this.workerIds, id)
+	this.mutex.Unlock()
+}
+
+/** Run the event at the head of a queue. The queue goes back to the end
+ * of the ready channel if it has more events, so that a busy key does not
+ * hold on to a worker.
+ */
+func (this *EventDispatcher) runOne(q *dispatchQueue) {
+	this.mutex.Lock()
+	task := q.tasks[0]
+	this.mutex.Unlock()
+
+	panicked := this.run(task)
+
+	this.mutex.Lock()
+	q.tasks[0] = nil
+	q.tasks = q.tasks[1:]
+	reschedule := len(q.tasks) > 0
+	if !reschedule {
+		delete(this.queues, q.key)
+	}
+	this.metrics.Pending--
+	this.metrics.Completed++
+	if panicked {
+		this.metrics.Panics++
+	}
+	this.notFull.Signal()
+	if this.metrics.Pending == 0 {
+		this.drained.Broadcast()
+	}
+	this.mutex.Unlock()
+
+	if reschedule {
+		this.ready <- q
+	}
+}
+
+func (this *EventDispatcher) run(task func()) (panicked bool) {
+	defer func() {
+		if recover() != nil {
+			panicked = true
+		}
+	}()
+	task()
+	return false
+}
+
+/** Get the id of the calling goroutine from the header of its stack
+ * trace, "goroutine N [...]". It is only used when the dispatcher is full.
+ */
+func goroutineId() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if n := bytes.IndexByte(b, ' '); n >= 0 {
+		b = b[:n]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
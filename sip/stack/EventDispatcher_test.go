@@ -0,0 +1,111 @@
+package stack
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventDispatcherOrder(t *testing.T) {
+	dispatcher := NewEventDispatcher(4, 64)
+
+	var mutex sync.Mutex
+	seen := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for k := 0; k < 8; k++ {
+			key, n := fmt.Sprintf("call-%d", k), i
+			if err := dispatcher.Dispatch(key, func() {
+				mutex.Lock()
+				seen[key] = append(seen[key], n)
+				mutex.Unlock()
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	dispatcher.Close()
+
+	for k := 0; k < 8; k++ {
+		events := seen[fmt.Sprintf("call-%d", k)]
+		if len(events) != 100 {
+			t.Fatalf("call-%d: %d events", k, len(events))
+		}
+		for i, n := range events {
+			if n != i {
+				t.Fatalf("call-%d: event %d ran at position %d", k, n, i)
+			}
+		}
+	}
+	metrics := dispatcher.GetMetrics()
+	if metrics.Dispatched != 800 || metrics.Completed != 800 || metrics.Pending != 0 {
+		t.Fatalf("bad metrics %+v", metrics)
+	}
+	if metrics.MaxPending > 64 {
+		t.Fatalf("more than 64 pending events: %d", metrics.MaxPending)
+	}
+}
+
+func TestEventDispatcherBackPressure(t *testing.T) {
+	dispatcher := NewEventDispatcher(1, 2)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var grown string
+	dispatcher.SetQueueGrowthHandler(2, func(key string, depth int) {
+		grown = key
+	})
+	dispatcher.Dispatch("a", func() {
+		close(started)
+		<-release
+	})
+	<-started
+	if err := dispatcher.TryDispatch("a", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dispatcher.TryDispatch("b", func() {}); err == nil {
+		t.Fatal("full dispatcher accepted an event")
+	}
+	if grown != "a" {
+		t.Fatal("queue growth not reported")
+	}
+	close(release)
+	dispatcher.Close()
+
+	metrics := dispatcher.GetMetrics()
+	if metrics.Rejected != 1 || metrics.Completed != 2 || metrics.MaxQueueDepth != 2 {
+		t.Fatalf("bad metrics %+v", metrics)
+	}
+	if err := dispatcher.Dispatch("a", func() {}); err == nil {
+		t.Fatal("closed dispatcher accepted an event")
+	}
+}
+
+func TestEventDispatcherReentrant(t *testing.T) {
+	dispatcher := NewEventDispatcher(1, 1)
+	var order []string
+	done := make(chan struct{})
+	dispatcher.Dispatch("a", func() {
+		order = append(order, "first")
+		// The dispatcher is full: waiting here would wait for this worker.
+		if err := dispatcher.Dispatch("a", func() {
+			order = append(order, "second")
+			close(done)
+		}); err != nil {
+			t.Error(err)
+		}
+	})
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("re-entrant Dispatch deadlocked")
+	}
+	dispatcher.Close()
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("bad order %v", order)
+	}
+	if metrics := dispatcher.GetMetrics(); metrics.Reentrant != 1 || metrics.Blocked != 0 || metrics.Completed != 2 {
+		t.Fatalf("bad metrics %+v", metrics)
+	}
+}
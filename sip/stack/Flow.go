@@ -0,0 +1,336 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/** Keepalive messages of SIP Outbound (RFC 5626 section 3.5.1). The client
+ * sends a double CRLF "ping" on a connection-oriented flow and the server
+ * answers with a single CRLF "pong".
+ */
+const (
+	Flow_PING = "\r\n\r\n"
+	Flow_PONG = "\r\n"
+)
+
+/** Default keepalive intervals (RFC 5626 section 4.4.1), used when the
+ * registrar sends no Flow-Timer.
+ */
+const (
+	Flow_DEFAULT_RELIABLE_INTERVAL   = 120 * time.Second
+	Flow_DEFAULT_UNRELIABLE_INTERVAL = 29 * time.Second
+	Flow_PONG_TIMEOUT                = 10 * time.Second
+)
+
+/**
+ * A KeepAliveChannel is a MessageChannel that can also carry the raw
+ * keepalive messages of SIP Outbound.
+ */
+type KeepAliveChannel interface {
+	MessageChannel
+
+	/** Send raw bytes on the channel.
+	 */
+	SendBytes(data []byte) (IOException error)
+}
+
+/** Return true if the transport is connection-oriented (TCP, TLS, SCTP,
+ * WS), i.e. uses CRLF keepalives rather than STUN.
+ */
+func IsReliableTransport(transport string) bool {
+	return !strings.EqualFold(transport, "UDP")
+}
+
+/**
+ * A Flow is a client-initiated connection to an edge proxy registered with
+ * a reg-id (RFC 5626). The flow sends keepalives at a randomized interval
+ * and is considered failed when a pong or STUN response does not arrive in
+ * time, when the STUN mapped address changes, or when the transport
+ * reports an error.
+ */
+type Flow struct {
+	mutex sync.Mutex
+
+	channel KeepAliveChannel
+
+	regId int
+
+	interval time.Duration
+
+	timeout time.Duration
+
+	awaitingPong bool
+
+	stunRequest *StunMessage
+
+	mappedAddress string
+
+	failed bool
+
+	failureHandler func(flow *Flow)
+
+	timer *time.Timer
+
+	started bool
+}
+
+/** Create a flow for a reg-id over a channel.
+ */
+func NewFlow(channel KeepAliveChannel, regId int) *Flow {
+	this := &Flow{channel: channel, regId: regId}
+	if IsReliableTransport(channel.GetTransport()) {
+		this.interval = Flow_DEFAULT_RELIABLE_INTERVAL
+	} else {
+		this.interval = Flow_DEFAULT_UNRELIABLE_INTERVAL
+	}
+	this.timeout = Flow_PONG_TIMEOUT
+	return this
+}
+
+/** Get the channel of the flow.
+ */
+func (this *Flow) GetChannel() KeepAliveChannel {
+	return this.channel
+}
+
+/** Get the reg-id of the flow.
+ */
+func (this *Flow) GetRegId() int {
+	return this.regId
+}
+
+/** Set the keepalive interval. Keepalives are sent at a random time
+ * between 80% and 100% of the interval.
+ */
+func (this *Flow) SetKeepAliveInterval(interval time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.interval = interval
+}
+
+/** Set the keepalive interval from the Flow-Timer of a registration
+ * response (in seconds).
+ */
+func (this *Flow) SetFlowTimer(seconds int) {
+	if seconds > 0 {
+		this.SetKeepAliveInterval(time.Duration(seconds) * time.Second)
+	}
+}
+
+/** Set the time to wait for a pong or STUN response.
+ */
+func (this *Flow) SetPongTimeout(timeout time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.timeout = timeout
+}
+
+/** Set the function called once when the flow fails.
+ */
+func (this *Flow) SetFailureHandler(failureHandler func(flow *Flow)) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.failureHandler = failureHandler
+}
+
+/** Get the address the STUN server last saw for this flow ("" over
+ * connection-oriented transports).
+ */
+func (this *Flow) GetMappedAddress() string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.mappedAddress
+}
+
+/** Return true if the flow has failed.
+ */
+func (this *Flow) IsFailed() bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.failed
+}
+
+/** Start sending keepalives.
+ */
+func (this *Flow) Start() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.started || this.failed {
+		return
+	}
+	this.started = true
+	this.schedule(this.nextKeepAlive())
+}
+
+/** Stop sending keepalives without failing the flow.
+ */
+func (this *Flow) Stop() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.started = false
+	if this.timer != nil {
+		this.timer.Stop()
+		this.timer = nil
+	}
+}
+
+/** Send a keepalive now: a CRLF ping on a connection-oriented transport
+ * or a STUN Binding request over UDP.
+ */
+func (this *Flow) SendKeepAlive() (IOException error) {
+	this.mutex.Lock()
+	if this.failed {
+		this.mutex.Unlock()
+		return errors.New("IOException: Flow failed")
+	}
+	var data []byte
+	if IsReliableTransport(this.channel.GetTransport()) {
+		data = []byte(Flow_PING)
+	} else {
+		this.stunRequest = NewStunBindingRequest()
+		data = this.stunRequest.Encode()
+	}
+	this.awaitingPong = true
+	this.mutex.Unlock()
+
+	if err := this.channel.SendBytes(data); err != nil {
+		this.Fail()
+		return err
+	}
+	return nil
+}
+
+/** Process data received on the flow. It returns true if the data was a
+ * keepalive response and has been consumed.
+ */
+func (this *Flow) ProcessKeepAliveResponse(data []byte) bool {
+	this.mutex.Lock()
+	if IsReliableTransport(this.channel.GetTransport()) {
+		if !bytes.Equal(data, []byte(Flow_PONG)) {
+			this.mutex.Unlock()
+			return false
+		}
+		this.awaitingPong = false
+		this.mutex.Unlock()
+		return true
+	}
+
+	if !IsStunMessage(data) {
+		this.mutex.Unlock()
+		return false
+	}
+	response, err := ParseStunMessage(data)
+	if err != nil || this.stunRequest == nil || !response.IsResponseTo(this.stunRequest) {
+		this.mutex.Unlock()
+		return true
+	}
+	this.awaitingPong = false
+	this.stunRequest = nil
+	mappedAddress := ""
+	if response.GetMappedIP() != nil {
+		mappedAddress = net.JoinHostPort(response.GetMappedIP().String(), strconv.Itoa(response.GetMappedPort()))
+	}
+	// A new mapped address means the NAT binding was lost and the
+	// registration no longer reaches us (RFC 5626 section 4.4.2).
+	changed := this.mappedAddress != "" && mappedAddress != "" && mappedAddress != this.mappedAddress
+	if mappedAddress != "" {
+		this.mappedAddress = mappedAddress
+	}
+	this.mutex.Unlock()
+	if changed {
+		this.Fail()
+	}
+	return true
+}
+
+/** Mark the flow failed, stop the keepalives and call the failure
+ * handler. Further calls have no effect.
+ */
+func (this *Flow) Fail() {
+	this.mutex.Lock()
+	if this.failed {
+		this.mutex.Unlock()
+		return
+	}
+	this.failed = true
+	this.started = false
+	if this.timer != nil {
+		this.timer.Stop()
+		this.timer = nil
+	}
+	failureHandler := this.failureHandler
+	this.mutex.Unlock()
+	if failureHandler != nil {
+		failureHandler(this)
+	}
+}
+
+/** Time to the next keepalive: a random value between 80% and 100% of
+ * the interval (RFC 5626 section 4.4.1). Called with the mutex held.
+ */
+func (this *Flow) nextKeepAlive() time.Duration {
+	return this.interval*4/5 + time.Duration(rand.Int63n(int64(this.interval/5)+1))
+}
+
+/** Called with the mutex held. */
+func (this *Flow) schedule(d time.Duration) {
+	this.timer = time.AfterFunc(d, this.onKeepAliveTimer)
+}
+
+func (this *Flow) onKeepAliveTimer() {
+	this.mutex.Lock()
+	started := this.started
+	this.mutex.Unlock()
+	if !started || this.SendKeepAlive() != nil {
+		return
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.started {
+		this.timer = time.AfterFunc(this.timeout, this.onPongTimer)
+	}
+}
+
+func (this *Flow) onPongTimer() {
+	this.mutex.Lock()
+	if !this.started {
+		this.mutex.Unlock()
+		return
+	}
+	if this.awaitingPong {
+		this.mutex.Unlock()
+		this.Fail()
+		return
+	}
+	this.schedule(this.nextKeepAlive())
+	this.mutex.Unlock()
+}
+
+/** Answer a keepalive received by a server on a channel: a CRLF ping with
+ * a pong, or a STUN Binding request with the source address of the
+ * channel. It returns true if the data was a keepalive.
+ */
+func ProcessKeepAliveRequest(channel KeepAliveChannel, data []byte) (consumed bool, IOException error) {
+	if IsReliableTransport(channel.GetTransport()) {
+		if !bytes.Equal(data, []byte(Flow_PING)) {
+			return false, nil
+		}
+		return true, channel.SendBytes([]byte(Flow_PONG))
+	}
+	if !IsStunMessage(data) {
+		return false, nil
+	}
+	request, err := ParseStunMessage(data)
+	if err != nil || request.GetMessageType() != StunMessage_BINDING_REQUEST {
+		return true, nil
+	}
+	response := NewStunBindingResponse(request, net.ParseIP(channel.GetPeerAddress()), channel.GetPeerPort())
+	return true, channel.SendBytes(response.Encode())
+}
@@ -0,0 +1,195 @@
+package stack
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Length of the HMAC that protects a flow token.
+ */
+const FlowTable_MAC_LENGTH = 10
+
+/**
+ * FlowTable is the edge proxy side of SIP Outbound (RFC 5626 section 5).
+ * It remembers the channels of the flows opened by user agents and names
+ * each of them with a flow token: the transport and source address of the
+ * flow, protected with an HMAC so that it cannot be forged. The token is
+ * put in the user part of the URI the edge proxy adds to Path or
+ * Record-Route, and requests that come back with that URI in their Route
+ * header are sent over the same flow.
+ */
+type FlowTable struct {
+	mutex sync.RWMutex
+
+	key []byte
+
+	flows map[string]MessageChannel
+}
+
+/** Create a flow table. A random key is used when key is empty.
+ */
+func NewFlowTable(key []byte) *FlowTable {
+	this := &FlowTable{}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	this.key = key
+	this.flows = make(map[string]MessageChannel)
+	return this
+}
+
+/** Get the identifier of the flow of a channel: the transport and the
+ * address of the peer.
+ */
+func GetFlowId(channel MessageChannel) string {
+	return channel.GetTransport() + "/" + net.JoinHostPort(channel.GetPeerAddress(), strconv.Itoa(channel.GetPeerPort()))
+}
+
+/** Remember the channel of a flow and return its token.
+ */
+func (this *FlowTable) AddFlow(channel MessageChannel) (token string) {
+	this.mutex.Lock()
+	this.flows[GetFlowId(channel)] = channel
+	this.mutex.Unlock()
+	return this.CreateToken(channel)
+}
+
+/** Forget the channel of a flow, e.g. when its connection closes.
+ */
+func (this *FlowTable) RemoveFlow(channel MessageChannel) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	flowId := GetFlowId(channel)
+	if this.flows[flowId] == channel {
+		delete(this.flows, flowId)
+	}
+}
+
+/** Create the token of the flow of a channel.
+ */
+func (this *FlowTable) CreateToken(channel MessageChannel) string {
+	flowId := []byte(GetFlowId(channel))
+	token := append(this.mac(flowId), flowId...)
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+/** Check a token and return the flow identifier it carries.
+ */
+func (this *FlowTable) ParseToken(token string) (flowId string, SipException error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) <= FlowTable_MAC_LENGTH {
+		return "", errors.New("SipException: Bad flow token")
+	}
+	if !hmac.Equal(data[:FlowTable_MAC_LENGTH], this.mac(data[FlowTable_MAC_LENGTH:])) {
+		return "", errors.New("SipException: Bad flow token")
+	}
+	return string(data[FlowTable_MAC_LENGTH:]), nil
+}
+
+/** Get the channel named by a token. A nil channel and no error means the
+ * token is genuine but the flow is gone.
+ */
+func (this *FlowTable) GetChannel(token string) (channel MessageChannel, SipException error) {
+	flowId, err := this.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.flows[flowId], nil
+}
+
+/** Create the URI the edge proxy puts in Path or Record-Route for the flow
+ * of a channel: sip:token@host:port;lr;ob.
+ */
+func (this *FlowTable) CreateFlowURI(host string, port int, channel MessageChannel) *address.SipURIImpl {
+	uri := address.NewSipURIImpl()
+	uri.SetUser(this.AddFlow(channel))
+	uri.SetHostString(host)
+	if port > 0 {
+		uri.SetPort(port)
+	}
+	uri.SetLrParam()
+	uri.SetUriParameter(core.NewNameValue(header.ParameterNames_OB, nil))
+	return uri
+}
+
+/** Add a Record-Route for the flow a request arrived on, so that requests
+ * within the dialog come back over the same flow.
+ */
+func (this *FlowTable) AddRecordRoute(request *message.SIPRequest, host string, port int, channel MessageChannel) {
+	addr := address.NewAddressImpl()
+	addr.SetAddressType(address.NAME_ADDR)
+	addr.SetURI(this.CreateFlowURI(host, port, channel))
+	recordRouteList := header.NewRecordRouteList()
+	recordRouteList.PushBack(header.NewRecordRouteFromAddress(addr))
+	request.AttachHeader3(recordRouteList, false, true)
+}
+
+/** Add a Path for the flow a REGISTER arrived on (RFC 5626 section 5.1),
+ * so that the registrar routes the requests for the registered contact
+ * back through this edge proxy and over the same flow.
+ */
+func (this *FlowTable) AddPath(request *message.SIPRequest, host string, port int, channel MessageChannel) {
+	addr := address.NewAddressImpl()
+	addr.SetAddressType(address.NAME_ADDR)
+	addr.SetURI(this.CreateFlowURI(host, port, channel))
+	pathList := header.NewPathList()
+	pathList.PushBack(header.NewPathFromAddress(addr))
+	request.AttachHeader3(pathList, false, true)
+}
+
+/** Route a request over a flow. When the top Route header carries a flow
+ * token, the Route is removed and the channel of the flow is returned. If
+ * the flow is gone an error is returned and the request should be answered
+ * with 430 Flow Failed. A nil channel and no error means the request does
+ * not target a flow.
+ */
+func (this *FlowTable) RouteRequest(request *message.SIPRequest) (channel MessageChannel, SipException error) {
+	if !request.HasHeader(core.SIPHeaderNames_ROUTE) {
+		return nil, nil
+	}
+	routeList := request.GetRouteHeaders()
+	if routeList.Len() == 0 {
+		return nil, nil
+	}
+	top := routeList.Front()
+	uri, ok := top.Value.(*header.Route).GetAddress().GetURI().(*address.SipURIImpl)
+	if !ok || uri.GetUser() == "" {
+		return nil, nil
+	}
+	flowId, err := this.ParseToken(uri.GetUser())
+	if err != nil {
+		return nil, nil
+	}
+
+	routeList.Remove(top)
+	if routeList.Len() == 0 {
+		request.RemoveHeader(core.SIPHeaderNames_ROUTE)
+	}
+	this.mutex.RLock()
+	channel = this.flows[flowId]
+	this.mutex.RUnlock()
+	if channel == nil {
+		return nil, errors.New("SipException: Flow failed")
+	}
+	return channel, nil
+}
+
+func (this *FlowTable) mac(data []byte) []byte {
+	mac := hmac.New(sha256.New, this.key)
+	mac.Write(data)
+	return mac.Sum(nil)[:FlowTable_MAC_LENGTH]
+}
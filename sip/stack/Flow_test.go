@@ -0,0 +1,268 @@
+package stack
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+type testKeepAliveChannel struct {
+	testChannel
+	transport string
+	peer      string
+	port      int
+	bytes     [][]byte
+	bytesLock sync.Mutex
+}
+
+func (this *testKeepAliveChannel) SendBytes(data []byte) error {
+	this.bytesLock.Lock()
+	defer this.bytesLock.Unlock()
+	this.bytes = append(this.bytes, data)
+	return nil
+}
+
+func (this *testKeepAliveChannel) lastBytes() []byte {
+	this.bytesLock.Lock()
+	defer this.bytesLock.Unlock()
+	if len(this.bytes) == 0 {
+		return nil
+	}
+	return this.bytes[len(this.bytes)-1]
+}
+
+func (this *testKeepAliveChannel) GetTransport() string   { return this.transport }
+func (this *testKeepAliveChannel) GetPeerAddress() string { return this.peer }
+func (this *testKeepAliveChannel) GetPeerPort() int       { return this.port }
+
+func TestStunMessage(t *testing.T) {
+	request := NewStunBindingRequest()
+	parsed, err := ParseStunMessage(request.Encode())
+	if err != nil || parsed.GetMessageType() != StunMessage_BINDING_REQUEST {
+		t.Fatal("bad binding request", err)
+	}
+	for _, ip := range []string{"192.0.2.1", "2001:db8::1"} {
+		response := NewStunBindingResponse(parsed, net.ParseIP(ip), 32853)
+		decoded, err := ParseStunMessage(response.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.IsResponseTo(request) || !decoded.GetMappedIP().Equal(net.ParseIP(ip)) || decoded.GetMappedPort() != 32853 {
+			t.Fatalf("bad mapped address %s:%d", decoded.GetMappedIP(), decoded.GetMappedPort())
+		}
+	}
+	if IsStunMessage([]byte("OPTIONS sip:a@b SIP/2.0\r\n\r\n")) {
+		t.Fatal("SIP taken for STUN")
+	}
+}
+
+func TestFlowCRLFKeepAlive(t *testing.T) {
+	server := &testKeepAliveChannel{transport: "TCP", peer: "192.0.2.2", port: 5060}
+	client := &testKeepAliveChannel{transport: "TCP", peer: "198.51.100.1", port: 5060}
+	flow := NewFlow(client, 1)
+
+	if err := flow.SendKeepAlive(); err != nil {
+		t.Fatal(err)
+	}
+	if string(client.lastBytes()) != Flow_PING {
+		t.Fatal("no double CRLF ping sent")
+	}
+	if consumed, err := ProcessKeepAliveRequest(server, client.lastBytes()); !consumed || err != nil {
+		t.Fatal("ping not answered")
+	}
+	if !flow.ProcessKeepAliveResponse(server.lastBytes()) {
+		t.Fatal("pong not consumed")
+	}
+	if flow.ProcessKeepAliveResponse([]byte("SIP/2.0 200 OK\r\n")) {
+		t.Fatal("SIP message consumed as a pong")
+	}
+}
+
+func TestFlowFailsWithoutPong(t *testing.T) {
+	client := &testKeepAliveChannel{transport: "TLS", peer: "198.51.100.1", port: 5061}
+	flow := NewFlow(client, 1)
+	flow.SetKeepAliveInterval(5 * time.Millisecond)
+	flow.SetPongTimeout(5 * time.Millisecond)
+	failed := make(chan *Flow, 1)
+	flow.SetFailureHandler(func(f *Flow) { failed <- f })
+	flow.Start()
+
+	select {
+	case f := <-failed:
+		if f != flow || !flow.IsFailed() {
+			t.Fatal("wrong flow failed")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("flow did not fail")
+	}
+}
+
+func TestFlowFailsWhenMappedAddressChanges(t *testing.T) {
+	client := &testKeepAliveChannel{transport: "UDP", peer: "198.51.100.1", port: 5060}
+	flow := NewFlow(client, 1)
+
+	for i, source := range []*testKeepAliveChannel{
+		{transport: "UDP", peer: "203.0.113.7", port: 40000},
+		{transport: "UDP", peer: "203.0.113.7", port: 40000},
+		{transport: "UDP", peer: "203.0.113.7", port: 40001},
+	} {
+		if err := flow.SendKeepAlive(); err != nil {
+			t.Fatal(err)
+		}
+		ProcessKeepAliveRequest(source, client.lastBytes())
+		if !flow.ProcessKeepAliveResponse(source.lastBytes()) {
+			t.Fatal("STUN response not consumed")
+		}
+		if flow.IsFailed() != (i == 2) {
+			t.Fatalf("keepalive %d: failed = %v", i, flow.IsFailed())
+		}
+	}
+	if flow.GetMappedAddress() != "203.0.113.7:40001" {
+		t.Fatal("bad mapped address " + flow.GetMappedAddress())
+	}
+}
+
+func TestOutboundRegister(t *testing.T) {
+	register := parseTestRequest(t, "REGISTER sip:example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/TCP 192.0.2.2;branch=z9hG4bKnashds7\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"From: Bob <sip:bob@example.com>;tag=456248\r\n"+
+		"To: Bob <sip:bob@example.com>\r\n"+
+		"Call-ID: 843817637684230@998sdasdh09\r\n"+
+		"CSeq: 1826 REGISTER\r\n"+
+		"Contact: <sip:line1@192.0.2.2;transport=tcp>\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	instance := "<urn:uuid:00000000-0000-1000-8000-000A95A0E128>"
+	if err := PrepareOutboundRegister(register, instance, 1); err != nil {
+		t.Fatal(err)
+	}
+	contact := register.GetContactHeaders().Front().Value.(*header.Contact)
+	if contact.GetRegId() != 1 || contact.GetSipInstance() != instance {
+		t.Fatal("bad Contact " + contact.String())
+	}
+	reparsed := parseTestRequest(t, register.String())
+	contact = reparsed.GetContactHeaders().Front().Value.(*header.Contact)
+	if contact.GetRegId() != 1 || contact.GetSipInstance() != instance {
+		t.Fatal("bad Contact after parsing " + contact.String())
+	}
+	if !reparsed.HasHeader("Supported") {
+		t.Fatal("no Supported: outbound")
+	}
+
+	response := register.CreateResponse(message.OK)
+	flowTimer := header.NewExtension(OutboundClient_FLOW_TIMER)
+	flowTimer.SetValue("30")
+	response.AttachHeader2(flowTimer, false)
+	if GetFlowTimer(response) != 30 {
+		t.Fatal("Flow-Timer not read")
+	}
+}
+
+func TestFlowRecoveryTime(t *testing.T) {
+	for failures := 0; failures < 20; failures++ {
+		d := GetFlowRecoveryTime(failures, true)
+		if d > OutboundClient_MAX_TIME || d < OutboundClient_BASE_TIME/2 {
+			t.Fatalf("%d failures: %v", failures, d)
+		}
+	}
+}
+
+func TestFlowTable(t *testing.T) {
+	flowTable := NewFlowTable(nil)
+	ua := &testKeepAliveChannel{transport: "TCP", peer: "203.0.113.7", port: 40000}
+
+	invite := parseTestRequest(t, testInvite)
+	flowTable.AddRecordRoute(invite, "edge.example.com", 5060, ua)
+	recordRoute := invite.GetRecordRouteHeaders().Front().Value.(*header.RecordRoute)
+
+	bye := "BYE sip:alice@pc33.atlanta.com SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP 192.0.2.4;branch=z9hG4bKnashds10\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"Route: " + recordRoute.EncodeBody() + "\r\n" +
+		"From: Bob <sip:bob@biloxi.com>;tag=a6c85cf\r\n" +
+		"To: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 231 BYE\r\n" +
+		"Content-Length: 0\r\n\r\n"
+	request := parseTestRequest(t, bye)
+	channel, err := flowTable.RouteRequest(request)
+	if err != nil || channel != ua {
+		t.Fatal("request not routed over the flow", err)
+	}
+	if request.HasHeader("Route") {
+		t.Fatal("flow Route not removed")
+	}
+
+	flowTable.RemoveFlow(ua)
+	if _, err := flowTable.RouteRequest(parseTestRequest(t, bye)); err == nil {
+		t.Fatal("failed flow not reported")
+	}
+}
+
+func TestFlowTablePath(t *testing.T) {
+	flowTable := NewFlowTable(nil)
+	ua := &testKeepAliveChannel{transport: "TCP", peer: "203.0.113.7", port: 40000}
+
+	register := parseTestRequest(t, "REGISTER sip:example.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/TCP 192.0.2.2;branch=z9hG4bKnashds7\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"From: Bob <sip:bob@example.com>;tag=456248\r\n"+
+		"To: Bob <sip:bob@example.com>\r\n"+
+		"Call-ID: 843817637684230@998sdasdh09\r\n"+
+		"CSeq: 1826 REGISTER\r\n"+
+		"Contact: <sip:line1@192.0.2.2;transport=tcp>\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	flowTable.AddPath(register, "edge.example.com", 5060, ua)
+	path := parseTestRequest(t, register.String()).GetSIPHeaderList("Path").Front().Value.(*header.Path)
+
+	invite := parseTestRequest(t, "INVITE sip:line1@192.0.2.2;transport=tcp SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP registrar.example.com;branch=z9hG4bKnashds9\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"Route: "+path.EncodeBody()+"\r\n"+
+		"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n"+
+		"To: Bob <sip:bob@example.com>\r\n"+
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n"+
+		"CSeq: 1 INVITE\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	if channel, err := flowTable.RouteRequest(invite); err != nil || channel != ua {
+		t.Fatal("request not routed over the registered flow", err)
+	}
+}
+
+func TestServiceRoute(t *testing.T) {
+	register := parseTestRequest(t, testOptions("SIP/2.0/UDP 192.0.2.2;branch=z9hG4bKnashds7"))
+	response := register.CreateResponse(message.OK)
+	serviceRoutes, err := parser.NewServiceRouteParser("Service-Route: <sip:p2.home.example.com;lr>,<sip:hsp.home.example.com;lr>\n").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.SetHeader(serviceRoutes)
+
+	serviceRoute := NewServiceRoute()
+	serviceRoute.ProcessRegisterResponse(response)
+	if len(serviceRoute.GetRoutes()) != 2 {
+		t.Fatal("Service-Route not stored")
+	}
+
+	options := parseTestRequest(t, testOptions("SIP/2.0/UDP 192.0.2.2;branch=z9hG4bKnashds8"))
+	serviceRoute.AddRoutes(options)
+	options = parseTestRequest(t, options.String())
+	routes := options.GetRouteHeaders()
+	if routes.Len() != 2 || routes.Front().Value.(*header.Route).GetAddress().GetURI().String() != "sip:p2.home.example.com;lr" {
+		t.Fatal("Service-Route not preloaded " + options.String())
+	}
+
+	serviceRoute.ProcessRegisterResponse(register.CreateResponse(message.FORBIDDEN))
+	if len(serviceRoute.GetRoutes()) != 2 {
+		t.Fatal("Service-Route changed by an error response")
+	}
+	serviceRoute.ProcessRegisterResponse(register.CreateResponse(message.OK))
+	if len(serviceRoute.GetRoutes()) != 0 {
+		t.Fatal("Service-Route not cleared")
+	}
+}
@@ -0,0 +1,40 @@
+package stack
+
+import (
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * A MessageChannel is the transport side of a transaction. The transport
+ * layer hands every incoming message to the stack together with the
+ * channel it arrived on, and the transaction layer uses that channel to
+ * send its responses back to the peer.
+ */
+type MessageChannel interface {
+	/** Send a message to the peer at the other end of this channel.
+	 */
+	SendMessage(msg message.Message) (IOException error)
+
+	/** Get the transport (UDP, TCP, TLS, ...) of this channel.
+	 */
+	GetTransport() string
+
+	/** Get the address of the peer at the other end of this channel.
+	 */
+	GetPeerAddress() string
+
+	/** Get the port of the peer at the other end of this channel.
+	 */
+	GetPeerPort() int
+}
+
+/**
+ * A ChannelFactory opens the channels the stack sends a request on when
+ * the request belongs to no dialog of the peer it is sent to, e.g. the
+ * INVITE triggered by a REFER, which goes to the Refer-To target.
+ */
+type ChannelFactory interface {
+	/** Get a channel to the given host and port over a transport.
+	 */
+	CreateMessageChannel(transport, host string, port int) (channel MessageChannel, IOException error)
+}
@@ -0,0 +1,63 @@
+package stack
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Get the next hop of a request (RFC 3261 section 8.1.2): the URI of the
+ * topmost Route, or the Request-URI if there is no Route. The maddr of the
+ * URI overrides its host, and when the URI has no port or transport the
+ * defaults of its scheme are used (RFC 3263 section 4, without DNS).
+ */
+func GetNextHop(request *message.SIPRequest) (transport, host string, port int, SipException error) {
+	uri := request.GetRequestURI()
+	if request.HasHeader(core.SIPHeaderNames_ROUTE) {
+		if routeList := request.GetRouteHeaders(); routeList != nil && routeList.Len() > 0 {
+			uri = routeList.Front().Value.(*header.Route).GetAddress().GetURI()
+		}
+	}
+	sipuri, ok := uri.(*address.SipURIImpl)
+	if !ok {
+		return "", "", 0, errors.New("SipException: the next hop is not a SIP URI")
+	}
+
+	transport = strings.ToUpper(sipuri.GetParameter(core.SIPTransportNames_TRANSPORT))
+	if transport == "" {
+		transport = "UDP"
+		if sipuri.IsSecure() {
+			transport = "TLS"
+		}
+	}
+	host = sipuri.GetMAddrParam()
+	if host == "" {
+		host = sipuri.GetHost()
+	}
+	port = sipuri.GetPort()
+	if port <= 0 {
+		port = ResponseRouting_DEFAULT_PORT
+		if transport == "TLS" {
+			port = ResponseRouting_DEFAULT_TLS_PORT
+		}
+	}
+	return transport, stripBrackets(host), port, nil
+}
+
+/** Get a channel to the next hop of a request from the ChannelFactory of
+ * the stack.
+ */
+func (this *SIPTransactionStack) CreateNextHopChannel(request *message.SIPRequest) (channel MessageChannel, SipException error) {
+	if this.channelFactory == nil {
+		return nil, errors.New("SipException: no channel factory")
+	}
+	transport, host, port, err := GetNextHop(request)
+	if err != nil {
+		return nil, err
+	}
+	return this.channelFactory.CreateMessageChannel(transport, host, port)
+}
@@ -0,0 +1,264 @@
+package stack
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Option tag and header of SIP Outbound (RFC 5626).
+ */
+const (
+	OutboundClient_OPTION_TAG  = "outbound"
+	OutboundClient_FLOW_TIMER  = "Flow-Timer"
+	OutboundClient_BASE_TIME   = 30 * time.Second
+	OutboundClient_BASE_TIME_2 = 90 * time.Second
+	OutboundClient_MAX_TIME    = 1800 * time.Second
+)
+
+/**
+ * OutboundClient maintains the flows of a user agent that registers with
+ * SIP Outbound (RFC 5626). Each reg-id has its own flow to an edge proxy.
+ * When a flow fails, the client waits for the back-off time of section
+ * 4.5, opens a new connection with the dialer and registers over it again
+ * with the register function.
+ */
+type OutboundClient struct {
+	mutex sync.Mutex
+
+	instanceId string
+
+	dialer func(regId int) (KeepAliveChannel, error)
+
+	register func(flow *Flow) error
+
+	flows map[int]*Flow
+
+	failures map[int]int
+
+	retryTimers map[int]*time.Timer
+
+	closed bool
+}
+
+/** Create a client for an instance id, e.g. "<urn:uuid:...>". The dialer
+ * opens the connection for a reg-id; the register function sends a
+ * REGISTER prepared with PrepareRegister over a flow and returns an error
+ * unless it succeeds.
+ */
+func NewOutboundClient(instanceId string, dialer func(regId int) (KeepAliveChannel, error), register func(flow *Flow) error) *OutboundClient {
+	this := &OutboundClient{}
+	this.instanceId = instanceId
+	this.dialer = dialer
+	this.register = register
+	this.flows = make(map[int]*Flow)
+	this.failures = make(map[int]int)
+	this.retryTimers = make(map[int]*time.Timer)
+	return this
+}
+
+/** Get the instance id of the client.
+ */
+func (this *OutboundClient) GetInstanceId() string {
+	return this.instanceId
+}
+
+/** Open and register the flow of a reg-id. On failure the flow is retried
+ * after the back-off time.
+ */
+func (this *OutboundClient) AddFlow(regId int) (SipException error) {
+	if regId <= 0 {
+		return errors.New("SipException: reg-id must be positive")
+	}
+	err := this.connect(regId)
+	if err != nil {
+		this.scheduleRetry(regId)
+	}
+	return err
+}
+
+/** Get the live flow of a reg-id (nil if there is none).
+ */
+func (this *OutboundClient) GetFlow(regId int) *Flow {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.flows[regId]
+}
+
+/** Stop all flows and pending retries.
+ */
+func (this *OutboundClient) Close() {
+	this.mutex.Lock()
+	this.closed = true
+	flows := this.flows
+	this.flows = make(map[int]*Flow)
+	for _, timer := range this.retryTimers {
+		timer.Stop()
+	}
+	this.retryTimers = make(map[int]*time.Timer)
+	this.mutex.Unlock()
+	for _, flow := range flows {
+		flow.Stop()
+	}
+}
+
+/** Add the SIP Outbound parameters to a REGISTER for a reg-id: the
+ * +sip.instance and reg-id Contact parameters and the outbound option tag
+ * in Supported.
+ */
+func (this *OutboundClient) PrepareRegister(request *message.SIPRequest, regId int) (SipException error) {
+	return PrepareOutboundRegister(request, this.instanceId, regId)
+}
+
+/** Apply the Flow-Timer of a successful registration response to a flow.
+ */
+func (this *OutboundClient) ProcessRegisterResponse(flow *Flow, response *message.SIPResponse) {
+	if seconds := GetFlowTimer(response); seconds > 0 {
+		flow.SetFlowTimer(seconds)
+	}
+}
+
+/** Compute the time to wait before recovering a flow (RFC 5626 section
+ * 4.5): min(max-time, base-time * 2^failures), scaled by a random factor
+ * between 50% and 100%. The base time is 30 seconds when all flows have
+ * failed and 90 seconds otherwise.
+ */
+func GetFlowRecoveryTime(consecutiveFailures int, allFailed bool) time.Duration {
+	baseTime := OutboundClient_BASE_TIME_2
+	if allFailed {
+		baseTime = OutboundClient_BASE_TIME
+	}
+	waitTime := OutboundClient_MAX_TIME
+	if consecutiveFailures < 16 {
+		if w := baseTime * time.Duration(1<<uint(consecutiveFailures)); w < waitTime {
+			waitTime = w
+		}
+	}
+	return waitTime/2 + time.Duration(rand.Int63n(int64(waitTime/2)+1))
+}
+
+/** Add the SIP Outbound parameters to the Contacts of a REGISTER.
+ */
+func PrepareOutboundRegister(request *message.SIPRequest, instanceId string, regId int) (SipException error) {
+	contacts := request.GetContactHeaders()
+	if contacts == nil || contacts.Len() == 0 {
+		return errors.New("SipException: REGISTER without Contact")
+	}
+	for e := contacts.Front(); e != nil; e = e.Next() {
+		contact := e.Value.(*header.Contact)
+		contact.SetSipInstance(instanceId)
+		if err := contact.SetRegId(regId); err != nil {
+			return err
+		}
+	}
+	AddOptionTag(request, OutboundClient_OPTION_TAG)
+	return nil
+}
+
+/** Add an option tag to the Supported header of a message, unless it is
+ * already there.
+ */
+func AddOptionTag(msg *message.SIPRequest, optionTag string) {
+	if !msg.HasHeader(core.SIPHeaderNames_SUPPORTED) {
+		supportedList := header.NewSupportedList()
+		supportedList.PushBack(header.NewSupportedFromString(optionTag))
+		msg.SetHeader(supportedList)
+		return
+	}
+	if HasOptionTag(&msg.SIPMessage, optionTag) {
+		return
+	}
+	supportedList := msg.GetSIPHeaderList(core.SIPHeaderNames_SUPPORTED)
+	supportedList.PushBack(header.NewSupportedFromString(optionTag))
+}
+
+/** Return true if the Supported header of a message lists an option tag.
+ */
+func HasOptionTag(msg *message.SIPMessage, optionTag string) bool {
+	if !msg.HasHeader(core.SIPHeaderNames_SUPPORTED) {
+		return false
+	}
+	supportedList := msg.GetSIPHeaderList(core.SIPHeaderNames_SUPPORTED)
+	for e := supportedList.Front(); e != nil; e = e.Next() {
+		if strings.EqualFold(e.Value.(*header.Supported).GetOptionTag(), optionTag) {
+			return true
+		}
+	}
+	return false
+}
+
+/** Get the Flow-Timer of a response in seconds, or 0 if it is absent.
+ */
+func GetFlowTimer(response *message.SIPResponse) int {
+	if !response.HasHeader(OutboundClient_FLOW_TIMER) {
+		return 0
+	}
+	h := response.GetHeader(OutboundClient_FLOW_TIMER)
+	if h == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(h.EncodeBody()))
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
+
+func (this *OutboundClient) connect(regId int) (SipException error) {
+	channel, err := this.dialer(regId)
+	if err != nil {
+		return err
+	}
+	flow := NewFlow(channel, regId)
+	flow.SetFailureHandler(this.flowFailed)
+	if err = this.register(flow); err != nil {
+		return err
+	}
+
+	this.mutex.Lock()
+	if this.closed {
+		this.mutex.Unlock()
+		return errors.New("SipException: Client closed")
+	}
+	this.flows[regId] = flow
+	this.failures[regId] = 0
+	delete(this.retryTimers, regId)
+	this.mutex.Unlock()
+
+	flow.Start()
+	return nil
+}
+
+func (this *OutboundClient) flowFailed(flow *Flow) {
+	this.mutex.Lock()
+	if this.flows[flow.GetRegId()] == flow {
+		delete(this.flows, flow.GetRegId())
+	}
+	this.mutex.Unlock()
+	this.scheduleRetry(flow.GetRegId())
+}
+
+func (this *OutboundClient) scheduleRetry(regId int) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.closed || this.retryTimers[regId] != nil {
+		return
+	}
+	delay := GetFlowRecoveryTime(this.failures[regId], len(this.flows) == 0)
+	this.failures[regId]++
+	this.retryTimers[regId] = time.AfterFunc(delay, func() {
+		this.mutex.Lock()
+		delete(this.retryTimers, regId)
+		this.mutex.Unlock()
+		if this.connect(regId) != nil {
+			this.scheduleRetry(regId)
+		}
+	})
+}
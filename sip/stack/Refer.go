@@ -0,0 +1,280 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+/** The event package of the implicit subscription of a REFER and the
+ * content type of its notifications (RFC 3515 section 2.4.4).
+ */
+const (
+	Refer_EVENT           = "refer"
+	Refer_SIPFRAG_TYPE    = "message"
+	Refer_SIPFRAG_SUBTYPE = "sipfrag"
+)
+
+/** A ReferPolicy decides whether to honor a REFER. It is given the
+ * Referred-By of the REFER, nil if there is none, to check who asked for
+ * the transfer (RFC 3892 section 3).
+ */
+type ReferPolicy func(refer *message.SIPRequest, referredBy *header.ReferredBy) bool
+
+/** A ReferResponseFunc receives the responses to the INVITE triggered by
+ * a REFER. The application sends the ACK of a 2xx.
+ */
+type ReferResponseFunc func(invite *message.SIPRequest, response *message.SIPResponse)
+
+/**
+ * A ReferHandler is the referee of a REFER received within a dialog
+ * (RFC 3515). When its policy accepts the REFER it answers 202, sends the
+ * INVITE to the Refer-To target on a channel to the next hop of the INVITE,
+ * which it gets from the ChannelFactory of the stack, and reports the
+ * progress of the INVITE to the referrer in NOTIFY requests carrying the
+ * status line of the responses. The Referred-By of the REFER is copied
+ * into the INVITE (RFC 3892 section 3).
+ *
+ * A REFER outside a dialog is answered with 403, as the referee then has
+ * no identity to send the INVITE with.
+ *
+ * The ReferHandler is a Handler, so it can be installed in a ServeMux for
+ * the REFER method.
+ */
+type ReferHandler struct {
+	sipStack *SIPTransactionStack
+
+	policy ReferPolicy
+
+	responseFunc ReferResponseFunc
+}
+
+/** Create a ReferHandler. A nil policy honors every REFER.
+ */
+func NewReferHandler(sipStack *SIPTransactionStack, policy ReferPolicy) *ReferHandler {
+	this := &ReferHandler{}
+	this.sipStack = sipStack
+	this.policy = policy
+	return this
+}
+
+/** Set the function the responses to the triggered INVITE are passed to.
+ */
+func (this *ReferHandler) SetResponseFunc(responseFunc ReferResponseFunc) {
+	this.responseFunc = responseFunc
+}
+
+/** Answer a REFER on its server transaction and trigger its INVITE.
+ */
+func (this *ReferHandler) ServeSIP(st sip.ServerTransaction, request *message.SIPRequest) {
+	dialog, _ := st.GetDialog().(*SIPDialog)
+	if dialog == nil {
+		this.sendResponse(st, request, message.FORBIDDEN)
+		return
+	}
+	if GetReferTo(request) == nil {
+		this.sendResponse(st, request, message.BAD_REQUEST)
+		return
+	}
+	if this.policy != nil && !this.policy(request, GetReferredBy(request)) {
+		this.sendResponse(st, request, message.DECLINE)
+		return
+	}
+	invite, err := dialog.CreateReferredInvite(request)
+	if err != nil {
+		this.sendResponse(st, request, message.BAD_REQUEST)
+		return
+	}
+	if this.sendResponse(st, request, message.ACCEPTED) != nil {
+		return
+	}
+
+	referCSeq := request.GetCSeqNumber()
+	dialog.notifyRefer(referCSeq, message.TRYING, false)
+	// The INVITE goes to the Refer-To target, not back to the referrer.
+	channel, err := this.sipStack.CreateNextHopChannel(invite)
+	if err != nil {
+		dialog.notifyRefer(referCSeq, message.SERVICE_UNAVAILABLE, true)
+		return
+	}
+	responses, err := this.sipStack.SendRequest(context.Background(), invite, channel)
+	if err != nil {
+		dialog.notifyRefer(referCSeq, message.SERVICE_UNAVAILABLE, true)
+		return
+	}
+	go func() {
+		for response := range responses {
+			if this.responseFunc != nil {
+				this.responseFunc(invite, response)
+			}
+			if statusCode := response.GetStatusCode(); statusCode >= 200 {
+				dialog.notifyRefer(referCSeq, statusCode, true)
+			}
+		}
+	}()
+}
+
+func (this *ReferHandler) sendResponse(st sip.ServerTransaction, request *message.SIPRequest, statusCode int) (SipException error) {
+	response := request.CreateResponse(statusCode)
+	if !request.HasToTag() {
+		SetResponseToTag(response, GenerateTag())
+	}
+	return st.SendResponse(response)
+}
+
+/** Get the Refer-To header of a request, or nil.
+ */
+func GetReferTo(request *message.SIPRequest) *header.ReferTo {
+	referTo, _ := request.GetHeader(core.SIPHeaderNames_REFER_TO).(*header.ReferTo)
+	return referTo
+}
+
+/** Get the Referred-By header of a request, or nil.
+ */
+func GetReferredBy(request *message.SIPRequest) *header.ReferredBy {
+	referredBy, _ := request.GetHeader(core.SIPHeaderNames_REFERRED_BY).(*header.ReferredBy)
+	return referredBy
+}
+
+/** Create the INVITE a REFER received in the dialog asks for (RFC 3515
+ * section 2.4.2). It is sent from the local party of the dialog, in a new
+ * Call-ID, to the Refer-To URI. The header fields embedded in the URI,
+ * such as a Replaces, are added to the INVITE and so is the Referred-By of
+ * the REFER (RFC 3892 section 3).
+ */
+func (this *SIPDialog) CreateReferredInvite(refer *message.SIPRequest) (invite *message.SIPRequest, SipException error) {
+	referTo := GetReferTo(refer)
+	if referTo == nil || referTo.GetAddress() == nil {
+		return nil, errors.New("SipException: the REFER has no Refer-To")
+	}
+	uri, ok := referTo.GetAddress().GetURI().Clone().(*address.SipURIImpl)
+	if !ok {
+		return nil, errors.New("SipException: the Refer-To is not a SIP URI")
+	}
+	if method := uri.GetMethodParam(); method != "" && !strings.EqualFold(method, message.INVITE) {
+		return nil, errors.New("SipException: the Refer-To asks for a " + method)
+	}
+	embedded := uri.GetHeaderNames()
+	uri.RemoveHeaders()
+	uri.RemoveMethod()
+
+	this.mutex.Lock()
+	via, err := this.createVia()
+	localParty, localTarget := this.localParty, this.localTarget
+	this.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	invite = message.NewSIPRequest()
+	invite.SetRequestLine(header.NewRequestLineFromString(uri, message.INVITE))
+	viaList := header.NewViaList()
+	viaList.PushBack(via)
+	invite.SetHeader(viaList)
+
+	maxForwards := header.NewMaxForwards()
+	maxForwards.SetMaxForwards(SIPDialog_MAX_FORWARDS)
+	invite.SetMaxForwards(maxForwards)
+
+	from := header.NewFrom()
+	from.SetAddress(localParty)
+	from.SetTag(GenerateTag())
+	invite.SetFrom(from)
+	toAddress := address.NewAddressImpl()
+	if displayName := referTo.GetAddress().GetDisplayName(); displayName != "" {
+		toAddress.SetDisplayName(displayName)
+	}
+	toAddress.SetURI(uri)
+	to := header.NewTo()
+	to.SetAddress(toAddress)
+	invite.SetTo(to)
+	callId, err := header.NewCallID(randomHex(8) + "@" + via.GetHost())
+	if err != nil {
+		return nil, err
+	}
+	invite.SetCallId(callId)
+	invite.SetCSeq(header.NewCSeq(1, message.INVITE))
+
+	if localTarget != nil {
+		contactList := header.NewContactList()
+		contact := header.NewContact()
+		contact.SetAddress(localTarget)
+		contactList.PushBack(contact)
+		invite.SetHeader(contactList)
+	}
+	if embedded != nil {
+		for e := embedded.Front(); e != nil; e = e.Next() {
+			nv := e.Value.(*core.NameValue)
+			h, err := parseEmbeddedHeader(nv)
+			if err != nil {
+				return nil, err
+			}
+			if h != nil {
+				invite.SetHeader(h)
+			}
+		}
+	}
+	if referredBy := GetReferredBy(refer); referredBy != nil {
+		copied := header.NewReferredByFromAddress(referredBy.GetAddress())
+		copied.SetParameters(referredBy.GetParameters().Clone().(*core.NameValueList))
+		invite.SetHeader(copied)
+	}
+	invite.SetContentLength(header.NewContentLengthFromInt(0))
+	return invite, nil
+}
+
+/** Send a NOTIFY of the implicit subscription of a REFER with the status
+ * line of a response to the INVITE (RFC 3515 section 2.4.5). The final
+ * one terminates the subscription.
+ */
+func (this *SIPDialog) notifyRefer(referCSeq, statusCode int, final bool) {
+	request, err := this.CreateRequest(message.NOTIFY)
+	if err != nil {
+		return
+	}
+	notify := request.(*message.SIPRequest)
+	event := header.NewEvent()
+	event.SetEventType(Refer_EVENT)
+	event.SetEventId(strconv.Itoa(referCSeq))
+	notify.SetHeader(event)
+	subscriptionState := header.NewSubscriptionState()
+	if final {
+		subscriptionState.SetState(header.SubscriptionState_TERMINATED)
+		subscriptionState.SetReasonCode(header.SubscriptionState_NORESOURCE)
+	} else {
+		subscriptionState.SetState(header.SubscriptionState_ACTIVE)
+	}
+	notify.SetHeader(subscriptionState)
+	statusLine := header.NewStatusLine()
+	statusLine.SetStatusCode(statusCode)
+	statusLine.SetReasonPhrase(message.NewSIPResponse().GetReasonPhraseFromInt(statusCode))
+	notify.SetMessageContentFromString(Refer_SIPFRAG_TYPE, Refer_SIPFRAG_SUBTYPE, statusLine.String())
+	this.sipStack.SendRequest(context.Background(), notify, this.channel)
+}
+
+/** Parse a header field embedded in a URI. The body of the URI is not a
+ * header and is ignored.
+ */
+func parseEmbeddedHeader(nv *core.NameValue) (h header.Header, ParseException error) {
+	if strings.EqualFold(nv.GetName(), "body") {
+		return nil, nil
+	}
+	value, _ := nv.GetValue().(string)
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+	headerParser, err := parser.CreateParser(nv.GetName() + ": " + value + "\n")
+	if err != nil {
+		return nil, err
+	}
+	return headerParser.Parse()
+}
@@ -0,0 +1,189 @@
+package stack
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+const testRefer = "REFER sip:bob@192.0.2.4 SIP/2.0\r\n" +
+	"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bKnashds11\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"To: Bob <sip:bob@biloxi.com>;tag=a6c85cf\r\n" +
+	"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 314160 REFER\r\n" +
+	"Refer-To: <sip:carol@chicago.com?Replaces=11a4f64d%40pc.chicago.com%3Bto-tag%3D8983%3Bfrom-tag%3D7743>\r\n" +
+	"b: <sip:alice@atlanta.com>;cid=\"20398823.2UWQFN309shb3@atlanta.com\"\r\n" +
+	"Contact: <sip:alice@pc33.atlanta.com>\r\n" +
+	"Content-Length: 0\r\n\r\n"
+
+/** Wait for the n-th request of a method sent on a channel. */
+func (this *testChannel) waitForRequests(t *testing.T, method string, n int) []*message.SIPRequest {
+	for i := 0; i < 100; i++ {
+		var requests []*message.SIPRequest
+		this.mutex.Lock()
+		for _, request := range this.requests {
+			if request.GetMethod() == method {
+				requests = append(requests, request)
+			}
+		}
+		this.mutex.Unlock()
+		if len(requests) >= n {
+			return requests
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("less than %d %s sent", n, method)
+	return nil
+}
+
+/** A ChannelFactory that opens a testChannel per transport and address. */
+type testChannelFactory struct {
+	mutex    sync.Mutex
+	channels map[string]*testChannel
+}
+
+func (this *testChannelFactory) CreateMessageChannel(transport, host string, port int) (MessageChannel, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	key := transport + "/" + net.JoinHostPort(host, strconv.Itoa(port))
+	if this.channels == nil {
+		this.channels = make(map[string]*testChannel)
+	}
+	if this.channels[key] == nil {
+		this.channels[key] = &testChannel{}
+	}
+	return this.channels[key], nil
+}
+
+func (this *testChannelFactory) getChannel(t *testing.T, key string) *testChannel {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	channel := this.channels[key]
+	if channel == nil {
+		t.Fatalf("no channel to %s", key)
+	}
+	return channel
+}
+
+func TestReferHandler(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channelFactory := &testChannelFactory{}
+	sipStack.SetChannelFactory(channelFactory)
+	channel := &testChannel{}
+	establishDialog(t, sipStack, listener, channel, message.OK)
+
+	var referredBy *header.ReferredBy
+	handler := NewReferHandler(sipStack, func(refer *message.SIPRequest, by *header.ReferredBy) bool {
+		referredBy = by
+		return true
+	})
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testRefer), channel); err != nil {
+		t.Fatal(err)
+	}
+	event := listener.requests[1]
+	handler.ServeSIP(event.GetServerTransaction(), event.GetRequest().(*message.SIPRequest))
+
+	if referredBy == nil || referredBy.GetCid() != "20398823.2UWQFN309shb3@atlanta.com" {
+		t.Fatal("Referred-By not given to the policy")
+	}
+	if accepted := channel.sent[len(channel.sent)-1]; accepted.GetStatusCode() != message.ACCEPTED {
+		t.Fatalf("expected 202, got %s", accepted.GetFirstLine())
+	}
+
+	// The INVITE goes to the Refer-To target, not to the referrer.
+	target := channelFactory.getChannel(t, "UDP/chicago.com:5060")
+	invite := target.waitForRequest(t, message.INVITE)
+	if n := channel.countRequests(message.INVITE); n != 0 {
+		t.Fatalf("%d INVITEs sent to the referrer", n)
+	}
+	encoded := invite.String()
+	if invite.GetRequestURI().String() != "sip:carol@chicago.com" {
+		t.Fatalf("bad Request-URI %s", invite.GetRequestURI())
+	}
+	if !strings.Contains(encoded, "Referred-By: <sip:alice@atlanta.com>;cid=\"20398823.2UWQFN309shb3@atlanta.com\"\r\n") {
+		t.Fatalf("Referred-By not copied:\n%s", encoded)
+	}
+	if !strings.Contains(encoded, "Replaces: 11a4f64d@pc.chicago.com;to-tag=8983;from-tag=7743\r\n") {
+		t.Fatalf("embedded Replaces not added:\n%s", encoded)
+	}
+	if invite.GetCallIdentifier() == "a84b4c76e66710@pc33.atlanta.com" || invite.GetFromTag() == "a6c85cf" ||
+		invite.GetFrom().GetAddress().String() != "\"Bob\" <sip:bob@biloxi.com>" || invite.HasToTag() {
+		t.Fatalf("INVITE not sent in a new call:\n%s", encoded)
+	}
+
+	ok := invite.CreateResponse(message.OK)
+	SetResponseToTag(ok, "9fxced76sl")
+	sipStack.ProcessResponse(ok, target)
+
+	notifies := channel.waitForRequests(t, message.NOTIFY, 2)
+	for i, expected := range []string{"SIP/2.0 100 Trying\r\n", "SIP/2.0 200 OK\r\n"} {
+		notify := notifies[i]
+		if notify.GetCallIdentifier() != "a84b4c76e66710@pc33.atlanta.com" || notify.GetToTag() != "1928301774" {
+			t.Fatalf("NOTIFY not sent in the dialog:\n%s", notify.String())
+		}
+		if content := notify.GetMessageContent(); content != expected {
+			t.Fatalf("expected sipfrag %q, got %q", expected, content)
+		}
+	}
+	if !strings.Contains(notifies[0].String(), "Event: refer;id=314160\r\n") ||
+		!strings.Contains(notifies[1].String(), "Subscription-State: terminated;reason=noresource\r\n") {
+		t.Fatalf("bad NOTIFY:\n%s%s", notifies[0].String(), notifies[1].String())
+	}
+}
+
+func TestReferHandlerNoChannel(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	establishDialog(t, sipStack, listener, channel, message.OK)
+
+	// Without a channel to the target the REFER fails with a 503 NOTIFY.
+	sipStack.ProcessRequest(parseTestRequest(t, testRefer), channel)
+	event := listener.requests[1]
+	NewReferHandler(sipStack, nil).ServeSIP(event.GetServerTransaction(), event.GetRequest().(*message.SIPRequest))
+	notifies := channel.waitForRequests(t, message.NOTIFY, 2)
+	if content := notifies[1].GetMessageContent(); content != "SIP/2.0 503 Service unavailable\r\n" {
+		t.Fatalf("expected sipfrag 503, got %q", content)
+	}
+	if n := channel.countRequests(message.INVITE); n != 0 {
+		t.Fatalf("%d INVITEs sent to the referrer", n)
+	}
+}
+
+func TestReferHandlerDeclined(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	establishDialog(t, sipStack, listener, channel, message.OK)
+
+	handler := NewReferHandler(sipStack, func(refer *message.SIPRequest, referredBy *header.ReferredBy) bool {
+		return referredBy != nil && referredBy.GetAddress().GetURI().String() == "sip:carol@chicago.com"
+	})
+	sipStack.ProcessRequest(parseTestRequest(t, testRefer), channel)
+	event := listener.requests[1]
+	handler.ServeSIP(event.GetServerTransaction(), event.GetRequest().(*message.SIPRequest))
+	if declined := channel.sent[len(channel.sent)-1]; declined.GetStatusCode() != message.DECLINE {
+		t.Fatalf("expected 603, got %s", declined.GetFirstLine())
+	}
+	if len(channel.requests) != 0 {
+		t.Fatal("declined REFER triggered a request")
+	}
+}
+
+func TestReferOutsideDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	refer := strings.Replace(testRefer, ";tag=a6c85cf", "", 1)
+	sipStack.ProcessRequest(parseTestRequest(t, refer), channel)
+	event := listener.requests[0]
+	NewReferHandler(sipStack, nil).ServeSIP(event.GetServerTransaction(), event.GetRequest().(*message.SIPRequest))
+	if forbidden := channel.sent[0]; forbidden.GetStatusCode() != message.FORBIDDEN || forbidden.GetToTag() == "" {
+		t.Fatalf("expected 403, got %s", forbidden.GetFirstLine())
+	}
+}
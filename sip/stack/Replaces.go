@@ -0,0 +1,123 @@
+package stack
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Find the dialog a Replaces header refers to (RFC 3891 section 3). The
+ * to-tag is the local tag of the dialog and the from-tag its remote tag.
+ * When the dialog cannot be replaced the status code of the response to
+ * send is returned instead: 481 if there is no such dialog or if it is an
+ * early dialog this UA did not initiate, and 486 if it is confirmed and
+ * the header asks for an early dialog only.
+ */
+func (this *SIPTransactionStack) FindReplacedDialog(replaces *header.Replaces) (dialog *SIPDialog, statusCode int) {
+	dialog = this.GetDialog(replaces.GetCallId(), replaces.GetToTag(), replaces.GetFromTag())
+	if dialog == nil {
+		return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+	}
+	switch dialog.GetState() {
+	case sip.DIALOGSTATE_EARLY:
+		if dialog.IsServer() {
+			return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+		}
+	case sip.DIALOGSTATE_CONFIRMED:
+		if replaces.IsEarlyOnly() {
+			return nil, message.BUSY_HERE
+		}
+	default:
+		return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+	}
+	return dialog, 0
+}
+
+/** Find the dialog a Join header refers to (RFC 3911 section 4). Only a
+ * confirmed dialog or an early dialog this UA initiated may be joined;
+ * otherwise 481 is returned.
+ */
+func (this *SIPTransactionStack) FindJoinedDialog(join *header.Join) (dialog *SIPDialog, statusCode int) {
+	dialog = this.GetDialog(join.GetCallId(), join.GetToTag(), join.GetFromTag())
+	if dialog == nil {
+		return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+	}
+	switch dialog.GetState() {
+	case sip.DIALOGSTATE_CONFIRMED:
+	case sip.DIALOGSTATE_EARLY:
+		if dialog.IsServer() {
+			return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+		}
+	default:
+		return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+	}
+	return dialog, 0
+}
+
+/** Match the Replaces or Join header of a new request to its dialog and
+ * attach the dialog to the server transaction. It returns the status code
+ * of the response that rejects the request, or 0 when the request goes on
+ * to the listener: Replaces and Join are only allowed in an INVITE, and
+ * not together (RFC 3891 section 3, RFC 3911 section 4).
+ */
+func (this *SIPTransactionStack) processReplaces(st *SIPServerTransaction, request *message.SIPRequest) (statusCode int) {
+	hasReplaces := request.HasHeader(core.SIPHeaderNames_REPLACES)
+	hasJoin := request.HasHeader(core.SIPHeaderNames_JOIN)
+	if !hasReplaces && !hasJoin {
+		return 0
+	}
+	if request.GetMethod() != message.INVITE || hasReplaces && hasJoin {
+		return message.BAD_REQUEST
+	}
+
+	if hasReplaces {
+		replaces, ok := request.GetHeader(core.SIPHeaderNames_REPLACES).(*header.Replaces)
+		if !ok {
+			return message.BAD_REQUEST
+		}
+		dialog, statusCode := this.FindReplacedDialog(replaces)
+		if dialog == nil {
+			return statusCode
+		}
+		st.mutex.Lock()
+		st.replacedDialog = dialog
+		st.mutex.Unlock()
+		return 0
+	}
+
+	join, ok := request.GetHeader(core.SIPHeaderNames_JOIN).(*header.Join)
+	if !ok {
+		return message.BAD_REQUEST
+	}
+	dialog, statusCode := this.FindJoinedDialog(join)
+	if dialog == nil {
+		return statusCode
+	}
+	st.mutex.Lock()
+	st.joinedDialog = dialog
+	st.mutex.Unlock()
+	return 0
+}
+
+/** Swap a new dialog in for the dialog its INVITE replaces, once the
+ * INVITE is answered with a 2xx: the application data moves to the new
+ * dialog and the old one is ended, with a BYE if it is confirmed or by
+ * cancelling its INVITE if it is early (RFC 3891 section 3).
+ */
+func (this *SIPTransactionStack) replaceDialog(replacedDialog, dialog *SIPDialog) {
+	if dialog.GetApplicationData() == nil {
+		dialog.SetApplicationData(replacedDialog.GetApplicationData())
+	}
+	switch replacedDialog.GetState() {
+	case sip.DIALOGSTATE_CONFIRMED:
+		if replacedDialog.SendBye() != nil {
+			replacedDialog.terminate()
+		}
+	case sip.DIALOGSTATE_EARLY:
+		if ct, ok := replacedDialog.GetFirstTransaction().(*SIPClientTransaction); ok {
+			ct.cancel()
+		}
+		replacedDialog.terminate()
+	}
+}
@@ -0,0 +1,161 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** An INVITE of a second call, from the transfer target, that carries the
+ * given Replaces or Join header. */
+func testReplacingInvite(hdr string) string {
+	return "INVITE sip:bob@192.0.2.4 SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc.chicago.com;branch=z9hG4bKa7c8dze\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: Bob <sip:bob@biloxi.com>\r\n" +
+		"From: Carol <sip:carol@chicago.com>;tag=8983\r\n" +
+		"Call-ID: 11a4f64d@pc.chicago.com\r\n" +
+		"CSeq: 1 INVITE\r\n" +
+		"Contact: <sip:carol@pc.chicago.com>\r\n" +
+		hdr +
+		"Content-Length: 0\r\n\r\n"
+}
+
+/** Establish the dialog of testInvite with Bob as the UAS. */
+func establishDialog(t *testing.T, sipStack *SIPTransactionStack, listener *testListener, channel *testChannel, statusCode int) *SIPDialog {
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testInvite), channel); err != nil {
+		t.Fatal(err)
+	}
+	st := listener.requests[len(listener.requests)-1].GetServerTransaction()
+	answerInvite(t, st, statusCode, "a6c85cf")
+	return st.GetDialog().(*SIPDialog)
+}
+
+func TestReplacesConfirmedDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	dialog := establishDialog(t, sipStack, listener, channel, message.OK)
+	dialog.SetApplicationData("call 1")
+
+	invite := testReplacingInvite("Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n")
+	if err := sipStack.ProcessRequest(parseTestRequest(t, invite), channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.requests) != 2 {
+		t.Fatal("INVITE with Replaces not delivered")
+	}
+	st := listener.requests[1].GetServerTransaction().(*SIPServerTransaction)
+	if st.GetReplacedDialog() != dialog {
+		t.Fatal("Replaces not matched to its dialog")
+	}
+
+	answerInvite(t, st, message.OK, "b7d96d0")
+	bye := channel.waitForRequest(t, message.BYE)
+	if bye.GetCallIdentifier() != "a84b4c76e66710@pc33.atlanta.com" || bye.GetToTag() != "1928301774" {
+		t.Fatalf("BYE not sent in the replaced dialog:\n%s", bye.String())
+	}
+	if dialog.GetState() != sip.DIALOGSTATE_TERMINATED {
+		t.Fatal("replaced dialog not terminated")
+	}
+	if newDialog := st.GetDialog(); newDialog.GetState() != sip.DIALOGSTATE_CONFIRMED || newDialog.GetApplicationData() != "call 1" {
+		t.Fatal("new dialog not swapped in")
+	}
+}
+
+func TestReplacesRejected(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		statusCode int
+		hdr        string
+		establish  int
+	}{
+		{"unknown dialog", message.CALL_OR_TRANSACTION_DOES_NOT_EXIST,
+			"Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=unknown\r\n", message.OK},
+		{"early-only", message.BUSY_HERE,
+			"Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774;early-only\r\n", message.OK},
+		{"early dialog of the UAS", message.CALL_OR_TRANSACTION_DOES_NOT_EXIST,
+			"Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n", message.RINGING},
+		{"Replaces and Join", message.BAD_REQUEST,
+			"Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n" +
+				"Join: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n", message.OK},
+	} {
+		sipStack, listener := newTestStack()
+		channel := &testChannel{}
+		dialog := establishDialog(t, sipStack, listener, channel, test.establish)
+		state := dialog.GetState()
+
+		if err := sipStack.ProcessRequest(parseTestRequest(t, testReplacingInvite(test.hdr)), channel); err != nil {
+			t.Fatal(err)
+		}
+		if len(listener.requests) != 1 {
+			t.Errorf("%s: rejected INVITE delivered", test.name)
+		}
+		if response := channel.sent[len(channel.sent)-1]; response.GetStatusCode() != test.statusCode || response.GetToTag() == "" {
+			t.Errorf("%s: expected %d, got %s", test.name, test.statusCode, response.GetFirstLine())
+		}
+		if dialog.GetState() != state {
+			t.Errorf("%s: dialog changed", test.name)
+		}
+	}
+}
+
+func TestReplacesInNonInvite(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	options := strings.Replace(testReplacingInvite("Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n"),
+		"INVITE", "OPTIONS", -1)
+	sipStack.ProcessRequest(parseTestRequest(t, options), channel)
+	if len(listener.requests) != 0 || channel.sent[0].GetStatusCode() != message.BAD_REQUEST {
+		t.Fatal("expected 400 for Replaces in OPTIONS")
+	}
+}
+
+func TestReplacesEarlyClientDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	invite := parseTestRequest(t, testInvite)
+	ct, err := sipStack.CreateClientTransaction(invite, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct.SendRequest()
+	ringing := invite.CreateResponse(message.RINGING)
+	SetResponseToTag(ringing, "a6c85cf")
+	sipStack.ProcessResponse(ringing, channel)
+	dialog := ct.GetDialog().(*SIPDialog)
+
+	// Alice is the UAC: her tag is the to-tag of the Replaces.
+	replacing := testReplacingInvite("Replaces: a84b4c76e66710@pc33.atlanta.com;to-tag=1928301774;from-tag=a6c85cf;early-only\r\n")
+	sipStack.ProcessRequest(parseTestRequest(t, replacing), channel)
+	st := listener.requests[0].GetServerTransaction().(*SIPServerTransaction)
+	if st.GetReplacedDialog() != dialog {
+		t.Fatal("Replaces not matched to the early dialog")
+	}
+	answerInvite(t, st, message.OK, "b7d96d0")
+	if cancel := channel.waitForRequest(t, message.CANCEL); cancel.GetCSeqNumber() != invite.GetCSeqNumber() {
+		t.Fatal("INVITE of the replaced dialog not cancelled")
+	}
+	if dialog.GetState() != sip.DIALOGSTATE_TERMINATED {
+		t.Fatal("replaced dialog not terminated")
+	}
+}
+
+func TestJoin(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	dialog := establishDialog(t, sipStack, listener, channel, message.OK)
+
+	invite := testReplacingInvite("Join: a84b4c76e66710@pc33.atlanta.com;to-tag=a6c85cf;from-tag=1928301774\r\n")
+	sipStack.ProcessRequest(parseTestRequest(t, invite), channel)
+	st := listener.requests[1].GetServerTransaction().(*SIPServerTransaction)
+	if st.GetJoinedDialog() != dialog {
+		t.Fatal("Join not matched to its dialog")
+	}
+	answerInvite(t, st, message.OK, "b7d96d0")
+	if dialog.GetState() != sip.DIALOGSTATE_CONFIRMED {
+		t.Fatal("joined dialog terminated")
+	}
+}
@@ -0,0 +1,116 @@
+package stack
+
+import (
+	"net"
+	"strings"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Default ports of the sent-by of a Via (RFC 3261 section 18.2.2).
+ */
+const (
+	ResponseRouting_DEFAULT_PORT     = 5060
+	ResponseRouting_DEFAULT_TLS_PORT = 5061
+)
+
+/**
+ * A DatagramChannel is a MessageChannel of a connectionless transport that
+ * shares one socket among all peers, e.g. a UDP listening point. Responses
+ * sent on such a channel are addressed with SendMessageTo to the address
+ * computed from the topmost Via (RFC 3261 section 18.2.2, RFC 3581).
+ */
+type DatagramChannel interface {
+	MessageChannel
+
+	/** Send a message to the given address and port.
+	 */
+	SendMessageTo(msg message.Message, host string, port int) (IOException error)
+}
+
+/** Stamp the topmost Via of a request received on a channel with the
+ * source of the packet. The received parameter is added when the sent-by
+ * host differs from the source address (RFC 3261 section 18.2.1), and when
+ * the client asked for rport the source port is filled in together with
+ * received, which is then always present (RFC 3581 section 4).
+ */
+func ProcessReceivedVia(request *message.SIPRequest, channel MessageChannel) {
+	if !request.HasHeader(core.SIPHeaderNames_VIA) {
+		return
+	}
+	via := request.GetTopmostVia()
+	sourceAddress := channel.GetPeerAddress()
+	if via == nil || sourceAddress == "" {
+		return
+	}
+	if via.HasRPort() {
+		via.SetReceived(sourceAddress)
+		if sourcePort := channel.GetPeerPort(); sourcePort > 0 {
+			via.SetRPortValue(sourcePort)
+		}
+	} else if !isSameHost(via.GetHost(), sourceAddress) {
+		via.SetReceived(sourceAddress)
+	}
+}
+
+/** Get the address a response is sent to over a connectionless transport
+ * (RFC 3261 section 18.2.2): the maddr of the topmost Via if present,
+ * else the received address with the rport, else the sent-by. When no
+ * port applies the default port of the transport is used.
+ */
+func GetResponseAddress(response *message.SIPResponse) (host string, port int) {
+	if !response.HasHeader(core.SIPHeaderNames_VIA) {
+		return "", 0
+	}
+	via := response.GetTopmostVia()
+	if via == nil {
+		return "", 0
+	}
+
+	port = via.GetPort()
+	if port <= 0 {
+		port = ResponseRouting_DEFAULT_PORT
+		if strings.EqualFold(via.GetTransport(), "TLS") {
+			port = ResponseRouting_DEFAULT_TLS_PORT
+		}
+	}
+	if maddr := via.GetMAddr(); maddr != "" {
+		return stripBrackets(maddr), port
+	}
+	if received := via.GetReceived(); received != "" {
+		if rport := via.GetRPort(); rport > 0 {
+			port = rport
+		}
+		return stripBrackets(received), port
+	}
+	return stripBrackets(via.GetHost()), port
+}
+
+/** Send a response on the channel its request arrived on. Connection
+ * oriented channels reuse the connection; datagram channels send to the
+ * address computed by GetResponseAddress, i.e. back to the source of the
+ * request when received and rport are set.
+ */
+func SendResponse(channel MessageChannel, response *message.SIPResponse) (IOException error) {
+	if datagramChannel, ok := channel.(DatagramChannel); ok && !IsReliableTransport(channel.GetTransport()) {
+		if host, port := GetResponseAddress(response); host != "" {
+			return datagramChannel.SendMessageTo(response, host, port)
+		}
+	}
+	return channel.SendMessage(response)
+}
+
+func isSameHost(host, address string) bool {
+	host, address = stripBrackets(host), stripBrackets(address)
+	hostIP, addressIP := net.ParseIP(host), net.ParseIP(address)
+	if hostIP != nil && addressIP != nil {
+		return hostIP.Equal(addressIP)
+	}
+	return strings.EqualFold(host, address)
+}
+
+/** Remove the brackets of an IPv6 reference. */
+func stripBrackets(host string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
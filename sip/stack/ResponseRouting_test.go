@@ -0,0 +1,89 @@
+package stack
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/use-go/gosips/sip/message"
+)
+
+type testDatagramChannel struct {
+	testChannel
+	peer string
+	port int
+	to   []string
+}
+
+func (this *testDatagramChannel) SendMessageTo(msg message.Message, host string, port int) error {
+	this.to = append(this.to, net.JoinHostPort(host, strconv.Itoa(port)))
+	return this.SendMessage(msg)
+}
+
+func (this *testDatagramChannel) GetPeerAddress() string { return this.peer }
+func (this *testDatagramChannel) GetPeerPort() int       { return this.port }
+
+func testOptions(via string) string {
+	return "OPTIONS sip:bob@biloxi.com SIP/2.0\r\n" +
+		"Via: " + via + "\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: Bob <sip:bob@biloxi.com>\r\n" +
+		"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 63104 OPTIONS\r\n" +
+		"Content-Length: 0\r\n\r\n"
+}
+
+func TestProcessReceivedVia(t *testing.T) {
+	for _, test := range []struct {
+		via, peer, received string
+		rport               int
+		destination         string
+	}{
+		// RFC 3581 section 4: rport is filled in and received is always set.
+		{"SIP/2.0/UDP 10.1.1.1:4540;rport;branch=z9hG4bKkjshdyff", "192.0.2.1", "192.0.2.1", 9988, "192.0.2.1:9988"},
+		{"SIP/2.0/UDP 192.0.2.1:4540;rport;branch=z9hG4bKkjshdyff", "192.0.2.1", "192.0.2.1", 9988, "192.0.2.1:9988"},
+		// RFC 3261 section 18.2.1: received only when the host differs.
+		{"SIP/2.0/UDP 10.1.1.1:4540;branch=z9hG4bKkjshdyff", "192.0.2.1", "192.0.2.1", -1, "192.0.2.1:4540"},
+		{"SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bKkjshdyff", "192.0.2.1", "192.0.2.1", -1, "192.0.2.1:5060"},
+		{"SIP/2.0/UDP 192.0.2.1:4540;branch=z9hG4bKkjshdyff", "192.0.2.1", "", -1, "192.0.2.1:4540"},
+		{"SIP/2.0/UDP [2001:db8::9]:4540;branch=z9hG4bKkjshdyff", "2001:db8::9", "", -1, "[2001:db8::9]:4540"},
+	} {
+		channel := &testDatagramChannel{peer: test.peer, port: 9988}
+		sipStack := NewSIPTransactionStack()
+		request := parseTestRequest(t, testOptions(test.via))
+		if err := sipStack.ProcessRequest(request, channel); err != nil {
+			t.Fatal(err)
+		}
+		via := request.GetTopmostVia()
+		if via.GetReceived() != test.received || via.GetRPort() != test.rport {
+			t.Errorf("%s: got %s", test.via, via.String())
+		}
+
+		st := sipStack.FindTransaction(request)
+		if err := st.SendResponse(request.CreateResponse(message.OK)); err != nil {
+			t.Fatal(err)
+		}
+		if len(channel.to) != 1 || channel.to[0] != test.destination {
+			t.Errorf("%s: response sent to %v", test.via, channel.to)
+		}
+	}
+}
+
+func TestResponseOverConnection(t *testing.T) {
+	channel := &testKeepAliveChannel{transport: "TCP", peer: "192.0.2.1", port: 9988}
+	sipStack := NewSIPTransactionStack()
+	request := parseTestRequest(t, testOptions("SIP/2.0/TCP 10.1.1.1:4540;rport;branch=z9hG4bKkjshdyff"))
+	if err := sipStack.ProcessRequest(request, channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := sipStack.FindTransaction(request).SendResponse(request.CreateResponse(message.OK)); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.sent) != 1 {
+		t.Fatal("response not sent on the connection")
+	}
+	if via := channel.sent[0].GetTopmostVia(); via.GetReceived() != "192.0.2.1" || via.GetRPort() != 9988 {
+		t.Fatal("bad Via in response " + via.String())
+	}
+}
@@ -0,0 +1,359 @@
+package stack
+
+import (
+	"errors"
+	"time"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Number of responses buffered for a transaction sent with SendRequest.
+ * Provisional responses are dropped when the buffer is full so that the
+ * final response can always be delivered.
+ */
+const SIPClientTransaction_RESPONSE_BUFFER = 8
+
+/**
+ * Client side of a SIP transaction. The state machine follows RFC 3261
+ * section 17.1: INVITE transactions start in the Calling state and
+ * non-INVITE transactions in the Trying state. The ACK for a non-2xx final
+ * response to an INVITE is generated by the transaction itself.
+ *
+ * A transaction created by SIPTransactionStack.SendRequest delivers its
+ * responses on a channel instead of the SipListener.
+ *
+ * Over an unreliable transport the request is retransmitted on Timer A
+ * (INVITE) or Timer E (non-INVITE). A transaction that gets no final
+ * response times out on Timer B (INVITE, while Calling) or Timer F
+ * (non-INVITE) and is ended with a locally generated 408 (RFC 3261
+ * 8.1.3.1); an INVITE that is still unanswered 64*T1 after its CANCEL is
+ * ended with a locally generated 487 (RFC 3261 9.1).
+ *
+ * A transaction that received a final response stays in the table of the
+ * stack to absorb its retransmissions until Timer D (INVITE) or Timer K
+ * (non-INVITE) ends it; the ACK is resent for each retransmission of a
+ * non-2xx final response to an INVITE.
+ */
+type SIPClientTransaction struct {
+	SIPTransaction
+
+	lastResponse *message.SIPResponse
+
+	ackRequest *message.SIPRequest
+
+	responses chan *message.SIPResponse
+
+	done chan struct{}
+
+	cancelPending bool
+}
+
+/** Create a client transaction for a request to be sent on a channel.
+ */
+func NewSIPClientTransaction(sipStack *SIPTransactionStack, channel MessageChannel, request *message.SIPRequest) *SIPClientTransaction {
+	this := &SIPClientTransaction{}
+	this.SIPTransaction.super(sipStack, channel, request)
+	if this.IsInviteTransaction() {
+		this.state = sip.TRANSACTIONSTATE_CALLING
+	} else {
+		this.state = sip.TRANSACTIONSTATE_TRYING
+	}
+	this.done = make(chan struct{})
+	return this
+}
+
+/** Send the request that created this transaction and start its timeout
+ * (Timer B or F) and, over an unreliable transport, its retransmissions
+ * (Timer A or E).
+ */
+func (this *SIPClientTransaction) SendRequest() (SipException error) {
+	this.mutex.Lock()
+	this.startTimer(this.getTimeout(), this.timeout)
+	if !this.isReliable() {
+		this.startRetransmissionTimer(this.getTimerT1())
+	}
+	this.mutex.Unlock()
+	return this.channel.SendMessage(this.originalRequest)
+}
+
+/** Create a CANCEL for the INVITE of this transaction.
+ */
+func (this *SIPClientTransaction) CreateCancel() (r message.Request, SipException error) {
+	if !this.IsInviteTransaction() {
+		return nil, errors.New("SipException: Only INVITE may be cancelled")
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.lastResponse != nil && this.lastResponse.IsFinalResponse() {
+		return nil, errors.New("SipException: Cannot cancel a completed transaction")
+	}
+	return this.originalRequest.CreateCancelRequest(), nil
+}
+
+/** Create an ACK for the final response received by this transaction.
+ */
+func (this *SIPClientTransaction) CreateAck() (r message.Request, SipException error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if !this.IsInviteTransaction() {
+		return nil, errors.New("SipException: Only INVITE is acknowledged")
+	}
+	if this.lastResponse == nil || !this.lastResponse.IsFinalResponse() {
+		return nil, errors.New("SipException: No final response received")
+	}
+	to, _ := this.lastResponse.GetTo().(*header.To)
+	return this.originalRequest.CreateAckRequest(to), nil
+}
+
+/** Get the last response received by this transaction (nil if none).
+ */
+func (this *SIPClientTransaction) GetLastResponse() *message.SIPResponse {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.lastResponse
+}
+
+/** Get the channel the responses of this transaction are delivered on, or
+ * nil if they go to the SipListener.
+ */
+func (this *SIPClientTransaction) GetResponses() <-chan *message.SIPResponse {
+	return this.responses
+}
+
+/** Run the state machine for a response. It returns true if the response
+ * must be passed to the SipListener.
+ */
+func (this *SIPClientTransaction) processResponse(response *message.SIPResponse) (notify bool, err error) {
+	if this.IsInviteTransaction() && this.sipStack != nil {
+		this.sipStack.processClientDialog(this, response)
+	}
+	this.mutex.Lock()
+	statusCode := response.GetStatusCode()
+
+	if this.state == sip.TRANSACTIONSTATE_COMPLETED ||
+		this.state == sip.TRANSACTIONSTATE_TERMINATED {
+		// Retransmission of the final response: the ACK is repeated for
+		// a non-2xx final response to an INVITE.
+		ack := this.ackRequest
+		this.mutex.Unlock()
+		if ack != nil && statusCode >= 300 {
+			return false, this.channel.SendMessage(ack)
+		}
+		// A 2xx to an INVITE goes to the TU, which acknowledges it.
+		return this.IsInviteTransaction() && statusCode/100 == 2 && this.responses == nil, nil
+	}
+
+	var sendCancel bool
+	if statusCode >= 200 || this.IsInviteTransaction() {
+		// Timers E and F run until the final response, Timers A and B
+		// until any response.
+		this.stopTimers()
+	}
+	if statusCode < 200 {
+		this.state = sip.TRANSACTIONSTATE_PROCEEDING
+		sendCancel = this.cancelPending
+		this.cancelPending = false
+	} else if this.IsInviteTransaction() && statusCode/100 == 2 {
+		this.state = sip.TRANSACTIONSTATE_TERMINATED
+	} else {
+		this.state = sip.TRANSACTIONSTATE_COMPLETED
+		if this.IsInviteTransaction() {
+			to, _ := response.GetTo().(*header.To)
+			this.ackRequest = this.originalRequest.CreateAckRequest(to)
+		}
+		this.startCompletedTimer()
+	}
+	this.lastResponse = response
+	final := statusCode >= 200
+	this.deliver(response, final)
+	ack := this.ackRequest
+	terminated := this.state == sip.TRANSACTIONSTATE_TERMINATED
+	this.mutex.Unlock()
+
+	if terminated && this.sipStack != nil {
+		this.sipStack.removeClientTransaction(this)
+	}
+	if ack != nil && final {
+		err = this.channel.SendMessage(ack)
+	}
+	if sendCancel {
+		if cerr := this.sendCancel(); err == nil {
+			err = cerr
+		}
+	}
+	return this.responses == nil, err
+}
+
+/** Hand a response to the channel of the transaction, closing the channel
+ * after the final response. Called with the mutex held.
+ */
+func (this *SIPClientTransaction) deliver(response *message.SIPResponse, final bool) {
+	if final {
+		close(this.done)
+	}
+	if this.responses == nil {
+		return
+	}
+	if final {
+		this.responses <- response
+		close(this.responses)
+	} else if len(this.responses) < cap(this.responses)-1 {
+		this.responses <- response
+	}
+}
+
+/** Cancel the transaction when the context of SendRequest is done. An
+ * INVITE is cancelled with a CANCEL request once a provisional response
+ * has been received (RFC 3261 9.1); any other request is abandoned.
+ */
+func (this *SIPClientTransaction) cancel() {
+	this.mutex.Lock()
+	if this.lastResponse != nil && this.lastResponse.IsFinalResponse() ||
+		this.state == sip.TRANSACTIONSTATE_TERMINATED {
+		this.mutex.Unlock()
+		return
+	}
+	if !this.IsInviteTransaction() {
+		this.state = sip.TRANSACTIONSTATE_TERMINATED
+		this.stopTimers()
+		close(this.done)
+		if this.responses != nil {
+			close(this.responses)
+		}
+		this.mutex.Unlock()
+		if this.sipStack != nil {
+			this.sipStack.removeClientTransaction(this)
+		}
+		return
+	}
+	if this.state == sip.TRANSACTIONSTATE_CALLING {
+		this.cancelPending = true
+		this.mutex.Unlock()
+		return
+	}
+	this.mutex.Unlock()
+	this.sendCancel()
+}
+
+/** Start the timer of the Completed state: Timer D for an INVITE, Timer
+ * K for a non-INVITE (RFC 3261 17.1.1.2 and 17.1.2.2). Called with the
+ * mutex held.
+ */
+func (this *SIPClientTransaction) startCompletedTimer() {
+	timer := this.getTimerT4()
+	if this.IsInviteTransaction() {
+		timer = this.getTimeout()
+	}
+	if this.isReliable() {
+		timer = 0
+	}
+	this.startTimer(timer, this.terminate)
+}
+
+/** Retransmit the request after the interval (Timer A or E). The interval
+ * doubles while the INVITE is Calling, and up to T2 while the non-INVITE
+ * is Trying; it is T2 once the non-INVITE is Proceeding. Called with the
+ * mutex held.
+ */
+func (this *SIPClientTransaction) startRetransmissionTimer(interval time.Duration) {
+	this.retransmissionTimer = time.AfterFunc(interval, func() {
+		this.mutex.Lock()
+		if this.state != sip.TRANSACTIONSTATE_CALLING && this.state != sip.TRANSACTIONSTATE_TRYING &&
+			(this.IsInviteTransaction() || this.state != sip.TRANSACTIONSTATE_PROCEEDING) {
+			this.mutex.Unlock()
+			return
+		}
+		interval *= 2
+		if !this.IsInviteTransaction() && (interval > this.getTimerT2() || this.state == sip.TRANSACTIONSTATE_PROCEEDING) {
+			interval = this.getTimerT2()
+		}
+		this.startRetransmissionTimer(interval)
+		this.mutex.Unlock()
+		this.channel.SendMessage(this.originalRequest)
+	})
+}
+
+/** End the transaction with a 408 when Timer B or F fires before a final
+ * response.
+ */
+func (this *SIPClientTransaction) timeout() {
+	this.mutex.Lock()
+	if this.state != sip.TRANSACTIONSTATE_CALLING && this.state != sip.TRANSACTIONSTATE_TRYING &&
+		(this.IsInviteTransaction() || this.state != sip.TRANSACTIONSTATE_PROCEEDING) {
+		this.mutex.Unlock()
+		return
+	}
+	this.fail(message.REQUEST_TIMEOUT)
+}
+
+/** End an INVITE with a 487 when it has no final response 64*T1 after
+ * its CANCEL was sent (RFC 3261 9.1).
+ */
+func (this *SIPClientTransaction) cancelTimeout() {
+	this.mutex.Lock()
+	if this.state != sip.TRANSACTIONSTATE_CALLING && this.state != sip.TRANSACTIONSTATE_PROCEEDING {
+		this.mutex.Unlock()
+		return
+	}
+	this.fail(message.REQUEST_TERMINATED)
+}
+
+/** End the transaction with a locally generated final response. The
+ * response is delivered as if it had been received, but it is not
+ * acknowledged. Called with the mutex held, which it releases.
+ */
+func (this *SIPClientTransaction) fail(statusCode int) {
+	response := this.originalRequest.CreateResponse(statusCode)
+	this.state = sip.TRANSACTIONSTATE_TERMINATED
+	this.cancelPending = false
+	this.stopTimers()
+	this.lastResponse = response
+	this.mutex.Unlock()
+
+	// The transaction leaves the table before the channel is closed.
+	if this.sipStack != nil {
+		this.sipStack.removeClientTransaction(this)
+		if this.IsInviteTransaction() {
+			this.sipStack.processClientDialog(this, response)
+		}
+	}
+	this.mutex.Lock()
+	this.deliver(response, true)
+	this.mutex.Unlock()
+	if this.sipStack != nil && this.responses == nil {
+		this.sipStack.notifyResponse(this, response)
+	}
+}
+
+/** Terminate the transaction when Timer D or K fires and remove it from
+ * the stack.
+ */
+func (this *SIPClientTransaction) terminate() {
+	this.mutex.Lock()
+	this.state = sip.TRANSACTIONSTATE_TERMINATED
+	this.stopTimers()
+	this.mutex.Unlock()
+	if this.sipStack != nil {
+		this.sipStack.removeClientTransaction(this)
+	}
+}
+
+/** Send a CANCEL for the INVITE of this transaction in a new client
+ * transaction, and end the INVITE if it is still unanswered 64*T1 later.
+ */
+func (this *SIPClientTransaction) sendCancel() (SipException error) {
+	this.mutex.Lock()
+	this.startTimer(this.getTimeout(), this.cancelTimeout)
+	this.mutex.Unlock()
+	cancel := this.originalRequest.CreateCancelRequest()
+	if this.sipStack == nil {
+		return this.channel.SendMessage(cancel)
+	}
+	ct, err := this.sipStack.CreateClientTransaction(cancel, this.channel)
+	if err != nil {
+		return err
+	}
+	return ct.SendRequest()
+}
@@ -0,0 +1,496 @@
+package stack
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Max-Forwards of the requests created by a dialog.
+ */
+const SIPDialog_MAX_FORWARDS = 70
+
+/**
+ * A dialog created by an INVITE (RFC 3261 section 12). The stack creates
+ * the dialog when a response with a To tag is sent or received for an
+ * INVITE: it is early after a provisional response and confirmed after a
+ * 2xx. A non-2xx final response terminates an early dialog, and a BYE
+ * terminates the dialog.
+ *
+ * The dialog is identified by its Call-ID and by the local and remote
+ * tags, and keeps the state needed to create the requests sent within it:
+ * the parties, the remote target, the route set and the sequence numbers.
+ */
+type SIPDialog struct {
+	mutex sync.Mutex
+
+	sipStack *SIPTransactionStack
+
+	channel MessageChannel
+
+	firstTransaction sip.Transaction
+
+	callId *header.CallID
+
+	localTag string
+
+	remoteTag string
+
+	localParty address.Address
+
+	remoteParty address.Address
+
+	localTarget address.Address
+
+	remoteTarget address.Address
+
+	routeSet []address.Address
+
+	localSequenceNumber int
+
+	remoteSequenceNumber int
+
+	server bool
+
+	secure bool
+
+	state *sip.DialogState
+
+	applicationData interface{}
+}
+
+/** Create the dialog of an INVITE server transaction from the response
+ * that establishes it (RFC 3261 section 12.1.1).
+ */
+func newServerDialog(st *SIPServerTransaction, response *message.SIPResponse) *SIPDialog {
+	request := st.GetOriginalRequest()
+	this := &SIPDialog{}
+	this.sipStack = st.sipStack
+	this.channel = st.GetMessageChannel()
+	this.firstTransaction = st
+	this.server = true
+	this.callId, _ = request.GetCallId().(*header.CallID)
+	this.localTag = response.GetToTag()
+	this.remoteTag = request.GetFromTag()
+	this.localParty = request.GetTo().GetAddress()
+	this.remoteParty = request.GetFrom().GetAddress()
+	this.localTarget = getContactAddress(&response.SIPMessage)
+	this.remoteTarget = getContactAddress(&request.SIPMessage)
+	this.routeSet = getRecordRoute(&request.SIPMessage, false)
+	this.remoteSequenceNumber = request.GetCSeqNumber()
+	this.secure = isSecureURI(request.GetRequestURI())
+	this.state = sip.DIALOGSTATE_EARLY
+	return this
+}
+
+/** Create a dialog of an INVITE client transaction from a response with a
+ * To tag (RFC 3261 section 12.1.2). A forked INVITE may create several.
+ */
+func newClientDialog(ct *SIPClientTransaction, response *message.SIPResponse) *SIPDialog {
+	request := ct.GetOriginalRequest()
+	this := &SIPDialog{}
+	this.sipStack = ct.sipStack
+	this.channel = ct.GetMessageChannel()
+	this.firstTransaction = ct
+	this.callId, _ = request.GetCallId().(*header.CallID)
+	this.localTag = request.GetFromTag()
+	this.remoteTag = response.GetToTag()
+	this.localParty = request.GetFrom().GetAddress()
+	this.remoteParty = request.GetTo().GetAddress()
+	this.localTarget = getContactAddress(&request.SIPMessage)
+	this.remoteTarget = getContactAddress(&response.SIPMessage)
+	this.routeSet = getRecordRoute(&response.SIPMessage, true)
+	this.localSequenceNumber = request.GetCSeqNumber()
+	this.secure = isSecureURI(request.GetRequestURI())
+	this.state = sip.DIALOGSTATE_EARLY
+	return this
+}
+
+/** Get the key of a dialog in the dialog table of the stack.
+ */
+func GetDialogKey(callId, localTag, remoteTag string) string {
+	return callId + ":" + strings.ToLower(localTag) + ":" + strings.ToLower(remoteTag)
+}
+
+/** Get the address of the local party.
+ */
+func (this *SIPDialog) GetLocalParty() address.Address {
+	return this.localParty
+}
+
+/** Get the address of the remote party.
+ */
+func (this *SIPDialog) GetRemoteParty() address.Address {
+	return this.remoteParty
+}
+
+/** Get the Contact of the remote party, the Request-URI of the requests
+ * sent within the dialog.
+ */
+func (this *SIPDialog) GetRemoteTarget() address.Address {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.remoteTarget
+}
+
+/** Get the Contact of the local party.
+ */
+func (this *SIPDialog) GetLocalTarget() address.Address {
+	return this.localTarget
+}
+
+/** Get the identifier of the dialog: the Call-ID and the local and remote
+ * tags.
+ */
+func (this *SIPDialog) GetDialogId() string {
+	return GetDialogKey(this.GetCallIdentifier(), this.localTag, this.remoteTag)
+}
+
+/** Get the Call-ID header of the dialog.
+ */
+func (this *SIPDialog) GetCallId() header.CallIdHeader {
+	return this.callId
+}
+
+/** Get the Call-ID of the dialog.
+ */
+func (this *SIPDialog) GetCallIdentifier() string {
+	if this.callId == nil {
+		return ""
+	}
+	return this.callId.GetCallId()
+}
+
+/** Get the CSeq of the last request sent within the dialog.
+ */
+func (this *SIPDialog) GetLocalSequenceNumber() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.localSequenceNumber
+}
+
+/** Get the CSeq of the last request received within the dialog.
+ */
+func (this *SIPDialog) GetRemoteSequenceNumber() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.remoteSequenceNumber
+}
+
+/** Get the route set of the dialog as a list of Route headers.
+ */
+func (this *SIPDialog) GetRouteSet() *list.List {
+	routeSet := list.New()
+	for _, addr := range this.routeSet {
+		routeSet.PushBack(header.NewRouteFromAddress(addr))
+	}
+	return routeSet
+}
+
+/** Return true if the dialog was created by a sips Request-URI.
+ */
+func (this *SIPDialog) IsSecure() bool {
+	return this.secure
+}
+
+/** Return true if the local party is the UAS of the INVITE.
+ */
+func (this *SIPDialog) IsServer() bool {
+	return this.server
+}
+
+/** Increment the local sequence number.
+ */
+func (this *SIPDialog) IncrementLocalSequenceNumber() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.localSequenceNumber++
+}
+
+/** Create a request within the dialog (RFC 3261 section 12.2.1.1). The
+ * Request-URI is the remote target and the route set becomes the Route
+ * headers. The local sequence number is incremented, except for ACK and
+ * CANCEL which reuse the CSeq of the request they refer to. The Via is
+ * built from the local target with a new branch.
+ */
+func (this *SIPDialog) CreateRequest(method string) (r message.Request, SipException error) {
+	if method == message.ACK || method == message.CANCEL {
+		return nil, errors.New("SipException: " + method + " is created from its transaction")
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.state == sip.DIALOGSTATE_TERMINATED {
+		return nil, errors.New("SipException: the dialog is terminated")
+	}
+	if this.remoteTarget == nil {
+		return nil, errors.New("SipException: the dialog has no remote target")
+	}
+	via, err := this.createVia()
+	if err != nil {
+		return nil, err
+	}
+
+	request := message.NewSIPRequest()
+	request.SetRequestLine(header.NewRequestLineFromString(this.remoteTarget.GetURI(), method))
+	viaList := header.NewViaList()
+	viaList.PushBack(via)
+	request.SetHeader(viaList)
+
+	maxForwards := header.NewMaxForwards()
+	maxForwards.SetMaxForwards(SIPDialog_MAX_FORWARDS)
+	request.SetMaxForwards(maxForwards)
+
+	from := header.NewFrom()
+	from.SetAddress(this.localParty)
+	if this.localTag != "" {
+		from.SetTag(this.localTag)
+	}
+	request.SetFrom(from)
+	to := header.NewTo()
+	to.SetAddress(this.remoteParty)
+	if this.remoteTag != "" {
+		to.SetTag(this.remoteTag)
+	}
+	request.SetTo(to)
+	request.SetCallId(this.callId)
+
+	this.localSequenceNumber++
+	request.SetCSeq(header.NewCSeq(this.localSequenceNumber, method))
+
+	if len(this.routeSet) > 0 {
+		routeList := header.NewRouteList()
+		for _, addr := range this.routeSet {
+			routeList.PushBack(header.NewRouteFromAddress(addr))
+		}
+		request.SetHeader(routeList)
+	}
+	if this.localTarget != nil && method != message.BYE {
+		contactList := header.NewContactList()
+		contact := header.NewContact()
+		contact.SetAddress(this.localTarget)
+		contactList.PushBack(contact)
+		request.SetHeader(contactList)
+	}
+	request.SetContentLength(header.NewContentLengthFromInt(0))
+	return request, nil
+}
+
+/** Send a request created with CreateRequest in a client transaction of
+ * the stack. Sending a BYE terminates the dialog.
+ */
+func (this *SIPDialog) SendRequest(clientTransaction sip.ClientTransaction) (SipException error) {
+	if this.GetState() == sip.DIALOGSTATE_TERMINATED {
+		return errors.New("SipException: the dialog is terminated")
+	}
+	request, ok := clientTransaction.GetRequest().(*message.SIPRequest)
+	if !ok || request.GetCallIdentifier() != this.GetCallIdentifier() {
+		return errors.New("SipException: the request does not belong to the dialog")
+	}
+	if request.GetMethod() == message.BYE {
+		this.terminate()
+	}
+	return clientTransaction.SendRequest()
+}
+
+/** Send a BYE within the dialog and terminate it. The responses to the
+ * BYE are absorbed by the stack.
+ */
+func (this *SIPDialog) SendBye() (SipException error) {
+	bye, err := this.CreateRequest(message.BYE)
+	if err != nil {
+		return err
+	}
+	this.terminate()
+	_, err = this.sipStack.SendRequest(context.Background(), bye.(*message.SIPRequest), this.channel)
+	return err
+}
+
+/** Send the ACK for a 2xx response on the channel of the dialog.
+ */
+func (this *SIPDialog) SendAck(ackRequest message.Request) (SipException error) {
+	if ackRequest == nil || ackRequest.GetMethod() != message.ACK {
+		return errors.New("SipException: Bad ACK request")
+	}
+	return this.channel.SendMessage(ackRequest)
+}
+
+/** Get the state of the dialog.
+ */
+func (this *SIPDialog) GetState() *sip.DialogState {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.state
+}
+
+/** Terminate the dialog and remove it from the stack.
+ */
+func (this *SIPDialog) Delete() {
+	this.terminate()
+}
+
+/** Get the transaction that created the dialog.
+ */
+func (this *SIPDialog) GetFirstTransaction() sip.Transaction {
+	return this.firstTransaction
+}
+
+/** Get the tag of the local party.
+ */
+func (this *SIPDialog) GetLocalTag() string {
+	return this.localTag
+}
+
+/** Get the tag of the remote party.
+ */
+func (this *SIPDialog) GetRemoteTag() string {
+	return this.remoteTag
+}
+
+/** Attach application data to the dialog.
+ */
+func (this *SIPDialog) SetApplicationData(applicationData interface{}) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.applicationData = applicationData
+}
+
+/** Get the application data of the dialog.
+ */
+func (this *SIPDialog) GetApplicationData() interface{} {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.applicationData
+}
+
+/** Confirm the dialog when a 2xx is sent or received.
+ */
+func (this *SIPDialog) confirm() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.state == sip.DIALOGSTATE_EARLY {
+		this.state = sip.DIALOGSTATE_CONFIRMED
+	}
+}
+
+func (this *SIPDialog) terminate() {
+	this.mutex.Lock()
+	this.state = sip.DIALOGSTATE_TERMINATED
+	this.mutex.Unlock()
+	if this.sipStack != nil {
+		this.sipStack.removeDialog(this)
+	}
+}
+
+/** Check the CSeq of a request received within the dialog and remember
+ * it. A CSeq lower than the last one is out of order (RFC 3261 section
+ * 12.2.2).
+ */
+func (this *SIPDialog) processRequest(request *message.SIPRequest) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	cseq := request.GetCSeqNumber()
+	if this.remoteSequenceNumber > 0 && cseq < this.remoteSequenceNumber {
+		return false
+	}
+	this.remoteSequenceNumber = cseq
+	// A re-INVITE refreshes the remote target (RFC 3261 section 12.2.2).
+	if request.GetMethod() == message.INVITE {
+		if remoteTarget := getContactAddress(&request.SIPMessage); remoteTarget != nil {
+			this.remoteTarget = remoteTarget
+		}
+	}
+	return true
+}
+
+/** Update an early client dialog with a later response of its INVITE:
+ * the remote target and the route set are taken from the last response
+ * (RFC 3261 section 13.2.2.4).
+ */
+func (this *SIPDialog) processResponse(response *message.SIPResponse) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.state != sip.DIALOGSTATE_EARLY {
+		return
+	}
+	if remoteTarget := getContactAddress(&response.SIPMessage); remoteTarget != nil {
+		this.remoteTarget = remoteTarget
+	}
+	if response.HasHeader(core.SIPHeaderNames_RECORD_ROUTE) {
+		this.routeSet = getRecordRoute(&response.SIPMessage, true)
+	}
+}
+
+/** Called with the mutex held. */
+func (this *SIPDialog) createVia() (*header.Via, error) {
+	via := header.NewVia()
+	protocol := header.NewProtocol()
+	if this.channel != nil {
+		protocol.SetTransport(this.channel.GetTransport())
+	}
+	via.SetSentProtocol(protocol)
+
+	host, port := "", -1
+	if firstRequest, ok := this.firstTransaction.GetRequest().(*message.SIPRequest); ok && !this.server {
+		if topVia := firstRequest.GetTopmostVia(); topVia != nil {
+			host, port = topVia.GetHost(), topVia.GetPort()
+		}
+	}
+	if host == "" && this.localTarget != nil {
+		if uri, ok := this.localTarget.GetURI().(*address.SipURIImpl); ok {
+			host, port = uri.GetHost(), uri.GetPort()
+		}
+	}
+	if host == "" {
+		return nil, errors.New("SipException: no local address for the Via")
+	}
+	via.SetHostFromString(host)
+	if port > 0 {
+		via.SetPort(port)
+	}
+	via.SetBranch(GenerateBranchId())
+	return via, nil
+}
+
+/** Get the address of the first Contact of a message, or nil.
+ */
+func getContactAddress(msg *message.SIPMessage) address.Address {
+	if !msg.HasHeader(core.SIPHeaderNames_CONTACT) {
+		return nil
+	}
+	contact, ok := msg.GetContactHeaders().Front().Value.(*header.Contact)
+	if !ok {
+		return nil
+	}
+	return contact.GetAddress()
+}
+
+/** Get the route set of a dialog from the Record-Route of a message: in
+ * order for the UAS and reversed for the UAC.
+ */
+func getRecordRoute(msg *message.SIPMessage, reverse bool) []address.Address {
+	if !msg.HasHeader(core.SIPHeaderNames_RECORD_ROUTE) {
+		return nil
+	}
+	var routeSet []address.Address
+	for e := msg.GetRecordRouteHeaders().Front(); e != nil; e = e.Next() {
+		addr := e.Value.(*header.RecordRoute).GetAddress()
+		if reverse {
+			routeSet = append([]address.Address{addr}, routeSet...)
+		} else {
+			routeSet = append(routeSet, addr)
+		}
+	}
+	return routeSet
+}
+
+func isSecureURI(uri address.URI) bool {
+	return uri != nil && strings.EqualFold(uri.GetScheme(), core.SIPTransportNames_SIPS)
+}
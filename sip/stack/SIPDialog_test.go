@@ -0,0 +1,154 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+const testBye = "BYE sip:bob@192.0.2.4 SIP/2.0\r\n" +
+	"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bKnashds10\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"To: Bob <sip:bob@biloxi.com>;tag=a6c85cf\r\n" +
+	"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 314160 BYE\r\n" +
+	"Content-Length: 0\r\n\r\n"
+
+/** Answer the INVITE of a server transaction with a Contact. */
+func answerInvite(t *testing.T, st sip.ServerTransaction, statusCode int, toTag string) {
+	response := st.GetRequest().(*message.SIPRequest).CreateResponse(statusCode)
+	SetResponseToTag(response, toTag)
+	contact, err := parser.NewContactParser("Contact: <sip:bob@192.0.2.4>\n").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.SetHeader(contact)
+	if err := st.SendResponse(response); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testInvite), channel); err != nil {
+		t.Fatal(err)
+	}
+	st := listener.requests[0].GetServerTransaction()
+	answerInvite(t, st, message.RINGING, "a6c85cf")
+	dialog := sipStack.GetDialog("a84b4c76e66710@pc33.atlanta.com", "a6c85cf", "1928301774")
+	if dialog == nil || st.GetDialog() != dialog || dialog.GetState() != sip.DIALOGSTATE_EARLY {
+		t.Fatal("no early dialog after 180")
+	}
+	if !dialog.IsServer() || dialog.GetRemoteTarget().String() != "<sip:alice@pc33.atlanta.com>" {
+		t.Fatalf("bad dialog: remote target %s", dialog.GetRemoteTarget())
+	}
+	answerInvite(t, st, message.OK, "a6c85cf")
+	if dialog.GetState() != sip.DIALOGSTATE_CONFIRMED {
+		t.Fatal("dialog not confirmed after 200")
+	}
+
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testBye), channel); err != nil {
+		t.Fatal(err)
+	}
+	if listener.requests[1].GetServerTransaction().GetDialog() != dialog {
+		t.Fatal("BYE not matched to its dialog")
+	}
+	if dialog.GetState() != sip.DIALOGSTATE_TERMINATED || len(sipStack.GetDialogs()) != 0 {
+		t.Fatal("dialog not terminated by BYE")
+	}
+}
+
+func TestServerDialogRejected(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	sipStack.ProcessRequest(parseTestRequest(t, testInvite), channel)
+	st := listener.requests[0].GetServerTransaction()
+	answerInvite(t, st, message.RINGING, "a6c85cf")
+	answerInvite(t, st, message.BUSY_HERE, "a6c85cf")
+	if st.GetDialog().GetState() != sip.DIALOGSTATE_TERMINATED || len(sipStack.GetDialogs()) != 0 {
+		t.Fatal("early dialog not terminated by 486")
+	}
+}
+
+func TestDialogCreateRequest(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	invite := strings.Replace(testInvite, "Contact:", "Record-Route: <sip:p2.biloxi.com;lr>,<sip:p1.atlanta.com;lr>\r\nContact:", 1)
+	sipStack.ProcessRequest(parseTestRequest(t, invite), channel)
+	st := listener.requests[0].GetServerTransaction()
+	answerInvite(t, st, message.OK, "a6c85cf")
+	dialog := st.GetDialog().(*SIPDialog)
+
+	if err := dialog.SendBye(); err != nil {
+		t.Fatal(err)
+	}
+	bye := channel.waitForRequest(t, message.BYE)
+	if bye.GetRequestURI().String() != "sip:alice@pc33.atlanta.com" {
+		t.Fatalf("bad Request-URI %s", bye.GetRequestURI())
+	}
+	if bye.GetFromTag() != "a6c85cf" || bye.GetToTag() != "1928301774" ||
+		bye.GetCallIdentifier() != "a84b4c76e66710@pc33.atlanta.com" || bye.GetCSeq().GetMethod() != message.BYE {
+		t.Fatalf("bad BYE:\n%s", bye.String())
+	}
+	if route := bye.GetRouteHeaders(); route.Len() != 2 || !strings.Contains(route.String(), "<sip:p2.biloxi.com;lr>,<sip:p1.atlanta.com;lr>") {
+		t.Fatalf("bad route set:\n%s", bye.String())
+	}
+	if via := bye.GetTopmostVia(); via.GetHost() != "192.0.2.4" || !strings.HasPrefix(via.GetBranch(), "z9hG4bK") {
+		t.Fatalf("bad Via %s", via.String())
+	}
+	if dialog.GetState() != sip.DIALOGSTATE_TERMINATED {
+		t.Fatal("dialog not terminated by BYE")
+	}
+	if _, err := dialog.CreateRequest(message.INFO); err == nil {
+		t.Fatal("request created in a terminated dialog")
+	}
+}
+
+func TestClientDialog(t *testing.T) {
+	sipStack, _ := newTestStack()
+	channel := &testChannel{}
+
+	invite := parseTestRequest(t, testInvite)
+	ct, err := sipStack.CreateClientTransaction(invite, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct.SendRequest()
+	for _, toTag := range []string{"a6c85cf", "b7d96d0"} {
+		ringing := invite.CreateResponse(message.RINGING)
+		SetResponseToTag(ringing, toTag)
+		sipStack.ProcessResponse(ringing, channel)
+	}
+	if len(sipStack.GetDialogs()) != 2 {
+		t.Fatal("expected an early dialog per To tag")
+	}
+	dialog := sipStack.GetDialog("a84b4c76e66710@pc33.atlanta.com", "1928301774", "a6c85cf")
+	if dialog == nil || dialog.IsServer() || dialog.GetLocalSequenceNumber() != 314159 {
+		t.Fatal("bad client dialog")
+	}
+
+	ok := invite.CreateResponse(message.OK)
+	SetResponseToTag(ok, "a6c85cf")
+	contact, _ := parser.NewContactParser("Contact: <sip:bob@192.0.2.4>\n").Parse()
+	ok.SetHeader(contact)
+	sipStack.ProcessResponse(ok, channel)
+	if dialog.GetState() != sip.DIALOGSTATE_CONFIRMED {
+		t.Fatal("dialog not confirmed after 200")
+	}
+	request, err := dialog.CreateRequest(message.INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.(*message.SIPRequest).GetCSeqNumber() != 314160 ||
+		request.(*message.SIPRequest).GetRequestURI().String() != "sip:bob@192.0.2.4" {
+		t.Fatalf("bad request:\n%s", request.String())
+	}
+}
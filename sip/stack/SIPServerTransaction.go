@@ -0,0 +1,217 @@
+package stack
+
+import (
+	"errors"
+	"time"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * Server side of a SIP transaction. The transaction is created by the stack
+ * when a new request arrives, and the application answers the request with
+ * SendResponse. The state machine follows RFC 3261 section 17.2: INVITE
+ * transactions start in the Proceeding state and non-INVITE transactions in
+ * the Trying state.
+ *
+ * A transaction that sent a final response stays in the table of the stack
+ * to absorb retransmissions until its timers end it: Timers G, H and I for
+ * an INVITE answered with a non-2xx response, Timer J for a non-INVITE.
+ */
+type SIPServerTransaction struct {
+	SIPTransaction
+
+	lastResponse *message.SIPResponse
+
+	replacedDialog *SIPDialog
+
+	joinedDialog *SIPDialog
+
+	targetDialog *SIPDialog
+}
+
+/** Create a server transaction for a request received on a channel.
+ */
+func NewSIPServerTransaction(sipStack *SIPTransactionStack, channel MessageChannel, request *message.SIPRequest) *SIPServerTransaction {
+	this := &SIPServerTransaction{}
+	this.SIPTransaction.super(sipStack, channel, request)
+	if this.IsInviteTransaction() {
+		this.state = sip.TRANSACTIONSTATE_PROCEEDING
+	} else {
+		this.state = sip.TRANSACTIONSTATE_TRYING
+	}
+	return this
+}
+
+/** Send a response to the request of this transaction and update the
+ * state machine accordingly.
+ */
+func (this *SIPServerTransaction) SendResponse(response message.Response) (SipException error) {
+	sipResponse, ok := response.(*message.SIPResponse)
+	if !ok || sipResponse == nil {
+		return errors.New("SipException: Bad response")
+	}
+	this.mutex.Lock()
+	if this.state == sip.TRANSACTIONSTATE_COMPLETED ||
+		this.state == sip.TRANSACTIONSTATE_CONFIRMED ||
+		this.state == sip.TRANSACTIONSTATE_TERMINATED {
+		this.mutex.Unlock()
+		return errors.New("SipException: Final response already sent")
+	}
+
+	statusCode := sipResponse.GetStatusCode()
+	if this.IsInviteTransaction() {
+		if statusCode/100 == 2 {
+			// A 2xx terminates the INVITE server transaction; the
+			// retransmissions are handled by the TU (RFC 3261 17.2.1).
+			this.state = sip.TRANSACTIONSTATE_TERMINATED
+		} else if statusCode >= 300 {
+			this.state = sip.TRANSACTIONSTATE_COMPLETED
+		}
+	} else {
+		if statusCode < 200 {
+			this.state = sip.TRANSACTIONSTATE_PROCEEDING
+		} else {
+			this.state = sip.TRANSACTIONSTATE_COMPLETED
+		}
+	}
+	this.lastResponse = sipResponse
+	terminated := this.state == sip.TRANSACTIONSTATE_TERMINATED
+	if this.state == sip.TRANSACTIONSTATE_COMPLETED {
+		this.startCompletedTimers()
+	}
+	this.mutex.Unlock()
+
+	if terminated && this.sipStack != nil {
+		this.sipStack.removeServerTransaction(this)
+	}
+	SipException = SendResponse(this.channel, sipResponse)
+	if this.IsInviteTransaction() && this.sipStack != nil {
+		this.sipStack.processServerDialog(this, sipResponse)
+	}
+	return SipException
+}
+
+/** Get the last response sent by this transaction (nil if none).
+ */
+func (this *SIPServerTransaction) GetLastResponse() *message.SIPResponse {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.lastResponse
+}
+
+/** Get the dialog the INVITE of this transaction replaces (RFC 3891), or
+ * nil. The dialog is terminated once the INVITE is answered with a 2xx.
+ */
+func (this *SIPServerTransaction) GetReplacedDialog() *SIPDialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.replacedDialog
+}
+
+/** Get the dialog the INVITE of this transaction joins (RFC 3911), or nil.
+ */
+func (this *SIPServerTransaction) GetJoinedDialog() *SIPDialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.joinedDialog
+}
+
+/** Get the dialog the Target-Dialog header of the request of this
+ * transaction refers to (RFC 4538), or nil.
+ */
+func (this *SIPServerTransaction) GetTargetDialog() *SIPDialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.targetDialog
+}
+
+/** Return true if a final response has been sent by this transaction.
+ */
+func (this *SIPServerTransaction) IsFinalResponseSent() bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.lastResponse != nil && this.lastResponse.IsFinalResponse()
+}
+
+/** Handle a retransmission of the request of this transaction by resending
+ * the last response, if there is one.
+ */
+func (this *SIPServerTransaction) processRetransmission() (IOException error) {
+	lastResponse := this.GetLastResponse()
+	if lastResponse == nil {
+		return nil
+	}
+	return SendResponse(this.channel, lastResponse)
+}
+
+/** Handle the ACK for a non-2xx final response to an INVITE: the
+ * retransmissions stop and Timer I absorbs the ACK retransmissions.
+ */
+func (this *SIPServerTransaction) processAck() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.state == sip.TRANSACTIONSTATE_COMPLETED {
+		this.state = sip.TRANSACTIONSTATE_CONFIRMED
+		this.stopTimers()
+		timerI := this.getTimerT4()
+		if this.isReliable() {
+			timerI = 0
+		}
+		this.startTimer(timerI, this.terminate)
+	}
+}
+
+/** Start the timers of the Completed state (RFC 3261 17.2.1 and 17.2.2).
+ * An INVITE retransmits its response on Timer G until the ACK arrives and
+ * gives up on Timer H; a non-INVITE absorbs retransmissions until Timer J.
+ * Called with the mutex held.
+ */
+func (this *SIPServerTransaction) startCompletedTimers() {
+	if !this.IsInviteTransaction() {
+		timerJ := this.getTimeout()
+		if this.isReliable() {
+			timerJ = 0
+		}
+		this.startTimer(timerJ, this.terminate)
+		return
+	}
+	if !this.isReliable() {
+		this.startRetransmissionTimer(this.getTimerT1())
+	}
+	this.startTimer(this.getTimeout(), this.terminate)
+}
+
+/** Retransmit the final response after the interval and double the
+ * interval up to T2 (Timer G). Called with the mutex held.
+ */
+func (this *SIPServerTransaction) startRetransmissionTimer(interval time.Duration) {
+	this.retransmissionTimer = time.AfterFunc(interval, func() {
+		this.mutex.Lock()
+		if this.state != sip.TRANSACTIONSTATE_COMPLETED {
+			this.mutex.Unlock()
+			return
+		}
+		lastResponse := this.lastResponse
+		if interval *= 2; interval > this.getTimerT2() {
+			interval = this.getTimerT2()
+		}
+		this.startRetransmissionTimer(interval)
+		this.mutex.Unlock()
+		SendResponse(this.channel, lastResponse)
+	})
+}
+
+/** Terminate the transaction when its last timer fires and remove it from
+ * the stack.
+ */
+func (this *SIPServerTransaction) terminate() {
+	this.mutex.Lock()
+	this.state = sip.TRANSACTIONSTATE_TERMINATED
+	this.stopTimers()
+	this.mutex.Unlock()
+	if this.sipStack != nil {
+		this.sipStack.removeServerTransaction(this)
+	}
+}
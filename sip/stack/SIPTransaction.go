@@ -0,0 +1,237 @@
+package stack
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** Default values of the timers of RFC 3261 section 17 (in milliseconds):
+ * T1 is the round-trip time estimate, T2 the maximum retransmission
+ * interval and T4 the time a message may stay in the network. T2 and T4
+ * scale with the T1 of a transaction.
+ */
+const (
+	SIPTransaction_T1 = 500
+	SIPTransaction_T2 = 4000
+	SIPTransaction_T4 = 5000
+)
+
+/** Number of T1 intervals after which a transaction times out (Timers B,
+ * F, H and J are 64*T1).
+ */
+const SIPTransaction_TIMEOUT_INTERVALS = 64
+
+/**
+ * Abstract base of the client and server transactions. It holds the request
+ * that created the transaction, the channel the transaction talks over and
+ * the current state of the transaction state machine. The mutex guards the
+ * state and the messages the state machine keeps.
+ */
+type SIPTransaction struct {
+	sipStack *SIPTransactionStack
+
+	mutex sync.Mutex
+
+	channel MessageChannel
+
+	originalRequest *message.SIPRequest
+
+	branch string
+
+	method string
+
+	state *sip.TransactionState
+
+	dialog sip.Dialog
+
+	retransmitTimer int
+
+	timer *time.Timer
+
+	retransmissionTimer *time.Timer
+}
+
+func (this *SIPTransaction) super(sipStack *SIPTransactionStack, channel MessageChannel, request *message.SIPRequest) {
+	this.sipStack = sipStack
+	this.channel = channel
+	this.originalRequest = request
+	this.method = request.GetMethod()
+	this.retransmitTimer = SIPTransaction_T1
+	if sipStack != nil {
+		this.retransmitTimer = sipStack.GetBaseTimerInterval()
+	}
+	if topVia := request.GetTopmostVia(); topVia != nil {
+		this.branch = topVia.GetBranch()
+	}
+}
+
+/** Get the transaction identifier. This is the branch id for RFC 3261
+ * requests and a hash of the identifying headers for older ones.
+ */
+func (this *SIPTransaction) GetTransactionId() string {
+	return this.originalRequest.GetTransactionId()
+}
+
+/** Get the dialog of this transaction (nil if there is none).
+ */
+func (this *SIPTransaction) GetDialog() sip.Dialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.dialog
+}
+
+/** Set the dialog of this transaction.
+ */
+func (this *SIPTransaction) SetDialog(dialog sip.Dialog) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.dialog = dialog
+}
+
+/** Get the current state of the transaction.
+ */
+func (this *SIPTransaction) GetState() sip.TransactionState {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return *this.state
+}
+
+/** Set the current state of the transaction.
+ */
+func (this *SIPTransaction) SetState(state *sip.TransactionState) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.state = state
+}
+
+/** Get the retransmission timer (in milliseconds).
+ */
+func (this *SIPTransaction) GetRetransmitTimer() (retransmitTimer int, UnsupportedOperationException error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.retransmitTimer, nil
+}
+
+/** Set the retransmission timer (in milliseconds).
+ */
+func (this *SIPTransaction) SetRetransmitTimer(retransmitTimer int) (UnsupportedOperationException error) {
+	if retransmitTimer <= 0 {
+		return errors.New("IllegalArgumentException: Retransmit timer must be positive")
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.retransmitTimer = retransmitTimer
+	return nil
+}
+
+/** Get the branch id of the topmost via of the original request.
+ */
+func (this *SIPTransaction) GetBranchId() string {
+	return this.branch
+}
+
+/** Get the request that created this transaction.
+ */
+func (this *SIPTransaction) GetRequest() message.Request {
+	return this.originalRequest
+}
+
+/** Get the request that created this transaction as a SIPRequest.
+ */
+func (this *SIPTransaction) GetOriginalRequest() *message.SIPRequest {
+	return this.originalRequest
+}
+
+/** Get the method of the request that created this transaction.
+ */
+func (this *SIPTransaction) GetMethod() string {
+	return this.method
+}
+
+/** Get the channel this transaction talks over.
+ */
+func (this *SIPTransaction) GetMessageChannel() MessageChannel {
+	return this.channel
+}
+
+/** Return true if this is an INVITE transaction.
+ */
+func (this *SIPTransaction) IsInviteTransaction() bool {
+	return this.method == message.INVITE
+}
+
+/** Return true if the transaction is terminated.
+ */
+func (this *SIPTransaction) IsTerminated() bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.state == sip.TRANSACTIONSTATE_TERMINATED
+}
+
+/** Return true if the branch carries the RFC 3261 magic cookie, so that
+ * it can be used on its own to match requests to transactions.
+ */
+func (this *SIPTransaction) isRFC3261Branch() bool {
+	return strings.HasPrefix(strings.ToLower(this.branch),
+		strings.ToLower(header.SIPConstants_BRANCH_MAGIC_COOKIE))
+}
+
+/** Return true if the channel of the transaction is reliable, in which
+ * case nothing is retransmitted and the wait timers are 0.
+ */
+func (this *SIPTransaction) isReliable() bool {
+	return IsReliableTransport(this.channel.GetTransport())
+}
+
+/** Get the timer T1 of the transaction. Called with the mutex held.
+ */
+func (this *SIPTransaction) getTimerT1() time.Duration {
+	return time.Duration(this.retransmitTimer) * time.Millisecond
+}
+
+/** Get the timer T2 of the transaction. Called with the mutex held.
+ */
+func (this *SIPTransaction) getTimerT2() time.Duration {
+	return this.getTimerT1() * SIPTransaction_T2 / SIPTransaction_T1
+}
+
+/** Get the timer T4 of the transaction. Called with the mutex held.
+ */
+func (this *SIPTransaction) getTimerT4() time.Duration {
+	return this.getTimerT1() * SIPTransaction_T4 / SIPTransaction_T1
+}
+
+/** Get the timeout of the transaction, 64*T1. Called with the mutex held.
+ */
+func (this *SIPTransaction) getTimeout() time.Duration {
+	return this.getTimerT1() * SIPTransaction_TIMEOUT_INTERVALS
+}
+
+/** Start the timer of the current state, replacing the previous one.
+ * Called with the mutex held.
+ */
+func (this *SIPTransaction) startTimer(d time.Duration, f func()) {
+	if this.timer != nil {
+		this.timer.Stop()
+	}
+	this.timer = time.AfterFunc(d, f)
+}
+
+/** Stop the timers of the transaction. Called with the mutex held.
+ */
+func (this *SIPTransaction) stopTimers() {
+	if this.timer != nil {
+		this.timer.Stop()
+		this.timer = nil
+	}
+	if this.retransmissionTimer != nil {
+		this.retransmissionTimer.Stop()
+		this.retransmissionTimer = nil
+	}
+}
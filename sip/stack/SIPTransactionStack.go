@@ -0,0 +1,555 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * The transaction layer of the stack. The transport layer hands every
+ * incoming request to ProcessRequest and every incoming response to
+ * ProcessResponse, together with the channel it arrived on. The stack
+ * matches the message to a transaction (creating a server transaction for
+ * new requests), absorbs retransmissions and ACKs, handles CANCEL and
+ * passes everything else on to the SipListener.
+ *
+ * Requests may also be sent with SendRequest, which returns the responses
+ * on a channel, and inbound requests may be routed by method with a
+ * ServeMux installed as the SipListener.
+ *
+ * The stack keeps the dialogs created by INVITE (see SIPDialog) and hands
+ * the dialog of an in-dialog request to the listener with its server
+ * transaction. An INVITE with Replaces or Join is matched to the dialog it
+ * refers to before it reaches the listener (RFC 3891, RFC 3911).
+ *
+ * The listener is called on the goroutine that hands the message to the
+ * stack unless an EventDispatcher is set. With a dispatcher, the events of
+ * a call run one at a time and in order, while different calls run in
+ * parallel on the workers of the dispatcher.
+ */
+type SIPTransactionStack struct {
+	sipListener sip.SipListener
+
+	eventDispatcher *EventDispatcher
+
+	channelFactory ChannelFactory
+
+	mutex sync.Mutex
+
+	serverTransactions map[string]*SIPServerTransaction
+
+	clientTransactions map[string]*SIPClientTransaction
+
+	dialogs map[string]*SIPDialog
+
+	baseTimerInterval int
+}
+
+/** Create an empty transaction stack.
+ */
+func NewSIPTransactionStack() *SIPTransactionStack {
+	this := &SIPTransactionStack{}
+	this.serverTransactions = make(map[string]*SIPServerTransaction)
+	this.clientTransactions = make(map[string]*SIPClientTransaction)
+	this.dialogs = make(map[string]*SIPDialog)
+	this.baseTimerInterval = SIPTransaction_T1
+	return this
+}
+
+/** Set the timer T1 of the transactions created from now on (in
+ * milliseconds). The other timers of the transactions scale with it.
+ */
+func (this *SIPTransactionStack) SetBaseTimerInterval(baseTimerInterval int) (IllegalArgumentException error) {
+	if baseTimerInterval <= 0 {
+		return errors.New("IllegalArgumentException: Base timer interval must be positive")
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.baseTimerInterval = baseTimerInterval
+	return nil
+}
+
+/** Get the timer T1 of the transactions (in milliseconds).
+ */
+func (this *SIPTransactionStack) GetBaseTimerInterval() int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.baseTimerInterval
+}
+
+/** Set the listener that receives the requests processed by this stack.
+ */
+func (this *SIPTransactionStack) SetSipListener(sipListener sip.SipListener) {
+	this.sipListener = sipListener
+}
+
+/** Get the listener that receives the requests processed by this stack.
+ */
+func (this *SIPTransactionStack) GetSipListener() sip.SipListener {
+	return this.sipListener
+}
+
+/** Set the dispatcher that runs the listener, or nil to run it on the
+ * goroutine that hands the message to the stack.
+ */
+func (this *SIPTransactionStack) SetEventDispatcher(eventDispatcher *EventDispatcher) {
+	this.eventDispatcher = eventDispatcher
+}
+
+/** Get the dispatcher that runs the listener (nil if there is none).
+ */
+func (this *SIPTransactionStack) GetEventDispatcher() *EventDispatcher {
+	return this.eventDispatcher
+}
+
+/** Set the factory of the channels to the next hop of the requests that
+ * belong to no dialog of their target, or nil if there is none.
+ */
+func (this *SIPTransactionStack) SetChannelFactory(channelFactory ChannelFactory) {
+	this.channelFactory = channelFactory
+}
+
+/** Get the factory of the channels to the next hop (nil if there is none).
+ */
+func (this *SIPTransactionStack) GetChannelFactory() ChannelFactory {
+	return this.channelFactory
+}
+
+/** Process a request received on a channel. The topmost Via is first
+ * stamped with the source of the request (see ProcessReceivedVia).
+ */
+func (this *SIPTransactionStack) ProcessRequest(request *message.SIPRequest, channel MessageChannel) (SipException error) {
+	ProcessReceivedVia(request, channel)
+	switch request.GetMethod() {
+	case message.CANCEL:
+		return this.processCancel(request, channel)
+	case message.ACK:
+		// The ACK for a non-2xx final response is part of the INVITE
+		// transaction; the ACK for a 2xx goes straight to the TU.
+		if st := this.getServerTransaction(request, message.INVITE); st != nil {
+			st.processAck()
+			return nil
+		}
+		this.notifyRequest(nil, request)
+		return nil
+	}
+
+	st := NewSIPServerTransaction(this, channel, request)
+	if existing := this.addServerTransaction(st); existing != nil {
+		return existing.processRetransmission()
+	}
+	if request.HasToTag() {
+		if dialog := this.GetDialog(request.GetCallIdentifier(), request.GetToTag(), request.GetFromTag()); dialog != nil {
+			if !dialog.processRequest(request) {
+				return st.SendResponse(request.CreateResponse(message.SERVER_INTERNAL_ERROR))
+			}
+			st.SetDialog(dialog)
+			if request.GetMethod() == message.BYE {
+				dialog.terminate()
+			}
+		}
+	} else {
+		statusCode := this.processReplaces(st, request)
+		if statusCode == 0 {
+			statusCode = this.processTargetDialog(st, request)
+		}
+		if statusCode != 0 {
+			response := request.CreateResponse(statusCode)
+			SetResponseToTag(response, GenerateTag())
+			return st.SendResponse(response)
+		}
+	}
+	this.notifyRequest(st, request)
+	return nil
+}
+
+/** Find the server transaction for a request, or nil if there is none.
+ */
+func (this *SIPTransactionStack) FindTransaction(request *message.SIPRequest) *SIPServerTransaction {
+	method := request.GetMethod()
+	if method == message.ACK {
+		method = message.INVITE
+	}
+	return this.getServerTransaction(request, method)
+}
+
+/** Find the INVITE server transaction a CANCEL refers to, or nil if there
+ * is none. The branch is used when it carries the magic cookie, otherwise
+ * the request is matched field by field as described in RFC 3261 9.2.
+ */
+func (this *SIPTransactionStack) FindCancelledTransaction(cancel *message.SIPRequest) *SIPServerTransaction {
+	if st := this.getServerTransaction(cancel, message.INVITE); st != nil {
+		return st
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	for _, st := range this.serverTransactions {
+		if !st.IsInviteTransaction() || st.isRFC3261Branch() {
+			continue
+		}
+		if this.isCancelFor(cancel, st.GetOriginalRequest()) {
+			return st
+		}
+	}
+	return nil
+}
+
+/** Handle a CANCEL: answer it with 200 and terminate the pending INVITE
+ * with 487, or answer 481 when no INVITE transaction matches.
+ */
+func (this *SIPTransactionStack) processCancel(cancel *message.SIPRequest, channel MessageChannel) (SipException error) {
+	if st := this.getServerTransaction(cancel, message.CANCEL); st != nil {
+		return st.processRetransmission()
+	}
+
+	inviteTransaction := this.FindCancelledTransaction(cancel)
+	if inviteTransaction == nil {
+		return SendResponse(channel, cancel.CreateResponse(message.CALL_OR_TRANSACTION_DOES_NOT_EXIST))
+	}
+
+	cancelTransaction := NewSIPServerTransaction(this, channel, cancel)
+	if existing := this.addServerTransaction(cancelTransaction); existing != nil {
+		return existing.processRetransmission()
+	}
+
+	// The 200 for the CANCEL and the 487 for the INVITE carry the same
+	// To tag as any response already sent for the INVITE.
+	toTag := ""
+	if lastResponse := inviteTransaction.GetLastResponse(); lastResponse != nil {
+		toTag = lastResponse.GetToTag()
+	}
+	if toTag == "" {
+		toTag = GenerateTag()
+	}
+
+	okResponse := cancel.CreateResponse(message.OK)
+	SetResponseToTag(okResponse, toTag)
+	if err := cancelTransaction.SendResponse(okResponse); err != nil {
+		return err
+	}
+
+	if !inviteTransaction.IsFinalResponseSent() {
+		terminated := inviteTransaction.GetOriginalRequest().CreateResponse(message.REQUEST_TERMINATED)
+		SetResponseToTag(terminated, toTag)
+		// The TU may have sent a final response in the meantime.
+		if err := inviteTransaction.SendResponse(terminated); err != nil && !inviteTransaction.IsFinalResponseSent() {
+			return err
+		}
+	}
+
+	this.notifyRequest(cancelTransaction, cancel)
+	return nil
+}
+
+func (this *SIPTransactionStack) notifyRequest(st *SIPServerTransaction, request *message.SIPRequest) {
+	sipListener := this.sipListener
+	if sipListener == nil {
+		return
+	}
+	var serverTransaction sip.ServerTransaction
+	if st != nil {
+		serverTransaction = st
+	}
+	requestEvent := sip.NewRequestEvent(serverTransaction, request)
+	this.dispatch(&request.SIPMessage, func() {
+		sipListener.ProcessRequest(*requestEvent)
+	})
+}
+
+/** Create a client transaction for a request and add it to the table.
+ * A branch is generated when the topmost Via does not carry one.
+ */
+func (this *SIPTransactionStack) CreateClientTransaction(request *message.SIPRequest, channel MessageChannel) (ct *SIPClientTransaction, SipException error) {
+	if request.GetMethod() == message.ACK {
+		return nil, errors.New("SipException: Cannot create a client transaction for ACK")
+	}
+	topVia := request.GetTopmostVia()
+	if topVia == nil {
+		return nil, errors.New("SipException: No Via header in request")
+	}
+	if topVia.GetBranch() == "" {
+		if err := topVia.SetBranch(GenerateBranchId()); err != nil {
+			return nil, err
+		}
+	}
+	ct = NewSIPClientTransaction(this, channel, request)
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	key := this.getTransactionKey(request, request.GetMethod())
+	if _, ok := this.clientTransactions[key]; ok {
+		return nil, errors.New("SipException: Transaction already exists")
+	}
+	this.clientTransactions[key] = ct
+	return ct, nil
+}
+
+/** Send a request in a new client transaction and return the channel its
+ * responses are delivered on. The channel is closed after the final
+ * response. When ctx is done before the final response an INVITE is
+ * cancelled with a CANCEL request, and any other request is abandoned
+ * with the channel closed.
+ */
+func (this *SIPTransactionStack) SendRequest(ctx context.Context, request *message.SIPRequest, channel MessageChannel) (responses <-chan *message.SIPResponse, SipException error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ct, err := this.CreateClientTransaction(request, channel)
+	if err != nil {
+		return nil, err
+	}
+	ct.responses = make(chan *message.SIPResponse, SIPClientTransaction_RESPONSE_BUFFER)
+	if err = ct.SendRequest(); err != nil {
+		this.removeClientTransaction(ct)
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-ct.done:
+		case <-ctx.Done():
+			ct.cancel()
+		}
+	}()
+	return ct.responses, nil
+}
+
+/** Process a response received on a channel. Responses that match no
+ * client transaction are passed to the SipListener as they are.
+ */
+func (this *SIPTransactionStack) ProcessResponse(response *message.SIPResponse, channel MessageChannel) (SipException error) {
+	if response.GetCSeq() == nil {
+		return errors.New("SipException: No CSeq header in response")
+	}
+	key := response.GetTransactionId() + ":" + response.GetCSeq().GetMethod()
+	this.mutex.Lock()
+	ct := this.clientTransactions[key]
+	this.mutex.Unlock()
+
+	if ct == nil {
+		this.notifyResponse(nil, response)
+		return nil
+	}
+	notify, err := ct.processResponse(response)
+	if notify {
+		this.notifyResponse(ct, response)
+	}
+	return err
+}
+
+func (this *SIPTransactionStack) notifyResponse(ct *SIPClientTransaction, response *message.SIPResponse) {
+	sipListener := this.sipListener
+	if sipListener == nil {
+		return
+	}
+	var clientTransaction sip.ClientTransaction
+	if ct != nil {
+		clientTransaction = ct
+	}
+	responseEvent := sip.NewResponseEvent(clientTransaction, response)
+	this.dispatch(&response.SIPMessage, func() {
+		sipListener.ProcessResponse(*responseEvent)
+	})
+}
+
+/** Run an event on the dispatcher, keyed by the Call-ID of the message so
+ * that the events of a dialog and of its transactions stay in order.
+ * Messages without a Call-ID share a single key.
+ */
+func (this *SIPTransactionStack) dispatch(msg *message.SIPMessage, event func()) {
+	if this.eventDispatcher == nil {
+		event()
+		return
+	}
+	key := ""
+	if msg.HasHeader(core.SIPHeaderNames_CALL_ID) {
+		key = msg.GetCallIdentifier()
+	}
+	if this.eventDispatcher.Dispatch(key, event) != nil {
+		// The dispatcher is closed: run the event in place rather than
+		// lose it.
+		event()
+	}
+}
+
+func (this *SIPTransactionStack) removeClientTransaction(ct *SIPClientTransaction) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	key := this.getTransactionKey(ct.GetOriginalRequest(), ct.GetMethod())
+	if this.clientTransactions[key] == ct {
+		delete(this.clientTransactions, key)
+	}
+}
+
+/** Get the dialog with the given Call-ID and tags, or nil if there is
+ * none.
+ */
+func (this *SIPTransactionStack) GetDialog(callId, localTag, remoteTag string) *SIPDialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.dialogs[GetDialogKey(callId, localTag, remoteTag)]
+}
+
+/** Get the dialogs of the stack.
+ */
+func (this *SIPTransactionStack) GetDialogs() []*SIPDialog {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	dialogs := make([]*SIPDialog, 0, len(this.dialogs))
+	for _, dialog := range this.dialogs {
+		dialogs = append(dialogs, dialog)
+	}
+	return dialogs
+}
+
+/** Create or update the dialog of an INVITE server transaction for a
+ * response it sends.
+ */
+func (this *SIPTransactionStack) processServerDialog(st *SIPServerTransaction, response *message.SIPResponse) {
+	statusCode := response.GetStatusCode()
+	dialog, _ := st.GetDialog().(*SIPDialog)
+	if statusCode >= 300 {
+		if dialog != nil && dialog.GetState() == sip.DIALOGSTATE_EARLY {
+			dialog.terminate()
+		}
+		return
+	}
+	if statusCode == message.TRYING || response.GetToTag() == "" {
+		return
+	}
+	if dialog == nil {
+		dialog = newServerDialog(st, response)
+		this.mutex.Lock()
+		this.dialogs[dialog.GetDialogId()] = dialog
+		this.mutex.Unlock()
+		st.SetDialog(dialog)
+	}
+	if statusCode/100 == 2 {
+		dialog.confirm()
+		if replacedDialog := st.GetReplacedDialog(); replacedDialog != nil {
+			this.replaceDialog(replacedDialog, dialog)
+		}
+	}
+}
+
+/** Create or update the dialogs of an INVITE client transaction for a
+ * response it receives. Each To tag of a forked INVITE creates a dialog.
+ */
+func (this *SIPTransactionStack) processClientDialog(ct *SIPClientTransaction, response *message.SIPResponse) {
+	statusCode := response.GetStatusCode()
+	if statusCode >= 300 {
+		for _, dialog := range this.GetDialogs() {
+			if dialog.GetFirstTransaction() == ct && dialog.GetState() == sip.DIALOGSTATE_EARLY {
+				dialog.terminate()
+			}
+		}
+		return
+	}
+	if statusCode == message.TRYING || response.GetToTag() == "" {
+		return
+	}
+	request := ct.GetOriginalRequest()
+	this.mutex.Lock()
+	key := GetDialogKey(request.GetCallIdentifier(), request.GetFromTag(), response.GetToTag())
+	dialog := this.dialogs[key]
+	if dialog == nil {
+		dialog = newClientDialog(ct, response)
+		this.dialogs[key] = dialog
+	} else {
+		dialog.processResponse(response)
+	}
+	this.mutex.Unlock()
+	if ct.GetDialog() == nil {
+		ct.SetDialog(dialog)
+	}
+	if statusCode/100 == 2 {
+		dialog.confirm()
+	}
+}
+
+func (this *SIPTransactionStack) removeDialog(dialog *SIPDialog) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.dialogs[dialog.GetDialogId()] == dialog {
+		delete(this.dialogs, dialog.GetDialogId())
+	}
+}
+
+func (this *SIPTransactionStack) getServerTransaction(request *message.SIPRequest, method string) *SIPServerTransaction {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.serverTransactions[this.getTransactionKey(request, method)]
+}
+
+/** Add a server transaction to the table. If a transaction with the same
+ * key is already there it is returned and the table is left unchanged.
+ */
+func (this *SIPTransactionStack) addServerTransaction(st *SIPServerTransaction) *SIPServerTransaction {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	key := this.getTransactionKey(st.GetOriginalRequest(), st.GetMethod())
+	if existing := this.serverTransactions[key]; existing != nil {
+		return existing
+	}
+	this.serverTransactions[key] = st
+	return nil
+}
+
+func (this *SIPTransactionStack) removeServerTransaction(st *SIPServerTransaction) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	key := this.getTransactionKey(st.GetOriginalRequest(), st.GetMethod())
+	if this.serverTransactions[key] == st {
+		delete(this.serverTransactions, key)
+	}
+}
+
+/** The transaction table is keyed by transaction id and method, since a
+ * CANCEL shares the branch of the INVITE it cancels.
+ */
+func (this *SIPTransactionStack) getTransactionKey(request *message.SIPRequest, method string) string {
+	return request.GetTransactionId() + ":" + method
+}
+
+/** Compare a CANCEL with an INVITE as described in RFC 3261 9.2.
+ */
+func (this *SIPTransactionStack) isCancelFor(cancel, invite *message.SIPRequest) bool {
+	if cancel.GetRequestURI() == nil || invite.GetRequestURI() == nil ||
+		cancel.GetRequestURI().String() != invite.GetRequestURI().String() {
+		return false
+	}
+	if cancel.GetFromTag() != invite.GetFromTag() || cancel.GetToTag() != invite.GetToTag() {
+		return false
+	}
+	if cancel.GetCallIdentifier() != invite.GetCallIdentifier() ||
+		cancel.GetCSeqNumber() != invite.GetCSeqNumber() {
+		return false
+	}
+	cancelVia, inviteVia := cancel.GetTopmostVia(), invite.GetTopmostVia()
+	if cancelVia == nil || inviteVia == nil {
+		return false
+	}
+	return strings.EqualFold(cancelVia.EncodeBody(), inviteVia.EncodeBody())
+}
+
+/** Put a tagged copy of the To header in a response. The headers of a
+ * response created with CreateResponse are shared with the request, so the
+ * To header of the request must not be modified in place.
+ */
+func SetResponseToTag(response *message.SIPResponse, tag string) {
+	to, ok := response.GetTo().(*header.To)
+	if !ok || to == nil || to.HasTag() {
+		return
+	}
+	newTo := header.NewTo()
+	newTo.SetAddress(to.GetAddress())
+	newTo.SetParameters(to.GetParameters().Clone().(*core.NameValueList))
+	newTo.SetTag(tag)
+	response.SetTo(newTo)
+}
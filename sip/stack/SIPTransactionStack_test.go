@@ -0,0 +1,459 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+type testChannel struct {
+	mutex    sync.Mutex
+	sent     []*message.SIPResponse
+	requests []*message.SIPRequest
+}
+
+func (this *testChannel) SendMessage(msg message.Message) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if request, ok := msg.(*message.SIPRequest); ok {
+		this.requests = append(this.requests, request)
+	} else {
+		this.sent = append(this.sent, msg.(*message.SIPResponse))
+	}
+	return nil
+}
+
+func (this *testChannel) waitForRequest(t *testing.T, method string) *message.SIPRequest {
+	for i := 0; i < 100; i++ {
+		this.mutex.Lock()
+		for _, request := range this.requests {
+			if request.GetMethod() == method {
+				this.mutex.Unlock()
+				return request
+			}
+		}
+		this.mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("no %s sent", method)
+	return nil
+}
+
+func (this *testChannel) GetTransport() string   { return "UDP" }
+func (this *testChannel) GetPeerAddress() string { return "127.0.0.1" }
+func (this *testChannel) GetPeerPort() int       { return 5060 }
+
+type testListener struct {
+	requests []sip.RequestEvent
+}
+
+func (this *testListener) ProcessRequest(requestEvent sip.RequestEvent) {
+	this.requests = append(this.requests, requestEvent)
+}
+func (this *testListener) ProcessResponse(responseEvent sip.ResponseEvent) {}
+func (this *testListener) ProcessTimeout(timeoutEvent sip.TimeoutEvent)    {}
+
+const testInvite = "INVITE sip:bob@biloxi.com SIP/2.0\r\n" +
+	"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"To: Bob <sip:bob@biloxi.com>\r\n" +
+	"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 314159 INVITE\r\n" +
+	"Contact: <sip:alice@pc33.atlanta.com>\r\n" +
+	"Content-Length: 0\r\n\r\n"
+
+const testCancel = "CANCEL sip:bob@biloxi.com SIP/2.0\r\n" +
+	"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+	"Max-Forwards: 70\r\n" +
+	"To: Bob <sip:bob@biloxi.com>\r\n" +
+	"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n" +
+	"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+	"CSeq: 314159 CANCEL\r\n" +
+	"Content-Length: 0\r\n\r\n"
+
+func parseTestRequest(t *testing.T, s string) *message.SIPRequest {
+	smp := parser.NewStringMsgParser()
+	msg, err := smp.ParseSIPMessage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.(*message.SIPRequest)
+}
+
+func newTestStack() (*SIPTransactionStack, *testListener) {
+	listener := &testListener{}
+	sipStack := NewSIPTransactionStack()
+	sipStack.SetSipListener(listener)
+	return sipStack, listener
+}
+
+func TestCancelPendingInvite(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	invite := parseTestRequest(t, testInvite)
+	if err := sipStack.ProcessRequest(invite, channel); err != nil {
+		t.Fatal(err)
+	}
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testCancel), channel); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channel.sent) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(channel.sent))
+	}
+	ok, terminated := channel.sent[0], channel.sent[1]
+	if ok.GetStatusCode() != message.OK || ok.GetCSeq().GetMethod() != message.CANCEL {
+		t.Fatalf("expected 200 for CANCEL, got %s", ok.GetFirstLine())
+	}
+	if terminated.GetStatusCode() != message.REQUEST_TERMINATED || terminated.GetCSeq().GetMethod() != message.INVITE {
+		t.Fatalf("expected 487 for INVITE, got %s", terminated.GetFirstLine())
+	}
+	if terminated.GetToTag() == "" || terminated.GetToTag() != ok.GetToTag() {
+		t.Fatalf("bad To tags %q %q", ok.GetToTag(), terminated.GetToTag())
+	}
+	if invite.HasToTag() {
+		t.Fatal("To header of the INVITE was modified")
+	}
+
+	if len(listener.requests) != 2 {
+		t.Fatalf("expected 2 requests delivered, got %d", len(listener.requests))
+	}
+	if listener.requests[1].GetRequest().GetMethod() != message.CANCEL {
+		t.Fatal("CANCEL was not delivered")
+	}
+	st := listener.requests[0].GetServerTransaction()
+	if st.GetState() != *sip.TRANSACTIONSTATE_COMPLETED {
+		t.Fatal("INVITE transaction not completed")
+	}
+}
+
+func TestCancelWithoutInvite(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testCancel), channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.sent) != 1 || channel.sent[0].GetStatusCode() != message.CALL_OR_TRANSACTION_DOES_NOT_EXIST {
+		t.Fatal("expected a single 481")
+	}
+	if len(listener.requests) != 0 {
+		t.Fatal("CANCEL without a transaction was delivered")
+	}
+}
+
+func TestCancelAfterFinalResponse(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testInvite), channel); err != nil {
+		t.Fatal(err)
+	}
+	st := listener.requests[0].GetServerTransaction()
+	busy := st.GetRequest().(*message.SIPRequest).CreateResponse(message.BUSY_HERE)
+	if err := st.SendResponse(busy); err != nil {
+		t.Fatal(err)
+	}
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testCancel), channel); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(channel.sent) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(channel.sent))
+	}
+	if channel.sent[1].GetStatusCode() != message.OK {
+		t.Fatalf("expected 200 for CANCEL, got %s", channel.sent[1].GetFirstLine())
+	}
+}
+
+func TestServerTransactionsDrain(t *testing.T) {
+	sipStack, listener := newTestStack()
+	sipStack.SetBaseTimerInterval(1)
+	channel := &testChannel{}
+
+	// An INVITE answered with 486 and acknowledged (Timers G and I), an
+	// INVITE answered with 486 and never acknowledged (Timer H) and an
+	// OPTIONS answered with 200 (Timer J).
+	acked := parseTestRequest(t, testInvite)
+	unacked := parseTestRequest(t, strings.Replace(testInvite, "z9hG4bK776asdhds", "z9hG4bK776asdhdt", 1))
+	options := parseTestRequest(t, strings.NewReplacer("INVITE", "OPTIONS", "z9hG4bK776asdhds", "z9hG4bK776asdhdu").Replace(testInvite))
+	for _, request := range []*message.SIPRequest{acked, unacked, options} {
+		if err := sipStack.ProcessRequest(request, channel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, requestEvent := range listener.requests {
+		request := requestEvent.GetRequest().(*message.SIPRequest)
+		statusCode := message.BUSY_HERE
+		if request.GetMethod() == message.OPTIONS {
+			statusCode = message.OK
+		}
+		response := request.CreateResponse(statusCode)
+		SetResponseToTag(response, "a6c85cf")
+		if err := requestEvent.GetServerTransaction().SendResponse(response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ack := parseTestRequest(t, strings.NewReplacer("INVITE", "ACK",
+		"<sip:bob@biloxi.com>\r\n", "<sip:bob@biloxi.com>;tag=a6c85cf\r\n").Replace(testInvite))
+	if err := sipStack.ProcessRequest(ack, channel); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; ; i++ {
+		sipStack.mutex.Lock()
+		n := len(sipStack.serverTransactions)
+		sipStack.mutex.Unlock()
+		if n == 0 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("%d server transactions left", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Timer G retransmitted the unacknowledged 486.
+	channel.mutex.Lock()
+	defer channel.mutex.Unlock()
+	if len(channel.sent) <= 3 {
+		t.Fatalf("expected retransmissions, got %d responses", len(channel.sent))
+	}
+}
+
+func TestSendRequestCancelledByContext(t *testing.T) {
+	sipStack, _ := newTestStack()
+	channel := &testChannel{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	invite := parseTestRequest(t, testInvite)
+	responses, err := sipStack.SendRequest(ctx, invite, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel.waitForRequest(t, message.INVITE)
+
+	ringing := invite.CreateResponse(message.RINGING)
+	SetResponseToTag(ringing, "a6c85cf")
+	if err := sipStack.ProcessResponse(ringing, channel); err != nil {
+		t.Fatal(err)
+	}
+	if response := <-responses; response.GetStatusCode() != message.RINGING {
+		t.Fatalf("expected 180, got %s", response.GetFirstLine())
+	}
+
+	cancel()
+	if request := channel.waitForRequest(t, message.CANCEL); request.GetCSeqNumber() != invite.GetCSeqNumber() {
+		t.Fatal("CANCEL does not match the INVITE")
+	}
+	if invite.GetMethod() != message.INVITE || invite.GetCSeq().GetMethod() != message.INVITE {
+		t.Fatal("INVITE was modified by CreateCancelRequest")
+	}
+
+	terminated := invite.CreateResponse(message.REQUEST_TERMINATED)
+	SetResponseToTag(terminated, "a6c85cf")
+	if err := sipStack.ProcessResponse(terminated, channel); err != nil {
+		t.Fatal(err)
+	}
+	if response := <-responses; response.GetStatusCode() != message.REQUEST_TERMINATED {
+		t.Fatalf("expected 487, got %s", response.GetFirstLine())
+	}
+	if _, ok := <-responses; ok {
+		t.Fatal("response channel not closed after the final response")
+	}
+	channel.waitForRequest(t, message.ACK)
+}
+
+func TestSendRequestTimeout(t *testing.T) {
+	sipStack, _ := newTestStack()
+	sipStack.SetBaseTimerInterval(1)
+	channel := &testChannel{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The context is done while the INVITE is still Calling and nothing
+	// answers: Timer B ends the transaction with a 408.
+	responses, err := sipStack.SendRequest(ctx, parseTestRequest(t, testInvite), channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	var statusCodes []int
+	for response := range responses {
+		statusCodes = append(statusCodes, response.GetStatusCode())
+	}
+	if len(statusCodes) != 1 || statusCodes[0] != message.REQUEST_TIMEOUT {
+		t.Fatalf("expected a single 408, got %v", statusCodes)
+	}
+	sipStack.mutex.Lock()
+	defer sipStack.mutex.Unlock()
+	if len(sipStack.clientTransactions) != 0 {
+		t.Fatalf("%d client transactions left", len(sipStack.clientTransactions))
+	}
+}
+
+func (this *testChannel) countRequests(method string) int {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	n := 0
+	for _, request := range this.requests {
+		if request.GetMethod() == method {
+			n++
+		}
+	}
+	return n
+}
+
+func waitForClientTransactions(t *testing.T, sipStack *SIPTransactionStack) {
+	for i := 0; ; i++ {
+		sipStack.mutex.Lock()
+		n := len(sipStack.clientTransactions)
+		sipStack.mutex.Unlock()
+		if n == 0 {
+			return
+		}
+		if i == 100 {
+			t.Fatalf("%d client transactions left", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientTransactionCompleted(t *testing.T) {
+	sipStack, _ := newTestStack()
+	sipStack.SetBaseTimerInterval(10)
+	channel := &testChannel{}
+
+	invite := parseTestRequest(t, testInvite)
+	responses, err := sipStack.SendRequest(context.Background(), invite, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy := invite.CreateResponse(message.BUSY_HERE)
+	SetResponseToTag(busy, "a6c85cf")
+	if err := sipStack.ProcessResponse(busy, channel); err != nil {
+		t.Fatal(err)
+	}
+	if response := <-responses; response.GetStatusCode() != message.BUSY_HERE {
+		t.Fatalf("expected 486, got %s", response.GetFirstLine())
+	}
+
+	// The retransmitted 486 matches the Completed transaction and is
+	// acknowledged again.
+	if err := sipStack.ProcessResponse(busy, channel); err != nil {
+		t.Fatal(err)
+	}
+	if n := channel.countRequests(message.ACK); n != 2 {
+		t.Fatalf("expected 2 ACKs, got %d", n)
+	}
+	if _, ok := <-responses; ok {
+		t.Fatal("retransmitted 486 delivered")
+	}
+
+	// Timer D ends the transaction.
+	waitForClientTransactions(t, sipStack)
+}
+
+func TestClientTransactionRetransmissions(t *testing.T) {
+	sipStack, _ := newTestStack()
+	sipStack.SetBaseTimerInterval(1)
+	channel := &testChannel{}
+
+	// Timer E retransmits the OPTIONS over UDP until Timer F ends it.
+	options := parseTestRequest(t, strings.Replace(testInvite, "INVITE", "OPTIONS", -1))
+	responses, err := sipStack.SendRequest(context.Background(), options, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response := <-responses; response.GetStatusCode() != message.REQUEST_TIMEOUT {
+		t.Fatalf("expected 408, got %s", response.GetFirstLine())
+	}
+	if n := channel.countRequests(message.OPTIONS); n < 3 {
+		t.Fatalf("expected retransmissions, got %d OPTIONS", n)
+	}
+}
+
+func TestSendRequestCancelTimeout(t *testing.T) {
+	sipStack, _ := newTestStack()
+	sipStack.SetBaseTimerInterval(1)
+	channel := &testChannel{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	invite := parseTestRequest(t, testInvite)
+	responses, err := sipStack.SendRequest(ctx, invite, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ringing := invite.CreateResponse(message.RINGING)
+	SetResponseToTag(ringing, "a6c85cf")
+	if err := sipStack.ProcessResponse(ringing, channel); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	channel.waitForRequest(t, message.CANCEL)
+
+	// Nothing answers the CANCEL: the INVITE ends 64*T1 later.
+	var statusCodes []int
+	for response := range responses {
+		statusCodes = append(statusCodes, response.GetStatusCode())
+	}
+	if len(statusCodes) != 2 || statusCodes[1] != message.REQUEST_TERMINATED {
+		t.Fatalf("expected 180 and 487, got %v", statusCodes)
+	}
+	waitForClientTransactions(t, sipStack)
+}
+
+func TestConcurrentRequestsWithDispatcher(t *testing.T) {
+	sipStack := NewSIPTransactionStack()
+	dispatcher := NewEventDispatcher(4, 16)
+	sipStack.SetEventDispatcher(dispatcher)
+
+	var mutex sync.Mutex
+	methods := make(map[string][]string)
+	mux := NewServeMux()
+	record := func(st sip.ServerTransaction, request *message.SIPRequest) {
+		mutex.Lock()
+		methods[request.GetCallIdentifier()] = append(methods[request.GetCallIdentifier()], request.GetMethod())
+		mutex.Unlock()
+	}
+	mux.HandleFunc(message.INVITE, func(st sip.ServerTransaction, request *message.SIPRequest) {
+		record(st, request)
+		st.SendResponse(request.CreateResponse(message.RINGING))
+	})
+	mux.HandleFunc(message.CANCEL, record)
+	sipStack.SetSipListener(mux)
+
+	channel := &testChannel{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			replacer := strings.NewReplacer("z9hG4bK776asdhds", fmt.Sprintf("z9hG4bK%d", i),
+				"a84b4c76e66710", fmt.Sprintf("call%d", i))
+			sipStack.ProcessRequest(parseTestRequest(t, replacer.Replace(testInvite)), channel)
+			sipStack.ProcessRequest(parseTestRequest(t, replacer.Replace(testCancel)), channel)
+		}(i)
+	}
+	wg.Wait()
+	dispatcher.Close()
+
+	if len(methods) != 20 {
+		t.Fatalf("expected 20 calls, got %d", len(methods))
+	}
+	for callId, m := range methods {
+		if len(m) != 2 || m[0] != message.INVITE || m[1] != message.CANCEL {
+			t.Fatalf("%s: events out of order %v", callId, m)
+		}
+	}
+}
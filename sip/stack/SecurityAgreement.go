@@ -0,0 +1,250 @@
+package stack
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** The option tag of the security mechanism agreement (RFC 3329).
+ */
+const SecurityAgreement_OPTION_TAG = "sec-agree"
+
+/**
+ * A SecurityAgreementClient negotiates the security mechanism of a UA with
+ * its first-hop server (RFC 3329 section 2.3.1). Until a mechanism is
+ * agreed it advertises the mechanisms it supports in Security-Client
+ * headers. From the Security-Server headers of a 494 response it chooses
+ * the server mechanism it supports with the highest preference, and then
+ * echoes the server list in the Security-Verify headers of the requests it
+ * protects with that mechanism.
+ */
+type SecurityAgreementClient struct {
+	mutex sync.Mutex
+
+	mechanisms []*header.SecurityClient
+
+	serverMechanisms []*header.SecurityServer
+
+	mechanism *header.SecurityServer
+}
+
+/** Create a client supporting some mechanisms.
+ */
+func NewSecurityAgreementClient(mechanisms ...*header.SecurityClient) *SecurityAgreementClient {
+	this := &SecurityAgreementClient{}
+	this.mechanisms = mechanisms
+	return this
+}
+
+/** Get the agreed mechanism, or nil if none is agreed yet.
+ */
+func (this *SecurityAgreementClient) GetMechanism() *header.SecurityServer {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.mechanism
+}
+
+/** Add the headers of the agreement to a request sent to the first-hop
+ * server: the Security-Client headers until a mechanism is agreed, then
+ * the Security-Verify headers, with Require and Proxy-Require sec-agree.
+ */
+func (this *SecurityAgreementClient) PrepareRequest(request *message.SIPRequest) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.mechanism == nil {
+		securityClientList := header.NewSecurityClientList()
+		for _, mechanism := range this.mechanisms {
+			securityClient := header.NewSecurityClient()
+			copySecurityAgreement(&securityClient.SecurityAgreement, &mechanism.SecurityAgreement)
+			securityClientList.PushBack(securityClient)
+		}
+		request.SetHeader(securityClientList)
+		request.RemoveHeader(core.SIPHeaderNames_SECURITY_VERIFY)
+	} else {
+		securityVerifyList := header.NewSecurityVerifyList()
+		for _, mechanism := range this.serverMechanisms {
+			securityVerify := header.NewSecurityVerify()
+			copySecurityAgreement(&securityVerify.SecurityAgreement, &mechanism.SecurityAgreement)
+			securityVerifyList.PushBack(securityVerify)
+		}
+		request.SetHeader(securityVerifyList)
+		request.RemoveHeader(core.SIPHeaderNames_SECURITY_CLIENT)
+	}
+	addRequiredOptionTag(&request.SIPMessage, SecurityAgreement_OPTION_TAG, true)
+}
+
+/** Choose a mechanism from the Security-Server headers of a response,
+ * usually a 494 (Security Agreement Required). The request that got it is
+ * then sent again, prepared with PrepareRequest, over the chosen
+ * mechanism.
+ */
+func (this *SecurityAgreementClient) ProcessResponse(response *message.SIPResponse) (mechanism *header.SecurityServer, SipException error) {
+	if !response.HasHeader(core.SIPHeaderNames_SECURITY_SERVER) {
+		return nil, errors.New("SipException: the response has no Security-Server")
+	}
+	var serverMechanisms []*header.SecurityServer
+	for e := response.GetSIPHeaderList(core.SIPHeaderNames_SECURITY_SERVER).Front(); e != nil; e = e.Next() {
+		serverMechanisms = append(serverMechanisms, e.Value.(*header.SecurityServer))
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	for _, serverMechanism := range serverMechanisms {
+		if !this.supports(serverMechanism) {
+			continue
+		}
+		if mechanism == nil || serverMechanism.GetQValue() > mechanism.GetQValue() {
+			mechanism = serverMechanism
+		}
+	}
+	if mechanism == nil {
+		return nil, errors.New("SipException: no common security mechanism")
+	}
+	this.serverMechanisms = serverMechanisms
+	this.mechanism = mechanism
+	return mechanism, nil
+}
+
+/** Forget the agreed mechanism, e.g. when the security association ends.
+ */
+func (this *SecurityAgreementClient) Reset() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.serverMechanisms = nil
+	this.mechanism = nil
+}
+
+func (this *SecurityAgreementClient) supports(serverMechanism *header.SecurityServer) bool {
+	for _, mechanism := range this.mechanisms {
+		if strings.EqualFold(mechanism.GetMechanismName(), serverMechanism.GetMechanismName()) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * A SecurityAgreementServer is the first-hop server of the security
+ * mechanism agreement (RFC 3329 section 2.3.1). It asks the clients to
+ * agree on a mechanism with a 494 response listing its mechanisms in
+ * Security-Server headers, and checks that the Security-Verify headers of
+ * the protected requests are the list it sent, which a man in the middle
+ * removing the strongest mechanisms would have changed.
+ */
+type SecurityAgreementServer struct {
+	mechanisms []*header.SecurityServer
+}
+
+/** Create a server supporting some mechanisms, in the order of the
+ * Security-Server headers.
+ */
+func NewSecurityAgreementServer(mechanisms ...*header.SecurityServer) *SecurityAgreementServer {
+	this := &SecurityAgreementServer{}
+	this.mechanisms = mechanisms
+	return this
+}
+
+/** Check the agreement of a request. It returns nil if the request is
+ * protected by an agreed mechanism and may be served, else the response
+ * to send: a 494 listing the mechanisms of the server if the client asks
+ * for an agreement or its Security-Verify headers do not match them, or a
+ * 421 (Extension Required) if the client does not use the agreement.
+ */
+func (this *SecurityAgreementServer) ProcessRequest(request *message.SIPRequest) *message.SIPResponse {
+	if request.HasHeader(core.SIPHeaderNames_SECURITY_VERIFY) && this.verify(request) {
+		return nil
+	}
+	if !request.HasHeader(core.SIPHeaderNames_SECURITY_VERIFY) && !request.HasHeader(core.SIPHeaderNames_SECURITY_CLIENT) {
+		response := request.CreateResponse(message.EXTENSION_REQUIRED)
+		addRequiredOptionTag(&response.SIPMessage, SecurityAgreement_OPTION_TAG, false)
+		return response
+	}
+	response := request.CreateResponse(message.SECURITY_AGREEMENT_REQUIRED)
+	this.AddSecurityServer(response)
+	return response
+}
+
+/** Add the mechanisms of the server to a response, with Require
+ * sec-agree.
+ */
+func (this *SecurityAgreementServer) AddSecurityServer(response *message.SIPResponse) {
+	securityServerList := header.NewSecurityServerList()
+	for _, mechanism := range this.mechanisms {
+		securityServer := header.NewSecurityServer()
+		copySecurityAgreement(&securityServer.SecurityAgreement, &mechanism.SecurityAgreement)
+		securityServerList.PushBack(securityServer)
+	}
+	response.SetHeader(securityServerList)
+	addRequiredOptionTag(&response.SIPMessage, SecurityAgreement_OPTION_TAG, false)
+}
+
+/** Return true if the Security-Verify headers of a request are the
+ * Security-Server list of the server.
+ */
+func (this *SecurityAgreementServer) verify(request *message.SIPRequest) bool {
+	securityVerifyList := request.GetSIPHeaderList(core.SIPHeaderNames_SECURITY_VERIFY)
+	if securityVerifyList.Len() != len(this.mechanisms) {
+		return false
+	}
+	i := 0
+	for e := securityVerifyList.Front(); e != nil; e = e.Next() {
+		securityVerify, ok := e.Value.(*header.SecurityVerify)
+		if !ok || !this.mechanisms[i].Matches(securityVerify) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+/** Copy the mechanism and parameters of a security agreement header into
+ * another one.
+ */
+func copySecurityAgreement(to, from *header.SecurityAgreement) {
+	to.SetMechanismName(from.GetMechanismName())
+	to.SetParameters(from.GetParameters().Clone().(*core.NameValueList))
+}
+
+/** Add an option tag to the Require header of a message and, for a
+ * request, to its Proxy-Require header, unless it is already there.
+ */
+func addRequiredOptionTag(msg *message.SIPMessage, optionTag string, proxy bool) {
+	if !hasHeaderOptionTag(msg, core.SIPHeaderNames_REQUIRE, optionTag) {
+		if msg.HasHeader(core.SIPHeaderNames_REQUIRE) {
+			msg.GetSIPHeaderList(core.SIPHeaderNames_REQUIRE).PushBack(header.NewRequireFromString(optionTag))
+		} else {
+			requireList := header.NewRequireList()
+			requireList.PushBack(header.NewRequireFromString(optionTag))
+			msg.SetHeader(requireList)
+		}
+	}
+	if proxy && !hasHeaderOptionTag(msg, core.SIPHeaderNames_PROXY_REQUIRE, optionTag) {
+		if msg.HasHeader(core.SIPHeaderNames_PROXY_REQUIRE) {
+			msg.GetSIPHeaderList(core.SIPHeaderNames_PROXY_REQUIRE).PushBack(header.NewProxyRequireFromString(optionTag))
+		} else {
+			proxyRequireList := header.NewProxyRequireList()
+			proxyRequireList.PushBack(header.NewProxyRequireFromString(optionTag))
+			msg.SetHeader(proxyRequireList)
+		}
+	}
+}
+
+/** Return true if a Require or Proxy-Require header of a message lists an
+ * option tag.
+ */
+func hasHeaderOptionTag(msg *message.SIPMessage, headerName, optionTag string) bool {
+	if !msg.HasHeader(headerName) {
+		return false
+	}
+	for e := msg.GetSIPHeaderList(headerName).Front(); e != nil; e = e.Next() {
+		if h, ok := e.Value.(header.OptionTag); ok && strings.EqualFold(h.GetOptionTag(), optionTag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+func newTestSecurityClient(name string, q float32) *header.SecurityClient {
+	securityClient := header.NewSecurityClient()
+	securityClient.SetMechanismName(name)
+	securityClient.SetQValue(q)
+	return securityClient
+}
+
+func newTestSecurityServer(name string, q float32) *header.SecurityServer {
+	securityServer := header.NewSecurityServer()
+	securityServer.SetMechanismName(name)
+	securityServer.SetQValue(q)
+	return securityServer
+}
+
+/** Send a message over the wire: encode it and parse it again.
+ */
+func transmit(t *testing.T, msg message.Message) message.Message {
+	parsed, err := parser.NewStringMsgParser().ParseSIPMessage(msg.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestSecurityAgreement(t *testing.T) {
+	digest := newTestSecurityServer(header.SecurityAgreement_DIGEST, 0.1)
+	digest.SetDigestAlgorithm("MD5")
+	digest.SetDigestVerify("0123456789abcdef")
+	server := NewSecurityAgreementServer(newTestSecurityServer(header.SecurityAgreement_IPSEC_IKE, 0.3),
+		newTestSecurityServer(header.SecurityAgreement_TLS, 0.2), digest)
+	client := NewSecurityAgreementClient(newTestSecurityClient(header.SecurityAgreement_DIGEST, 0.1),
+		newTestSecurityClient(header.SecurityAgreement_TLS, 0.2))
+
+	request := parseTestRequest(t, testInvite)
+	client.PrepareRequest(request)
+	if !strings.Contains(request.String(), "Security-Client: digest;q=0.1,tls;q=0.2\r\n") ||
+		!strings.Contains(request.String(), "Require: sec-agree\r\n") ||
+		!strings.Contains(request.String(), "Proxy-Require: sec-agree\r\n") {
+		t.Fatalf("mechanisms not advertised:\n%s", request.String())
+	}
+	response := server.ProcessRequest(transmit(t, request).(*message.SIPRequest))
+	if response == nil || response.GetStatusCode() != message.SECURITY_AGREEMENT_REQUIRED {
+		t.Fatal("no 494 to a request asking for an agreement")
+	}
+
+	mechanism, err := client.ProcessResponse(transmit(t, response).(*message.SIPResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mechanism.GetMechanismName() != header.SecurityAgreement_TLS || client.GetMechanism() != mechanism {
+		t.Fatalf("chose %s, expected tls", mechanism.GetMechanismName())
+	}
+
+	request = parseTestRequest(t, testInvite)
+	client.PrepareRequest(request)
+	client.PrepareRequest(request)
+	if request.HasHeader(core.SIPHeaderNames_SECURITY_CLIENT) || request.GetSIPHeaderList(core.SIPHeaderNames_REQUIRE).Len() != 1 {
+		t.Fatalf("bad protected request:\n%s", request.String())
+	}
+	if response = server.ProcessRequest(transmit(t, request).(*message.SIPRequest)); response != nil {
+		t.Fatalf("protected request rejected with %d:\n%s", response.GetStatusCode(), request.String())
+	}
+
+	downgraded := strings.Replace(transmit(t, request).String(), "ipsec-ike;q=0.3,", "", 1)
+	if response = server.ProcessRequest(parseTestRequest(t, downgraded)); response == nil ||
+		response.GetStatusCode() != message.SECURITY_AGREEMENT_REQUIRED {
+		t.Fatal("Security-Verify not matching the server list accepted")
+	}
+	changed := strings.Replace(transmit(t, request).String(), "d-ver=\"0123456789abcdef\"", "d-ver=\"0123456789abcdee\"", 1)
+	if response = server.ProcessRequest(parseTestRequest(t, changed)); response == nil {
+		t.Fatal("Security-Verify with another d-ver accepted")
+	}
+
+	if response = server.ProcessRequest(parseTestRequest(t, testInvite)); response == nil ||
+		response.GetStatusCode() != message.EXTENSION_REQUIRED || !strings.Contains(response.String(), "Require: sec-agree\r\n") {
+		t.Fatal("request without an agreement not rejected with 421")
+	}
+
+	client.Reset()
+	request = parseTestRequest(t, testInvite)
+	client.PrepareRequest(request)
+	if !request.HasHeader(core.SIPHeaderNames_SECURITY_CLIENT) || request.HasHeader(core.SIPHeaderNames_SECURITY_VERIFY) {
+		t.Fatal("agreement not reset")
+	}
+}
+
+func TestSecurityAgreementNoCommonMechanism(t *testing.T) {
+	server := NewSecurityAgreementServer(newTestSecurityServer(header.SecurityAgreement_IPSEC_3GPP, 0.1))
+	client := NewSecurityAgreementClient(newTestSecurityClient(header.SecurityAgreement_TLS, 0.1))
+	request := parseTestRequest(t, testInvite)
+	client.PrepareRequest(request)
+	response := server.ProcessRequest(request)
+	if _, err := client.ProcessResponse(response); err == nil || client.GetMechanism() != nil {
+		t.Fatal("mechanism chosen without a common one")
+	}
+}
@@ -0,0 +1,125 @@
+package stack
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * A Handler answers an inbound request. The server transaction is nil for
+ * requests that are not part of a transaction, such as the ACK for a 2xx.
+ */
+type Handler interface {
+	ServeSIP(st sip.ServerTransaction, request *message.SIPRequest)
+}
+
+/**
+ * The HandlerFunc type is an adapter to allow the use of ordinary functions
+ * as request handlers.
+ */
+type HandlerFunc func(st sip.ServerTransaction, request *message.SIPRequest)
+
+/** ServeSIP calls f(st, request).
+ */
+func (f HandlerFunc) ServeSIP(st sip.ServerTransaction, request *message.SIPRequest) {
+	f(st, request)
+}
+
+/**
+ * ServeMux is a request multiplexer keyed by method. It implements
+ * SipListener, so it can be installed on a SIPTransactionStack with
+ * SetSipListener. Requests for a method without a handler are answered
+ * with 405 Method Not Allowed and an Allow header listing the methods
+ * that have one.
+ */
+type ServeMux struct {
+	mutex sync.RWMutex
+
+	handlers map[string]Handler
+}
+
+/** Create an empty ServeMux.
+ */
+func NewServeMux() *ServeMux {
+	this := &ServeMux{}
+	this.handlers = make(map[string]Handler)
+	return this
+}
+
+/** Register the handler for a method, replacing any previous one.
+ */
+func (this *ServeMux) Handle(method string, handler Handler) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.handlers[strings.ToUpper(method)] = handler
+}
+
+/** Register the handler function for a method.
+ */
+func (this *ServeMux) HandleFunc(method string, handler func(st sip.ServerTransaction, request *message.SIPRequest)) {
+	this.Handle(method, HandlerFunc(handler))
+}
+
+/** Get the handler for a method (nil if there is none).
+ */
+func (this *ServeMux) GetHandler(method string) Handler {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	return this.handlers[strings.ToUpper(method)]
+}
+
+/** Dispatch a request to the handler of its method.
+ */
+func (this *ServeMux) ServeSIP(st sip.ServerTransaction, request *message.SIPRequest) {
+	if handler := this.GetHandler(request.GetMethod()); handler != nil {
+		handler.ServeSIP(st, request)
+		return
+	}
+	if st == nil || request.GetMethod() == message.ACK || request.GetMethod() == message.CANCEL {
+		return
+	}
+	response := request.CreateResponse(message.METHOD_NOT_ALLOWED)
+	allowList := header.NewAllowList()
+	for _, method := range this.getMethods() {
+		allowList.PushBack(header.NewAllowFromString(method))
+	}
+	response.SetHeader(allowList)
+	st.SendResponse(response)
+}
+
+/** Dispatch a request event to the handler of its method.
+ */
+func (this *ServeMux) ProcessRequest(requestEvent sip.RequestEvent) {
+	request, ok := requestEvent.GetRequest().(*message.SIPRequest)
+	if !ok {
+		return
+	}
+	this.ServeSIP(requestEvent.GetServerTransaction(), request)
+}
+
+/** Responses for requests sent with SendRequest are delivered on their
+ * channel; other responses are ignored by the ServeMux.
+ */
+func (this *ServeMux) ProcessResponse(responseEvent sip.ResponseEvent) {
+}
+
+/** Timeouts are ignored by the ServeMux.
+ */
+func (this *ServeMux) ProcessTimeout(timeoutEvent sip.TimeoutEvent) {
+}
+
+func (this *ServeMux) getMethods() []string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+	methods := make([]string, 0, len(this.handlers))
+	for method := range this.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,46 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+)
+
+func TestServeMux(t *testing.T) {
+	sipStack := NewSIPTransactionStack()
+	mux := NewServeMux()
+	sipStack.SetSipListener(mux)
+
+	var invites int
+	mux.HandleFunc("invite", func(st sip.ServerTransaction, request *message.SIPRequest) {
+		invites++
+		st.SendResponse(request.CreateResponse(message.RINGING))
+	})
+
+	channel := &testChannel{}
+	if err := sipStack.ProcessRequest(parseTestRequest(t, testInvite), channel); err != nil {
+		t.Fatal(err)
+	}
+	if invites != 1 || len(channel.sent) != 1 || channel.sent[0].GetStatusCode() != message.RINGING {
+		t.Fatal("INVITE was not dispatched to its handler")
+	}
+
+	options := parseTestRequest(t, "OPTIONS sip:bob@biloxi.com SIP/2.0\r\n"+
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bKhjhs8ass877\r\n"+
+		"Max-Forwards: 70\r\n"+
+		"To: <sip:bob@biloxi.com>\r\n"+
+		"From: Alice <sip:alice@atlanta.com>;tag=1928301774\r\n"+
+		"Call-ID: a84b4c76e66710\r\n"+
+		"CSeq: 63104 OPTIONS\r\n"+
+		"Content-Length: 0\r\n\r\n")
+	if err := sipStack.ProcessRequest(options, channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.sent) != 2 || channel.sent[1].GetStatusCode() != message.METHOD_NOT_ALLOWED {
+		t.Fatal("expected 405 for OPTIONS")
+	}
+	if !channel.sent[1].HasHeader("Allow") {
+		t.Fatal("405 without an Allow header")
+	}
+}
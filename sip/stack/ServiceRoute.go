@@ -0,0 +1,78 @@
+package stack
+
+import (
+	"sync"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/address"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * ServiceRoute keeps the Service-Route a user agent received when it
+ * registered (RFC 3608) and preloads it as the route set of the requests
+ * the user agent sends outside of a dialog. Every successful registration
+ * replaces the stored route set, and a 2xx without Service-Route clears it.
+ */
+type ServiceRoute struct {
+	mutex sync.Mutex
+
+	routes []address.Address
+}
+
+/** Create an empty service route.
+ */
+func NewServiceRoute() *ServiceRoute {
+	return &ServiceRoute{}
+}
+
+/** Store the Service-Route of a response to a REGISTER. Only 2xx
+ * responses change the route set.
+ */
+func (this *ServiceRoute) ProcessRegisterResponse(response *message.SIPResponse) {
+	if response.GetStatusCode()/100 != 2 {
+		return
+	}
+	var routes []address.Address
+	if response.HasHeader(core.SIPHeaderNames_SERVICE_ROUTE) {
+		for e := response.GetSIPHeaderList(core.SIPHeaderNames_SERVICE_ROUTE).Front(); e != nil; e = e.Next() {
+			routes = append(routes, e.Value.(*header.ServiceRoute).GetAddress())
+		}
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.routes = routes
+}
+
+/** Get the stored route set.
+ */
+func (this *ServiceRoute) GetRoutes() []address.Address {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	return this.routes
+}
+
+/** Forget the stored route set, e.g. when the user agent unregisters.
+ */
+func (this *ServiceRoute) Clear() {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.routes = nil
+}
+
+/** Preload the stored route set in a request sent outside of a dialog:
+ * the Service-Route entries are put on top of the Route headers of the
+ * request, in order.
+ */
+func (this *ServiceRoute) AddRoutes(request *message.SIPRequest) {
+	routes := this.GetRoutes()
+	if len(routes) == 0 {
+		return
+	}
+	routeList := header.NewRouteList()
+	for _, addr := range routes {
+		routeList.PushBack(header.NewRouteFromAddress(addr))
+	}
+	request.AttachHeader3(routeList, false, true)
+}
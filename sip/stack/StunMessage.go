@@ -0,0 +1,206 @@
+package stack
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+/** STUN message types and attributes (RFC 5389) used for the keepalives
+ * of SIP Outbound flows over UDP.
+ */
+const (
+	StunMessage_BINDING_REQUEST    = 0x0001
+	StunMessage_BINDING_SUCCESS    = 0x0101
+	StunMessage_MAGIC_COOKIE       = 0x2112A442
+	StunMessage_HEADER_LENGTH      = 20
+	StunMessage_XOR_MAPPED_ADDRESS = 0x0020
+	StunMessage_MAPPED_ADDRESS     = 0x0001
+	stunMessage_FAMILY_IPV4        = 0x01
+	stunMessage_FAMILY_IPV6        = 0x02
+)
+
+/**
+ * A minimal STUN message: a Binding request or a Binding success response
+ * with the mapped address of the client.
+ */
+type StunMessage struct {
+	messageType uint16
+
+	transactionId [12]byte
+
+	mappedIP net.IP
+
+	mappedPort int
+}
+
+/** Create a Binding request with a random transaction id.
+ */
+func NewStunBindingRequest() *StunMessage {
+	this := &StunMessage{messageType: StunMessage_BINDING_REQUEST}
+	rand.Read(this.transactionId[:])
+	return this
+}
+
+/** Create the Binding success response to a request, carrying the address
+ * the request came from.
+ */
+func NewStunBindingResponse(request *StunMessage, ip net.IP, port int) *StunMessage {
+	return &StunMessage{
+		messageType:   StunMessage_BINDING_SUCCESS,
+		transactionId: request.transactionId,
+		mappedIP:      ip,
+		mappedPort:    port,
+	}
+}
+
+/** Return true if the data looks like a STUN message: the two top bits
+ * are zero and the magic cookie is in place. This is how STUN is told
+ * apart from SIP on a shared port (RFC 5626 section 8).
+ */
+func IsStunMessage(data []byte) bool {
+	return len(data) >= StunMessage_HEADER_LENGTH && data[0]&0xC0 == 0 &&
+		binary.BigEndian.Uint32(data[4:8]) == StunMessage_MAGIC_COOKIE
+}
+
+/** Parse a STUN message.
+ */
+func ParseStunMessage(data []byte) (msg *StunMessage, ParseException error) {
+	if !IsStunMessage(data) {
+		return nil, errors.New("ParseException: not a STUN message")
+	}
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if len(data) < StunMessage_HEADER_LENGTH+length {
+		return nil, errors.New("ParseException: truncated STUN message")
+	}
+	this := &StunMessage{messageType: binary.BigEndian.Uint16(data[0:2])}
+	copy(this.transactionId[:], data[8:20])
+
+	attributes := data[StunMessage_HEADER_LENGTH : StunMessage_HEADER_LENGTH+length]
+	for len(attributes) >= 4 {
+		attrType := binary.BigEndian.Uint16(attributes[0:2])
+		attrLength := int(binary.BigEndian.Uint16(attributes[2:4]))
+		if len(attributes) < 4+attrLength {
+			return nil, errors.New("ParseException: truncated STUN attribute")
+		}
+		value := attributes[4 : 4+attrLength]
+		switch attrType {
+		case StunMessage_XOR_MAPPED_ADDRESS:
+			this.parseAddress(value, true)
+		case StunMessage_MAPPED_ADDRESS:
+			if this.mappedIP == nil {
+				this.parseAddress(value, false)
+			}
+		}
+		// Attributes are padded to a multiple of four bytes.
+		padded := (attrLength + 3) &^ 3
+		if len(attributes) < 4+padded {
+			break
+		}
+		attributes = attributes[4+padded:]
+	}
+	return this, nil
+}
+
+/** Encode the message.
+ */
+func (this *StunMessage) Encode() []byte {
+	var attributes []byte
+	if this.mappedIP != nil {
+		value := this.encodeAddress()
+		attributes = make([]byte, 4+len(value))
+		binary.BigEndian.PutUint16(attributes[0:2], StunMessage_XOR_MAPPED_ADDRESS)
+		binary.BigEndian.PutUint16(attributes[2:4], uint16(len(value)))
+		copy(attributes[4:], value)
+	}
+	data := make([]byte, StunMessage_HEADER_LENGTH+len(attributes))
+	binary.BigEndian.PutUint16(data[0:2], this.messageType)
+	binary.BigEndian.PutUint16(data[2:4], uint16(len(attributes)))
+	binary.BigEndian.PutUint32(data[4:8], StunMessage_MAGIC_COOKIE)
+	copy(data[8:20], this.transactionId[:])
+	copy(data[20:], attributes)
+	return data
+}
+
+/** Get the message type.
+ */
+func (this *StunMessage) GetMessageType() int {
+	return int(this.messageType)
+}
+
+/** Get the transaction id.
+ */
+func (this *StunMessage) GetTransactionId() []byte {
+	return this.transactionId[:]
+}
+
+/** Return true if this is the Binding success response to a request.
+ */
+func (this *StunMessage) IsResponseTo(request *StunMessage) bool {
+	return this.messageType == StunMessage_BINDING_SUCCESS && this.transactionId == request.transactionId
+}
+
+/** Get the mapped address (nil if there is none).
+ */
+func (this *StunMessage) GetMappedIP() net.IP {
+	return this.mappedIP
+}
+
+/** Get the mapped port.
+ */
+func (this *StunMessage) GetMappedPort() int {
+	return this.mappedPort
+}
+
+/** XOR-MAPPED-ADDRESS hides the address behind the magic cookie and the
+ * transaction id (RFC 5389 section 15.2).
+ */
+func (this *StunMessage) xorKey() []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key[0:4], StunMessage_MAGIC_COOKIE)
+	copy(key[4:], this.transactionId[:])
+	return key
+}
+
+func (this *StunMessage) parseAddress(value []byte, xor bool) {
+	if len(value) < 8 {
+		return
+	}
+	family := int(value[1])
+	port := binary.BigEndian.Uint16(value[2:4])
+	var ip net.IP
+	if family == stunMessage_FAMILY_IPV4 {
+		ip = net.IP(append([]byte(nil), value[4:8]...))
+	} else if family == stunMessage_FAMILY_IPV6 && len(value) >= 20 {
+		ip = net.IP(append([]byte(nil), value[4:20]...))
+	} else {
+		return
+	}
+	if xor {
+		port ^= StunMessage_MAGIC_COOKIE >> 16
+		key := this.xorKey()
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+	this.mappedIP = ip
+	this.mappedPort = int(port)
+}
+
+func (this *StunMessage) encodeAddress() []byte {
+	ip := this.mappedIP.To4()
+	family := stunMessage_FAMILY_IPV4
+	if ip == nil {
+		ip = this.mappedIP.To16()
+		family = stunMessage_FAMILY_IPV6
+	}
+	value := make([]byte, 4+len(ip))
+	value[1] = byte(family)
+	binary.BigEndian.PutUint16(value[2:4], uint16(this.mappedPort)^(StunMessage_MAGIC_COOKIE>>16))
+	key := this.xorKey()
+	for i := range ip {
+		value[4+i] = ip[i] ^ key[i]
+	}
+	return value
+}
@@ -0,0 +1,110 @@
+package stack
+
+import (
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** The option tag of the Target-Dialog extension (RFC 4538 section 7).
+ */
+const TargetDialog_OPTION_TAG = "tdialog"
+
+/** Find the live dialog a Target-Dialog header refers to (RFC 4538
+ * section 5). The tags of the header are those of the sender, so its
+ * remote-tag is the local tag of the dialog and its local-tag the remote
+ * tag. 481 is returned if there is no such dialog or if it has ended.
+ */
+func (this *SIPTransactionStack) FindTargetDialog(targetDialog *header.TargetDialog) (dialog *SIPDialog, statusCode int) {
+	dialog = this.GetDialog(targetDialog.GetCallId(), targetDialog.GetRemoteTag(), targetDialog.GetLocalTag())
+	if dialog == nil || !isLiveDialog(dialog) {
+		return nil, message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+	}
+	return dialog, 0
+}
+
+/** Match the Target-Dialog header of a new request to its dialog and
+ * attach the dialog to the server transaction. A request whose dialog is
+ * unknown still goes on to the listener, which decides whether to accept
+ * it; only a malformed header is rejected, with 400.
+ */
+func (this *SIPTransactionStack) processTargetDialog(st *SIPServerTransaction, request *message.SIPRequest) (statusCode int) {
+	if !request.HasHeader(core.SIPHeaderNames_TARGET_DIALOG) {
+		return 0
+	}
+	targetDialog, ok := request.GetHeader(core.SIPHeaderNames_TARGET_DIALOG).(*header.TargetDialog)
+	if !ok {
+		return message.BAD_REQUEST
+	}
+	if dialog, _ := this.FindTargetDialog(targetDialog); dialog != nil {
+		st.mutex.Lock()
+		st.targetDialog = dialog
+		st.mutex.Unlock()
+	}
+	return 0
+}
+
+/** Create the Target-Dialog header a request sent outside of this dialog
+ * carries to refer to it (RFC 4538 section 4).
+ */
+func (this *SIPDialog) CreateTargetDialog() *header.TargetDialog {
+	targetDialog := header.NewTargetDialog()
+	targetDialog.SetCallId(this.GetCallIdentifier())
+	targetDialog.SetLocalTag(this.GetLocalTag())
+	targetDialog.SetRemoteTag(this.GetRemoteTag())
+	return targetDialog
+}
+
+/**
+ * A TargetDialogHandler lets a request outside of a dialog, e.g. a REFER
+ * or an INFO, through to its handler only when its Target-Dialog header
+ * refers to a live dialog of this UA, which authorizes the request
+ * (RFC 4538 section 5). Other requests outside of a dialog are answered
+ * with 481 if they carry a Target-Dialog header and with 403 if not.
+ * Requests within a dialog are passed on unchanged.
+ *
+ * The TargetDialogHandler is a Handler, so it can be installed in a
+ * ServeMux in front of the handler of a method.
+ */
+type TargetDialogHandler struct {
+	handler Handler
+}
+
+/** Create a TargetDialogHandler that passes the requests it accepts to
+ * the given handler.
+ */
+func NewTargetDialogHandler(handler Handler) *TargetDialogHandler {
+	this := &TargetDialogHandler{}
+	this.handler = handler
+	return this
+}
+
+/** Pass a request to the handler if it is authorized by a live dialog.
+ */
+func (this *TargetDialogHandler) ServeSIP(st sip.ServerTransaction, request *message.SIPRequest) {
+	if dialog, _ := st.GetDialog().(*SIPDialog); dialog == nil && request.GetToTag() == "" {
+		var targetDialog *SIPDialog
+		if sst, ok := st.(*SIPServerTransaction); ok {
+			targetDialog = sst.GetTargetDialog()
+		}
+		if targetDialog == nil || !isLiveDialog(targetDialog) {
+			statusCode := message.FORBIDDEN
+			if request.HasHeader(core.SIPHeaderNames_TARGET_DIALOG) {
+				statusCode = message.CALL_OR_TRANSACTION_DOES_NOT_EXIST
+			}
+			response := request.CreateResponse(statusCode)
+			SetResponseToTag(response, GenerateTag())
+			st.SendResponse(response)
+			return
+		}
+	}
+	this.handler.ServeSIP(st, request)
+}
+
+/** Return true if a dialog is early or confirmed.
+ */
+func isLiveDialog(dialog *SIPDialog) bool {
+	state := dialog.GetState()
+	return state == sip.DIALOGSTATE_EARLY || state == sip.DIALOGSTATE_CONFIRMED
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/use-go/gosips/sip"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** An INFO sent by Alice outside of her dialog with Bob, carrying the
+ * given Target-Dialog header. */
+func testTargetDialogInfo(hdr string) string {
+	return "INFO sip:bob@192.0.2.4 SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bKnashds9\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: Bob <sip:bob@biloxi.com>\r\n" +
+		"From: Alice <sip:alice@atlanta.com>;tag=88sja8x\r\n" +
+		"Call-ID: 7dd5d1e4@pc33.atlanta.com\r\n" +
+		"CSeq: 1 INFO\r\n" +
+		hdr +
+		"Content-Length: 0\r\n\r\n"
+}
+
+func TestTargetDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	dialog := establishDialog(t, sipStack, listener, channel, message.OK)
+
+	var served int
+	handler := NewTargetDialogHandler(HandlerFunc(func(st sip.ServerTransaction, request *message.SIPRequest) {
+		served++
+		st.SendResponse(request.CreateResponse(message.OK))
+	}))
+
+	info := testTargetDialogInfo("Target-Dialog: a84b4c76e66710@pc33.atlanta.com;local-tag=1928301774;remote-tag=a6c85cf\r\n")
+	if err := sipStack.ProcessRequest(parseTestRequest(t, info), channel); err != nil {
+		t.Fatal(err)
+	}
+	requestEvent := listener.requests[len(listener.requests)-1]
+	st := requestEvent.GetServerTransaction().(*SIPServerTransaction)
+	if st.GetTargetDialog() != dialog {
+		t.Fatal("Target-Dialog not matched to its dialog")
+	}
+	handler.ServeSIP(st, requestEvent.GetRequest().(*message.SIPRequest))
+	if served != 1 || channel.sent[len(channel.sent)-1].GetStatusCode() != message.OK {
+		t.Fatal("INFO referring to a live dialog not accepted")
+	}
+}
+
+func TestTargetDialogRejected(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		statusCode int
+		hdr        string
+	}{
+		{"no Target-Dialog", message.FORBIDDEN, ""},
+		{"unknown dialog", message.CALL_OR_TRANSACTION_DOES_NOT_EXIST,
+			"Target-Dialog: a84b4c76e66710@pc33.atlanta.com;local-tag=unknown;remote-tag=a6c85cf\r\n"},
+		{"swapped tags", message.CALL_OR_TRANSACTION_DOES_NOT_EXIST,
+			"Target-Dialog: a84b4c76e66710@pc33.atlanta.com;local-tag=a6c85cf;remote-tag=1928301774\r\n"},
+	} {
+		sipStack, listener := newTestStack()
+		channel := &testChannel{}
+		establishDialog(t, sipStack, listener, channel, message.OK)
+
+		handler := NewTargetDialogHandler(HandlerFunc(func(st sip.ServerTransaction, request *message.SIPRequest) {
+			t.Errorf("%s: request served", test.name)
+		}))
+		sipStack.ProcessRequest(parseTestRequest(t, testTargetDialogInfo(test.hdr)), channel)
+		requestEvent := listener.requests[len(listener.requests)-1]
+		handler.ServeSIP(requestEvent.GetServerTransaction(), requestEvent.GetRequest().(*message.SIPRequest))
+		if response := channel.sent[len(channel.sent)-1]; response.GetStatusCode() != test.statusCode || response.GetToTag() == "" {
+			t.Errorf("%s: expected %d, got %s", test.name, test.statusCode, response.GetFirstLine())
+		}
+	}
+}
+
+func TestTargetDialogTerminated(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	dialog := establishDialog(t, sipStack, listener, channel, message.OK)
+	dialog.terminate()
+
+	info := testTargetDialogInfo("Target-Dialog: a84b4c76e66710@pc33.atlanta.com;local-tag=1928301774;remote-tag=a6c85cf\r\n")
+	sipStack.ProcessRequest(parseTestRequest(t, info), channel)
+	st := listener.requests[len(listener.requests)-1].GetServerTransaction().(*SIPServerTransaction)
+	if st.GetTargetDialog() != nil {
+		t.Fatal("Target-Dialog matched to a terminated dialog")
+	}
+}
+
+func TestCreateTargetDialog(t *testing.T) {
+	sipStack, listener := newTestStack()
+	channel := &testChannel{}
+	dialog := establishDialog(t, sipStack, listener, channel, message.OK)
+
+	targetDialog := dialog.CreateTargetDialog()
+	if targetDialog.GetCallId() != "a84b4c76e66710@pc33.atlanta.com" ||
+		targetDialog.GetLocalTag() != "a6c85cf" || targetDialog.GetRemoteTag() != "1928301774" {
+		t.Fatalf("unexpected %s", targetDialog.String())
+	}
+}
@@ -0,0 +1,29 @@
+package stack
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/use-go/gosips/sip/header"
+)
+
+/** Generate a random string of n bytes, hex encoded.
+ */
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+/** Generate a tag for a From or To header.
+ */
+func GenerateTag() string {
+	return randomHex(4)
+}
+
+/** Generate a cryptographically random branch identifier carrying the
+ * RFC 3261 magic cookie.
+ */
+func GenerateBranchId() string {
+	return header.SIPConstants_BRANCH_MAGIC_COOKIE + randomHex(8)
+}
@@ -0,0 +1,112 @@
+package stir
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/** The longest a certificate file may be.
+ */
+const certificateFetcher_MAX_SIZE = 64 * 1024
+
+/**
+ * A CertificateFetcher gets the certificate chain an Identity header
+ * refers to by its info parameter, the signing certificate first. A
+ * verifier may cache certificates in its fetcher; tests stub it with local
+ * certificates.
+ */
+type CertificateFetcher interface {
+	FetchCertificates(url string) ([]*x509.Certificate, error)
+}
+
+/** A CertificateFetcherFunc is a function used as a CertificateFetcher.
+ */
+type CertificateFetcherFunc func(url string) ([]*x509.Certificate, error)
+
+func (f CertificateFetcherFunc) FetchCertificates(url string) ([]*x509.Certificate, error) {
+	return f(url)
+}
+
+/**
+ * An HTTPCertificateFetcher gets the PEM encoded certificates at an HTTPS
+ * URL (RFC 8226 section 9). A URL with another scheme, or a redirection to
+ * one, is refused.
+ */
+type HTTPCertificateFetcher struct {
+	client *http.Client
+}
+
+/** Create an HTTPCertificateFetcher with a timeout.
+ */
+func NewHTTPCertificateFetcher(timeout time.Duration) *HTTPCertificateFetcher {
+	this := &HTTPCertificateFetcher{}
+	this.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return checkHTTPS(request.URL)
+		},
+	}
+	return this
+}
+
+func (this *HTTPCertificateFetcher) FetchCertificates(rawurl string) ([]*x509.Certificate, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkHTTPS(u); err != nil {
+		return nil, err
+	}
+	response, err := this.client.Get(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("SipException: fetching " + rawurl + ": HTTP " + strconv.Itoa(response.StatusCode))
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, response.Body, certificateFetcher_MAX_SIZE))
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificates(data)
+}
+
+/** Check that a certificate is fetched over HTTPS.
+ */
+func checkHTTPS(u *url.URL) (SipException error) {
+	if !strings.EqualFold(u.Scheme, "https") || u.Host == "" {
+		return errors.New("SipException: the certificate URL " + u.String() + " is not an HTTPS URL")
+	}
+	return nil
+}
+
+/** Parse the PEM encoded certificates of a chain.
+ */
+func ParseCertificates(data []byte) (certificates []*x509.Certificate, ParseException error) {
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("ParseException: no certificate found")
+	}
+	return certificates, nil
+}
@@ -0,0 +1,72 @@
+package stir
+
+import (
+	"strings"
+
+	"github.com/use-go/gosips/sip/address"
+)
+
+/** Get the telephone number of a URI in the canonical form of RFC 8224
+ * section 8.3, digits only without visual separators or the leading '+',
+ * or "" if the URI does not carry a telephone number: a tel URI does, and
+ * so does a SIP URI with user=phone or a user part that is a number.
+ */
+func CanonicalTelephoneNumber(uri address.URI) string {
+	var number string
+	switch u := uri.(type) {
+	case *address.TelURLImpl:
+		number = u.GetPhoneNumber()
+	case *address.SipURIImpl:
+		number = u.GetUser()
+		if u.GetUserParam() != "phone" && !isTelephoneNumber(number) {
+			return ""
+		}
+	default:
+		return ""
+	}
+	if i := strings.IndexByte(number, ';'); i >= 0 {
+		number = number[:i]
+	}
+	var canonical strings.Builder
+	for _, c := range number {
+		if c >= '0' && c <= '9' || c == '*' || c == '#' {
+			canonical.WriteRune(c)
+		}
+	}
+	return canonical.String()
+}
+
+/** Get the orig or a dest claim of a PASSporT from an address: the
+ * telephone number if it carries one, else the URI without parameters
+ * (RFC 8225 section 5.2).
+ */
+func identityOf(addr address.Address) (tn, uri string) {
+	if addr == nil || addr.GetURI() == nil {
+		return "", ""
+	}
+	if tn = CanonicalTelephoneNumber(addr.GetURI()); tn != "" {
+		return tn, ""
+	}
+	if sipURI, ok := addr.GetURI().(*address.SipURIImpl); ok {
+		if user := sipURI.GetUser(); user != "" {
+			return "", sipURI.GetScheme() + ":" + user + "@" + sipURI.GetHost()
+		}
+		return "", sipURI.GetScheme() + ":" + sipURI.GetHost()
+	}
+	return "", addr.GetURI().String()
+}
+
+func isTelephoneNumber(user string) bool {
+	user = strings.TrimPrefix(user, "+")
+	digits := 0
+	for _, c := range user {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '-' || c == '.' || c == '(' || c == ')':
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
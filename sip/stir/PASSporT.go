@@ -0,0 +1,170 @@
+package stir
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+/** The values of the PASSporT header of SHAKEN (RFC 8225, RFC 8588).
+ */
+const (
+	PASSporT_ALG_ES256 = "ES256"
+	PASSporT_TYP       = "passport"
+	PASSporT_PPT       = "shaken"
+)
+
+/** The attestation levels of SHAKEN (RFC 8588 section 4): full, partial
+ * and gateway.
+ */
+const (
+	PASSporT_ATTEST_FULL    = "A"
+	PASSporT_ATTEST_PARTIAL = "B"
+	PASSporT_ATTEST_GATEWAY = "C"
+)
+
+/** The size in bytes of each of the r and s values of an ES256 signature.
+ */
+const passportES256KeySize = 32
+
+/** The JOSE header of a PASSporT. The fields are in lexicographic order so
+ * that the JSON encoding is the canonical form (RFC 8225 section 9).
+ */
+type PASSporTHeader struct {
+	Alg string `json:"alg"`
+	Ppt string `json:"ppt,omitempty"`
+	Typ string `json:"typ"`
+	X5u string `json:"x5u"`
+}
+
+/** The originating identity of a PASSporT: a telephone number or a URI.
+ */
+type PASSporTOrig struct {
+	Tn  string `json:"tn,omitempty"`
+	Uri string `json:"uri,omitempty"`
+}
+
+/** The destination identities of a PASSporT.
+ */
+type PASSporTDest struct {
+	Tn  []string `json:"tn,omitempty"`
+	Uri []string `json:"uri,omitempty"`
+}
+
+/** The claims of a SHAKEN PASSporT, in lexicographic order.
+ */
+type PASSporTClaims struct {
+	Attest string       `json:"attest,omitempty"`
+	Dest   PASSporTDest `json:"dest"`
+	Iat    int64        `json:"iat"`
+	Orig   PASSporTOrig `json:"orig"`
+	Origid string       `json:"origid,omitempty"`
+}
+
+/**
+ * A PASSporT (RFC 8225) is the JSON Web Token an authentication service
+ * signs to assert the identity of a caller, carried in the Identity
+ * header. With the shaken extension (RFC 8588) it also carries the
+ * attestation level of the originating network and an origination
+ * identifier.
+ */
+type PASSporT struct {
+	Header PASSporTHeader
+
+	Claims PASSporTClaims
+}
+
+/** Create a SHAKEN PASSporT whose certificate is at x5u.
+ */
+func NewPASSporT(x5u string) *PASSporT {
+	this := &PASSporT{}
+	this.Header.Alg = PASSporT_ALG_ES256
+	this.Header.Ppt = PASSporT_PPT
+	this.Header.Typ = PASSporT_TYP
+	this.Header.X5u = x5u
+	return this
+}
+
+/** Sign the PASSporT with an ECDSA P-256 key and return it in compact
+ * form: header.claims.signature, each base64url encoded.
+ */
+func (this *PASSporT) Sign(key *ecdsa.PrivateKey) (token string, SipException error) {
+	if key == nil || key.Curve != elliptic.P256() {
+		return "", errors.New("SipException: ES256 needs a P-256 key")
+	}
+	signingInput, err := this.signingInput()
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 2*passportES256KeySize)
+	r.FillBytes(signature[:passportES256KeySize])
+	s.FillBytes(signature[passportES256KeySize:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+/** Parse a PASSporT in compact form without verifying it. The signing
+ * input and the signature are returned for VerifySignature.
+ */
+func ParsePASSporT(token string) (passport *PASSporT, signingInput string, signature []byte, ParseException error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, "", nil, errors.New("ParseException: a PASSporT has three parts")
+	}
+	passport = &PASSporT{}
+	if err := decodePASSporTPart(parts[0], &passport.Header); err != nil {
+		return nil, "", nil, err
+	}
+	if err := decodePASSporTPart(parts[1], &passport.Claims); err != nil {
+		return nil, "", nil, err
+	}
+	if signature, ParseException = base64.RawURLEncoding.DecodeString(parts[2]); ParseException != nil {
+		return nil, "", nil, errors.New("ParseException: bad PASSporT signature encoding")
+	}
+	return passport, parts[0] + "." + parts[1], signature, nil
+}
+
+/** Verify an ES256 signature over the signing input of a PASSporT.
+ */
+func VerifySignature(signingInput string, signature []byte, key *ecdsa.PublicKey) bool {
+	if key == nil || len(signature) != 2*passportES256KeySize {
+		return false
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	r := new(big.Int).SetBytes(signature[:passportES256KeySize])
+	s := new(big.Int).SetBytes(signature[passportES256KeySize:])
+	return ecdsa.Verify(key, digest[:], r, s)
+}
+
+func (this *PASSporT) signingInput() (string, error) {
+	header, err := json.Marshal(&this.Header)
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(&this.Claims)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims), nil
+}
+
+func decodePASSporTPart(part string, v interface{}) (ParseException error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("ParseException: bad PASSporT encoding")
+	}
+	if err = json.Unmarshal(decoded, v); err != nil {
+		return errors.New("ParseException: bad PASSporT JSON: " + err.Error())
+	}
+	return nil
+}
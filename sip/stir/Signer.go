@@ -0,0 +1,115 @@
+package stir
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/**
+ * A Signer is the authentication service of STIR/SHAKEN (RFC 8224 section
+ * 5, RFC 8588). It builds the PASSporT of a request from its From, To and
+ * Date headers, signs it with the private key of the certificate found at
+ * x5u and adds the Identity header carrying it.
+ */
+type Signer struct {
+	key *ecdsa.PrivateKey
+
+	x5u string
+
+	attest string
+
+	origId string
+}
+
+/** Create a Signer with a P-256 key and the URL of its certificate. The
+ * attestation defaults to full.
+ */
+func NewSigner(key *ecdsa.PrivateKey, x5u string) *Signer {
+	this := &Signer{}
+	this.key = key
+	this.x5u = x5u
+	this.attest = PASSporT_ATTEST_FULL
+	return this
+}
+
+/** Set the attestation level: PASSporT_ATTEST_FULL, PARTIAL or GATEWAY.
+ */
+func (this *Signer) SetAttestation(attest string) (SipException error) {
+	switch attest {
+	case PASSporT_ATTEST_FULL, PASSporT_ATTEST_PARTIAL, PASSporT_ATTEST_GATEWAY:
+		this.attest = attest
+		return nil
+	}
+	return errors.New("SipException: bad attestation " + attest)
+}
+
+/** Set the origination identifier of the signed requests. By default each
+ * request gets a new random UUID.
+ */
+func (this *Signer) SetOrigId(origId string) {
+	this.origId = origId
+}
+
+/** Sign a request and add its Identity header. A Date header is added if
+ * the request has none, as the iat claim is its date (RFC 8224 section
+ * 6.1.1). The signed PASSporT is returned.
+ */
+func (this *Signer) Sign(request *message.SIPRequest) (passport *PASSporT, SipException error) {
+	if request.GetFrom() == nil || request.GetTo() == nil {
+		return nil, errors.New("SipException: the request has no From or To")
+	}
+	date, ok := request.GetHeader(core.SIPHeaderNames_DATE).(*header.Date)
+	if !ok || date.GetDate() == nil {
+		now := time.Now().In(time.FixedZone("GMT", 0)).Truncate(time.Second)
+		date = header.NewDate()
+		date.SetDate(&now)
+		request.SetHeader(date)
+	}
+
+	passport = NewPASSporT(this.x5u)
+	passport.Claims.Attest = this.attest
+	passport.Claims.Iat = date.GetDate().Unix()
+	passport.Claims.Orig.Tn, passport.Claims.Orig.Uri = identityOf(request.GetFrom().GetAddress())
+	tn, uri := identityOf(request.GetTo().GetAddress())
+	if tn != "" {
+		passport.Claims.Dest.Tn = []string{tn}
+	} else if uri != "" {
+		passport.Claims.Dest.Uri = []string{uri}
+	}
+	if passport.Claims.Origid = this.origId; passport.Claims.Origid == "" {
+		passport.Claims.Origid = newUUID()
+	}
+
+	token, err := passport.Sign(this.key)
+	if err != nil {
+		return nil, err
+	}
+	identity := header.NewIdentity()
+	if err = identity.SetSignedIdentityDigest(token); err != nil {
+		return nil, err
+	}
+	if err = identity.SetInfo(this.x5u); err != nil {
+		return nil, err
+	}
+	identity.SetAlg(passport.Header.Alg)
+	identity.SetPpt(passport.Header.Ppt)
+	request.SetHeader(identity)
+	return passport, nil
+}
+
+/** Generate a random (version 4) UUID.
+ */
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,163 @@
+package stir
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"time"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+)
+
+/** The default longest time between the iat of a PASSporT and its
+ * verification (RFC 8224 section 6.2.1).
+ */
+const Verifier_DEFAULT_FRESHNESS = 60 * time.Second
+
+/**
+ * A Verifier is the verification service of STIR/SHAKEN (RFC 8224 section
+ * 6). It fetches the certificate an Identity header refers to, checks that
+ * it chains to a trusted certification authority, checks the signature of
+ * the PASSporT, that its iat is the Date of the request and is fresh, and
+ * that it asserts the From and To of the request.
+ */
+type Verifier struct {
+	fetcher CertificateFetcher
+
+	roots *x509.CertPool
+
+	freshness time.Duration
+
+	now func() time.Time
+}
+
+/** Create a Verifier getting certificates from a fetcher. The
+ * certificates must chain to one of the roots; without roots every
+ * PASSporT is rejected.
+ */
+func NewVerifier(fetcher CertificateFetcher, roots *x509.CertPool) *Verifier {
+	this := &Verifier{}
+	this.fetcher = fetcher
+	this.roots = roots
+	this.freshness = Verifier_DEFAULT_FRESHNESS
+	this.now = time.Now
+	return this
+}
+
+/** Set the longest time between the iat of a PASSporT and now.
+ */
+func (this *Verifier) SetFreshness(freshness time.Duration) {
+	this.freshness = freshness
+}
+
+/** Verify the Identity header of a request. It returns the PASSporT, or
+ * the status code of the response rejecting the request (RFC 8224 section
+ * 6.2.2):
+ * <ul>
+ * <li>428 if the request has no Identity header;
+ * <li>436 if the header has no info or its certificate cannot be fetched;
+ * <li>437 if the certificate is not valid or trusted or has no P-256 key;
+ * <li>438 if the header or the signature is not valid, the iat of the
+ * PASSporT is not the Date of the request or the PASSporT does not assert
+ * the From and To of the request;
+ * <li>403 if the PASSporT is stale.
+ * </ul>
+ */
+func (this *Verifier) Verify(request *message.SIPRequest) (passport *PASSporT, statusCode int) {
+	identity, ok := request.GetHeader(core.SIPHeaderNames_IDENTITY).(*header.Identity)
+	if !ok {
+		return nil, message.USE_IDENTITY_HEADER
+	}
+	passport, signingInput, signature, err := ParsePASSporT(identity.GetSignedIdentityDigest())
+	if err != nil {
+		return nil, message.INVALID_IDENTITY_HEADER
+	}
+	if passport.Header.Alg != PASSporT_ALG_ES256 ||
+		identity.GetAlg() != "" && identity.GetAlg() != passport.Header.Alg ||
+		identity.GetPpt() != passport.Header.Ppt ||
+		passport.Header.X5u != identity.GetInfo() {
+		return nil, message.INVALID_IDENTITY_HEADER
+	}
+
+	if identity.GetInfo() == "" {
+		return nil, message.BAD_IDENTITY_INFO
+	}
+	certificates, err := this.fetcher.FetchCertificates(identity.GetInfo())
+	if err != nil || len(certificates) == 0 {
+		return nil, message.BAD_IDENTITY_INFO
+	}
+	key, ok := certificates[0].PublicKey.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() || !this.isTrusted(certificates) {
+		return nil, message.UNSUPPORTED_CREDENTIAL
+	}
+	if !VerifySignature(signingInput, signature, key) {
+		return nil, message.INVALID_IDENTITY_HEADER
+	}
+
+	// The iat is the Date of the request (RFC 8224 section 6.2.1).
+	date, ok := request.GetHeader(core.SIPHeaderNames_DATE).(*header.Date)
+	if !ok || date.GetDate() == nil || date.GetDate().Unix() != passport.Claims.Iat {
+		return nil, message.INVALID_IDENTITY_HEADER
+	}
+	iat := time.Unix(passport.Claims.Iat, 0)
+	if age := this.now().Sub(iat); age > this.freshness || age < -this.freshness {
+		return nil, message.FORBIDDEN
+	}
+	if !this.matchesOrig(passport, request) || !this.matchesDest(passport, request) {
+		return nil, message.INVALID_IDENTITY_HEADER
+	}
+	return passport, 0
+}
+
+/** Check a certificate chain, the signing certificate first.
+ */
+func (this *Verifier) isTrusted(certificates []*x509.Certificate) bool {
+	now := this.now()
+	if now.Before(certificates[0].NotBefore) || now.After(certificates[0].NotAfter) {
+		return false
+	}
+	if this.roots == nil {
+		return false
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         this.roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+func (this *Verifier) matchesOrig(passport *PASSporT, request *message.SIPRequest) bool {
+	if request.GetFrom() == nil {
+		return false
+	}
+	tn, uri := identityOf(request.GetFrom().GetAddress())
+	return passport.Claims.Orig.Tn == tn && passport.Claims.Orig.Uri == uri
+}
+
+func (this *Verifier) matchesDest(passport *PASSporT, request *message.SIPRequest) bool {
+	if request.GetTo() == nil {
+		return false
+	}
+	tn, uri := identityOf(request.GetTo().GetAddress())
+	if tn != "" {
+		return contains(passport.Claims.Dest.Tn, tn)
+	}
+	return uri != "" && contains(passport.Claims.Dest.Uri, uri)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package stir
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/use-go/gosips/core"
+	"github.com/use-go/gosips/sip/header"
+	"github.com/use-go/gosips/sip/message"
+	"github.com/use-go/gosips/sip/parser"
+)
+
+const testX5u = "https://cert.example.org/passport.pem"
+
+func parseTestRequest(t *testing.T, s string) *message.SIPRequest {
+	msg, err := parser.NewStringMsgParser().ParseSIPMessage(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.(*message.SIPRequest)
+}
+
+func testInvite(from, to string) string {
+	return "INVITE " + to + " SIP/2.0\r\n" +
+		"Via: SIP/2.0/UDP pc33.atlanta.com;branch=z9hG4bK776asdhds\r\n" +
+		"Max-Forwards: 70\r\n" +
+		"To: <" + to + ">\r\n" +
+		"From: <" + from + ">;tag=1928301774\r\n" +
+		"Call-ID: a84b4c76e66710@pc33.atlanta.com\r\n" +
+		"CSeq: 314159 INVITE\r\n" +
+		"Contact: <sip:alice@pc33.atlanta.com>\r\n" +
+		"Content-Length: 0\r\n\r\n"
+}
+
+func newTestCertificate(t *testing.T, key interface{}, public interface{}) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "SHAKEN test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, public, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, newTestCertificate(t, key, &key.PublicKey)
+}
+
+func stubFetcher(certificates ...*x509.Certificate) CertificateFetcher {
+	return CertificateFetcherFunc(func(url string) ([]*x509.Certificate, error) {
+		if url != testX5u {
+			return nil, errors.New("not found")
+		}
+		return certificates, nil
+	})
+}
+
+func newTestRoots(certificates ...*x509.Certificate) *x509.CertPool {
+	roots := x509.NewCertPool()
+	for _, certificate := range certificates {
+		roots.AddCert(certificate)
+	}
+	return roots
+}
+
+/** Sign an INVITE and return it as received by the verifier.
+ */
+func signTestInvite(t *testing.T, key *ecdsa.PrivateKey, from, to string) *message.SIPRequest {
+	request := parseTestRequest(t, testInvite(from, to))
+	signer := NewSigner(key, testX5u)
+	if _, err := signer.Sign(request); err != nil {
+		t.Fatal(err)
+	}
+	return parseTestRequest(t, request.String())
+}
+
+func TestSigner(t *testing.T) {
+	key, _ := newTestKey(t)
+	request := parseTestRequest(t, testInvite("sip:+1-215-555-0100@atlanta.com;user=phone", "tel:+12155550199"))
+	signer := NewSigner(key, testX5u)
+	signer.SetOrigId("123e4567-e89b-12d3-a456-426655440000")
+	if err := signer.SetAttestation("D"); err == nil {
+		t.Fatal("bad attestation accepted")
+	}
+	signer.SetAttestation(PASSporT_ATTEST_PARTIAL)
+	passport, err := signer.Sign(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := passport.Claims
+	if claims.Orig.Tn != "12155550100" || len(claims.Dest.Tn) != 1 || claims.Dest.Tn[0] != "12155550199" ||
+		claims.Attest != PASSporT_ATTEST_PARTIAL || claims.Origid != "123e4567-e89b-12d3-a456-426655440000" {
+		t.Fatalf("bad claims %+v", claims)
+	}
+	date, ok := request.GetHeader(core.SIPHeaderNames_DATE).(*header.Date)
+	if !ok || date.GetDate().Unix() != claims.Iat {
+		t.Fatal("iat is not the Date")
+	}
+	identity, ok := request.GetHeader(core.SIPHeaderNames_IDENTITY).(*header.Identity)
+	if !ok || identity.GetInfo() != testX5u || identity.GetAlg() != "ES256" || identity.GetPpt() != "shaken" {
+		t.Fatalf("bad Identity header %v", request.GetHeader(core.SIPHeaderNames_IDENTITY))
+	}
+	parsed, _, _, err := ParsePASSporT(identity.GetSignedIdentityDigest())
+	if err != nil || parsed.Header.Typ != "passport" || parsed.Header.X5u != testX5u || parsed.Claims.Iat != claims.Iat {
+		t.Fatalf("bad PASSporT %+v: %v", parsed, err)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	key, certificate := newTestKey(t)
+	request := signTestInvite(t, key, "sip:+12155550100@atlanta.com;user=phone", "sip:bob@biloxi.com")
+	verifier := NewVerifier(stubFetcher(certificate), newTestRoots(certificate))
+	passport, statusCode := verifier.Verify(request)
+	if statusCode != 0 {
+		t.Fatalf("verification failed with %d", statusCode)
+	}
+	if passport.Claims.Orig.Tn != "12155550100" || len(passport.Claims.Dest.Uri) != 1 || passport.Claims.Dest.Uri[0] != "sip:bob@biloxi.com" {
+		t.Fatalf("bad claims %+v", passport.Claims)
+	}
+
+	// Without roots no certificate is trusted.
+	if _, statusCode = NewVerifier(stubFetcher(certificate), nil).Verify(request); statusCode != message.UNSUPPORTED_CREDENTIAL {
+		t.Fatalf("certificate accepted without roots: %d", statusCode)
+	}
+}
+
+func TestVerifierRejected(t *testing.T) {
+	key, certificate := newTestKey(t)
+	otherKey, otherCertificate := newTestKey(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaCertificate := newTestCertificate(t, rsaKey, &rsaKey.PublicKey)
+	from, to := "tel:+12155550100", "tel:+12155550199"
+	roots, otherRoots := newTestRoots(certificate), newTestRoots(otherCertificate)
+	noDate := signTestInvite(t, key, from, to)
+	noDate.RemoveHeader(core.SIPHeaderNames_DATE)
+	otherDate := signTestInvite(t, key, from, to)
+	date := header.NewDate()
+	later := time.Now().Add(time.Second)
+	date.SetDate(&later)
+	otherDate.SetHeader(date)
+
+	for _, test := range []struct {
+		name       string
+		request    *message.SIPRequest
+		verifier   *Verifier
+		statusCode int
+	}{
+		{"no Identity", parseTestRequest(t, testInvite(from, to)), NewVerifier(stubFetcher(certificate), roots), message.USE_IDENTITY_HEADER},
+		{"no certificate", signTestInvite(t, key, from, to), NewVerifier(stubFetcher(), roots), message.BAD_IDENTITY_INFO},
+		{"RSA certificate", signTestInvite(t, key, from, to), NewVerifier(stubFetcher(rsaCertificate), newTestRoots(rsaCertificate)), message.UNSUPPORTED_CREDENTIAL},
+		{"other key", signTestInvite(t, otherKey, from, to), NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"From changed", parseTestRequest(t, strings.Replace(signTestInvite(t, key, from, to).String(),
+			"From: <tel:+12155550100>", "From: <tel:+12155550101>", 1)), NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"To changed", parseTestRequest(t, strings.Replace(signTestInvite(t, key, from, to).String(),
+			"To: <tel:+12155550199>", "To: <tel:+12155550198>", 1)), NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"ppt changed", parseTestRequest(t, strings.Replace(signTestInvite(t, key, from, to).String(),
+			"ppt=shaken", "ppt=div", 1)), NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"no Date", noDate, NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"Date changed", otherDate, NewVerifier(stubFetcher(certificate), roots), message.INVALID_IDENTITY_HEADER},
+		{"untrusted certificate", signTestInvite(t, key, from, to), NewVerifier(stubFetcher(certificate), otherRoots), message.UNSUPPORTED_CREDENTIAL},
+	} {
+		if _, statusCode := test.verifier.Verify(test.request); statusCode != test.statusCode {
+			t.Errorf("%s: got %d, expected %d", test.name, statusCode, test.statusCode)
+		}
+	}
+
+	verifier := NewVerifier(stubFetcher(certificate), roots)
+	verifier.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, statusCode := verifier.Verify(signTestInvite(t, key, from, to)); statusCode != message.FORBIDDEN {
+		t.Errorf("stale PASSporT: got %d", statusCode)
+	}
+	verifier.SetFreshness(5 * time.Minute)
+	if _, statusCode := verifier.Verify(signTestInvite(t, key, from, to)); statusCode != 0 {
+		t.Errorf("fresh PASSporT: got %d", statusCode)
+	}
+}
+
+func TestHTTPCertificateFetcher(t *testing.T) {
+	fetcher := NewHTTPCertificateFetcher(time.Second)
+	for _, url := range []string{"http://cert.example.org/passport.pem", "file:///etc/passport.pem", "https:///passport.pem"} {
+		if _, err := fetcher.FetchCertificates(url); err == nil || !strings.Contains(err.Error(), "not an HTTPS URL") {
+			t.Errorf("%s: got %v", url, err)
+		}
+	}
+}
+
+func TestCanonicalTelephoneNumber(t *testing.T) {
+	for uri, tn := range map[string]string{
+		"tel:+1-215-555-0100":                          "12155550100",
+		"sip:+1(215)555.0100@atlanta.com":              "12155550100",
+		"sip:12155550100@atlanta.com":                  "12155550100",
+		"sip:alice@atlanta.com":                        "",
+		"sip:5550100;phone-context=x@a.com;user=phone": "5550100",
+	} {
+		request := parseTestRequest(t, testInvite(uri, "sip:bob@biloxi.com"))
+		if got := CanonicalTelephoneNumber(request.GetFrom().GetAddress().GetURI()); got != tn {
+			t.Errorf("%s: got %q, expected %q", uri, got, tn)
+		}
+	}
+}